@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -12,13 +13,44 @@ import (
 	_ "github.com/lib/pq" // PostgreSQL driver (needed for user & monitoring repos)
 
 	"prabogo/internal/adapter/inbound/http"
+	"prabogo/internal/adapter/outbound/api_key_repository"
+	"prabogo/internal/adapter/outbound/application_repository"
+	"prabogo/internal/adapter/outbound/attempt_store"
+	"prabogo/internal/adapter/outbound/audit_repository"
+	"prabogo/internal/adapter/outbound/diagnosis_repository"
+	"prabogo/internal/adapter/outbound/harvest_repository"
+	"prabogo/internal/adapter/outbound/inspection_repository"
+	"prabogo/internal/adapter/outbound/invitation_repository"
+	"prabogo/internal/adapter/outbound/location_repository"
+	"prabogo/internal/adapter/outbound/maintenance_rule_repository"
 	"prabogo/internal/adapter/outbound/monitoring_repository"
+	"prabogo/internal/adapter/outbound/notifier"
+	"prabogo/internal/adapter/outbound/oidc_provider"
+	"prabogo/internal/adapter/outbound/password_reset_repository"
+	"prabogo/internal/adapter/outbound/role_repository"
 	"prabogo/internal/adapter/outbound/sawit_client"
 	"prabogo/internal/adapter/outbound/sawit_repository"
+	"prabogo/internal/adapter/outbound/scan_repository"
+	"prabogo/internal/adapter/outbound/session_repository"
+	"prabogo/internal/adapter/outbound/species_repository"
+	"prabogo/internal/adapter/outbound/task_repository"
+	"prabogo/internal/adapter/outbound/tree_cache"
+	"prabogo/internal/adapter/outbound/tree_change_repository"
 	"prabogo/internal/adapter/outbound/tree_repository"
+	"prabogo/internal/adapter/outbound/tree_stats_repository"
+	"prabogo/internal/adapter/outbound/two_factor_repository"
+	"prabogo/internal/adapter/outbound/user_identity_repository"
+	"prabogo/internal/adapter/outbound/user_location_repository"
 	"prabogo/internal/adapter/outbound/user_repository"
 	"prabogo/internal/cache"
+	"prabogo/internal/domain/analytics"
+	"prabogo/internal/domain/application"
+	"prabogo/internal/domain/audit"
 	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/diagnosis"
+	"prabogo/internal/domain/harvest"
+	"prabogo/internal/domain/inspection"
+	"prabogo/internal/domain/task"
 	"prabogo/internal/domain/tree"
 	"prabogo/utils/database"
 )
@@ -30,10 +62,13 @@ func main() {
 	ctx := context.Background()
 
 	// Initialize Gib.Run cache
+	redisReady := true
 	if err := cache.InitGibRun(); err != nil {
+		redisReady = false
 		fmt.Printf("⚠️ Warning: Failed to initialize cache: %v\n", err)
-		fmt.Println("   Continuing without cache (direct database queries)")
+		fmt.Println("   Continuing with in-memory cache; Redis is retried in the background")
 	}
+	go cache.Default.Watch(ctx)
 	// Choose database: SawitDB or PostgreSQL
 	useSawitDB := os.Getenv("USE_SAWITDB") == "true"
 
@@ -92,29 +127,116 @@ func main() {
 		monitoringRepo = monitoring_repository.NewMonitoringRepository(db)
 	}
 
+	// Read-through tree cache (memory + Redis); invalidations are broadcast to the other instances
+	broadcaster := tree_cache.NewRedisBroadcaster()
+	defer broadcaster.Close()
+	cachedTreeRepo := tree_cache.NewCachedTreeRepository(treeRepo, cache.Default, broadcaster, tree_cache.GetConfig())
+	go cachedTreeRepo.Listen(ctx)
+	treeRepo = cachedTreeRepo
+
+	// Repositories that always live in PostgreSQL (also in hybrid mode)
+	handlerDB := database.InitDatabase(ctx, "postgres")
+	treeChangeRepo := tree_change_repository.NewTreeChangeRepository(handlerDB)
+	speciesRepo := species_repository.NewSpeciesRepository(handlerDB)
+	locationRepo := location_repository.NewLocationRepository(handlerDB)
+	auditRepo := audit_repository.NewAuditRepository(handlerDB)
+	sessionRepo := session_repository.NewSessionRepository(handlerDB)
+	passwordResetRepo := password_reset_repository.NewPasswordResetRepository(handlerDB)
+	twoFactorRepo := two_factor_repository.NewTwoFactorRepository(handlerDB)
+	roleRepo := role_repository.NewRoleRepository(handlerDB)
+	userLocationRepo := user_location_repository.NewUserLocationRepository(handlerDB)
+	apiKeyRepo := api_key_repository.NewAPIKeyRepository(handlerDB)
+	invitationRepo := invitation_repository.NewInvitationRepository(handlerDB)
+	scanRepo := scan_repository.NewScanRepository(handlerDB)
+	treeStatsRepo := tree_stats_repository.NewTreeStatsRepository(handlerDB, cache.Default)
+	maintenanceRuleRepo := maintenance_rule_repository.NewMaintenanceRuleRepository(handlerDB)
+	taskRepo := task_repository.NewTaskRepository(handlerDB)
+	inspectionRepo := inspection_repository.NewInspectionRepository(handlerDB)
+	applicationRepo := application_repository.NewApplicationRepository(handlerDB)
+	diagnosisRepo := diagnosis_repository.NewDiagnosisRepository(handlerDB)
+	harvestRepo := harvest_repository.NewHarvestRepository(handlerDB)
+
+	// Notifier for password reset and inspection reminder emails (NOTIFIER_DRIVER=smtp|log)
+	userNotifier, err := notifier.NewFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize notifier: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Login attempt counters: Redis shared across instances, memory as fallback
+	var attemptStore auth.AttemptStore = attempt_store.NewMemoryStore()
+	if redisReady {
+		attemptStore = attempt_store.NewFallbackStore(attempt_store.NewRedisStore(cache.Client), attemptStore)
+	} else {
+		fmt.Println("⚠️ Login throttling uses in-memory counters (per instance)")
+	}
+	loginThrottle := auth.NewLoginThrottle(attemptStore, auth.GetThrottleConfig())
+	apiKeyLimiter := auth.NewAPIKeyLimiter(attemptStore)
+
+	// OpenID Connect single sign-on (enabled by OIDC_ISSUER)
+	var oidcLogin *auth.OIDCLogin
+	identityProvider, err := oidc_provider.NewFromEnv(ctx)
+	if err != nil {
+		fmt.Printf("❌ Failed to initialize OIDC provider: %v\n", err)
+		os.Exit(1)
+	}
+	if identityProvider != nil {
+		identityRepo := user_identity_repository.NewUserIdentityRepository(handlerDB)
+		oidcLogin = auth.NewOIDCLogin(identityProvider, identityRepo, attemptStore, auth.GetOIDCConfig())
+	}
+
 	// Initialize services & use cases
-	treeUseCase := tree.NewTreeUseCase(treeRepo, monitoringRepo)
-	authService := auth.NewAuthService(userRepo)
+	auditService := audit.NewAuditService(auditRepo)
+	scanService := analytics.NewScanService(scanRepo)
+	go scanService.RunRetention(ctx, time.Hour)
+	treeUseCase := tree.NewTreeUseCase(treeRepo, monitoringRepo, treeChangeRepo, treeStatsRepo, speciesRepo, locationRepo, maintenanceRuleRepo, auditService)
+	go treeUseCase.RunStatisticsRebuild(ctx, tree.GetStatsRebuildInterval())
+	go treeUseCase.RunMaintenanceRefresh(ctx, tree.GetMaintenanceRefreshInterval())
+	taskService := task.NewTaskService(taskRepo, treeUseCase, userRepo, auditService)
+	inspectionService := inspection.NewInspectionService(inspectionRepo, inspectionRepo, inspectionRepo, treeUseCase, taskService, userRepo, userNotifier, auditService)
+	go inspectionService.RunScheduler(ctx, inspection.GetSchedulerInterval())
+	applicationService := application.NewApplicationService(applicationRepo, treeUseCase, userRepo, auditService)
+	diagnosisService := diagnosis.NewDiagnosisService(diagnosisRepo, diagnosisRepo, treeUseCase, userNotifier, auditService)
+	harvestService := harvest.NewHarvestService(harvestRepo, treeUseCase, userRepo, auditService)
+	authService := auth.NewAuthService(userRepo, sessionRepo, passwordResetRepo, twoFactorRepo, roleRepo, userLocationRepo, apiKeyRepo, invitationRepo, userNotifier, loginThrottle, oidcLogin, auditService)
 
 	// Initialize handlers
-	treeHandler := http.NewTreeHandler(treeUseCase, userRepo)
+	treeHandler := http.NewTreeHandler(treeUseCase, userRepo, scanService, harvestService)
 	authHandler := http.NewAuthHandler(authService)
 	userHandler := http.NewUserHandler(authService) // User Management Handler
 	// MonitoringHandler requires concrete type (always uses PostgreSQL)
-	monitoringHandlerRepo := monitoring_repository.NewMonitoringRepository(database.InitDatabase(ctx, "postgres"))
+	monitoringHandlerRepo := monitoring_repository.NewMonitoringRepository(handlerDB)
 	monitoringHandler := http.NewMonitoringHandler(monitoringHandlerRepo, userRepo, treeRepo)
+	tagHandler := http.NewTagHandler(treeUseCase, speciesRepo)
+	auditHandler := http.NewAuditHandler(auditService)
+	analyticsHandler := http.NewAnalyticsHandler(scanService)
+	maintenanceHandler := http.NewMaintenanceHandler(treeUseCase)
+	taskHandler := http.NewTaskHandler(taskService)
+	inspectionHandler := http.NewInspectionHandler(inspectionService)
+	applicationHandler := http.NewApplicationHandler(applicationService)
+	diagnosisHandler := http.NewDiagnosisHandler(diagnosisService)
+	harvestHandler := http.NewHarvestHandler(harvestService)
+	roleHandler := http.NewRoleHandler(authService)
+	apiKeyHandler := http.NewAPIKeyHandler(authService)
 
 	// Create auth middleware
 	authMiddleware := http.AuthMiddleware(authService)
+	// Tree and monitoring routes also accept machine clients' API keys
+	clientAuthMiddleware := http.APIKeyMiddleware(authService, apiKeyLimiter, authMiddleware)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName: "Tree-ID API v1.0",
+		// Behind a reverse proxy set PROXY_HEADER=X-Forwarded-For so per-IP
+		// login throttling and audit entries see the client, not the proxy
+		ProxyHeader: os.Getenv("PROXY_HEADER"),
 	})
 
 	// Global middleware
 	app.Use(logger.New())
 	app.Use(cors.New())
+	app.Use(http.RequestIDMiddleware())
+	app.Use(http.AuditMiddleware(auditService))
 
 	// Serve static frontend files
 	app.Static("/", "./web")
@@ -124,22 +246,37 @@ func main() {
 		return c.JSON(fiber.Map{
 			"status": "healthy",
 			"app":    "Tree-ID API",
+			"cache":  cache.Default.Stats(),
 		})
 	})
 
+	// Register tree routes (with auth protection)
+	// Must come before the public /api/trees/:code route, otherwise
+	// static paths like /api/trees/export are captured as a tree code
+	treeHandler.RoutesWithAuth(app, clientAuthMiddleware)
+	tagHandler.Routes(app, authMiddleware)
+
 	// Register public routes (no auth required)
-	treeHandler.RoutesPublic(app)
+	treeHandler.RoutesPublic(app, http.OptionalAuthMiddleware(authService))
 
 	// Register auth routes
 	authHandler.Routes(app, authMiddleware)
 	userHandler.Routes(app, authMiddleware) // Register User Routes
-
-	// Register tree routes (with auth protection)
-	treeHandler.RoutesWithAuth(app, authMiddleware)
+	auditHandler.Routes(app, authMiddleware)
+	analyticsHandler.Routes(app, authMiddleware)
+	maintenanceHandler.Routes(app, authMiddleware)
+	taskHandler.Routes(app, authMiddleware)
+	inspectionHandler.Routes(app, authMiddleware)
+	applicationHandler.Routes(app, authMiddleware)
+	diagnosisHandler.Routes(app, authMiddleware)
+	harvestHandler.Routes(app, authMiddleware)
+	roleHandler.Routes(app, authMiddleware)
+	apiKeyHandler.Routes(app, authMiddleware)
 
 	// Register monitoring routes
 	api := app.Group("/api")
-	api.Get("/trees/:code/history", authMiddleware, monitoringHandler.GetTreeHistory)
+	api.Get("/trees/:code/history", clientAuthMiddleware, http.RequireScope(auth.ScopeMonitoringRead), monitoringHandler.GetTreeHistory)
+	api.Get("/trees/:code/history/export", clientAuthMiddleware, http.RequireScope(auth.ScopeMonitoringRead), http.RequirePermission(auth.PermReportExport), monitoringHandler.ExportTreeHistory)
 
 	// Print banner
 	printBanner()
@@ -170,19 +307,127 @@ func printBanner() {
 		fmt.Println("✅ AQL Translator: Active")
 	}
 	fmt.Println("✅ Authentication: JWT Enabled")
+	if os.Getenv("OIDC_ISSUER") != "" {
+		fmt.Printf("✅ Single sign-on: OIDC (%s)\n", os.Getenv("OIDC_ISSUER"))
+	}
+	fmt.Println("✅ Authorization: Role permissions (editable via /api/roles)")
+	fmt.Println("✅ Location scope: roles without tree:all_locations see assigned locations only")
+	fmt.Println("✅ API keys: tree & monitoring routes accept X-API-Key (scoped, rate limited)")
+	fmt.Println("✅ Dashboard statistics: materialized counters, updated on every change, rebuilt every TREE_STATS_REBUILD_INTERVAL")
+	fmt.Println("✅ Scan analytics: public tree views recorded, hourly/daily rollups, raw events kept SCAN_EVENT_RETENTION_DAYS")
 	fmt.Println("\n📍 Public Routes:")
 	fmt.Println("   GET    /health")
 	fmt.Println("   POST   /api/auth/register")
 	fmt.Println("   POST   /api/auth/login")
+	fmt.Println("   POST   /api/auth/refresh       (rotates refresh token)")
+	fmt.Println("   POST   /api/auth/password/forgot")
+	fmt.Println("   POST   /api/auth/password/reset")
+	fmt.Println("   POST   /api/auth/2fa/verify    (second login step)")
+	fmt.Println("   POST   /api/auth/2fa/enroll/setup")
+	fmt.Println("   POST   /api/auth/2fa/enroll/complete")
+	fmt.Println("   GET    /api/auth/oidc          (single sign-on enabled?)")
+	fmt.Println("   GET    /api/auth/oidc/login    (provider URL, PKCE)")
+	fmt.Println("   POST   /api/auth/oidc/callback (code + state -> same tokens as login)")
+	fmt.Println("   GET    /api/auth/invitations/:token (invitation preview)")
+	fmt.Println("   POST   /api/auth/invitations/accept (token + username + password)")
 	fmt.Println("\n🔒 Protected Routes:")
 	fmt.Println("   GET    /api/auth/me")
-	fmt.Println("   POST   /api/trees              (admin, editor)")
-	fmt.Println("   GET    /api/trees/:code        (all roles)")
-	fmt.Println("   GET    /api/trees              (all roles)")
-	fmt.Println("   PUT    /api/trees/:code/status (admin, editor)")
-	fmt.Println("   DELETE /api/trees/:code        (admin only)")
-	fmt.Println("   GET    /api/stats              (all roles)")
-	fmt.Println("   GET    /api/trees/:code/history (all roles)")
+	fmt.Println("   PUT    /api/auth/me            (own full name & email)")
+	fmt.Println("   GET    /api/auth/me/permissions")
+	fmt.Println("   POST   /api/auth/logout")
+	fmt.Println("   POST   /api/auth/logout-all")
+	fmt.Println("   POST   /api/auth/password/change")
+	fmt.Println("   GET    /api/auth/2fa")
+	fmt.Println("   POST   /api/auth/2fa/setup")
+	fmt.Println("   POST   /api/auth/2fa/enable")
+	fmt.Println("   POST   /api/auth/2fa/disable")
+	fmt.Println("   POST   /api/auth/2fa/recovery-codes")
+	fmt.Println("   PUT    /api/users/:id          (user:manage, full name & email)")
+	fmt.Println("   POST   /api/users/:id/deactivate (user:manage)")
+	fmt.Println("   POST   /api/users/:id/reactivate (user:manage)")
+	fmt.Println("   DELETE /api/users/:id          (user:manage, leaves a tombstone)")
+	fmt.Println("   GET    /api/users/invitations  (user:manage)")
+	fmt.Println("   POST   /api/users/invitations  (user:manage, emails an expiring link)")
+	fmt.Println("   POST   /api/users/invitations/:id/resend (user:manage)")
+	fmt.Println("   DELETE /api/users/invitations/:id (user:manage, revoke)")
+	fmt.Println("   POST   /api/users/:id/revoke-sessions (user:manage)")
+	fmt.Println("   POST   /api/users/:id/unlock   (user:manage, clears login lockout)")
+	fmt.Println("   POST   /api/users/unlock-ip    (user:manage)")
+	fmt.Println("   POST   /api/users/:id/2fa/reset (user:manage)")
+	fmt.Println("   GET    /api/users/:id/locations (user:manage)")
+	fmt.Println("   PUT    /api/users/:id/locations (user:manage, assigned locations)")
+	fmt.Println("   GET    /api/roles              (role:manage or user:manage)")
+	fmt.Println("   GET    /api/permissions        (role:manage or user:manage)")
+	fmt.Println("   POST   /api/roles              (role:manage)")
+	fmt.Println("   PUT    /api/roles/:name        (role:manage)")
+	fmt.Println("   DELETE /api/roles/:name        (role:manage, custom roles only)")
+	fmt.Println("   GET    /api/clients            (client:manage, API keys)")
+	fmt.Println("   GET    /api/clients/scopes     (client:manage)")
+	fmt.Println("   POST   /api/clients            (client:manage, key shown once)")
+	fmt.Println("   PUT    /api/clients/:id        (client:manage, scopes & rate limit)")
+	fmt.Println("   POST   /api/clients/:id/rotate (client:manage)")
+	fmt.Println("   DELETE /api/clients/:id        (client:manage, revoke)")
+	fmt.Println("   POST   /api/trees              (tree:create)")
+	fmt.Println("   GET    /api/trees/:code        (authenticated)")
+	fmt.Println("   GET    /api/trees              (authenticated)")
+	fmt.Println("   PUT    /api/trees/:code/status (tree:update)")
+	fmt.Println("   PATCH  /api/trees/:code        (tree:update - merge patch)")
+	fmt.Println("   GET    /api/trees/:code/changes (authenticated)")
+	fmt.Println("   DELETE /api/trees/:code        (tree:delete, moves to trash)")
+	fmt.Println("   GET    /api/trees/trash        (tree:restore)")
+	fmt.Println("   POST   /api/trees/:code/restore (tree:restore)")
+	fmt.Println("   DELETE /api/trees/trash[/:code] (tree:purge, purge past retention)")
+	fmt.Println("   GET    /api/stats              (report:view, materialized, with as_of and harvest production)")
+	fmt.Println("   GET    /api/trees/:code/history (authenticated)")
+	fmt.Println("   GET    /api/trees/export       (report:export, ?format=csv|xlsx)")
+	fmt.Println("   GET    /api/trees/:code/history/export (report:export, ?format=csv|xlsx)")
+	fmt.Println("   GET    /api/trees/:code/qr     (authenticated, ?format=png|svg)")
+	fmt.Println("   GET    /api/trees/labels       (tag:print - PDF label sheet)")
+	fmt.Println("   GET    /api/audit              (audit:read, ?actor_id&action&target_type&target_id&from&to)")
+	fmt.Println("   GET    /api/audit/verify       (audit:read - hash chain check)")
+	fmt.Println("   GET    /api/analytics/scans/top     (report:view, most-scanned trees)")
+	fmt.Println("   GET    /api/analytics/scans/series  (report:view, ?granularity=hour|day&tree_code&location_id)")
+	fmt.Println("   GET    /api/analytics/scans/heatmap (report:view, located scans on a grid)")
+	fmt.Println("   GET    /api/maintenance        (report:view, ranked queue, ?location_id&species_id&limit&offset)")
+	fmt.Println("   GET    /api/maintenance/rules  (report:view)")
+	fmt.Println("   POST   /api/maintenance/rules  (maintenance:manage)")
+	fmt.Println("   PUT    /api/maintenance/rules/:id (maintenance:manage)")
+	fmt.Println("   DELETE /api/maintenance/rules/:id (maintenance:manage)")
+	fmt.Println("   GET    /api/tasks              (own tasks; task:manage sees all, ?status&assignee_id&tree_code&overdue)")
+	fmt.Println("   POST   /api/tasks              (task:manage, work order on trees)")
+	fmt.Println("   GET    /api/tasks/:id          (assignee or task:manage)")
+	fmt.Println("   PUT    /api/tasks/:id          (task:manage, reassign/reschedule)")
+	fmt.Println("   POST   /api/tasks/:id/start    (assignee or task:manage)")
+	fmt.Println("   POST   /api/tasks/:id/complete (assignee or task:manage, logs work on every tree)")
+	fmt.Println("   POST   /api/tasks/:id/verify   (task:manage)")
+	fmt.Println("   POST   /api/tasks/:id/reject   (task:manage, back to in_progress)")
+	fmt.Println("   GET    /api/inspections/due    (own; report:view sees all, ?assignee_id&overdue&within_days)")
+	fmt.Println("   GET    /api/inspections/schedules (report:view)")
+	fmt.Println("   POST   /api/inspections/schedules (inspection:manage)")
+	fmt.Println("   PUT    /api/inspections/schedules/:id (inspection:manage)")
+	fmt.Println("   DELETE /api/inspections/schedules/:id (inspection:manage)")
+	fmt.Println("   GET    /api/applications       (report:view, ?location_id&tree_code&product&from&to)")
+	fmt.Println("   POST   /api/applications       (application:record, tree_codes or whole block)")
+	fmt.Println("   GET    /api/applications/usage (report:view, per period/block/product, ?period=day|week|month)")
+	fmt.Println("   GET    /api/applications/:id   (report:view)")
+	fmt.Println("   DELETE /api/applications/:id   (application:record)")
+	fmt.Println("   GET    /api/diagnoses/catalog  (authenticated, pests & diseases with severity scales)")
+	fmt.Println("   POST   /api/diagnoses/catalog  (diagnosis:manage)")
+	fmt.Println("   PUT    /api/diagnoses/catalog/:id (diagnosis:manage)")
+	fmt.Println("   DELETE /api/diagnoses/catalog/:id (diagnosis:manage, only if never diagnosed)")
+	fmt.Println("   POST   /api/trees/:code/diagnoses (tree:update, monitoring entry with diagnoses)")
+	fmt.Println("   GET    /api/trees/:code/diagnoses (authenticated)")
+	fmt.Println("   GET    /api/diagnoses/spread   (report:view, cases per period/block, ?period&entry&location_id)")
+	fmt.Println("   GET    /api/diagnoses/alerts   (report:view, outbreak alerts, ?unacknowledged=true)")
+	fmt.Println("   POST   /api/diagnoses/alerts/:id/acknowledge (diagnosis:manage)")
+	fmt.Println("   GET    /api/harvests           (report:view, ?tree_code&location_id&harvester_id&grade&from&to)")
+	fmt.Println("   POST   /api/harvests           (harvest:record, one tree or a whole block)")
+	fmt.Println("   POST   /api/harvests/batch     (harvest:record, up to 500 records, all or nothing)")
+	fmt.Println("   GET    /api/harvests/export    (report:export, ?format=csv|xlsx)")
+	fmt.Println("   GET    /api/harvests/yield     (report:view, kg per tree/ha vs health, ?period=week|month|year)")
+	fmt.Println("   GET    /api/harvests/yield/export (report:export, ?format=csv|xlsx)")
+	fmt.Println("   GET    /api/harvests/:id       (report:view)")
+	fmt.Println("   DELETE /api/harvests/:id       (harvest:record)")
 	fmt.Printf("\n🚀 Server running on http://localhost:%s\n", getPort())
 	fmt.Println("============================================================\n")
 }
@@ -6,6 +6,7 @@ require (
 	cloud.google.com/go/pubsub v1.49.0
 	github.com/arielfikru/gibrun v1.0.0
 	github.com/doug-martin/goqu/v9 v9.19.0
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/gofiber/fiber/v2 v2.52.8
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/golang/mock v1.6.0
@@ -19,6 +20,7 @@ require (
 	github.com/rabbitmq/amqp091-go v1.10.0
 	github.com/redis/go-redis/v9 v9.8.0
 	github.com/sirupsen/logrus v1.9.3
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/smartystreets/goconvey v1.8.1
 	golang.org/x/crypto v0.46.0
 	google.golang.org/api v0.234.0
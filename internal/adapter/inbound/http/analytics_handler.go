@@ -0,0 +1,157 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"prabogo/internal/domain/analytics"
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/tree"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AnalyticsHandler serves tree scan analytics
+type AnalyticsHandler struct {
+	scans *analytics.ScanService
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(scans *analytics.ScanService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		scans: scans,
+	}
+}
+
+// Routes registers analytics routes (report:view, limited to assigned locations)
+func (h *AnalyticsHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	api := app.Group("/api")
+	scans := api.Group("/analytics/scans", authMiddleware, RequirePermission(auth.PermReportView))
+
+	scans.Get("/top", h.GetTopTrees)
+	scans.Get("/series", h.GetSeries)
+	scans.Get("/heatmap", h.GetHeatmap)
+}
+
+// GetTopTrees handles GET /api/analytics/scans/top
+// Filters: location_id, from, to (RFC3339 or YYYY-MM-DD), limit
+func (h *AnalyticsHandler) GetTopTrees(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := analytics.TopFilter{
+		LocationID: c.Query("location_id"),
+		Limit:      c.QueryInt("limit", 10),
+	}
+	var err error
+	if filter.From, filter.To, err = parseRangeQuery(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	trees, err := h.scans.TopTrees(ctx, filter)
+	if err != nil {
+		return c.Status(analyticsErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    trees,
+		"count":   len(trees),
+	})
+}
+
+// GetSeries handles GET /api/analytics/scans/series
+// Filters: granularity (hour|day), tree_code, location_id, from, to
+func (h *AnalyticsHandler) GetSeries(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := analytics.SeriesFilter{
+		Granularity: analytics.Granularity(c.Query("granularity", string(analytics.GranularityDay))),
+		TreeCode:    c.Query("tree_code"),
+		LocationID:  c.Query("location_id"),
+	}
+	var err error
+	if filter.From, filter.To, err = parseRangeQuery(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	series, err := h.scans.Series(ctx, filter)
+	if err != nil {
+		return c.Status(analyticsErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"data":        series,
+		"granularity": filter.Granularity,
+	})
+}
+
+// GetHeatmap handles GET /api/analytics/scans/heatmap
+// Filters: tree_code, location_id, from, to, precision (1-5 decimals, default 3)
+// Built from raw events, so only covers SCAN_EVENT_RETENTION_DAYS.
+func (h *AnalyticsHandler) GetHeatmap(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := analytics.HeatmapFilter{
+		TreeCode:   c.Query("tree_code"),
+		LocationID: c.Query("location_id"),
+		Precision:  c.QueryInt("precision", 3),
+	}
+	var err error
+	if filter.From, filter.To, err = parseRangeQuery(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	cells, err := h.scans.Heatmap(ctx, filter)
+	if err != nil {
+		return c.Status(analyticsErrorStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    cells,
+		"count":   len(cells),
+	})
+}
+
+func analyticsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, analytics.ErrInvalidRange):
+		return fiber.StatusBadRequest
+	case errors.Is(err, tree.ErrOutOfScope):
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// parseRangeQuery reads from/to like the audit log (plain "to" dates are inclusive)
+func parseRangeQuery(c *fiber.Ctx) (from, to time.Time, err error) {
+	if from, err = parseAuditTime(c.Query("from"), false); err != nil {
+		return from, to, errors.New("invalid from date")
+	}
+	if to, err = parseAuditTime(c.Query("to"), true); err != nil {
+		return from, to, errors.New("invalid to date")
+	}
+	return from, to, nil
+}
+
+// queryFloat returns a float query parameter, or nil when absent or malformed
+func queryFloat(c *fiber.Ctx, key string) *float64 {
+	v, err := strconv.ParseFloat(c.Query(key), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,173 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"prabogo/internal/domain/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler handles API key management for machine clients
+type APIKeyHandler struct {
+	authService *auth.AuthService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(authService *auth.AuthService) *APIKeyHandler {
+	return &APIKeyHandler{
+		authService: authService,
+	}
+}
+
+// Routes registers API key routes (user login only, keys cannot manage keys)
+func (h *APIKeyHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	api := app.Group("/api")
+	clients := api.Group("/clients", authMiddleware, RequirePermission(auth.PermClientManage))
+
+	clients.Get("/scopes", h.ListScopes)
+	clients.Get("/", h.ListAPIKeys)
+	clients.Post("/", h.CreateAPIKey)
+	clients.Put("/:id", h.UpdateAPIKey)
+	clients.Post("/:id/rotate", h.RotateAPIKey)
+	clients.Delete("/:id", h.RevokeAPIKey)
+}
+
+// apiKeyError maps API key errors to status codes
+func apiKeyError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, auth.ErrAPIKeyNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, auth.ErrInvalidAPIKey):
+		status = fiber.StatusConflict
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// ListScopes handles GET /api/clients/scopes
+func (h *APIKeyHandler) ListScopes(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    auth.APIKeyScopes,
+	})
+}
+
+// ListAPIKeys handles GET /api/clients
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	keys, err := h.authService.ListAPIKeys(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	if keys == nil {
+		keys = []*auth.APIKey{}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+// CreateAPIKey handles POST /api/clients
+// Body: {"name": "drone-01", "scopes": ["tree:read"], "rate_limit": 60, "expires_in_days": 365}
+// The key is only returned in this response.
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.APIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	issued, err := h.authService.CreateAPIKey(ctx, req, userID)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    issued,
+		"message": "store the key now, it cannot be shown again",
+	})
+}
+
+// UpdateAPIKey handles PUT /api/clients/:id
+// Replaces name, scopes, rate limit and expiry; the secret stays the same.
+func (h *APIKeyHandler) UpdateAPIKey(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apiKeyError(c, auth.ErrAPIKeyNotFound)
+	}
+
+	var req auth.APIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	key, err := h.authService.UpdateAPIKey(ctx, id, req)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// RotateAPIKey handles POST /api/clients/:id/rotate
+// Body (optional): {"expires_in_days": 365}
+func (h *APIKeyHandler) RotateAPIKey(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apiKeyError(c, auth.ErrAPIKeyNotFound)
+	}
+
+	var req struct {
+		ExpiresInDays int `json:"expires_in_days"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+		}
+	}
+
+	issued, err := h.authService.RotateAPIKey(ctx, id, req.ExpiresInDays)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    issued,
+		"message": "the previous key no longer works; store the new key now",
+	})
+}
+
+// RevokeAPIKey handles DELETE /api/clients/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apiKeyError(c, auth.ErrAPIKeyNotFound)
+	}
+
+	if err := h.authService.RevokeAPIKey(ctx, id); err != nil {
+		return apiKeyError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "api key revoked",
+	})
+}
@@ -0,0 +1,203 @@
+package http
+
+import (
+	"errors"
+	"time"
+
+	"prabogo/internal/domain/application"
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/tree"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ApplicationHandler handles fertilizer and pesticide application requests
+type ApplicationHandler struct {
+	applications *application.ApplicationService
+}
+
+// NewApplicationHandler creates a new application handler
+func NewApplicationHandler(applications *application.ApplicationService) *ApplicationHandler {
+	return &ApplicationHandler{
+		applications: applications,
+	}
+}
+
+// Routes registers application routes
+// Reading needs report:view, recording and deleting application:record.
+func (h *ApplicationHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	applications := app.Group("/api/applications", authMiddleware)
+
+	applications.Get("/", RequirePermission(auth.PermReportView), h.ListApplications)
+	applications.Post("/", RequirePermission(auth.PermApplicationRecord), h.RecordApplication)
+	applications.Get("/usage", RequirePermission(auth.PermReportView), h.Usage)
+	applications.Get("/:id", RequirePermission(auth.PermReportView), h.GetApplication)
+	applications.Delete("/:id", RequirePermission(auth.PermApplicationRecord), h.DeleteApplication)
+}
+
+// applicationError maps application errors to status codes
+func applicationError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, application.ErrApplicationNotFound), errors.Is(err, tree.ErrTreeNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, tree.ErrOutOfScope):
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// ListApplications handles GET /api/applications
+// Filters: location_id, tree_code, product (substring), category, from, to, limit, offset
+func (h *ApplicationHandler) ListApplications(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := application.Filter{
+		LocationID: c.Query("location_id"),
+		TreeCode:   c.Query("tree_code"),
+		Product:    c.Query("product"),
+		Category:   application.Category(c.Query("category")),
+		Limit:      c.QueryInt("limit", 20),
+		Offset:     c.QueryInt("offset", 0),
+	}
+	var err error
+	if filter.From, filter.To, err = parseRangeQuery(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	applications, total, err := h.applications.List(ctx, filter)
+	if err != nil {
+		return applicationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    applications,
+		"count":   len(applications),
+		"total":   total,
+	})
+}
+
+// GetApplication handles GET /api/applications/:id
+func (h *ApplicationHandler) GetApplication(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	a, err := h.applications.Get(ctx, c.Params("id"))
+	if err != nil {
+		return applicationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    a,
+	})
+}
+
+// RecordApplication handles POST /api/applications
+// Body: {"product": "NPK 15-15-15", "category": "fertilizer", "dose_per_tree": 0.5, "unit": "kg",
+// "method": "ring", "batch_number": "B-2026-10", "operator_id": "...", "applied_at": "2026-10-18",
+// "notes": "...", "tree_codes": ["C001"] | "location_id": "LOC001", "species_id": "...", "set_status": true}
+// operator_id defaults to the caller; location_id covers every living tree of the block.
+func (h *ApplicationHandler) RecordApplication(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	userID, _ := c.Locals("userID").(string)
+
+	var req struct {
+		Product     string   `json:"product"`
+		Category    string   `json:"category"`
+		DosePerTree float64  `json:"dose_per_tree"`
+		Unit        string   `json:"unit"`
+		Method      string   `json:"method"`
+		BatchNumber string   `json:"batch_number"`
+		OperatorID  string   `json:"operator_id"`
+		AppliedAt   string   `json:"applied_at"`
+		Notes       string   `json:"notes"`
+		TreeCodes   []string `json:"tree_codes"`
+		LocationID  string   `json:"location_id"`
+		SpeciesID   string   `json:"species_id"`
+		SetStatus   bool     `json:"set_status"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	appliedAt, err := time.Parse("2006-01-02", req.AppliedAt)
+	if err != nil {
+		if appliedAt, err = time.Parse(time.RFC3339, req.AppliedAt); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid applied_at (expected YYYY-MM-DD or RFC3339)"})
+		}
+	}
+
+	a, warnings, err := h.applications.Record(ctx, application.RecordRequest{
+		Product:     req.Product,
+		Category:    application.Category(req.Category),
+		DosePerTree: req.DosePerTree,
+		Unit:        application.Unit(req.Unit),
+		Method:      application.Method(req.Method),
+		BatchNumber: req.BatchNumber,
+		OperatorID:  req.OperatorID,
+		AppliedAt:   appliedAt.UTC(),
+		Notes:       req.Notes,
+		TreeCodes:   req.TreeCodes,
+		LocationID:  req.LocationID,
+		SpeciesID:   req.SpeciesID,
+		SetStatus:   req.SetStatus,
+	}, userID)
+	if err != nil {
+		return applicationError(c, err)
+	}
+
+	// The tree list of a whole block is long; GET /api/applications/:id has it
+	a.Trees = nil
+	response := fiber.Map{
+		"success": true,
+		"data":    a,
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// DeleteApplication handles DELETE /api/applications/:id
+func (h *ApplicationHandler) DeleteApplication(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	if err := h.applications.Delete(ctx, c.Params("id")); err != nil {
+		return applicationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Application record deleted",
+	})
+}
+
+// Usage handles GET /api/applications/usage
+// Query: period (day, week, month; default month), location_id, product, category, from, to
+// Returns quantities per period, block and product for inventory reconciliation.
+func (h *ApplicationHandler) Usage(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := application.UsageFilter{
+		Period:     application.Period(c.Query("period")),
+		LocationID: c.Query("location_id"),
+		Product:    c.Query("product"),
+		Category:   application.Category(c.Query("category")),
+	}
+	var err error
+	if filter.From, filter.To, err = parseRangeQuery(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	rows, err := h.applications.Usage(ctx, filter)
+	if err != nil {
+		return applicationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rows,
+		"count":   len(rows),
+	})
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler exposes the audit log to administrators
+type AuditHandler struct {
+	auditService *audit.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService *audit.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// Routes registers audit routes (audit:read)
+func (h *AuditHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	api := app.Group("/api")
+	auditGroup := api.Group("/audit", authMiddleware, RequirePermission(auth.PermAuditRead))
+
+	auditGroup.Get("/", h.GetAuditLog)
+	auditGroup.Get("/verify", h.VerifyAuditLog)
+}
+
+// GetAuditLog handles GET /api/audit
+// Filters: actor_id, action (prefix), target_type, target_id, from, to (RFC3339 or YYYY-MM-DD), limit, offset
+func (h *AuditHandler) GetAuditLog(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := audit.Filter{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Limit:      c.QueryInt("limit", 50),
+		Offset:     c.QueryInt("offset", 0),
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid from date"})
+	}
+	if filter.To, err = parseAuditTime(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid to date"})
+	}
+
+	entries, err := h.auditService.Query(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    entries,
+		"count":   len(entries),
+	})
+}
+
+// VerifyAuditLog handles GET /api/audit/verify
+func (h *AuditHandler) VerifyAuditLog(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	result, err := h.auditService.Verify(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// parseAuditTime accepts RFC3339 timestamps or plain dates; empty means unset
+// A plain "to" date is made inclusive by moving it to the next midnight.
+func parseAuditTime(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return t, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -1,8 +1,12 @@
 package http
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
 	"prabogo/internal/domain/auth"
-	"prabogo/utils/activity"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -27,14 +31,35 @@ func (h *AuthHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
 	// Public routes
 	authGroup.Post("/register", h.Register)
 	authGroup.Post("/login", h.Login)
+	authGroup.Post("/refresh", h.Refresh)
+	authGroup.Post("/password/forgot", h.ForgotPassword)
+	authGroup.Post("/password/reset", h.ResetPassword)
+	authGroup.Post("/2fa/verify", h.VerifyTwoFactor)
+	authGroup.Post("/2fa/enroll/setup", h.SetupTwoFactorEnrollment)
+	authGroup.Post("/2fa/enroll/complete", h.CompleteTwoFactorEnrollment)
+	authGroup.Get("/oidc", h.GetOIDCConfig)
+	authGroup.Get("/oidc/login", h.StartOIDCLogin)
+	authGroup.Post("/oidc/callback", h.CompleteOIDCLogin)
+	authGroup.Get("/invitations/:token", h.GetInvitation)
+	authGroup.Post("/invitations/accept", h.AcceptInvitation)
 
 	// Protected routes
 	authGroup.Get("/me", authMiddleware, h.GetMe)
+	authGroup.Put("/me", authMiddleware, h.UpdateMe)
+	authGroup.Get("/me/permissions", authMiddleware, h.GetMyPermissions)
+	authGroup.Post("/logout", authMiddleware, h.Logout)
+	authGroup.Post("/logout-all", authMiddleware, h.LogoutAll)
+	authGroup.Post("/password/change", authMiddleware, h.ChangePassword)
+	authGroup.Get("/2fa", authMiddleware, h.GetTwoFactorStatus)
+	authGroup.Post("/2fa/setup", authMiddleware, h.SetupTwoFactor)
+	authGroup.Post("/2fa/enable", authMiddleware, h.EnableTwoFactor)
+	authGroup.Post("/2fa/disable", authMiddleware, h.DisableTwoFactor)
+	authGroup.Post("/2fa/recovery-codes", authMiddleware, h.RegenerateRecoveryCodes)
 }
 
 // Register handles POST /api/auth/register
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 
 	// Parse request
 	var req auth.RegisterRequest
@@ -63,7 +88,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 
 // Login handles POST /api/auth/login
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 
 	// Parse request
 	var req auth.LoginRequest
@@ -75,7 +100,52 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	// Login user
-	token, user, err := h.authService.Login(ctx, req)
+	result, err := h.authService.Login(ctx, req)
+	if err != nil {
+		return authError(c, err, fiber.StatusUnauthorized)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    newLoginResultResponse(result),
+	})
+}
+
+// authError maps login-related errors to status codes
+// Throttled attempts get 429 with Retry-After in whole seconds.
+func authError(c *fiber.Ctx, err error, fallback int) error {
+	var throttled *auth.LoginThrottledError
+	status := fallback
+	switch {
+	case errors.As(err, &throttled):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		status = fiber.StatusTooManyRequests
+	case errors.Is(err, auth.ErrInvalidTwoFactorCode), errors.Is(err, auth.ErrWrongPassword):
+		status = fiber.StatusUnauthorized
+	case errors.Is(err, auth.ErrTwoFactorRequired):
+		status = fiber.StatusForbidden
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// Refresh handles POST /api/auth/refresh
+// The presented refresh token is consumed; the response carries its replacement.
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid request body",
+		})
+	}
+
+	pair, user, err := h.authService.Refresh(ctx, req.RefreshToken)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"success": false,
@@ -85,13 +155,156 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data": auth.LoginResponse{
-			Token: token,
-			User:  user.ToResponse(),
-		},
+		"data":    newLoginResponse(pair, user),
+	})
+}
+
+// Logout handles POST /api/auth/logout (current session only)
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	sessionID, _ := c.Locals("sessionID").(string)
+	if err := h.authService.Logout(ctx, sessionID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "logged out",
+	})
+}
+
+// LogoutAll handles POST /api/auth/logout-all (every session of the current user)
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	userID, _ := c.Locals("userID").(string)
+	revoked, err := h.authService.LogoutAll(ctx, userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "logged out from all sessions",
+		"revoked": revoked,
+	})
+}
+
+// ChangePassword handles POST /api/auth/password/change
+// All sessions are revoked; the response carries a new token pair for the caller.
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "unauthorized",
+		})
+	}
+
+	var req auth.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid request body",
+		})
+	}
+
+	pair, err := h.authService.ChangePassword(ctx, user.ID, req)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, auth.ErrWrongPassword) {
+			status = fiber.StatusForbidden
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "password changed, other sessions signed out",
+		"data":    newLoginResponse(pair, user),
+	})
+}
+
+// ForgotPassword handles POST /api/auth/password/forgot
+// Always answers the same way so it does not reveal which emails are registered.
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "email is required",
+		})
+	}
+
+	if err := h.authService.ForgotPassword(ctx, req.Email); err != nil {
+		fmt.Printf("❌ ERROR: Password reset request failed: %v\n", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "if the email is registered, a reset link has been sent",
+	})
+}
+
+// ResetPassword handles POST /api/auth/password/reset
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid request body",
+		})
+	}
+
+	if err := h.authService.ResetPassword(ctx, req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "password has been reset, please login",
 	})
 }
 
+func newLoginResultResponse(result *auth.LoginResult) auth.LoginResponse {
+	if result.Tokens != nil {
+		return newLoginResponse(result.Tokens, result.User)
+	}
+	return auth.LoginResponse{
+		TwoFactorRequired:  result.TwoFactorRequired,
+		EnrollmentRequired: result.EnrollmentRequired,
+		PreAuthToken:       result.PreAuthToken,
+	}
+}
+
+func newLoginResponse(pair *auth.TokenPair, user *auth.User) auth.LoginResponse {
+	return auth.LoginResponse{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+		User:         user.ToResponse(),
+	}
+}
+
 // GetMe handles GET /api/auth/me
 func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 	// Get user from context (set by middleware)
@@ -108,3 +321,109 @@ func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 		"data":    user.ToResponse(),
 	})
 }
+
+// UpdateMe handles PUT /api/auth/me
+// Lets a user change their own full name and email.
+func (h *AuthHandler) UpdateMe(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "unauthorized",
+		})
+	}
+
+	var req auth.ProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid request body",
+		})
+	}
+
+	updated, err := h.authService.UpdateProfile(ctx, userActor(c), user.ID, req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "profile updated",
+		"data":    updated.ToResponse(),
+	})
+}
+
+// GetInvitation handles GET /api/auth/invitations/:token
+// Shows the accept page which email and role the invitation is for.
+func (h *AuthHandler) GetInvitation(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	preview, err := h.authService.GetInvitation(ctx, c.Params("token"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    preview,
+	})
+}
+
+// AcceptInvitation handles POST /api/auth/invitations/accept
+func (h *AuthHandler) AcceptInvitation(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.AcceptInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid request body",
+		})
+	}
+
+	user, err := h.authService.AcceptInvitation(ctx, req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "account created, please login",
+		"data":    user.ToResponse(),
+	})
+}
+
+// GetMyPermissions handles GET /api/auth/me/permissions
+// The web UI uses it to hide actions the user's role does not allow.
+func (h *AuthHandler) GetMyPermissions(c *fiber.Ctx) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "unauthorized",
+		})
+	}
+
+	// locations is null when the role can access every location
+	locations, _ := c.Locals("locationScope").([]string)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"role":        user.Role,
+			"permissions": GetCurrentPermissions(c).List(),
+			"locations":   locations,
+		},
+	})
+}
@@ -0,0 +1,316 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/diagnosis"
+	"prabogo/internal/domain/tree"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DiagnosisHandler handles pest and disease catalog, diagnosis and outbreak requests
+type DiagnosisHandler struct {
+	diagnoses *diagnosis.DiagnosisService
+}
+
+// NewDiagnosisHandler creates a new diagnosis handler
+func NewDiagnosisHandler(diagnoses *diagnosis.DiagnosisService) *DiagnosisHandler {
+	return &DiagnosisHandler{
+		diagnoses: diagnoses,
+	}
+}
+
+// Routes registers diagnosis routes
+// Any signed-in user can read the catalog; editing it and acknowledging alerts
+// needs diagnosis:manage. Diagnosing a tree is a status update (tree:update).
+func (h *DiagnosisHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	diagnoses := app.Group("/api/diagnoses", authMiddleware)
+
+	diagnoses.Get("/catalog", h.ListCatalog)
+	diagnoses.Post("/catalog", RequirePermission(auth.PermDiagnosisManage), h.CreateEntry)
+	diagnoses.Get("/catalog/:id", h.GetEntry)
+	diagnoses.Put("/catalog/:id", RequirePermission(auth.PermDiagnosisManage), h.UpdateEntry)
+	diagnoses.Delete("/catalog/:id", RequirePermission(auth.PermDiagnosisManage), h.DeleteEntry)
+	diagnoses.Get("/spread", RequirePermission(auth.PermReportView), h.Spread)
+	diagnoses.Get("/alerts", RequirePermission(auth.PermReportView), h.ListAlerts)
+	diagnoses.Post("/alerts/:id/acknowledge", RequirePermission(auth.PermDiagnosisManage), h.AcknowledgeAlert)
+
+	// Not a group: /api/trees/:code is public
+	app.Get("/api/trees/:code/diagnoses", authMiddleware, RequireScope(auth.ScopeMonitoringRead), h.TreeDiagnoses)
+	app.Post("/api/trees/:code/diagnoses", authMiddleware, RequirePermission(auth.PermTreeUpdate), h.Diagnose)
+}
+
+// diagnosisError maps diagnosis errors to status codes
+func diagnosisError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, diagnosis.ErrEntryNotFound), errors.Is(err, diagnosis.ErrAlertNotFound), errors.Is(err, tree.ErrTreeNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, diagnosis.ErrDuplicateCode), errors.Is(err, diagnosis.ErrEntryInUse), errors.Is(err, tree.ErrVersionConflict):
+		status = fiber.StatusConflict
+	case errors.Is(err, tree.ErrOutOfScope):
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// catalogEntryRequest is the body for creating and updating catalog entries
+type catalogEntryRequest struct {
+	Code            string                    `json:"code"`
+	Name            string                    `json:"name"`
+	Kind            string                    `json:"kind"`
+	Description     string                    `json:"description"`
+	Treatment       string                    `json:"treatment"`
+	SeverityScale   []diagnosis.SeverityLevel `json:"severity_scale"`
+	AlertThreshold  int                       `json:"alert_threshold"`
+	AlertWindowDays int                       `json:"alert_window_days"`
+	Active          *bool                     `json:"active"`
+}
+
+func (r catalogEntryRequest) toEntry() diagnosis.CatalogEntry {
+	entry := diagnosis.CatalogEntry{
+		Code:            r.Code,
+		Name:            r.Name,
+		Kind:            diagnosis.Kind(r.Kind),
+		Description:     r.Description,
+		Treatment:       r.Treatment,
+		SeverityScale:   r.SeverityScale,
+		AlertThreshold:  r.AlertThreshold,
+		AlertWindowDays: r.AlertWindowDays,
+		Active:          true,
+	}
+	if r.Active != nil {
+		entry.Active = *r.Active
+	}
+	return entry
+}
+
+// ListCatalog handles GET /api/diagnoses/catalog (?active=true for diagnosable entries only)
+func (h *DiagnosisHandler) ListCatalog(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	entries, err := h.diagnoses.ListCatalog(ctx, c.QueryBool("active"))
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    entries,
+		"count":   len(entries),
+	})
+}
+
+// GetEntry handles GET /api/diagnoses/catalog/:id (ID or code)
+func (h *DiagnosisHandler) GetEntry(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	entry, err := h.diagnoses.GetEntry(ctx, c.Params("id"))
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    entry,
+	})
+}
+
+// CreateEntry handles POST /api/diagnoses/catalog
+// Body: {"code": "BERCAK_DAUN", "name": "...", "kind": "disease", "treatment": "...",
+// "severity_scale": [{"level": 1, "label": "ringan"}, {"level": 2, "label": "berat", "treatment": "..."}],
+// "alert_threshold": 5, "alert_window_days": 14}
+func (h *DiagnosisHandler) CreateEntry(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req catalogEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	entry, err := h.diagnoses.CreateEntry(ctx, req.toEntry())
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    entry,
+	})
+}
+
+// UpdateEntry handles PUT /api/diagnoses/catalog/:id (full replacement, same body as create)
+func (h *DiagnosisHandler) UpdateEntry(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req catalogEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	entry, err := h.diagnoses.UpdateEntry(ctx, c.Params("id"), req.toEntry())
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    entry,
+	})
+}
+
+// DeleteEntry handles DELETE /api/diagnoses/catalog/:id
+func (h *DiagnosisHandler) DeleteEntry(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	if err := h.diagnoses.DeleteEntry(ctx, c.Params("id")); err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "catalog entry deleted",
+	})
+}
+
+// Diagnose handles POST /api/trees/:code/diagnoses
+// Body: {"status": "SAKIT", "health_score": 40, "notes": "...",
+// "diagnoses": [{"entry": "GANODERMA", "severity": 2, "notes": "tubuh buah di pangkal"}]}
+// Writes a monitoring log like PUT /api/trees/:code/status (status and health_score
+// default to the current values) and attaches the diagnoses. Honors If-Match.
+func (h *DiagnosisHandler) Diagnose(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	userID, _ := c.Locals("userID").(string)
+
+	var req struct {
+		Status      string `json:"status"`
+		HealthScore *int   `json:"health_score"`
+		Notes       string `json:"notes"`
+		Diagnoses   []struct {
+			Entry    string `json:"entry"`
+			Severity int    `json:"severity"`
+			Notes    string `json:"notes"`
+		} `json:"diagnoses"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	expectedVersion, ok := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid If-Match header (use the ETag from GET /api/trees/:code)",
+		})
+	}
+
+	findings := make([]diagnosis.Finding, len(req.Diagnoses))
+	for i, d := range req.Diagnoses {
+		findings[i] = diagnosis.Finding{EntryID: d.Entry, Severity: d.Severity, Notes: d.Notes}
+	}
+
+	diagnoses, err := h.diagnoses.Diagnose(ctx, diagnosis.DiagnoseRequest{
+		TreeCode:        c.Params("code"),
+		Status:          tree.TreeStatus(req.Status),
+		HealthScore:     req.HealthScore,
+		Notes:           req.Notes,
+		Findings:        findings,
+		ExpectedVersion: expectedVersion,
+	}, userID)
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    diagnoses,
+		"message": fmt.Sprintf("%d diagnoses recorded", len(diagnoses)),
+	})
+}
+
+// TreeDiagnoses handles GET /api/trees/:code/diagnoses
+func (h *DiagnosisHandler) TreeDiagnoses(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	diagnoses, err := h.diagnoses.TreeDiagnoses(ctx, c.Params("code"))
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    diagnoses,
+		"count":   len(diagnoses),
+	})
+}
+
+// Spread handles GET /api/diagnoses/spread
+// Query: period (day, week, month; default week), entry (ID or code), location_id, from, to
+// Returns cases per period, block and pest or disease.
+func (h *DiagnosisHandler) Spread(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := diagnosis.SpreadFilter{
+		Period:     diagnosis.Period(c.Query("period")),
+		EntryID:    c.Query("entry"),
+		LocationID: c.Query("location_id"),
+	}
+	var err error
+	if filter.From, filter.To, err = parseRangeQuery(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	rows, err := h.diagnoses.Spread(ctx, filter)
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rows,
+		"count":   len(rows),
+	})
+}
+
+// ListAlerts handles GET /api/diagnoses/alerts
+// Filters: entry (ID or code), location_id, unacknowledged=true, limit, offset
+func (h *DiagnosisHandler) ListAlerts(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	alerts, total, err := h.diagnoses.Alerts(ctx, diagnosis.AlertFilter{
+		EntryID:        c.Query("entry"),
+		LocationID:     c.Query("location_id"),
+		Unacknowledged: c.QueryBool("unacknowledged"),
+		Limit:          c.QueryInt("limit", 20),
+		Offset:         c.QueryInt("offset", 0),
+	})
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    alerts,
+		"count":   len(alerts),
+		"total":   total,
+	})
+}
+
+// AcknowledgeAlert handles POST /api/diagnoses/alerts/:id/acknowledge
+func (h *DiagnosisHandler) AcknowledgeAlert(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	userID, _ := c.Locals("userID").(string)
+
+	alert, err := h.diagnoses.AcknowledgeAlert(ctx, c.Params("id"), userID)
+	if err != nil {
+		return diagnosisError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    alert,
+	})
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"prabogo/utils/export"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamExport sets download headers and streams rows produced by write.
+// Rows are flushed to the client as they are written, so the full export
+// is never held in memory. Errors after the header is sent can only be logged.
+func streamExport(c *fiber.Ctx, format export.Format, name string, header []string, write func(export.RowWriter) error) error {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		rw, err := export.NewRowWriter(format, w)
+		if err != nil {
+			fmt.Printf("❌ Export %s failed: %v\n", filename, err)
+			return
+		}
+
+		if err := rw.WriteRow(header); err != nil {
+			fmt.Printf("❌ Export %s failed: %v\n", filename, err)
+			return
+		}
+
+		if err := write(rw); err != nil {
+			fmt.Printf("❌ Export %s interrupted: %v\n", filename, err)
+		}
+
+		if err := rw.Close(); err != nil {
+			fmt.Printf("❌ Export %s failed to finalise: %v\n", filename, err)
+		}
+		w.Flush()
+	})
+
+	return nil
+}
+
+// usernameCache memoizes user ID lookups for the duration of one export
+type usernameCache struct {
+	lookup func(userID string) string
+	names  map[string]string
+}
+
+func newUsernameCache(lookup func(userID string) string) *usernameCache {
+	return &usernameCache{lookup: lookup, names: make(map[string]string)}
+}
+
+// Get returns the resolved username, querying at most once per user ID
+func (u *usernameCache) Get(userID string) string {
+	if name, ok := u.names[userID]; ok {
+		return name
+	}
+	name := u.lookup(userID)
+	u.names[userID] = name
+	return name
+}
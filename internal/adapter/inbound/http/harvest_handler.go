@@ -0,0 +1,337 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/harvest"
+	"prabogo/internal/domain/tree"
+	"prabogo/utils/export"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HarvestHandler handles harvest and yield requests
+type HarvestHandler struct {
+	harvests *harvest.HarvestService
+}
+
+// NewHarvestHandler creates a new harvest handler
+func NewHarvestHandler(harvests *harvest.HarvestService) *HarvestHandler {
+	return &HarvestHandler{
+		harvests: harvests,
+	}
+}
+
+// Routes registers harvest routes
+// Reading needs report:view, exports report:export, recording and deleting harvest:record.
+func (h *HarvestHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	harvests := app.Group("/api/harvests", authMiddleware)
+
+	harvests.Get("/", RequirePermission(auth.PermReportView), h.ListHarvests)
+	harvests.Post("/", RequirePermission(auth.PermHarvestRecord), h.RecordHarvest)
+	harvests.Post("/batch", RequirePermission(auth.PermHarvestRecord), h.RecordHarvests)
+	harvests.Get("/export", RequirePermission(auth.PermReportExport), h.ExportHarvests)
+	harvests.Get("/yield", RequirePermission(auth.PermReportView), h.Yield)
+	harvests.Get("/yield/export", RequirePermission(auth.PermReportExport), h.ExportYield)
+	harvests.Get("/:id", RequirePermission(auth.PermReportView), h.GetHarvest)
+	harvests.Delete("/:id", RequirePermission(auth.PermHarvestRecord), h.DeleteHarvest)
+}
+
+// harvestError maps harvest errors to status codes
+func harvestError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, harvest.ErrHarvestNotFound), errors.Is(err, tree.ErrTreeNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, tree.ErrOutOfScope):
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// harvestRequest is one record in POST bodies
+type harvestRequest struct {
+	TreeCode    string  `json:"tree_code"`
+	LocationID  string  `json:"location_id"`
+	Bunches     int     `json:"bunches"`
+	WeightKg    float64 `json:"weight_kg"`
+	Grade       string  `json:"grade"`
+	HarvesterID string  `json:"harvester_id"`
+	HarvestedAt string  `json:"harvested_at"`
+	Notes       string  `json:"notes"`
+}
+
+func (r harvestRequest) toRecord() (harvest.RecordRequest, error) {
+	harvestedAt, err := time.Parse("2006-01-02", r.HarvestedAt)
+	if err != nil {
+		if harvestedAt, err = time.Parse(time.RFC3339, r.HarvestedAt); err != nil {
+			return harvest.RecordRequest{}, errors.New("invalid harvested_at (expected YYYY-MM-DD or RFC3339)")
+		}
+	}
+	return harvest.RecordRequest{
+		TreeCode:    r.TreeCode,
+		LocationID:  r.LocationID,
+		Bunches:     r.Bunches,
+		WeightKg:    r.WeightKg,
+		Grade:       harvest.Grade(r.Grade),
+		HarvesterID: r.HarvesterID,
+		HarvestedAt: harvestedAt.UTC(),
+		Notes:       r.Notes,
+	}, nil
+}
+
+// parseHarvestFilter reads Filter fields from query params
+func parseHarvestFilter(c *fiber.Ctx) (harvest.Filter, error) {
+	filter := harvest.Filter{
+		TreeCode:    c.Query("tree_code"),
+		LocationID:  c.Query("location_id"),
+		HarvesterID: c.Query("harvester_id"),
+		Grade:       harvest.Grade(c.Query("grade")),
+	}
+	var err error
+	filter.From, filter.To, err = parseRangeQuery(c)
+	return filter, err
+}
+
+// ListHarvests handles GET /api/harvests
+// Filters: tree_code, location_id, harvester_id, grade, from, to, limit, offset
+func (h *HarvestHandler) ListHarvests(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter, err := parseHarvestFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	filter.Limit = c.QueryInt("limit", 20)
+	filter.Offset = c.QueryInt("offset", 0)
+
+	harvests, total, err := h.harvests.List(ctx, filter)
+	if err != nil {
+		return harvestError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    harvests,
+		"count":   len(harvests),
+		"total":   total,
+	})
+}
+
+// GetHarvest handles GET /api/harvests/:id
+func (h *HarvestHandler) GetHarvest(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	record, err := h.harvests.Get(ctx, c.Params("id"))
+	if err != nil {
+		return harvestError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    record,
+	})
+}
+
+// RecordHarvest handles POST /api/harvests
+// Body: {"tree_code": "C001" | "location_id": "LOC001", "bunches": 2, "weight_kg": 46.5,
+// "grade": "A", "harvester_id": "...", "harvested_at": "2026-10-18", "notes": "..."}
+// Without tree_code the record is for the whole block; harvester_id defaults to the caller.
+func (h *HarvestHandler) RecordHarvest(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	userID, _ := c.Locals("userID").(string)
+
+	var req harvestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+	record, err := req.toRecord()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	harvests, err := h.harvests.Record(ctx, []harvest.RecordRequest{record}, userID)
+	if err != nil {
+		return harvestError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    harvests[0],
+	})
+}
+
+// RecordHarvests handles POST /api/harvests/batch
+// Body: {"records": [<same as POST /api/harvests>, ...]} (max 500, all or nothing)
+func (h *HarvestHandler) RecordHarvests(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	userID, _ := c.Locals("userID").(string)
+
+	var req struct {
+		Records []harvestRequest `json:"records"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	records := make([]harvest.RecordRequest, len(req.Records))
+	for i, r := range req.Records {
+		record, err := r.toRecord()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "record " + strconv.Itoa(i+1) + ": " + err.Error(),
+			})
+		}
+		records[i] = record
+	}
+
+	harvests, err := h.harvests.Record(ctx, records, userID)
+	if err != nil {
+		return harvestError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    harvests,
+		"count":   len(harvests),
+	})
+}
+
+// DeleteHarvest handles DELETE /api/harvests/:id
+func (h *HarvestHandler) DeleteHarvest(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	if err := h.harvests.Delete(ctx, c.Params("id")); err != nil {
+		return harvestError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "harvest record deleted",
+	})
+}
+
+// ExportHarvests handles GET /api/harvests/export?format=csv|xlsx (same filters as the list)
+func (h *HarvestHandler) ExportHarvests(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	filter, err := parseHarvestFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	header := []string{
+		"id", "harvested_at", "location_id", "tree_code", "bunches", "weight_kg", "grade",
+		"harvester_id", "notes", "created_by", "created_at",
+	}
+
+	return streamExport(c, format, "harvests", header, func(rw export.RowWriter) error {
+		return h.harvests.Export(ctx, filter, func(r *harvest.Harvest) error {
+			return rw.WriteRow([]string{
+				r.ID, r.HarvestedAt.Format("2006-01-02"), r.LocationID, r.TreeCode,
+				strconv.Itoa(r.Bunches), strconv.FormatFloat(r.WeightKg, 'f', 2, 64), string(r.Grade),
+				r.HarvesterID, r.Notes, r.CreatedBy, r.CreatedAt.Format(time.RFC3339),
+			})
+		})
+	})
+}
+
+// parseYieldFilter reads YieldFilter fields from query params
+func parseYieldFilter(c *fiber.Ctx) (harvest.YieldFilter, error) {
+	filter := harvest.YieldFilter{
+		Period:     harvest.Period(c.Query("period")),
+		LocationID: c.Query("location_id"),
+		TreeCode:   c.Query("tree_code"),
+	}
+	var err error
+	filter.From, filter.To, err = parseRangeQuery(c)
+	return filter, err
+}
+
+// Yield handles GET /api/harvests/yield
+// Query: period (week, month, year; default month), location_id, tree_code, from, to
+// Returns yield per tree and per hectare per period and block with the average
+// health score and status mix of the same trees, yield by status at harvest
+// time, and the correlation between health and yield.
+func (h *HarvestHandler) Yield(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter, err := parseYieldFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	report, err := h.harvests.Yield(ctx, filter)
+	if err != nil {
+		return harvestError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// ExportYield handles GET /api/harvests/yield/export?format=csv|xlsx (series of GET /api/harvests/yield)
+func (h *HarvestHandler) ExportYield(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	filter, err := parseYieldFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	// The series is small, so it is computed before the download starts
+	report, err := h.harvests.Yield(ctx, filter)
+	if err != nil {
+		return harvestError(c, err)
+	}
+
+	header := []string{
+		"period_start", "location_id", "records", "bunches", "weight_kg", "avg_bunch_kg",
+		"productive_trees", "kg_per_tree", "area_hectare", "kg_per_hectare",
+		"avg_health_score", "monitoring_logs", "share_sehat", "share_sakit", "share_dipupuk", "share_dipantau", "share_mati",
+	}
+
+	return streamExport(c, format, "yield", header, func(rw export.RowWriter) error {
+		for _, p := range report.Series {
+			err := rw.WriteRow([]string{
+				p.PeriodStart.Format("2006-01-02"), p.LocationID, strconv.Itoa(p.Records), strconv.Itoa(p.Bunches),
+				formatFloat(p.WeightKg), formatFloat(p.AvgBunchKg),
+				strconv.Itoa(p.ProductiveTrees), formatFloat(p.KgPerTree), formatFloat(p.AreaHectare), formatOptional(p.KgPerHectare),
+				formatOptional(p.AvgHealthScore), strconv.Itoa(p.MonitoringLogs),
+				formatFloat(p.StatusShare[string(tree.StatusSehat)]), formatFloat(p.StatusShare[string(tree.StatusSakit)]),
+				formatFloat(p.StatusShare[string(tree.StatusDipupuk)]), formatFloat(p.StatusShare[string(tree.StatusDipantau)]),
+				formatFloat(p.StatusShare[string(tree.StatusMati)]),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// formatOptional leaves missing values empty
+func formatOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
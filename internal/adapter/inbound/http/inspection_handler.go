@@ -0,0 +1,187 @@
+package http
+
+import (
+	"errors"
+
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/inspection"
+	"prabogo/internal/domain/tree"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// InspectionHandler serves inspection schedules and due inspections
+type InspectionHandler struct {
+	inspections *inspection.InspectionService
+}
+
+// NewInspectionHandler creates a new inspection handler
+func NewInspectionHandler(inspections *inspection.InspectionService) *InspectionHandler {
+	return &InspectionHandler{
+		inspections: inspections,
+	}
+}
+
+// Routes registers inspection routes
+// Anyone can list the inspections assigned to them; report:view lists all.
+func (h *InspectionHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	api := app.Group("/api/inspections", authMiddleware)
+
+	api.Get("/due", h.GetDue)
+	api.Get("/schedules", RequirePermission(auth.PermReportView), h.ListSchedules)
+	api.Post("/schedules", RequirePermission(auth.PermInspectionManage), h.CreateSchedule)
+	api.Put("/schedules/:id", RequirePermission(auth.PermInspectionManage), h.UpdateSchedule)
+	api.Delete("/schedules/:id", RequirePermission(auth.PermInspectionManage), h.DeleteSchedule)
+}
+
+// inspectionScheduleRequest is the body of POST and PUT /api/inspections/schedules
+type inspectionScheduleRequest struct {
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	SpeciesID    string `json:"species_id"`
+	LocationID   string `json:"location_id"`
+	TreeCode     string `json:"tree_code"`
+	IntervalDays int    `json:"interval_days"`
+	LeadDays     int    `json:"lead_days"`
+	AssigneeID   string `json:"assignee_id"`
+	CreateTasks  bool   `json:"create_tasks"`
+	Enabled      *bool  `json:"enabled"` // Default true
+}
+
+func (r inspectionScheduleRequest) schedule() inspection.Schedule {
+	return inspection.Schedule{
+		Name:         r.Name,
+		Status:       tree.TreeStatus(r.Status),
+		SpeciesID:    r.SpeciesID,
+		LocationID:   r.LocationID,
+		TreeCode:     r.TreeCode,
+		IntervalDays: r.IntervalDays,
+		LeadDays:     r.LeadDays,
+		AssigneeID:   r.AssigneeID,
+		CreateTasks:  r.CreateTasks,
+		Enabled:      r.Enabled == nil || *r.Enabled,
+	}
+}
+
+// inspectionError maps inspection errors to status codes
+func inspectionError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, inspection.ErrScheduleNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, tree.ErrOutOfScope):
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// GetDue handles GET /api/inspections/due
+// Filters: assignee_id (or "me"), location_id, species_id, overdue=true,
+// within_days (also list inspections due soon), limit, offset
+// Without report:view only the caller's own inspections are listed.
+func (h *InspectionHandler) GetDue(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := inspection.DueFilter{
+		AssigneeID: c.Query("assignee_id"),
+		LocationID: c.Query("location_id"),
+		SpeciesID:  c.Query("species_id"),
+		Overdue:    c.QueryBool("overdue"),
+		WithinDays: c.QueryInt("within_days", 0),
+		Limit:      c.QueryInt("limit", 20),
+		Offset:     c.QueryInt("offset", 0),
+	}
+	userID, _ := c.Locals("userID").(string)
+	if filter.AssigneeID == "me" || !GetCurrentPermissions(c).Has(auth.PermReportView) {
+		filter.AssigneeID = userID
+	}
+
+	dues, total, byAssignee, err := h.inspections.Due(ctx, filter)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, tree.ErrOutOfScope) {
+			status = fiber.StatusForbidden
+		}
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"data":        dues,
+		"count":       len(dues),
+		"total":       total,
+		"by_assignee": byAssignee,
+	})
+}
+
+// ListSchedules handles GET /api/inspections/schedules
+func (h *InspectionHandler) ListSchedules(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	schedules, err := h.inspections.ListSchedules(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    schedules,
+	})
+}
+
+// CreateSchedule handles POST /api/inspections/schedules
+// Body: {"name": "Pemantauan intensif", "status": "DIPANTAU", "interval_days": 14,
+// "lead_days": 2, "assignee_id": "...", "create_tasks": true}
+func (h *InspectionHandler) CreateSchedule(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req inspectionScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	schedule, err := h.inspections.CreateSchedule(ctx, req.schedule())
+	if err != nil {
+		return inspectionError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// UpdateSchedule handles PUT /api/inspections/schedules/:id
+// Replaces every setting of the schedule.
+func (h *InspectionHandler) UpdateSchedule(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req inspectionScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	schedule, err := h.inspections.UpdateSchedule(ctx, c.Params("id"), req.schedule())
+	if err != nil {
+		return inspectionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    schedule,
+	})
+}
+
+// DeleteSchedule handles DELETE /api/inspections/schedules/:id
+func (h *InspectionHandler) DeleteSchedule(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	if err := h.inspections.DeleteSchedule(ctx, c.Params("id")); err != nil {
+		return inspectionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "inspection schedule deleted",
+	})
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"errors"
+
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/tree"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaintenanceHandler serves the maintenance queue and its rules
+type MaintenanceHandler struct {
+	usecase *tree.TreeUseCase
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(usecase *tree.TreeUseCase) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		usecase: usecase,
+	}
+}
+
+// Routes registers maintenance routes
+// Reading rules is open to report viewers so the queue can be explained.
+func (h *MaintenanceHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	api := app.Group("/api/maintenance", authMiddleware)
+
+	api.Get("/", RequirePermission(auth.PermReportView), h.GetQueue)
+	api.Get("/rules", RequirePermission(auth.PermReportView), h.ListRules)
+	api.Post("/rules", RequirePermission(auth.PermMaintenanceManage), h.CreateRule)
+	api.Put("/rules/:id", RequirePermission(auth.PermMaintenanceManage), h.UpdateRule)
+	api.Delete("/rules/:id", RequirePermission(auth.PermMaintenanceManage), h.DeleteRule)
+}
+
+// maintenanceRuleRequest is the body of POST and PUT /api/maintenance/rules
+type maintenanceRuleRequest struct {
+	Code       string                   `json:"code"`
+	Kind       tree.MaintenanceRuleKind `json:"kind"`
+	Statuses   []tree.TreeStatus        `json:"statuses"`
+	Threshold  float64                  `json:"threshold"`
+	Weight     int                      `json:"weight"`
+	SpeciesID  string                   `json:"species_id"`
+	LocationID string                   `json:"location_id"`
+	Reason     string                   `json:"reason"`
+	Enabled    *bool                    `json:"enabled"` // Default true
+}
+
+func (r maintenanceRuleRequest) rule() tree.MaintenanceRule {
+	enabled := r.Enabled == nil || *r.Enabled
+	return tree.MaintenanceRule{
+		Code:       r.Code,
+		Kind:       r.Kind,
+		Statuses:   r.Statuses,
+		Threshold:  r.Threshold,
+		Weight:     r.Weight,
+		SpeciesID:  r.SpeciesID,
+		LocationID: r.LocationID,
+		Reason:     r.Reason,
+		Enabled:    enabled,
+	}
+}
+
+// maintenanceError maps maintenance errors to status codes
+func maintenanceError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, tree.ErrMaintenanceRuleNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, tree.ErrMaintenanceRuleExists):
+		status = fiber.StatusConflict
+	case errors.Is(err, tree.ErrOutOfScope):
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// GetQueue handles GET /api/maintenance
+// Filters: location_id, species_id, limit (default 20, max 100), offset
+// Trees are ranked by score (sum of matched rule weights); the queue is
+// recomputed periodically, as_of tells when.
+func (h *MaintenanceHandler) GetQueue(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := tree.MaintenanceFilter{
+		LocationID: c.Query("location_id"),
+		SpeciesID:  c.Query("species_id"),
+		Limit:      c.QueryInt("limit", 20),
+		Offset:     c.QueryInt("offset", 0),
+	}
+
+	queue, total, asOf, err := h.usecase.MaintenanceQueue(ctx, filter)
+	if err != nil {
+		return c.Status(treeErrorStatus(err, fiber.StatusInternalServerError)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    queue,
+		"count":   len(queue),
+		"total":   total,
+		"as_of":   asOf,
+	})
+}
+
+// ListRules handles GET /api/maintenance/rules
+func (h *MaintenanceHandler) ListRules(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	rules, err := h.usecase.ListMaintenanceRules(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// CreateRule handles POST /api/maintenance/rules
+// Body: {"code": "low_health", "kind": "health_below", "threshold": 70, "weight": 40,
+// "species_id": "SP-001", "reason": "Kesehatan Buruk"}
+func (h *MaintenanceHandler) CreateRule(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req maintenanceRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	rule, err := h.usecase.CreateMaintenanceRule(ctx, req.rule())
+	if err != nil {
+		return maintenanceError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// UpdateRule handles PUT /api/maintenance/rules/:id
+// Replaces every setting of the rule.
+func (h *MaintenanceHandler) UpdateRule(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req maintenanceRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	rule, err := h.usecase.UpdateMaintenanceRule(ctx, c.Params("id"), req.rule())
+	if err != nil {
+		return maintenanceError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// DeleteRule handles DELETE /api/maintenance/rules/:id
+func (h *MaintenanceHandler) DeleteRule(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	if err := h.usecase.DeleteMaintenanceRule(ctx, c.Params("id")); err != nil {
+		return maintenanceError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "maintenance rule deleted",
+	})
+}
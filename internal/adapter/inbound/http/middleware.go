@@ -1,12 +1,19 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
 	"strings"
 
+	"prabogo/internal/domain/audit"
 	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/tree"
 	"prabogo/utils/activity"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // AuthMiddleware validates JWT token
@@ -34,7 +41,7 @@ func AuthMiddleware(authService *auth.AuthService) fiber.Handler {
 
 		// Validate token and get user
 		ctx := activity.NewContext(c.Path())
-		user, err := authService.ValidateToken(ctx, token)
+		user, claims, err := authService.ValidateAccessToken(ctx, token)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"success": false,
@@ -42,34 +49,152 @@ func AuthMiddleware(authService *auth.AuthService) fiber.Handler {
 			})
 		}
 
+		// Resolve role permissions once per request (cached in the service)
+		permissions, err := authService.PermissionsForRole(ctx, user.Role)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   "failed to load permissions",
+			})
+		}
+
+		// Field users without tree:all_locations are limited to their assigned locations
+		if !permissions.Has(auth.PermTreeAllLocs) {
+			locationIDs, err := authService.GetUserLocations(ctx, user.ID)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"success": false,
+					"error":   "failed to load assigned locations",
+				})
+			}
+			c.Locals("locationScope", locationIDs)
+		}
+
 		// Store user in context
 		c.Locals("user", user)
 		c.Locals("userID", user.ID)
 		c.Locals("userRole", user.Role)
+		c.Locals("sessionID", claims.SessionID)
+		c.Locals("permissions", permissions)
+
+		return c.Next()
+	}
+}
+
+// OptionalAuthMiddleware identifies signed-in users on public routes
+// Requests without a valid token continue anonymously. Location scope is not
+// applied: public pages show every tree.
+func OptionalAuthMiddleware(authService *auth.AuthService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		parts := strings.Split(c.Get("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return c.Next()
+		}
+
+		ctx := activity.NewContext(c.Path())
+		if user, _, err := authService.ValidateAccessToken(ctx, parts[1]); err == nil {
+			c.Locals("user", user)
+			c.Locals("userID", user.ID)
+		}
+
+		return c.Next()
+	}
+}
+
+// APIKeyMiddleware accepts machine clients' API keys and passes every other
+// request to userAuth. Keys come as "X-API-Key: tlk_..." or "Bearer tlk_...".
+// The key's scopes become its permissions; writes act on behalf of the admin
+// who issued it. Keys see trees in every location.
+func APIKeyMiddleware(authService *auth.AuthService, limiter *auth.APIKeyLimiter, userAuth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		raw := c.Get("X-API-Key")
+		if raw == "" {
+			if bearer := strings.TrimPrefix(c.Get("Authorization"), "Bearer "); auth.IsAPIKey(bearer) {
+				raw = bearer
+			}
+		}
+		if raw == "" {
+			return userAuth(c)
+		}
+
+		ctx := activity.NewContext(c.Path())
+		key, err := authService.ValidateAPIKey(ctx, raw)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		remaining, err := limiter.Allow(ctx, key)
+		c.Set("X-RateLimit-Limit", strconv.Itoa(key.RateLimit))
+		if err != nil {
+			var limited *auth.APIKeyRateLimitedError
+			if errors.As(err, &limited) {
+				c.Set("X-RateLimit-Remaining", "0")
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+			return err
+		}
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		c.Locals("apiKey", key)
+		c.Locals("userID", key.CreatedBy)
+		c.Locals("permissions", key.ScopeSet())
+
+		return c.Next()
+	}
+}
 
+// RequireScope limits API keys to routes their scopes cover; users pass
+// through, because these routes only need a login for them.
+func RequireScope(scope auth.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetCurrentAPIKey(c) != nil && !GetCurrentPermissions(c).Has(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "api key lacks scope " + string(scope),
+			})
+		}
 		return c.Next()
 	}
 }
 
-// RoleMiddleware checks if user has required role
-func RoleMiddleware(allowedRoles ...auth.UserRole) fiber.Handler {
+// RequirePermission allows the request only if the user's role (or the API
+// key's scopes) grants every given permission. Must run after AuthMiddleware.
+func RequirePermission(perms ...auth.Permission) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get user from context
-		user, ok := c.Locals("user").(*auth.User)
-		if !ok {
+		if _, ok := c.Locals("user").(*auth.User); !ok && GetCurrentAPIKey(c) == nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"success": false,
 				"error":   "unauthorized",
 			})
 		}
 
-		// Check if user role is allowed
-		for _, role := range allowedRoles {
-			if user.Role == role {
+		if !GetCurrentPermissions(c).Has(perms...) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "insufficient permissions",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// RequireAnyPermission allows the request if the user's role grants at least
+// one of the given permissions. Must run after AuthMiddleware.
+func RequireAnyPermission(perms ...auth.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		permissions := GetCurrentPermissions(c)
+		for _, p := range perms {
+			if permissions.Has(p) {
 				return c.Next()
 			}
 		}
-
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"error":   "insufficient permissions",
@@ -85,3 +210,85 @@ func GetCurrentUser(c *fiber.Ctx) (*auth.User, error) {
 	}
 	return user, nil
 }
+
+// GetCurrentAPIKey returns the API key set by APIKeyMiddleware (nil for users)
+func GetCurrentAPIKey(c *fiber.Ctx) *auth.APIKey {
+	key, _ := c.Locals("apiKey").(*auth.APIKey)
+	return key
+}
+
+// GetCurrentPermissions returns the permissions resolved by AuthMiddleware
+func GetCurrentPermissions(c *fiber.Ctx) auth.PermissionSet {
+	permissions, _ := c.Locals("permissions").(auth.PermissionSet)
+	return permissions
+}
+
+// RequestIDMiddleware assigns one transaction ID per request
+// The ID is echoed in X-Transaction-ID so clients can quote it in bug reports.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		trxID := c.Get("X-Transaction-ID")
+		if trxID == "" {
+			trxID = uuid.New().String()
+		}
+		c.Locals("transactionID", trxID)
+		c.Set("X-Transaction-ID", trxID)
+		return c.Next()
+	}
+}
+
+// AuditMiddleware records every mutating request after it has been handled
+// Only method, route, params and status are stored - never request bodies,
+// which may contain passwords. Services add detailed before/after entries.
+func AuditMiddleware(recorder audit.Recorder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		recorder.Record(requestContext(c), audit.Entry{
+			Action:     c.Method() + " " + c.Route().Path,
+			TargetType: audit.TargetRequest,
+			TargetID:   c.Path(),
+			After:      audit.Snapshot(map[string]interface{}{"status": status, "params": c.AllParams()}),
+		})
+
+		return err
+	}
+}
+
+// requestContext builds the activity context for a handler
+// It carries the request's transaction ID, the authenticated user and client info.
+func requestContext(c *fiber.Ctx) context.Context {
+	var ctx context.Context
+	if trxID, ok := c.Locals("transactionID").(string); ok && trxID != "" {
+		ctx = activity.NewContextWithTransactionID(c.Path(), trxID)
+	} else {
+		ctx = activity.NewContext(c.Path())
+	}
+
+	if key := GetCurrentAPIKey(c); key != nil {
+		ctx = activity.WithClientID(ctx, strconv.Itoa(key.ID))
+		ctx = activity.WithActorID(ctx, key.ActorID())
+	} else if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+		ctx = activity.WithActorID(ctx, userID)
+	}
+
+	if locationIDs, ok := c.Locals("locationScope").([]string); ok {
+		ctx = tree.WithLocationScope(ctx, locationIDs)
+	}
+
+	return activity.WithClientInfo(ctx, c.IP(), c.Get(fiber.HeaderUserAgent))
+}
@@ -2,11 +2,13 @@ package http
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"prabogo/internal/adapter/outbound/monitoring_repository"
 	"prabogo/internal/domain/auth"
 	"prabogo/internal/domain/tree"
-	"prabogo/utils/activity"
+	"prabogo/utils/export"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -34,12 +36,12 @@ func (h *MonitoringHandler) populateUsername(ctx context.Context, userID string)
 	if err != nil || user == nil {
 		return userID // Fallback to UUID if lookup fails
 	}
-	return user.Username
+	return user.DisplayName()
 }
 
 // GetTreeHistory returns monitoring logs for a specific tree
 func (h *MonitoringHandler) GetTreeHistory(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 	treeCode := c.Params("code")
 
 	if treeCode == "" {
@@ -57,6 +59,12 @@ func (h *MonitoringHandler) GetTreeHistory(c *fiber.Ctx) error {
 			"error":   "tree not found in database",
 		})
 	}
+	if err := tree.CheckLocationScope(ctx, treeData.LocationID); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
 
 	// ✅ FIX Step 2: Query PostgreSQL logs directly by Tree ID (bypassing broken join)
 	logs, err := h.monitoringRepo.GetLogsByTreeID(ctx, treeData.ID)
@@ -96,6 +104,54 @@ func (h *MonitoringHandler) GetTreeHistory(c *fiber.Ctx) error {
 	})
 }
 
+// ExportTreeHistory handles GET /api/trees/:code/history/export?format=csv|xlsx
+func (h *MonitoringHandler) ExportTreeHistory(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	treeCode := c.Params("code")
+
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	treeData, err := h.treeRepo.FindByCode(ctx, treeCode)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "tree not found in database",
+		})
+	}
+	if err := tree.CheckLocationScope(ctx, treeData.LocationID); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	usernames := newUsernameCache(func(userID string) string {
+		return h.populateUsername(ctx, userID)
+	})
+
+	header := []string{
+		"tree_code", "monitor_date", "status", "health_score", "height_meters", "diameter_cm",
+		"observations", "actions_taken", "monitored_by", "monitored_by_username", "created_at",
+	}
+
+	return streamExport(c, format, "history-"+treeData.Code, header, func(rw export.RowWriter) error {
+		return h.monitoringRepo.StreamLogsByTreeID(ctx, treeData.ID, func(log *monitoring_repository.MonitoringLog) error {
+			return rw.WriteRow([]string{
+				treeData.Code, log.MonitorDate.UTC().Format(time.RFC3339), log.Status, strconv.Itoa(log.HealthScore),
+				strconv.FormatFloat(log.HeightMeters, 'f', 2, 64), strconv.FormatFloat(log.DiameterCm, 'f', 2, 64),
+				log.Observations, log.ActionsTaken, log.MonitoredBy, usernames.Get(log.MonitoredBy),
+				log.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		})
+	})
+}
+
 // batchFetchUsernames fetches all usernames in a single query (N+1 optimization)
 func (h *MonitoringHandler) batchFetchUsernames(ctx context.Context, userIDs map[string]bool) map[string]string {
 	if len(userIDs) == 0 {
@@ -108,7 +164,7 @@ func (h *MonitoringHandler) batchFetchUsernames(ctx context.Context, userIDs map
 	for userID := range userIDs {
 		user, err := h.userRepo.FindByID(ctx, userID)
 		if err == nil && user != nil {
-			result[userID] = user.Username
+			result[userID] = user.DisplayName()
 		}
 	}
 
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"errors"
+	"time"
+
+	"prabogo/internal/domain/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Single sign-on routes live on AuthHandler (registered in AuthHandler.Routes)
+
+// oidcStateCookie holds the signed login state between start and callback
+const oidcStateCookie = "oidc_state"
+
+// GetOIDCConfig handles GET /api/auth/oidc (lets the login page show the SSO button)
+func (h *AuthHandler) GetOIDCConfig(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"enabled": h.authService.OIDCEnabled()},
+	})
+}
+
+// StartOIDCLogin handles GET /api/auth/oidc/login
+// Returns the provider URL; the browser keeps the state in an HttpOnly cookie.
+func (h *AuthHandler) StartOIDCLogin(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	authURL, stateToken, err := h.authService.StartOIDCLogin(ctx)
+	if err != nil {
+		return oidcError(c, err)
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Path:     "/api/auth/oidc",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"authorization_url": authURL},
+	})
+}
+
+// CompleteOIDCLogin handles POST /api/auth/oidc/callback
+// Body: {"code": "...", "state": "..."} as received on the redirect URL.
+// The response is the same as POST /api/auth/login.
+func (h *AuthHandler) CompleteOIDCLogin(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req struct {
+		Code  string `json:"code"`
+		State string `json:"state"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid request body",
+		})
+	}
+
+	stateToken := c.Cookies(oidcStateCookie)
+	c.ClearCookie(oidcStateCookie)
+
+	result, err := h.authService.CompleteOIDCLogin(ctx, stateToken, req.State, req.Code)
+	if err != nil {
+		return oidcError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    newLoginResultResponse(result),
+	})
+}
+
+// oidcError maps single sign-on errors to status codes
+func oidcError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusUnauthorized
+	switch {
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		status = fiber.StatusNotFound
+	case errors.Is(err, auth.ErrOIDCState):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, auth.ErrOIDCNoAccount):
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
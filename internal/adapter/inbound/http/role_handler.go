@@ -0,0 +1,125 @@
+package http
+
+import (
+	"errors"
+
+	"prabogo/internal/domain/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RoleHandler handles role and permission management requests
+type RoleHandler struct {
+	authService *auth.AuthService
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(authService *auth.AuthService) *RoleHandler {
+	return &RoleHandler{
+		authService: authService,
+	}
+}
+
+// Routes registers role routes
+// Listing is also open to user managers, who pick roles when assigning them.
+func (h *RoleHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	api := app.Group("/api", authMiddleware)
+
+	api.Get("/permissions", RequireAnyPermission(auth.PermRoleManage, auth.PermUserManage), h.ListPermissions)
+	api.Get("/roles", RequireAnyPermission(auth.PermRoleManage, auth.PermUserManage), h.ListRoles)
+	api.Post("/roles", RequirePermission(auth.PermRoleManage), h.CreateRole)
+	api.Put("/roles/:name", RequirePermission(auth.PermRoleManage), h.UpdateRole)
+	api.Delete("/roles/:name", RequirePermission(auth.PermRoleManage), h.DeleteRole)
+}
+
+// roleError maps role errors to status codes
+func roleError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, auth.ErrRoleNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, auth.ErrRoleBuiltIn), errors.Is(err, auth.ErrRoleInUse):
+		status = fiber.StatusConflict
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// ListPermissions handles GET /api/permissions
+func (h *RoleHandler) ListPermissions(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    auth.Permissions,
+	})
+}
+
+// ListRoles handles GET /api/roles
+func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	roles, err := h.authService.ListRoles(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    roles,
+	})
+}
+
+// CreateRole handles POST /api/roles
+// Body: {"name": "surveyor", "description": "...", "permissions": ["tree:create"]}
+func (h *RoleHandler) CreateRole(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	role, err := h.authService.CreateRole(ctx, req)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// UpdateRole handles PUT /api/roles/:name
+// Replaces the description and the full permission list.
+func (h *RoleHandler) UpdateRole(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	role, err := h.authService.UpdateRole(ctx, auth.UserRole(c.Params("name")), req)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// DeleteRole handles DELETE /api/roles/:name
+// Built-in roles and roles still assigned to users cannot be deleted.
+func (h *RoleHandler) DeleteRole(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	if err := h.authService.DeleteRole(ctx, auth.UserRole(c.Params("name"))); err != nil {
+		return roleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "role deleted",
+	})
+}
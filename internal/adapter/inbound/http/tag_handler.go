@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"prabogo/internal/adapter/outbound/species_repository"
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/tree"
+	"prabogo/utils/label"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxLabelsPerBatch caps one PDF request (~42 A4 sheets)
+const maxLabelsPerBatch = 1000
+
+// TagHandler renders printable QR tags for trees
+type TagHandler struct {
+	usecase     *tree.TreeUseCase
+	speciesRepo *species_repository.SpeciesRepository
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(usecase *tree.TreeUseCase, speciesRepo *species_repository.SpeciesRepository) *TagHandler {
+	return &TagHandler{
+		usecase:     usecase,
+		speciesRepo: speciesRepo,
+	}
+}
+
+// Routes registers tag routes
+// Must be registered before the public /api/trees/:code route.
+func (h *TagHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	api := app.Group("/api")
+	trees := api.Group("/trees")
+
+	trees.Get("/labels", authMiddleware, RequirePermission(auth.PermTagPrint), h.GetLabelSheet)
+	trees.Get("/:code/qr", authMiddleware, h.GetQRCode)
+}
+
+// publicTreeURL builds the URL encoded in a tree's QR code
+// PUBLIC_BASE_URL should be set when the API sits behind a proxy.
+func publicTreeURL(c *fiber.Ctx, code string) string {
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = c.BaseURL()
+	}
+	return strings.TrimRight(base, "/") + "/#/trees/" + code
+}
+
+// GetQRCode handles GET /api/trees/:code/qr?format=png|svg&size=256
+func (h *TagHandler) GetQRCode(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	t, err := h.usecase.GetTreeByCode(ctx, c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	url := publicTreeURL(c, t.Code)
+
+	switch c.Query("format", "png") {
+	case "svg":
+		svg, err := label.QRCodeSVG(url)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		c.Set(fiber.HeaderContentType, "image/svg+xml")
+		return c.SendString(svg)
+
+	case "png":
+		size, err := strconv.Atoi(c.Query("size", "256"))
+		if err != nil || size < 64 || size > 2048 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "size must be between 64 and 2048 pixels",
+			})
+		}
+		png, err := label.QRCodePNG(url, size)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Send(png)
+	}
+
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"success": false,
+		"error":   "unsupported format (use png or svg)",
+	})
+}
+
+// GetLabelSheet handles GET /api/trees/labels
+// Accepts the list filters plus an optional code range (from=C001&to=C050).
+func (h *TagHandler) GetLabelSheet(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := parseTreeFilter(c)
+
+	from, to := -1, -1
+	if v := c.Query("from"); v != "" {
+		if _, err := fmt.Sscanf(v, "C%d", &from); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid from code"})
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if _, err := fmt.Sscanf(v, "C%d", &to); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid to code"})
+		}
+	}
+
+	// Species names are optional decoration; fall back to IDs
+	speciesNames, err := h.speciesRepo.CommonNames(ctx)
+	if err != nil {
+		fmt.Printf("⚠️ Warning: species lookup failed, printing IDs: %v\n", err)
+		speciesNames = map[string]string{}
+	}
+
+	var labels []label.Label
+	err = h.usecase.ExportTrees(ctx, filter, func(t *tree.TreeResponse) error {
+		var num int
+		fmt.Sscanf(t.Code, "C%d", &num)
+		if (from >= 0 && num < from) || (to >= 0 && num > to) {
+			return nil
+		}
+		if len(labels) >= maxLabelsPerBatch {
+			return fmt.Errorf("too many labels (max %d), narrow the filter", maxLabelsPerBatch)
+		}
+
+		species, ok := speciesNames[t.SpeciesID]
+		if !ok {
+			species = t.SpeciesID
+		}
+		labels = append(labels, label.Label{
+			Code:         t.Code,
+			Species:      species,
+			PlantingDate: t.PlantingDate,
+			URL:          publicTreeURL(c, t.Code),
+		})
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if len(labels) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "no trees match the filter",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := label.WriteLabelSheet(&buf, labels); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="labels-%s.pdf"`, time.Now().Format("20060102-150405")))
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,282 @@
+package http
+
+import (
+	"errors"
+	"time"
+
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/task"
+	"prabogo/internal/domain/tree"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TaskHandler handles work order requests
+type TaskHandler struct {
+	tasks *task.TaskService
+}
+
+// NewTaskHandler creates a new task handler
+func NewTaskHandler(tasks *task.TaskService) *TaskHandler {
+	return &TaskHandler{
+		tasks: tasks,
+	}
+}
+
+// Routes registers task routes
+// Any user can list and work on tasks assigned to them; creating, editing,
+// verifying and rejecting needs task:manage.
+func (h *TaskHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
+	tasks := app.Group("/api/tasks", authMiddleware)
+
+	tasks.Get("/", h.ListTasks)
+	tasks.Post("/", RequirePermission(auth.PermTaskManage), h.CreateTask)
+	tasks.Get("/:id", h.GetTask)
+	tasks.Put("/:id", RequirePermission(auth.PermTaskManage), h.UpdateTask)
+	tasks.Post("/:id/start", h.StartTask)
+	tasks.Post("/:id/complete", h.CompleteTask)
+	tasks.Post("/:id/verify", RequirePermission(auth.PermTaskManage), h.VerifyTask)
+	tasks.Post("/:id/reject", RequirePermission(auth.PermTaskManage), h.RejectTask)
+}
+
+// taskActor identifies the caller for the task service
+func taskActor(c *fiber.Ctx) task.Actor {
+	userID, _ := c.Locals("userID").(string)
+	return task.Actor{
+		UserID:    userID,
+		CanManage: GetCurrentPermissions(c).Has(auth.PermTaskManage),
+	}
+}
+
+// taskError maps task errors to status codes
+func taskError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case errors.Is(err, task.ErrTaskNotFound), errors.Is(err, tree.ErrTreeNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, task.ErrInvalidTransition), errors.Is(err, task.ErrTaskConflict):
+		status = fiber.StatusConflict
+	case errors.Is(err, tree.ErrOutOfScope):
+		status = fiber.StatusForbidden
+	}
+	return c.Status(status).JSON(fiber.Map{"success": false, "error": err.Error()})
+}
+
+// ListTasks handles GET /api/tasks
+// Filters: status, type, assignee_id, tree_code, location_id, overdue=true, limit, offset
+func (h *TaskHandler) ListTasks(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := task.Filter{
+		Status:     task.Status(c.Query("status")),
+		Type:       task.Type(c.Query("type")),
+		AssigneeID: c.Query("assignee_id"),
+		TreeCode:   c.Query("tree_code"),
+		LocationID: c.Query("location_id"),
+		Overdue:    c.QueryBool("overdue"),
+		Limit:      c.QueryInt("limit", 20),
+		Offset:     c.QueryInt("offset", 0),
+	}
+
+	tasks, total, err := h.tasks.List(ctx, taskActor(c), filter)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    tasks,
+		"count":   len(tasks),
+		"total":   total,
+	})
+}
+
+// GetTask handles GET /api/tasks/:id
+func (h *TaskHandler) GetTask(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	t, err := h.tasks.Get(ctx, taskActor(c), c.Params("id"))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    t,
+	})
+}
+
+// CreateTask handles POST /api/tasks
+// Body: {"type": "fertilize", "title": "...", "description": "...", "tree_codes": ["C001"],
+// "assignee_id": "...", "due_date": "2026-11-01", "result_status": "DIPUPUK"}
+func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req struct {
+		Type         string   `json:"type"`
+		Title        string   `json:"title"`
+		Description  string   `json:"description"`
+		TreeCodes    []string `json:"tree_codes"`
+		AssigneeID   string   `json:"assignee_id"`
+		DueDate      string   `json:"due_date"`
+		ResultStatus string   `json:"result_status"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid due_date (expected YYYY-MM-DD)"})
+	}
+
+	t, err := h.tasks.Create(ctx, taskActor(c), task.CreateRequest{
+		Type:         task.Type(req.Type),
+		Title:        req.Title,
+		Description:  req.Description,
+		TreeCodes:    req.TreeCodes,
+		AssigneeID:   req.AssigneeID,
+		DueDate:      dueDate,
+		ResultStatus: tree.TreeStatus(req.ResultStatus),
+	})
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    t,
+	})
+}
+
+// UpdateTask handles PUT /api/tasks/:id
+// Body (all optional): {"title", "description", "assignee_id", "due_date"}
+func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		AssigneeID  *string `json:"assignee_id"`
+		DueDate     *string `json:"due_date"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	update := task.UpdateRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		AssigneeID:  req.AssigneeID,
+	}
+	if req.DueDate != nil {
+		dueDate, err := time.Parse("2006-01-02", *req.DueDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid due_date (expected YYYY-MM-DD)"})
+		}
+		update.DueDate = &dueDate
+	}
+
+	t, err := h.tasks.Update(ctx, taskActor(c), c.Params("id"), update)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    t,
+	})
+}
+
+// StartTask handles POST /api/tasks/:id/start
+func (h *TaskHandler) StartTask(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	t, err := h.tasks.Start(ctx, taskActor(c), c.Params("id"))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    t,
+	})
+}
+
+// CompleteTask handles POST /api/tasks/:id/complete
+// Body: {"notes": "...", "health_score": 80} (health_score optional)
+// Writes a monitoring log per tree and applies the task's result status.
+func (h *TaskHandler) CompleteTask(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req struct {
+		Notes       string `json:"notes"`
+		HealthScore *int   `json:"health_score"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+		}
+	}
+
+	t, err := h.tasks.Complete(ctx, taskActor(c), c.Params("id"), task.CompleteRequest{
+		Notes:       req.Notes,
+		HealthScore: req.HealthScore,
+	})
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    t,
+	})
+}
+
+// VerifyTask handles POST /api/tasks/:id/verify
+// Body: {"notes": "..."} (optional)
+func (h *TaskHandler) VerifyTask(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+		}
+	}
+
+	t, err := h.tasks.Verify(ctx, taskActor(c), c.Params("id"), req.Notes)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    t,
+	})
+}
+
+// RejectTask handles POST /api/tasks/:id/reject
+// Body: {"notes": "why the work is not accepted"}
+func (h *TaskHandler) RejectTask(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request body"})
+	}
+
+	t, err := h.tasks.Reject(ctx, taskActor(c), c.Params("id"), req.Notes)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    t,
+	})
+}
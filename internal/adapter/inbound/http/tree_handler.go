@@ -2,14 +2,19 @@ package http
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"prabogo/internal/cache"
+	"prabogo/internal/domain/analytics"
 	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/harvest"
 	"prabogo/internal/domain/tree"
-	"prabogo/utils/activity"
+	"prabogo/utils/export"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,13 +23,17 @@ import (
 type TreeHandler struct {
 	usecase  *tree.TreeUseCase
 	userRepo auth.UserRepository
+	scans    *analytics.ScanService
+	harvests *harvest.HarvestService
 }
 
 // NewTreeHandler creates a new tree handler
-func NewTreeHandler(usecase *tree.TreeUseCase, userRepo auth.UserRepository) *TreeHandler {
+func NewTreeHandler(usecase *tree.TreeUseCase, userRepo auth.UserRepository, scans *analytics.ScanService, harvests *harvest.HarvestService) *TreeHandler {
 	return &TreeHandler{
 		usecase:  usecase,
 		userRepo: userRepo,
+		scans:    scans,
+		harvests: harvests,
 	}
 }
 
@@ -37,38 +46,48 @@ func (h *TreeHandler) populateUsername(ctx context.Context, userID string) strin
 	if err != nil || user == nil {
 		return userID // Fallback to UUID if lookup fails
 	}
-	return user.Username
+	return user.DisplayName()
 }
 
 // RoutesPublic registers public tree routes (accessible without login)
-func (h *TreeHandler) RoutesPublic(app *fiber.App) {
+// optionalAuth attributes scans to signed-in users.
+func (h *TreeHandler) RoutesPublic(app *fiber.App, optionalAuth fiber.Handler) {
 	api := app.Group("/api")
 	trees := api.Group("/trees")
 
 	// Public access to view tree details by code
-	trees.Get("/:code", h.GetTree)
+	trees.Get("/:code", optionalAuth, h.GetTree)
 }
 
 // RoutesWithAuth registers tree routes with authentication and role-based access
+// authMiddleware also accepts API keys; their scopes act as permissions.
 func (h *TreeHandler) RoutesWithAuth(app *fiber.App, authMiddleware fiber.Handler) {
 	api := app.Group("/api")
 	trees := api.Group("/trees")
 
 	// Protected Read Routes
-	trees.Get("/", authMiddleware, h.ListTrees)
-
-	// Protected Write Routes (Admin/Editor)
-	trees.Post("/", authMiddleware, RoleMiddleware(auth.RoleAdmin, auth.RoleEditor), h.CreateTree)
-	trees.Put("/:code/status", authMiddleware, RoleMiddleware(auth.RoleAdmin, auth.RoleEditor), h.UpdateTreeStatus)
-	trees.Delete("/:code", authMiddleware, RoleMiddleware(auth.RoleAdmin), h.DeleteTree)
-
-	// Stats available to all authenticated users
-	api.Get("/stats", authMiddleware, h.GetStatistics)
+	trees.Get("/", authMiddleware, RequireScope(auth.ScopeTreeRead), h.ListTrees)
+	trees.Get("/export", authMiddleware, RequireScope(auth.ScopeTreeRead), RequirePermission(auth.PermReportExport), h.ExportTrees)
+
+	// Protected Write Routes
+	trees.Post("/", authMiddleware, RequirePermission(auth.PermTreeCreate), h.CreateTree)
+	trees.Put("/:code/status", authMiddleware, RequirePermission(auth.PermTreeUpdate), h.UpdateTreeStatus)
+	trees.Patch("/:code", authMiddleware, RequirePermission(auth.PermTreeUpdate), h.PatchTree)
+	trees.Get("/:code/changes", authMiddleware, RequireScope(auth.ScopeTreeRead), h.GetTreeChanges)
+	// Trash (soft-deleted trees) - static paths before /:code
+	trees.Get("/trash", authMiddleware, RequirePermission(auth.PermTreeRestore), h.ListTrash)
+	trees.Delete("/trash", authMiddleware, RequirePermission(auth.PermTreePurge), h.PurgeExpiredTrash)
+	trees.Delete("/trash/:code", authMiddleware, RequirePermission(auth.PermTreePurge), h.PurgeTree)
+	trees.Post("/:code/restore", authMiddleware, RequirePermission(auth.PermTreeRestore), h.RestoreTree)
+	trees.Delete("/:code", authMiddleware, RequirePermission(auth.PermTreeDelete), h.DeleteTree)
+
+	// Stats
+	api.Get("/stats", authMiddleware, RequirePermission(auth.PermReportView), h.GetStatistics)
 }
 
 // CreateTree handles POST /api/trees
 func (h *TreeHandler) CreateTree(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 
 	// Parse request
 	var req struct {
@@ -109,7 +128,7 @@ func (h *TreeHandler) CreateTree(c *fiber.Ctx) error {
 	})
 
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(treeErrorStatus(err, fiber.StatusBadRequest)).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
@@ -121,29 +140,12 @@ func (h *TreeHandler) CreateTree(c *fiber.Ctx) error {
 	})
 }
 
-// GetTree handles GET /api/trees/:code with caching
+// GetTree handles GET /api/trees/:code (cached by the repository)
+// Every successful view is recorded as a scan; scanners may add ?lat=&lng=.
 func (h *TreeHandler) GetTree(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 	code := c.Params("code")
 
-	// Try cache first (Gib.Run - ~2-5ms)
-	// Try cache first (Gib.Run - ~2-5ms)
-	// Try cache first (Gib.Run - ~2-5ms)
-	// var cachedTree tree.Tree
-	// cacheHit, _ := cache.GetCachedTree(ctx, code, &cachedTree)
-
-	// if cacheHit {
-	// 	// Increment scan counter
-	// 	cache.IncrementScanCount(ctx, code)
-
-	// 	return c.JSON(fiber.Map{
-	// 		"success": true,
-	// 		"data":    cachedTree,
-	// 		"source":  "cache", // Debug: show cache hit
-	// 	})
-	// }
-
-	// Cache MISS -	// Get from database
 	response, err := h.usecase.GetTreeByCode(ctx, code)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -157,42 +159,34 @@ func (h *TreeHandler) GetTree(c *fiber.Ctx) error {
 		response.RegisteredByUsername = h.populateUsername(ctx, response.RegisteredBy)
 	}
 
-	// Store in cache for 5 minutes
-	cache.CacheTree(ctx, code, response, 5*time.Minute)
-
-	// Increment scan counter
+	// Increment scan counter and record the scan for analytics
 	cache.IncrementScanCount(ctx, code)
+	h.scans.Record(ctx, analytics.ScanEvent{
+		TreeID:     response.ID,
+		TreeCode:   response.Code,
+		LocationID: response.LocationID,
+		Latitude:   queryFloat(c, "lat"),
+		Longitude:  queryFloat(c, "lng"),
+	})
+
+	etag := treeETag(response.Version)
+	c.Set(fiber.HeaderETag, etag)
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    response,
-		"source":  "database", // Debug: show cache miss
-		"cached":  true,       // Now cached for next request
 	})
 }
 
 // ListTrees handles GET /api/trees
 func (h *TreeHandler) ListTrees(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 
 	// Parse query params
-	filter := tree.TreeFilter{
-		LocationID: c.Query("location_id"),
-		SpeciesID:  c.Query("species_id"),
-		Status:     tree.TreeStatus(c.Query("status")),
-	}
-
-	// Parse limit and offset
-	if limit := c.Query("limit"); limit != "" {
-		if val, err := strconv.Atoi(limit); err == nil {
-			filter.Limit = val
-		}
-	}
-	if offset := c.Query("offset"); offset != "" {
-		if val, err := strconv.Atoi(offset); err == nil {
-			filter.Offset = val
-		}
-	}
+	filter := parseTreeFilter(c)
 
 	// Default limit
 	if filter.Limit == 0 {
@@ -222,9 +216,45 @@ func (h *TreeHandler) ListTrees(c *fiber.Ctx) error {
 	})
 }
 
+// ExportTrees handles GET /api/trees/export?format=csv|xlsx
+func (h *TreeHandler) ExportTrees(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	// No default limit: export everything matching the filter
+	filter := parseTreeFilter(c)
+	usernames := newUsernameCache(func(userID string) string {
+		return h.populateUsername(ctx, userID)
+	})
+
+	header := []string{
+		"code", "species_id", "location_id", "planting_date", "age_years",
+		"height_meters", "diameter_cm", "status", "health_score", "notes",
+		"registered_by", "registered_by_username", "created_at", "updated_at",
+	}
+
+	return streamExport(c, format, "trees", header, func(rw export.RowWriter) error {
+		return h.usecase.ExportTrees(ctx, filter, func(t *tree.TreeResponse) error {
+			return rw.WriteRow([]string{
+				t.Code, t.SpeciesID, t.LocationID, t.PlantingDate, strconv.Itoa(t.AgeYears),
+				strconv.FormatFloat(t.HeightMeters, 'f', 2, 64), strconv.FormatFloat(t.DiameterCm, 'f', 2, 64),
+				t.Status, strconv.Itoa(t.HealthScore), t.Notes,
+				t.RegisteredBy, usernames.Get(t.RegisteredBy), t.CreatedAt, t.UpdatedAt,
+			})
+		})
+	})
+}
+
 // UpdateTreeStatus handles PUT /api/trees/:code/status
 func (h *TreeHandler) UpdateTreeStatus(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 	code := c.Params("code")
 
 	// Parse request
@@ -244,17 +274,31 @@ func (h *TreeHandler) UpdateTreeStatus(c *fiber.Ctx) error {
 	// Extract user ID from token
 	userID := c.Locals("userID").(string)
 
+	// Optional optimistic locking via If-Match: "<version>"
+	expectedVersion, ok := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid If-Match header (use the ETag from GET /api/trees/:code)",
+		})
+	}
+
 	// Call use case
-	err := h.usecase.UpdateTreeStatus(ctx, code, tree.TreeStatus(req.Status), req.HealthScore, req.Notes, userID)
+	err := h.usecase.UpdateTreeStatus(ctx, code, tree.TreeStatus(req.Status), req.HealthScore, req.Notes, userID, expectedVersion)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		if errors.Is(err, tree.ErrVersionConflict) {
+			return h.versionConflict(c, ctx, code, err)
+		}
+		return c.Status(treeErrorStatus(err, fiber.StatusBadRequest)).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
 	}
 
-	// Invalidate cache after update
-	cache.InvalidateTree(ctx, code)
+	// Return the new ETag so clients can chain updates
+	if updated, err := h.usecase.GetTreeByCode(ctx, code); err == nil {
+		c.Set(fiber.HeaderETag, treeETag(updated.Version))
+	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -262,14 +306,294 @@ func (h *TreeHandler) UpdateTreeStatus(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteTree handles DELETE /api/trees/:code
+// PatchTree handles PATCH /api/trees/:code (JSON merge patch of registration data)
+func (h *TreeHandler) PatchTree(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	code := c.Params("code")
+
+	patch, err := parseTreePatch(c.Body())
+	if err != nil {
+		return respondPatchError(c, err)
+	}
+
+	expectedVersion, ok := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid If-Match header (use the ETag from GET /api/trees/:code)",
+		})
+	}
+
+	userID := c.Locals("userID").(string)
+
+	response, changes, err := h.usecase.EditTree(ctx, code, patch, userID, expectedVersion)
+	if err != nil {
+		if errors.Is(err, tree.ErrVersionConflict) {
+			return h.versionConflict(c, ctx, code, err)
+		}
+		return respondPatchError(c, err)
+	}
+
+	if response.RegisteredBy != "" {
+		response.RegisteredByUsername = h.populateUsername(ctx, response.RegisteredBy)
+	}
+	if changes == nil {
+		changes = []tree.FieldChange{}
+	}
+
+	c.Set(fiber.HeaderETag, treeETag(response.Version))
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+		"changes": changes,
+	})
+}
+
+// GetTreeChanges handles GET /api/trees/:code/changes
+func (h *TreeHandler) GetTreeChanges(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	changes, err := h.usecase.GetTreeChanges(ctx, c.Params("code"))
+	if err != nil {
+		return c.Status(treeErrorStatus(err, fiber.StatusNotFound)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	usernames := newUsernameCache(func(userID string) string {
+		return h.populateUsername(ctx, userID)
+	})
+	for _, change := range changes {
+		change.ChangedByUsername = usernames.Get(change.ChangedBy)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// respondPatchError reports per-field validation errors when available
+func respondPatchError(c *fiber.Ctx, err error) error {
+	var fieldErrs tree.FieldErrors
+	if errors.As(err, &fieldErrs) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"error":   "validation failed",
+			"fields":  fieldErrs,
+		})
+	}
+	return c.Status(treeErrorStatus(err, fiber.StatusBadRequest)).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// treeErrorStatus maps trees outside the caller's locations to 403
+func treeErrorStatus(err error, fallback int) int {
+	if errors.Is(err, tree.ErrOutOfScope) {
+		return fiber.StatusForbidden
+	}
+	return fallback
+}
+
+// parseTreePatch decodes a JSON merge patch (RFC 7396) into a TreePatch
+// Absent members stay unchanged; null clears notes and measurements.
+func parseTreePatch(body []byte) (tree.TreePatch, error) {
+	var patch tree.TreePatch
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return patch, errors.New("Invalid request body (expected JSON object)")
+	}
+
+	errs := tree.FieldErrors{}
+	for field, value := range raw {
+		isNull := string(value) == "null"
+
+		switch field {
+		case "species_id", "location_id":
+			var v string
+			if isNull || json.Unmarshal(value, &v) != nil {
+				errs[field] = "must be a non-null string"
+				continue
+			}
+			if field == "species_id" {
+				patch.SpeciesID = &v
+			} else {
+				patch.LocationID = &v
+			}
+
+		case "planting_date":
+			var v string
+			if isNull || json.Unmarshal(value, &v) != nil {
+				errs[field] = "must be a date string (YYYY-MM-DD)"
+				continue
+			}
+			date, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				errs[field] = "invalid date format (use YYYY-MM-DD)"
+				continue
+			}
+			patch.PlantingDate = &date
+
+		case "height_meters", "diameter_cm":
+			var v float64
+			if !isNull && json.Unmarshal(value, &v) != nil {
+				errs[field] = "must be a number"
+				continue
+			}
+			if field == "height_meters" {
+				patch.HeightMeters = &v
+			} else {
+				patch.DiameterCm = &v
+			}
+
+		case "notes":
+			var v string
+			if !isNull && json.Unmarshal(value, &v) != nil {
+				errs[field] = "must be a string"
+				continue
+			}
+			patch.Notes = &v
+
+		case "status", "health_score":
+			errs[field] = "use PUT /api/trees/:code/status to change condition"
+
+		default:
+			errs[field] = "field is not editable"
+		}
+	}
+
+	if len(errs) > 0 {
+		return patch, errs
+	}
+	return patch, nil
+}
+
+// versionConflict responds 412 with the current tree state so the client can merge
+// The state is read past the cache, which may still hold the version that conflicted.
+func (h *TreeHandler) versionConflict(c *fiber.Ctx, ctx context.Context, code string, cause error) error {
+	current, err := h.usecase.GetCurrentTreeByCode(ctx, code)
+	if err != nil {
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"success": false,
+			"error":   cause.Error(),
+		})
+	}
+	if current.RegisteredBy != "" {
+		current.RegisteredByUsername = h.populateUsername(ctx, current.RegisteredBy)
+	}
+
+	c.Set(fiber.HeaderETag, treeETag(current.Version))
+	return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+		"success": false,
+		"error":   cause.Error(),
+		"current": current,
+	})
+}
+
+// DeleteTree handles DELETE /api/trees/:code (moves tree to trash)
 func (h *TreeHandler) DeleteTree(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 	code := c.Params("code")
 
-	err := h.usecase.DeleteTree(ctx, code)
+	// Reason may come from JSON body or ?reason= (some clients can't send DELETE bodies)
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = c.Query("reason")
+	}
+	if req.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "delete reason is required",
+		})
+	}
+
+	userID := c.Locals("userID").(string)
+
+	err := h.usecase.DeleteTree(ctx, code, userID, req.Reason)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		return c.Status(treeErrorStatus(err, fiber.StatusNotFound)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tree moved to trash",
+	})
+}
+
+// ListTrash handles GET /api/trees/trash
+func (h *TreeHandler) ListTrash(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	filter := parseTreeFilter(c)
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+
+	response, err := h.usecase.ListTrash(ctx, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	for i := range response {
+		if response[i].RegisteredBy != "" {
+			response[i].RegisteredByUsername = h.populateUsername(ctx, response[i].RegisteredBy)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"data":           response,
+		"total":          len(response),
+		"retention_days": int(tree.GetTrashRetention().Hours() / 24),
+	})
+}
+
+// RestoreTree handles POST /api/trees/:code/restore
+func (h *TreeHandler) RestoreTree(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	code := c.Params("code")
+	userID := c.Locals("userID").(string)
+
+	if err := h.usecase.RestoreTree(ctx, code, userID); err != nil {
+		return c.Status(treeErrorStatus(err, fiber.StatusBadRequest)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tree restored from trash",
+	})
+}
+
+// PurgeTree handles DELETE /api/trees/trash/:code
+func (h *TreeHandler) PurgeTree(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	code := c.Params("code")
+
+	if err := h.usecase.PurgeTree(ctx, code); err != nil {
+		return c.Status(treeErrorStatus(err, fiber.StatusConflict)).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
@@ -277,15 +601,35 @@ func (h *TreeHandler) DeleteTree(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "Tree deleted successfully",
+		"message": "Tree permanently deleted",
+	})
+}
+
+// PurgeExpiredTrash handles DELETE /api/trees/trash
+func (h *TreeHandler) PurgeExpiredTrash(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	purged, err := h.usecase.PurgeExpiredTrash(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"purged":  purged,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"purged":  purged,
 	})
 }
 
 // GetStatistics handles GET /api/stats
+// Production (harvest totals) is left out if it cannot be computed.
 func (h *TreeHandler) GetStatistics(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 
-	response, err := h.usecase.GetStatistics(ctx)
+	stats, err := h.usecase.GetStatistics(ctx)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -293,8 +637,61 @@ func (h *TreeHandler) GetStatistics(c *fiber.Ctx) error {
 		})
 	}
 
+	response := struct {
+		*tree.TreeStatisticsResponse
+		Production *harvest.Production `json:"production,omitempty"`
+	}{TreeStatisticsResponse: stats}
+	if production, err := h.harvests.Production(ctx); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to compute production statistics: %v\n", err)
+	} else {
+		response.Production = production
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    response,
 	})
 }
+
+// parseTreeFilter reads TreeFilter fields from query params
+func parseTreeFilter(c *fiber.Ctx) tree.TreeFilter {
+	filter := tree.TreeFilter{
+		LocationID: c.Query("location_id"),
+		SpeciesID:  c.Query("species_id"),
+		Status:     tree.TreeStatus(c.Query("status")),
+	}
+
+	// Parse limit and offset
+	if limit := c.Query("limit"); limit != "" {
+		if val, err := strconv.Atoi(limit); err == nil {
+			filter.Limit = val
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if val, err := strconv.Atoi(offset); err == nil {
+			filter.Offset = val
+		}
+	}
+
+	return filter
+}
+
+// treeETag formats a tree version as a strong ETag
+func treeETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseIfMatch extracts the expected version from an If-Match header
+// Missing header or "*" means no version check (returns 0).
+func parseIfMatch(header string) (int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	header = strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,203 @@
+package http
+
+import (
+	"prabogo/internal/domain/auth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Two-factor routes live on AuthHandler (registered in AuthHandler.Routes)
+
+// parseTwoFactorRequest reads the shared code request body
+func parseTwoFactorRequest(c *fiber.Ctx) (auth.TwoFactorCodeRequest, error) {
+	var req auth.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return req, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid request body",
+		})
+	}
+	return req, nil
+}
+
+// VerifyTwoFactor handles POST /api/auth/2fa/verify (second login step)
+// Body: {"pre_auth_token": "...", "code": "123456"} or {"pre_auth_token": "...", "recovery_code": "abcde-fghij"}
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	req, err := parseTwoFactorRequest(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.authService.VerifyTwoFactorLogin(ctx, req)
+	if err != nil {
+		return authError(c, err, fiber.StatusUnauthorized)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    newLoginResultResponse(result),
+	})
+}
+
+// SetupTwoFactorEnrollment handles POST /api/auth/2fa/enroll/setup
+// Used during login when the user's role requires 2FA but none is enrolled yet.
+func (h *AuthHandler) SetupTwoFactorEnrollment(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	req, err := parseTwoFactorRequest(c)
+	if err != nil {
+		return err
+	}
+
+	setup, err := h.authService.SetupTwoFactorEnrollment(ctx, req.PreAuthToken)
+	if err != nil {
+		return authError(c, err, fiber.StatusUnauthorized)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    setup,
+	})
+}
+
+// CompleteTwoFactorEnrollment handles POST /api/auth/2fa/enroll/complete
+// Returns a full login response plus the one-time recovery codes.
+func (h *AuthHandler) CompleteTwoFactorEnrollment(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	req, err := parseTwoFactorRequest(c)
+	if err != nil {
+		return err
+	}
+
+	result, codes, err := h.authService.CompleteTwoFactorEnrollment(ctx, req)
+	if err != nil {
+		return authError(c, err, fiber.StatusBadRequest)
+	}
+
+	response := newLoginResultResponse(result)
+	response.RecoveryCodes = codes
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// GetTwoFactorStatus handles GET /api/auth/2fa
+func (h *AuthHandler) GetTwoFactorStatus(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "unauthorized"})
+	}
+
+	status, err := h.authService.GetTwoFactorStatus(ctx, user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// SetupTwoFactor handles POST /api/auth/2fa/setup
+// Returns the secret and a QR code; 2FA is active only after /2fa/enable.
+func (h *AuthHandler) SetupTwoFactor(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "unauthorized"})
+	}
+
+	setup, err := h.authService.SetupTwoFactor(ctx, user.ID)
+	if err != nil {
+		return authError(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    setup,
+	})
+}
+
+// EnableTwoFactor handles POST /api/auth/2fa/enable
+func (h *AuthHandler) EnableTwoFactor(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "unauthorized"})
+	}
+
+	req, err := parseTwoFactorRequest(c)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.authService.EnableTwoFactor(ctx, user.ID, req.Code)
+	if err != nil {
+		return authError(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "two-factor authentication enabled, store the recovery codes safely",
+		"data":    fiber.Map{"recovery_codes": codes},
+	})
+}
+
+// DisableTwoFactor handles POST /api/auth/2fa/disable
+// Body: {"password": "...", "code": "123456"}
+func (h *AuthHandler) DisableTwoFactor(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "unauthorized"})
+	}
+
+	req, err := parseTwoFactorRequest(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.authService.DisableTwoFactor(ctx, user.ID, req); err != nil {
+		return authError(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes handles POST /api/auth/2fa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "unauthorized"})
+	}
+
+	req, err := parseTwoFactorRequest(c)
+	if err != nil {
+		return err
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(ctx, user.ID, req.Code)
+	if err != nil {
+		return authError(c, err, fiber.StatusBadRequest)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"recovery_codes": codes},
+	})
+}
@@ -1,8 +1,9 @@
 package http
 
 import (
+	"errors"
+
 	"prabogo/internal/domain/auth"
-	"prabogo/utils/activity"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -22,30 +23,42 @@ func NewUserHandler(authService *auth.AuthService) *UserHandler {
 // Routes registers user routes
 func (h *UserHandler) Routes(app *fiber.App, authMiddleware fiber.Handler) {
 	api := app.Group("/api")
-	usersGroup := api.Group("/users", authMiddleware)
+	usersGroup := api.Group("/users", authMiddleware, RequirePermission(auth.PermUserManage))
 
-	// Admin only users
 	usersGroup.Get("/", h.GetAllUsers)
 	usersGroup.Post("/", h.CreateUser)
+	usersGroup.Get("/invitations", h.ListInvitations)
+	usersGroup.Post("/invitations", h.InviteUser)
+	usersGroup.Post("/invitations/:id/resend", h.ResendInvitation)
+	usersGroup.Delete("/invitations/:id", h.RevokeInvitation)
+	usersGroup.Put("/:id", h.UpdateUser)
 	usersGroup.Put("/:id/role", h.UpdateUserRole)
+	usersGroup.Post("/:id/deactivate", h.DeactivateUser)
+	usersGroup.Post("/:id/reactivate", h.ReactivateUser)
 	usersGroup.Delete("/:id", h.DeleteUser)
+	usersGroup.Post("/:id/revoke-sessions", h.RevokeSessions)
+	usersGroup.Post("/:id/unlock", h.UnlockUser)
+	usersGroup.Post("/unlock-ip", h.UnlockIP)
+	usersGroup.Post("/:id/2fa/reset", h.ResetTwoFactor)
+	usersGroup.Get("/:id/locations", h.GetUserLocations)
+	usersGroup.Put("/:id/locations", h.SetUserLocations)
+}
+
+// userActor identifies the caller for account changes
+func userActor(c *fiber.Ctx) auth.Actor {
+	userID, _ := c.Locals("userID").(string)
+	return auth.Actor{
+		UserID:      userID,
+		Permissions: GetCurrentPermissions(c),
+	}
 }
 
 // GetAllUsers handles GET /api/users
+// Deleted users are listed only with ?include_deleted=true.
 func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
-
-	// Check if admin? (Ideally yes, but for now assuming middleware handles authentication.
-	// We should probably check role here)
-	user, err := GetCurrentUser(c)
-	if err != nil || user.Role != auth.RoleAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"error":   "forbidden: admin access required",
-		})
-	}
+	ctx := requestContext(c)
 
-	users, err := h.authService.GetAllUsers(ctx)
+	users, err := h.authService.GetAllUsers(ctx, c.QueryBool("include_deleted"))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -67,12 +80,7 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 
 // CreateUser handles POST /api/users
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
-
-	user, err := GetCurrentUser(c)
-	if err != nil || user.Role != auth.RoleAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "forbidden"})
-	}
+	ctx := requestContext(c)
 
 	var req auth.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -92,14 +100,9 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 
 // UpdateUserRole handles PUT /api/users/:id/role
 func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 	id := c.Params("id")
 
-	user, err := GetCurrentUser(c)
-	if err != nil || user.Role != auth.RoleAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "forbidden"})
-	}
-
 	var req struct {
 		Role auth.UserRole `json:"role"`
 	}
@@ -107,7 +110,13 @@ func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request"})
 	}
 
-	if err := h.authService.UpdateUserRole(ctx, id, req.Role); err != nil {
+	if err := h.authService.UpdateUserRole(ctx, userActor(c), id, req.Role); err != nil {
+		if errors.Is(err, auth.ErrPrivilegeEscalation) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": err.Error()})
+		}
+		if errors.Is(err, auth.ErrRoleNotFound) || errors.Is(err, auth.ErrOwnRole) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
@@ -117,14 +126,82 @@ func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
 	})
 }
 
+// UpdateUser handles PUT /api/users/:id
+// Edits full name and email; role and status have their own endpoints.
+func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	var req auth.ProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request"})
+	}
+
+	user, err := h.authService.UpdateProfile(ctx, userActor(c), id, req)
+	if err != nil {
+		if errors.Is(err, auth.ErrPrivilegeEscalation) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "user updated",
+		"data":    user.ToResponse(),
+	})
+}
+
+// DeactivateUser handles POST /api/users/:id/deactivate
+// The user can no longer log in and is signed out everywhere.
+func (h *UserHandler) DeactivateUser(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "unauthorized"})
+	}
+
+	// Prevent self-lockout
+	if user.ID == id {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "cannot deactivate yourself"})
+	}
+
+	if err := h.authService.DeactivateUser(ctx, id); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "user deactivated",
+	})
+}
+
+// ReactivateUser handles POST /api/users/:id/reactivate
+func (h *UserHandler) ReactivateUser(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	if err := h.authService.ReactivateUser(ctx, id); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "user reactivated",
+	})
+}
+
 // DeleteUser handles DELETE /api/users/:id
+// Leaves a tombstone so trees and monitoring logs keep their attribution.
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
-	ctx := activity.NewContext(c.Path())
+	ctx := requestContext(c)
 	id := c.Params("id")
 
 	user, err := GetCurrentUser(c)
-	if err != nil || user.Role != auth.RoleAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "forbidden"})
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "unauthorized"})
 	}
 
 	// Prevent self-deletion
@@ -133,7 +210,7 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	}
 
 	if err := h.authService.DeleteUser(ctx, id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
@@ -141,3 +218,198 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 		"message": "user deleted",
 	})
 }
+
+// RevokeSessions handles POST /api/users/:id/revoke-sessions
+// Signs the user out everywhere; access tokens stop working on their next request.
+func (h *UserHandler) RevokeSessions(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	revoked, err := h.authService.RevokeUserSessions(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "user sessions revoked",
+		"revoked": revoked,
+	})
+}
+
+// UnlockUser handles POST /api/users/:id/unlock
+// Clears failed login attempts and any lockout on the user's username.
+func (h *UserHandler) UnlockUser(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	if err := h.authService.UnlockUser(ctx, id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "user unlocked",
+	})
+}
+
+// ResetTwoFactor handles POST /api/users/:id/2fa/reset
+// Removes the user's authenticator and recovery codes, e.g. after a lost phone.
+func (h *UserHandler) ResetTwoFactor(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	if err := h.authService.ResetTwoFactor(ctx, id); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "two-factor authentication reset",
+	})
+}
+
+// GetUserLocations handles GET /api/users/:id/locations
+func (h *UserHandler) GetUserLocations(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	if _, err := h.authService.GetUserByID(ctx, id); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "user not found"})
+	}
+
+	locationIDs, err := h.authService.GetUserLocations(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"location_ids": locationIDs},
+	})
+}
+
+// SetUserLocations handles PUT /api/users/:id/locations
+// Only applies to roles without tree:all_locations; an empty list blocks all trees.
+func (h *UserHandler) SetUserLocations(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+	id := c.Params("id")
+
+	var req auth.LocationsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request"})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	locationIDs, err := h.authService.SetUserLocations(ctx, id, req.LocationIDs, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "user locations updated",
+		"data":    fiber.Map{"location_ids": locationIDs},
+	})
+}
+
+// UnlockIP handles POST /api/users/unlock-ip
+func (h *UserHandler) UnlockIP(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req struct {
+		IP string `json:"ip"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.IP == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "ip is required"})
+	}
+
+	if err := h.authService.UnlockIP(ctx, req.IP); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "ip unlocked",
+	})
+}
+
+// ListInvitations handles GET /api/users/invitations
+func (h *UserHandler) ListInvitations(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	invitations, err := h.authService.ListInvitations(ctx)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	response := make([]*auth.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		response = append(response, invitation.ToResponse())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// InviteUser handles POST /api/users/invitations
+// Emails a link with which the invitee picks their own username and password.
+func (h *UserHandler) InviteUser(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	var req auth.InviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid request"})
+	}
+
+	userID, _ := c.Locals("userID").(string)
+	invitation, err := h.authService.InviteUser(ctx, req, userID)
+	if err != nil {
+		return c.Status(invitationErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "invitation sent",
+		"data":    invitation.ToResponse(),
+	})
+}
+
+// ResendInvitation handles POST /api/users/invitations/:id/resend
+func (h *UserHandler) ResendInvitation(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	invitation, err := h.authService.ResendInvitation(ctx, c.Params("id"))
+	if err != nil {
+		return c.Status(invitationErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "invitation resent",
+		"data":    invitation.ToResponse(),
+	})
+}
+
+// RevokeInvitation handles DELETE /api/users/invitations/:id
+func (h *UserHandler) RevokeInvitation(c *fiber.Ctx) error {
+	ctx := requestContext(c)
+
+	if err := h.authService.RevokeInvitation(ctx, c.Params("id")); err != nil {
+		return c.Status(invitationErrorStatus(err)).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "invitation revoked",
+	})
+}
+
+func invitationErrorStatus(err error) int {
+	if errors.Is(err, auth.ErrInvitationNotFound) {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
@@ -0,0 +1,180 @@
+package api_key_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/auth"
+)
+
+// APIKeyRepository stores API keys in the clients and client_scopes tables
+// Always PostgreSQL, also in SawitDB hybrid mode (like users)
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+// FindAll returns every key with its scopes, newest first
+func (r *APIKeyRepository) FindAll(ctx context.Context) ([]*auth.APIKey, error) {
+	return r.query(ctx, "")
+}
+
+// FindByID returns one key
+func (r *APIKeyRepository) FindByID(ctx context.Context, id int) (*auth.APIKey, error) {
+	return r.queryOne(ctx, "WHERE c.id = $1", id)
+}
+
+// FindByHash returns the key whose secret hashes to keyHash
+func (r *APIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*auth.APIKey, error) {
+	return r.queryOne(ctx, "WHERE c.key_hash = $1", keyHash)
+}
+
+func (r *APIKeyRepository) queryOne(ctx context.Context, where string, args ...interface{}) (*auth.APIKey, error) {
+	keys, err := r.query(ctx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("api key not found")
+	}
+	return keys[0], nil
+}
+
+func (r *APIKeyRepository) query(ctx context.Context, where string, args ...interface{}) ([]*auth.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.id, COALESCE(c.name, ''), c.key_prefix, COALESCE(c.key_hash, ''), c.rate_limit,
+		       c.expires_at, c.revoked_at, c.last_used_at, COALESCE(c.created_by, ''),
+		       c.created_at, c.updated_at, s.scope
+		FROM clients c
+		LEFT JOIN client_scopes s ON s.client_id = c.id
+		`+where+`
+		ORDER BY c.id DESC, s.scope
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*auth.APIKey
+	var current *auth.APIKey
+	for rows.Next() {
+		var key auth.APIKey
+		var expiresAt, revokedAt, lastUsedAt sql.NullTime
+		var scope sql.NullString
+		if err := rows.Scan(
+			&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.RateLimit,
+			&expiresAt, &revokedAt, &lastUsedAt, &key.CreatedBy,
+			&key.CreatedAt, &key.UpdatedAt, &scope,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+
+		// One row per scope: start a new key when the ID changes
+		if current == nil || current.ID != key.ID {
+			current = &key
+			current.Scopes = []auth.Permission{}
+			current.ExpiresAt = nullTimePtr(expiresAt)
+			current.RevokedAt = nullTimePtr(revokedAt)
+			current.LastUsedAt = nullTimePtr(lastUsedAt)
+			keys = append(keys, current)
+		}
+		if scope.Valid {
+			current.Scopes = append(current.Scopes, auth.Permission(scope.String))
+		}
+	}
+	return keys, rows.Err()
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Create inserts a key and its scopes, setting key.ID
+func (r *APIKeyRepository) Create(ctx context.Context, key *auth.APIKey) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO clients (name, key_prefix, key_hash, rate_limit, expires_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING id
+	`, key.Name, key.Prefix, key.KeyHash, key.RateLimit, key.ExpiresAt, key.CreatedBy, key.CreatedAt, key.UpdatedAt).Scan(&key.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertScopes(ctx, tx, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update replaces name, secret, scopes, rate limit and expiry
+func (r *APIKeyRepository) Update(ctx context.Context, key *auth.APIKey) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE clients
+		SET name = $2, key_prefix = $3, key_hash = $4, rate_limit = $5, expires_at = $6, updated_at = $7
+		WHERE id = $1
+	`, key.ID, key.Name, key.Prefix, key.KeyHash, key.RateLimit, key.ExpiresAt, key.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("api key %d not found", key.ID)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM client_scopes WHERE client_id = $1`, key.ID); err != nil {
+		return err
+	}
+	if err := insertScopes(ctx, tx, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertScopes(ctx context.Context, tx *sql.Tx, key *auth.APIKey) error {
+	for _, s := range key.Scopes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO client_scopes (client_id, scope) VALUES ($1, $2)
+		`, key.ID, string(s)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Revoke marks a key as revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE clients SET revoked_at = $2, updated_at = $2 WHERE id = $1 AND revoked_at IS NULL
+	`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// TouchLastUsed records when a key was last accepted
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE clients SET last_used_at = $2 WHERE id = $1`, id, at)
+	return err
+}
@@ -0,0 +1,291 @@
+package application_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"prabogo/internal/domain/application"
+
+	"github.com/lib/pq"
+)
+
+// treeChunk is how many application trees go into one INSERT
+const treeChunk = 1000
+
+// ApplicationRepository stores input applications in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode.
+type ApplicationRepository struct {
+	db *sql.DB
+}
+
+func NewApplicationRepository(db *sql.DB) *ApplicationRepository {
+	return &ApplicationRepository{
+		db: db,
+	}
+}
+
+const selectApplication = `
+	SELECT id, product, category, dose_per_tree, unit, method, batch_number, operator_id,
+		applied_at, notes, tree_count, location_ids, created_by, created_at
+	FROM applications a
+`
+
+// Create inserts the record and its trees in one transaction
+// Trees are inserted in chunks so a whole block is a handful of statements.
+func (r *ApplicationRepository) Create(ctx context.Context, a *application.Application) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO applications (
+			id, product, category, dose_per_tree, unit, method, batch_number, operator_id,
+			applied_at, notes, tree_count, location_ids, created_by, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`,
+		a.ID, a.Product, string(a.Category), a.DosePerTree, string(a.Unit), string(a.Method), a.BatchNumber, a.OperatorID,
+		a.AppliedAt, a.Notes, a.TreeCount, pq.Array(a.LocationIDs), a.CreatedBy, a.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert application: %w", err)
+	}
+
+	for start := 0; start < len(a.Trees); start += treeChunk {
+		end := start + treeChunk
+		if end > len(a.Trees) {
+			end = len(a.Trees)
+		}
+		chunk := a.Trees[start:end]
+		ids := make([]string, len(chunk))
+		codes := make([]string, len(chunk))
+		locations := make([]string, len(chunk))
+		for i, t := range chunk {
+			ids[i], codes[i], locations[i] = t.TreeID, t.TreeCode, t.LocationID
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO application_trees (application_id, tree_id, tree_code, location_id)
+			SELECT $1, unnest($2::text[]), unnest($3::text[]), unnest($4::text[])
+		`, a.ID, pq.Array(ids), pq.Array(codes), pq.Array(locations))
+		if err != nil {
+			return fmt.Errorf("failed to insert application trees: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindByID returns one record with its trees
+func (r *ApplicationRepository) FindByID(ctx context.Context, id string) (*application.Application, error) {
+	a, err := scanApplication(r.db.QueryRowContext(ctx, selectApplication+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, application.ErrApplicationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tree_id, tree_code, location_id
+		FROM application_trees
+		WHERE application_id = $1
+		ORDER BY tree_code
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	a.Trees = []application.AppliedTree{}
+	for rows.Next() {
+		var t application.AppliedTree
+		if err := rows.Scan(&t.TreeID, &t.TreeCode, &t.LocationID); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		a.Trees = append(a.Trees, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return a, nil
+}
+
+// FindAll returns a page of records, latest application first, and the total matching
+func (r *ApplicationRepository) FindAll(ctx context.Context, filter application.Filter) ([]*application.Application, int, error) {
+	w := newWhere()
+	if filter.LocationID != "" {
+		w.add("$%d = ANY(a.location_ids)", filter.LocationID)
+	}
+	if filter.TreeCode != "" {
+		w.add("EXISTS (SELECT 1 FROM application_trees apt WHERE apt.application_id = a.id AND apt.tree_code = $%d)", filter.TreeCode)
+	}
+	if filter.Product != "" {
+		w.add("a.product ILIKE $%d", "%"+filter.Product+"%")
+	}
+	if filter.Category != "" {
+		w.add("a.category = $%d", string(filter.Category))
+	}
+	if !filter.From.IsZero() {
+		w.add("a.applied_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		w.add("a.applied_at < $%d", filter.To)
+	}
+	if filter.LocationIDs != nil {
+		w.add("a.location_ids && $%d", pq.Array(filter.LocationIDs))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM applications a WHERE "+w.String(), w.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count error: %w", err)
+	}
+
+	query := selectApplication + " WHERE " + w.String() +
+		fmt.Sprintf(" ORDER BY a.applied_at DESC, a.created_at DESC LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	applications := []*application.Application{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		applications = append(applications, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	return applications, total, nil
+}
+
+// Usage sums quantities per period, block and product
+// Quantities are counted per tree row, so a record spanning two blocks is
+// split between them.
+func (r *ApplicationRepository) Usage(ctx context.Context, filter application.UsageFilter) ([]application.UsageRow, error) {
+	w := newWhere()
+	w.add("a.applied_at >= $%d", filter.From)
+	w.add("a.applied_at < $%d", filter.To)
+	if filter.LocationID != "" {
+		w.add("apt.location_id = $%d", filter.LocationID)
+	}
+	if filter.Product != "" {
+		w.add("a.product ILIKE $%d", "%"+filter.Product+"%")
+	}
+	if filter.Category != "" {
+		w.add("a.category = $%d", string(filter.Category))
+	}
+	if filter.LocationIDs != nil {
+		w.add("apt.location_id = ANY($%d)", pq.Array(filter.LocationIDs))
+	}
+	w.args = append(w.args, string(filter.Period))
+
+	query := fmt.Sprintf(`
+		SELECT date_trunc($%d, a.applied_at) AS period_start, apt.location_id, a.product, a.category, a.unit,
+			COUNT(DISTINCT a.id), COUNT(*), SUM(a.dose_per_tree),
+			COALESCE(array_agg(DISTINCT a.batch_number) FILTER (WHERE a.batch_number <> ''), '{}')
+		FROM applications a
+		JOIN application_trees apt ON apt.application_id = a.id
+		WHERE %s
+		GROUP BY 1, 2, 3, 4, 5
+		ORDER BY 1, 2, 3, 5
+	`, len(w.args), w.String())
+
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []application.UsageRow{}
+	for rows.Next() {
+		var u application.UsageRow
+		var category, unit string
+		if err := rows.Scan(
+			&u.PeriodStart, &u.LocationID, &u.Product, &category, &unit,
+			&u.Applications, &u.Trees, &u.TotalQuantity, pq.Array(&u.Batches),
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		u.Category = application.Category(category)
+		u.Unit = application.Unit(unit)
+		usage = append(usage, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return usage, nil
+}
+
+// Delete removes a record; its trees go with it
+func (r *ApplicationRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM applications WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete application: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return application.ErrApplicationNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanApplication(row rowScanner) (*application.Application, error) {
+	var a application.Application
+	var category, unit, method string
+	err := row.Scan(
+		&a.ID, &a.Product, &category, &a.DosePerTree, &unit, &method, &a.BatchNumber, &a.OperatorID,
+		&a.AppliedAt, &a.Notes, &a.TreeCount, pq.Array(&a.LocationIDs), &a.CreatedBy, &a.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	a.Category = application.Category(category)
+	a.Unit = application.Unit(unit)
+	a.Method = application.Method(method)
+	a.TotalQuantity = a.DosePerTree * float64(a.TreeCount)
+	return &a, nil
+}
+
+// where builds a parameterized WHERE clause
+type where struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newWhere() *where {
+	return &where{}
+}
+
+func (w *where) add(cond string, value interface{}) {
+	w.args = append(w.args, value)
+	w.conditions = append(w.conditions, fmt.Sprintf(cond, len(w.args)))
+}
+
+func (w *where) String() string {
+	if len(w.conditions) == 0 {
+		return "1=1"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
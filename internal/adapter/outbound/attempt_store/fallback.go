@@ -0,0 +1,65 @@
+package attempt_store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/auth"
+)
+
+// FallbackStore uses primary (Redis) and switches to secondary (memory)
+// for any call that fails, so a Redis outage degrades to per-instance limits
+// instead of disabling brute-force protection.
+type FallbackStore struct {
+	primary   auth.AttemptStore
+	secondary auth.AttemptStore
+}
+
+func NewFallbackStore(primary auth.AttemptStore, secondary auth.AttemptStore) *FallbackStore {
+	return &FallbackStore{
+		primary:   primary,
+		secondary: secondary,
+	}
+}
+
+func (s *FallbackStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	count, err := s.primary.Incr(ctx, key, window)
+	if err != nil {
+		s.warn("incr", err)
+		return s.secondary.Incr(ctx, key, window)
+	}
+	return count, nil
+}
+
+func (s *FallbackStore) Block(ctx context.Context, key string, until time.Time) error {
+	if err := s.primary.Block(ctx, key, until); err != nil {
+		s.warn("block", err)
+		return s.secondary.Block(ctx, key, until)
+	}
+	return nil
+}
+
+// BlockedUntil checks both stores: blocks written during an outage stay in effect
+func (s *FallbackStore) BlockedUntil(ctx context.Context, key string) (time.Time, error) {
+	local, _ := s.secondary.BlockedUntil(ctx, key)
+
+	remote, err := s.primary.BlockedUntil(ctx, key)
+	if err != nil {
+		s.warn("check", err)
+		return local, nil
+	}
+	if local.After(remote) {
+		return local, nil
+	}
+	return remote, nil
+}
+
+func (s *FallbackStore) Reset(ctx context.Context, keys ...string) error {
+	s.secondary.Reset(ctx, keys...)
+	return s.primary.Reset(ctx, keys...)
+}
+
+func (s *FallbackStore) warn(op string, err error) {
+	fmt.Printf("⚠️ Warning: Redis attempt store %s failed, using in-memory: %v\n", op, err)
+}
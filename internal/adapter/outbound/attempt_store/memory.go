@@ -0,0 +1,91 @@
+package attempt_store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps login counters in process memory
+// Used when Redis is unavailable; counters are per instance and lost on restart.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]memoryCounter
+	blocks   map[string]time.Time
+}
+
+type memoryCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]memoryCounter),
+		blocks:   make(map[string]time.Time),
+	}
+}
+
+// Incr adds one failure; the window starts at the first failure
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expiresAt) {
+		c = memoryCounter{expiresAt: now.Add(window)}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, nil
+}
+
+// Block rejects attempts until the given time
+func (s *MemoryStore) Block(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocks[key] = until
+	return nil
+}
+
+// BlockedUntil returns the end of an active block
+func (s *MemoryStore) BlockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.blocks[key]
+	if !ok || time.Now().After(until) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+// Reset removes counters and blocks
+func (s *MemoryStore) Reset(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.counters, key)
+		delete(s.blocks, key)
+	}
+	return nil
+}
+
+// sweep drops expired entries so a stuffing run cannot grow the maps forever
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, c := range s.counters {
+		if now.After(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+	for key, until := range s.blocks {
+		if now.After(until) {
+			delete(s.blocks, key)
+		}
+	}
+}
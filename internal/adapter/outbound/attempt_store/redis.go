@@ -0,0 +1,57 @@
+package attempt_store
+
+import (
+	"context"
+	"time"
+
+	"github.com/arielfikru/gibrun"
+)
+
+// RedisStore keeps login counters in Redis so every instance shares them
+type RedisStore struct {
+	client *gibrun.Client
+}
+
+func NewRedisStore(client *gibrun.Client) *RedisStore {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+// Incr adds one failure; the window starts at the first failure
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	counter := s.client.Sprint(ctx, key+":count")
+	count, err := counter.Incr()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := counter.Expire(window); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// Block stores the unblock time (unix seconds), expiring with the block itself
+func (s *RedisStore) Block(ctx context.Context, key string, until time.Time) error {
+	return s.client.Sprint(ctx, key+":block").SetWithTTL(until.Unix(), time.Until(until))
+}
+
+// BlockedUntil returns the stored unblock time, zero when no block exists
+func (s *RedisStore) BlockedUntil(ctx context.Context, key string) (time.Time, error) {
+	until, err := s.client.Sprint(ctx, key+":block").Get()
+	if err != nil || until == 0 {
+		return time.Time{}, err
+	}
+	return time.Unix(until, 0), nil
+}
+
+// Reset removes counters and blocks
+func (s *RedisStore) Reset(ctx context.Context, keys ...string) error {
+	var redisKeys []string
+	for _, key := range keys {
+		redisKeys = append(redisKeys, key+":count", key+":block")
+	}
+	return s.client.Del(ctx, redisKeys...)
+}
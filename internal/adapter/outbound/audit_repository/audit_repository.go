@@ -0,0 +1,160 @@
+package audit_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+)
+
+// AuditRepository stores the hash-chained audit log in PostgreSQL
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+const auditColumns = `seq, id, COALESCE(actor_id, ''), COALESCE(actor_name, ''), action, target_type,
+		       COALESCE(target_id, ''), COALESCE(before_value, ''), COALESCE(after_value, ''),
+		       COALESCE(ip, ''), COALESCE(user_agent, ''), COALESCE(transaction_id, ''),
+		       created_at, prev_hash, hash`
+
+// Append links the entry to the current chain head and inserts it
+// The table lock serializes writers across all API instances.
+func (r *AuditRepository) Append(ctx context.Context, e *audit.Entry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE audit_logs IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+
+	var lastSeq int64
+	var lastHash string
+	err = tx.QueryRowContext(ctx, "SELECT seq, hash FROM audit_logs ORDER BY seq DESC LIMIT 1").Scan(&lastSeq, &lastHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read chain head: %w", err)
+	}
+
+	e.Seq = lastSeq + 1
+	e.PrevHash = lastHash
+	e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Microsecond) // PostgreSQL precision
+	e.Hash = e.ComputeHash()
+
+	query := `
+		INSERT INTO audit_logs (
+			seq, id, actor_id, actor_name, action, target_type, target_id,
+			before_value, after_value, ip, user_agent, transaction_id,
+			created_at, prev_hash, hash
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err = tx.ExecContext(ctx, query,
+		e.Seq, e.ID, e.ActorID, e.ActorName, e.Action, e.TargetType, e.TargetID,
+		e.Before, e.After, e.IP, e.UserAgent, e.TransactionID,
+		e.CreatedAt, e.PrevHash, e.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Find retrieves entries with filter, newest first
+func (r *AuditRepository) Find(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	add := func(cond string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.ActorID != "" {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action LIKE $%d", filter.Action+"%")
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = $%d", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_logs WHERE " + strings.Join(conditions, " AND ") +
+		fmt.Sprintf(" ORDER BY seq DESC LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*audit.Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return entries, nil
+}
+
+// StreamChain calls fn for every entry in Seq order
+func (r *AuditRepository) StreamChain(ctx context.Context, fn func(*audit.Entry) error) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+auditColumns+" FROM audit_logs ORDER BY seq")
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanEntry(rows *sql.Rows) (*audit.Entry, error) {
+	var e audit.Entry
+	err := rows.Scan(
+		&e.Seq, &e.ID, &e.ActorID, &e.ActorName, &e.Action, &e.TargetType,
+		&e.TargetID, &e.Before, &e.After,
+		&e.IP, &e.UserAgent, &e.TransactionID,
+		&e.CreatedAt, &e.PrevHash, &e.Hash,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+	return &e, nil
+}
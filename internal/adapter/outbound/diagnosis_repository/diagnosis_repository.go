@@ -0,0 +1,441 @@
+package diagnosis_repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/diagnosis"
+
+	"github.com/lib/pq"
+)
+
+// DiagnosisRepository stores the pest and disease catalog, diagnoses and outbreak alerts in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode.
+type DiagnosisRepository struct {
+	db *sql.DB
+}
+
+func NewDiagnosisRepository(db *sql.DB) *DiagnosisRepository {
+	return &DiagnosisRepository{
+		db: db,
+	}
+}
+
+const selectEntry = `
+	SELECT id, code, name, kind, description, treatment, severity_scale,
+		alert_threshold, alert_window_days, active, created_at, updated_at
+	FROM diagnosis_catalog
+`
+
+// Create inserts a catalog entry
+func (r *DiagnosisRepository) Create(ctx context.Context, e *diagnosis.CatalogEntry) error {
+	scale, err := json.Marshal(e.SeverityScale)
+	if err != nil {
+		return fmt.Errorf("failed to encode severity scale: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO diagnosis_catalog (
+			id, code, name, kind, description, treatment, severity_scale,
+			alert_threshold, alert_window_days, active, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		e.ID, e.Code, e.Name, string(e.Kind), e.Description, e.Treatment, scale,
+		e.AlertThreshold, e.AlertWindowDays, e.Active, e.CreatedAt, e.UpdatedAt,
+	)
+	if isPQError(err, "23505") {
+		return diagnosis.ErrDuplicateCode
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert catalog entry: %w", err)
+	}
+	return nil
+}
+
+// FindByID returns one catalog entry
+func (r *DiagnosisRepository) FindByID(ctx context.Context, id string) (*diagnosis.CatalogEntry, error) {
+	e, err := scanEntry(r.db.QueryRowContext(ctx, selectEntry+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, diagnosis.ErrEntryNotFound
+	}
+	return e, err
+}
+
+// FindByCode returns one catalog entry
+func (r *DiagnosisRepository) FindByCode(ctx context.Context, code string) (*diagnosis.CatalogEntry, error) {
+	e, err := scanEntry(r.db.QueryRowContext(ctx, selectEntry+" WHERE code = $1", code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, diagnosis.ErrEntryNotFound
+	}
+	return e, err
+}
+
+// FindAll returns catalog entries ordered by kind and name
+func (r *DiagnosisRepository) FindAll(ctx context.Context, activeOnly bool) ([]*diagnosis.CatalogEntry, error) {
+	query := selectEntry
+	if activeOnly {
+		query += " WHERE active"
+	}
+	rows, err := r.db.QueryContext(ctx, query+" ORDER BY kind, name")
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*diagnosis.CatalogEntry{}
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+// Update saves a catalog entry
+func (r *DiagnosisRepository) Update(ctx context.Context, e *diagnosis.CatalogEntry) error {
+	scale, err := json.Marshal(e.SeverityScale)
+	if err != nil {
+		return fmt.Errorf("failed to encode severity scale: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE diagnosis_catalog
+		SET code = $2, name = $3, kind = $4, description = $5, treatment = $6, severity_scale = $7,
+			alert_threshold = $8, alert_window_days = $9, active = $10, updated_at = $11
+		WHERE id = $1
+	`,
+		e.ID, e.Code, e.Name, string(e.Kind), e.Description, e.Treatment, scale,
+		e.AlertThreshold, e.AlertWindowDays, e.Active, e.UpdatedAt,
+	)
+	if isPQError(err, "23505") {
+		return diagnosis.ErrDuplicateCode
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update catalog entry: %w", err)
+	}
+	return requireRow(result, diagnosis.ErrEntryNotFound)
+}
+
+// Delete removes a catalog entry that has no diagnoses
+func (r *DiagnosisRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM diagnosis_catalog WHERE id = $1", id)
+	if isPQError(err, "23503") {
+		return diagnosis.ErrEntryInUse
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete catalog entry: %w", err)
+	}
+	return requireRow(result, diagnosis.ErrEntryNotFound)
+}
+
+// CreateDiagnoses inserts the diagnoses of one monitoring entry in one transaction
+func (r *DiagnosisRepository) CreateDiagnoses(ctx context.Context, diagnoses []*diagnosis.Diagnosis) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, d := range diagnoses {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO diagnoses (
+				id, log_id, tree_id, tree_code, location_id, entry_id, severity, notes, diagnosed_by, diagnosed_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`,
+			d.ID, d.LogID, d.TreeID, d.TreeCode, d.LocationID, d.EntryID, d.Severity, d.Notes, d.DiagnosedBy, d.DiagnosedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert diagnosis: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindByTree returns a tree's diagnoses, newest first
+func (r *DiagnosisRepository) FindByTree(ctx context.Context, treeID string) ([]*diagnosis.Diagnosis, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, log_id, tree_id, tree_code, location_id, entry_id, severity, notes, diagnosed_by, diagnosed_at
+		FROM diagnoses
+		WHERE tree_id = $1
+		ORDER BY diagnosed_at DESC
+	`, treeID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	diagnoses := []*diagnosis.Diagnosis{}
+	for rows.Next() {
+		var d diagnosis.Diagnosis
+		if err := rows.Scan(
+			&d.ID, &d.LogID, &d.TreeID, &d.TreeCode, &d.LocationID, &d.EntryID,
+			&d.Severity, &d.Notes, &d.DiagnosedBy, &d.DiagnosedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		diagnoses = append(diagnoses, &d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return diagnoses, nil
+}
+
+// Spread counts cases per period, block and entry
+func (r *DiagnosisRepository) Spread(ctx context.Context, filter diagnosis.SpreadFilter) ([]diagnosis.SpreadRow, error) {
+	w := newWhere()
+	w.add("d.diagnosed_at >= $%d", filter.From)
+	w.add("d.diagnosed_at < $%d", filter.To)
+	if filter.EntryID != "" {
+		w.add("d.entry_id = $%d", filter.EntryID)
+	}
+	if filter.LocationID != "" {
+		w.add("d.location_id = $%d", filter.LocationID)
+	}
+	if filter.LocationIDs != nil {
+		w.add("d.location_id = ANY($%d)", pq.Array(filter.LocationIDs))
+	}
+	w.args = append(w.args, string(filter.Period))
+
+	query := fmt.Sprintf(`
+		SELECT date_trunc($%d, d.diagnosed_at) AS period_start, d.location_id, d.entry_id, c.code,
+			COUNT(*), COUNT(DISTINCT d.tree_id), AVG(d.severity), MAX(d.severity)
+		FROM diagnoses d
+		JOIN diagnosis_catalog c ON c.id = d.entry_id
+		WHERE %s
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 4
+	`, len(w.args), w.String())
+
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	spread := []diagnosis.SpreadRow{}
+	for rows.Next() {
+		var s diagnosis.SpreadRow
+		if err := rows.Scan(
+			&s.PeriodStart, &s.LocationID, &s.EntryID, &s.EntryCode,
+			&s.Cases, &s.Trees, &s.AvgSeverity, &s.MaxSeverity,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		spread = append(spread, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return spread, nil
+}
+
+// CountTrees counts distinct trees of a block diagnosed with an entry since a time
+func (r *DiagnosisRepository) CountTrees(ctx context.Context, entryID string, locationID string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT tree_id)
+		FROM diagnoses
+		WHERE entry_id = $1 AND location_id = $2 AND diagnosed_at >= $3
+	`, entryID, locationID, since).Scan(&count)
+	return count, err
+}
+
+const selectAlert = `
+	SELECT a.id, a.entry_id, c.code, a.location_id, a.trees, a.threshold, a.window_start,
+		a.triggered_at, a.acknowledged_at, a.acknowledged_by
+	FROM outbreak_alerts a
+	JOIN diagnosis_catalog c ON c.id = a.entry_id
+`
+
+// CreateAlert stores the alert unless one for the entry and block was triggered since alert.WindowStart
+// An advisory lock per entry and block keeps concurrent diagnoses from both alerting.
+func (r *DiagnosisRepository) CreateAlert(ctx context.Context, alert *diagnosis.Alert) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "outbreak:"+alert.EntryID+":"+alert.LocationID); err != nil {
+		return false, fmt.Errorf("failed to lock: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO outbreak_alerts (id, entry_id, location_id, trees, threshold, window_start, triggered_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7
+		WHERE NOT EXISTS (
+			SELECT 1 FROM outbreak_alerts
+			WHERE entry_id = $2 AND location_id = $3 AND triggered_at >= $6
+		)
+	`, alert.ID, alert.EntryID, alert.LocationID, alert.Trees, alert.Threshold, alert.WindowStart, alert.TriggeredAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert alert: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, tx.Commit()
+}
+
+// FindAlertByID returns one alert
+func (r *DiagnosisRepository) FindAlertByID(ctx context.Context, id string) (*diagnosis.Alert, error) {
+	a, err := scanAlert(r.db.QueryRowContext(ctx, selectAlert+" WHERE a.id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, diagnosis.ErrAlertNotFound
+	}
+	return a, err
+}
+
+// FindAlerts returns a page of alerts, newest first, and the total matching
+func (r *DiagnosisRepository) FindAlerts(ctx context.Context, filter diagnosis.AlertFilter) ([]*diagnosis.Alert, int, error) {
+	w := newWhere()
+	if filter.EntryID != "" {
+		w.add("a.entry_id = $%d", filter.EntryID)
+	}
+	if filter.LocationID != "" {
+		w.add("a.location_id = $%d", filter.LocationID)
+	}
+	if filter.Unacknowledged {
+		w.conditions = append(w.conditions, "a.acknowledged_at IS NULL")
+	}
+	if filter.LocationIDs != nil {
+		w.add("a.location_id = ANY($%d)", pq.Array(filter.LocationIDs))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM outbreak_alerts a WHERE "+w.String(), w.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count error: %w", err)
+	}
+
+	query := selectAlert + " WHERE " + w.String() +
+		fmt.Sprintf(" ORDER BY a.triggered_at DESC LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*diagnosis.Alert{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		alerts = append(alerts, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	return alerts, total, nil
+}
+
+// AcknowledgeAlert marks an alert as handled
+func (r *DiagnosisRepository) AcknowledgeAlert(ctx context.Context, id string, userID string, at time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE outbreak_alerts SET acknowledged_at = $2, acknowledged_by = $3
+		WHERE id = $1 AND acknowledged_at IS NULL
+	`, id, at, userID)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge alert: %w", err)
+	}
+	return requireRow(result, diagnosis.ErrAlertNotFound)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEntry(row rowScanner) (*diagnosis.CatalogEntry, error) {
+	var e diagnosis.CatalogEntry
+	var kind string
+	var scale []byte
+	err := row.Scan(
+		&e.ID, &e.Code, &e.Name, &kind, &e.Description, &e.Treatment, &scale,
+		&e.AlertThreshold, &e.AlertWindowDays, &e.Active, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	e.Kind = diagnosis.Kind(kind)
+	if err := json.Unmarshal(scale, &e.SeverityScale); err != nil {
+		return nil, fmt.Errorf("invalid severity scale of %s: %w", e.Code, err)
+	}
+	return &e, nil
+}
+
+func scanAlert(row rowScanner) (*diagnosis.Alert, error) {
+	var a diagnosis.Alert
+	var acknowledgedAt sql.NullTime
+	err := row.Scan(
+		&a.ID, &a.EntryID, &a.EntryCode, &a.LocationID, &a.Trees, &a.Threshold, &a.WindowStart,
+		&a.TriggeredAt, &acknowledgedAt, &a.AcknowledgedBy,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+	if acknowledgedAt.Valid {
+		a.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	return &a, nil
+}
+
+func requireRow(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// where builds a parameterized WHERE clause
+type where struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newWhere() *where {
+	return &where{}
+}
+
+func (w *where) add(cond string, value interface{}) {
+	w.args = append(w.args, value)
+	w.conditions = append(w.conditions, fmt.Sprintf(cond, len(w.args)))
+}
+
+func (w *where) String() string {
+	if len(w.conditions) == 0 {
+		return "1=1"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
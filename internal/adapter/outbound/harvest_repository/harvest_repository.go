@@ -0,0 +1,397 @@
+package harvest_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/harvest"
+
+	"github.com/lib/pq"
+)
+
+// HarvestRepository stores harvests in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode.
+type HarvestRepository struct {
+	db *sql.DB
+}
+
+func NewHarvestRepository(db *sql.DB) *HarvestRepository {
+	return &HarvestRepository{
+		db: db,
+	}
+}
+
+const selectHarvest = `
+	SELECT id, tree_id, tree_code, location_id, bunches, weight_kg, grade, harvester_id,
+		harvested_at, notes, created_by, created_at
+	FROM harvests h
+`
+
+// CreateMany inserts the records in one transaction
+func (r *HarvestRepository) CreateMany(ctx context.Context, harvests []*harvest.Harvest) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, h := range harvests {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO harvests (
+				id, tree_id, tree_code, location_id, bunches, weight_kg, grade, harvester_id,
+				harvested_at, notes, created_by, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`,
+			h.ID, h.TreeID, h.TreeCode, h.LocationID, h.Bunches, h.WeightKg, string(h.Grade), h.HarvesterID,
+			h.HarvestedAt, h.Notes, h.CreatedBy, h.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert harvest: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindByID returns one record
+func (r *HarvestRepository) FindByID(ctx context.Context, id string) (*harvest.Harvest, error) {
+	h, err := scanHarvest(r.db.QueryRowContext(ctx, selectHarvest+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, harvest.ErrHarvestNotFound
+	}
+	return h, err
+}
+
+// FindAll returns a page of records, latest harvest first, and the total matching
+func (r *HarvestRepository) FindAll(ctx context.Context, filter harvest.Filter) ([]*harvest.Harvest, int, error) {
+	w := filterWhere(filter)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM harvests h WHERE "+w.String(), w.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count error: %w", err)
+	}
+
+	query := selectHarvest + " WHERE " + w.String() +
+		fmt.Sprintf(" ORDER BY h.harvested_at DESC, h.created_at DESC LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	harvests := []*harvest.Harvest{}
+	for rows.Next() {
+		h, err := scanHarvest(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		harvests = append(harvests, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+	return harvests, total, nil
+}
+
+// Stream calls fn for every matching record, oldest first, without loading them all
+func (r *HarvestRepository) Stream(ctx context.Context, filter harvest.Filter, fn func(*harvest.Harvest) error) error {
+	w := filterWhere(filter)
+	rows, err := r.db.QueryContext(ctx, selectHarvest+" WHERE "+w.String()+" ORDER BY h.harvested_at, h.created_at", w.args...)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h, err := scanHarvest(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(h); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func filterWhere(filter harvest.Filter) *where {
+	w := newWhere()
+	if filter.TreeCode != "" {
+		w.add("h.tree_code = $%d", filter.TreeCode)
+	}
+	if filter.LocationID != "" {
+		w.add("h.location_id = $%d", filter.LocationID)
+	}
+	if filter.HarvesterID != "" {
+		w.add("h.harvester_id = $%d", filter.HarvesterID)
+	}
+	if filter.Grade != "" {
+		w.add("h.grade = $%d", string(filter.Grade))
+	}
+	if !filter.From.IsZero() {
+		w.add("h.harvested_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		w.add("h.harvested_at < $%d", filter.To)
+	}
+	if filter.LocationIDs != nil {
+		w.add("h.location_id = ANY($%d)", pq.Array(filter.LocationIDs))
+	}
+	return w
+}
+
+// Delete removes a record
+func (r *HarvestRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM harvests WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete harvest: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return harvest.ErrHarvestNotFound
+	}
+	return nil
+}
+
+func yieldWhere(q harvest.YieldQuery) *where {
+	w := newWhere()
+	w.add("h.harvested_at >= $%d", q.From)
+	w.add("h.harvested_at < $%d", q.To)
+	if q.LocationID != "" {
+		w.add("h.location_id = $%d", q.LocationID)
+	}
+	if q.TreeID != "" {
+		w.add("h.tree_id = $%d", q.TreeID)
+	}
+	if q.LocationIDs != nil {
+		w.add("h.location_id = ANY($%d)", pq.Array(q.LocationIDs))
+	}
+	return w
+}
+
+// Yield sums harvests per period and block
+func (r *HarvestRepository) Yield(ctx context.Context, q harvest.YieldQuery) ([]harvest.YieldRow, error) {
+	w := yieldWhere(q)
+	w.args = append(w.args, string(q.Period))
+
+	query := fmt.Sprintf(`
+		SELECT date_trunc($%d, h.harvested_at) AS period_start, h.location_id,
+			COUNT(*), SUM(h.bunches), SUM(h.weight_kg), COUNT(DISTINCT NULLIF(h.tree_id, ''))
+		FROM harvests h
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, len(w.args), w.String())
+
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	yields := []harvest.YieldRow{}
+	for rows.Next() {
+		var y harvest.YieldRow
+		if err := rows.Scan(&y.PeriodStart, &y.LocationID, &y.Records, &y.Bunches, &y.WeightKg, &y.Trees); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		yields = append(yields, y)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return yields, nil
+}
+
+// YieldByStatus sums tree-level harvests by the latest monitoring status up to the harvest day
+func (r *HarvestRepository) YieldByStatus(ctx context.Context, q harvest.YieldQuery) ([]harvest.StatusYield, error) {
+	w := yieldWhere(q)
+	w.conditions = append(w.conditions, "h.tree_id <> ''")
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT COALESCE(st.status, ''), COUNT(*), COUNT(DISTINCT h.tree_id), SUM(h.bunches), SUM(h.weight_kg)
+		FROM harvests h
+		LEFT JOIN LATERAL (
+			SELECT l.status
+			FROM monitoring_logs l
+			WHERE l.tree_id = h.tree_id AND l.monitor_date <= h.harvested_at::date
+			ORDER BY l.monitor_date DESC
+			LIMIT 1
+		) st ON TRUE
+		WHERE `+w.String()+`
+		GROUP BY 1
+		ORDER BY 1
+	`, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := []harvest.StatusYield{}
+	for rows.Next() {
+		var s harvest.StatusYield
+		if err := rows.Scan(&s.Status, &s.Records, &s.Trees, &s.Bunches, &s.WeightKg); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		statuses = append(statuses, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return statuses, nil
+}
+
+// Health counts monitoring logs per period, block and status for the given trees
+func (r *HarvestRepository) Health(ctx context.Context, period harvest.Period, from, to time.Time, treeIDs, locationIDs []string) ([]harvest.HealthRow, error) {
+	health := []harvest.HealthRow{}
+	if len(treeIDs) == 0 {
+		return health, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		WITH t (tree_id, location_id) AS (
+			SELECT * FROM unnest($1::text[], $2::text[])
+		)
+		SELECT date_trunc($3, l.monitor_date::timestamp) AS period_start, t.location_id, l.status,
+			COUNT(*), COALESCE(SUM(l.health_score), 0)
+		FROM monitoring_logs l
+		JOIN t ON t.tree_id = l.tree_id
+		WHERE l.monitor_date >= $4 AND l.monitor_date < $5
+		GROUP BY 1, 2, 3
+	`, pq.Array(treeIDs), pq.Array(locationIDs), string(period), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h harvest.HealthRow
+		if err := rows.Scan(&h.PeriodStart, &h.LocationID, &h.Status, &h.Logs, &h.HealthScoreSum); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		health = append(health, h)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return health, nil
+}
+
+// Areas returns the area in hectares of blocks that have one
+func (r *HarvestRepository) Areas(ctx context.Context, locationIDs []string) (map[string]float64, error) {
+	areas := make(map[string]float64)
+	if len(locationIDs) == 0 {
+		return areas, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, area_hectare FROM locations
+		WHERE id = ANY($1) AND area_hectare > 0
+	`, pq.Array(locationIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var area float64
+		if err := rows.Scan(&id, &area); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		areas[id] = area
+	}
+	return areas, rows.Err()
+}
+
+// Totals sums harvests in [from, to) per block within scope
+func (r *HarvestRepository) Totals(ctx context.Context, from, to time.Time, scope []string) (*harvest.Totals, error) {
+	w := newWhere()
+	w.add("h.harvested_at >= $%d", from)
+	w.add("h.harvested_at < $%d", to)
+	if scope != nil {
+		w.add("h.location_id = ANY($%d)", pq.Array(scope))
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT h.location_id, COUNT(*), SUM(h.bunches), SUM(h.weight_kg)
+		FROM harvests h
+		WHERE `+w.String()+`
+		GROUP BY 1
+	`, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	totals := &harvest.Totals{WeightByLocation: make(map[string]float64)}
+	for rows.Next() {
+		var locationID string
+		var records, bunches int
+		var weight float64
+		if err := rows.Scan(&locationID, &records, &bunches, &weight); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		totals.Records += records
+		totals.Bunches += bunches
+		totals.WeightKg += weight
+		totals.WeightByLocation[locationID] = weight
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return totals, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanHarvest(row rowScanner) (*harvest.Harvest, error) {
+	var h harvest.Harvest
+	var grade string
+	err := row.Scan(
+		&h.ID, &h.TreeID, &h.TreeCode, &h.LocationID, &h.Bunches, &h.WeightKg, &grade, &h.HarvesterID,
+		&h.HarvestedAt, &h.Notes, &h.CreatedBy, &h.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	h.Grade = harvest.Grade(grade)
+	return &h, nil
+}
+
+// where builds a parameterized WHERE clause
+type where struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newWhere() *where {
+	return &where{}
+}
+
+func (w *where) add(cond string, value interface{}) {
+	w.args = append(w.args, value)
+	w.conditions = append(w.conditions, fmt.Sprintf(cond, len(w.args)))
+}
+
+func (w *where) String() string {
+	if len(w.conditions) == 0 {
+		return "1=1"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
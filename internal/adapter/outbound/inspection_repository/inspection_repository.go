@@ -0,0 +1,204 @@
+package inspection_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/inspection"
+	"prabogo/internal/domain/tree"
+
+	"github.com/lib/pq"
+)
+
+// InspectionRepository stores inspection schedules and reminders in PostgreSQL
+// and reads the monitoring history. Always PostgreSQL, also in SawitDB hybrid
+// mode (monitoring logs live there too).
+type InspectionRepository struct {
+	db *sql.DB
+}
+
+func NewInspectionRepository(db *sql.DB) *InspectionRepository {
+	return &InspectionRepository{
+		db: db,
+	}
+}
+
+const selectSchedule = `
+	SELECT id, name, status, species_id, location_id, tree_code, interval_days, lead_days,
+		assignee_id, create_tasks, enabled, created_at, updated_at
+	FROM inspection_schedules
+`
+
+// FindAll returns every schedule, shortest interval first
+func (r *InspectionRepository) FindAll(ctx context.Context) ([]*inspection.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, selectSchedule+" ORDER BY interval_days, name")
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []*inspection.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// FindByID returns one schedule
+func (r *InspectionRepository) FindByID(ctx context.Context, id string) (*inspection.Schedule, error) {
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx, selectSchedule+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, inspection.ErrScheduleNotFound
+	}
+	return schedule, err
+}
+
+// Create inserts a schedule
+func (r *InspectionRepository) Create(ctx context.Context, s *inspection.Schedule) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO inspection_schedules (
+			id, name, status, species_id, location_id, tree_code, interval_days, lead_days,
+			assignee_id, create_tasks, enabled, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`,
+		s.ID, s.Name, string(s.Status), s.SpeciesID, s.LocationID, s.TreeCode, s.IntervalDays, s.LeadDays,
+		s.AssigneeID, s.CreateTasks, s.Enabled, s.CreatedAt, s.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert inspection schedule: %w", err)
+	}
+	return nil
+}
+
+// Update replaces every field but the ID and creation time
+func (r *InspectionRepository) Update(ctx context.Context, s *inspection.Schedule) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE inspection_schedules
+		SET name = $2, status = $3, species_id = $4, location_id = $5, tree_code = $6,
+			interval_days = $7, lead_days = $8, assignee_id = $9, create_tasks = $10,
+			enabled = $11, updated_at = $12
+		WHERE id = $1
+	`,
+		s.ID, s.Name, string(s.Status), s.SpeciesID, s.LocationID, s.TreeCode,
+		s.IntervalDays, s.LeadDays, s.AssigneeID, s.CreateTasks, s.Enabled, s.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update inspection schedule: %w", err)
+	}
+	return requireRow(result)
+}
+
+// Delete removes a schedule; its reminders cascade
+func (r *InspectionRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM inspection_schedules WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete inspection schedule: %w", err)
+	}
+	return requireRow(result)
+}
+
+// LastInspections returns the latest monitoring log time per tree ID
+func (r *InspectionRepository) LastInspections(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT tree_id, MAX(monitor_date) FROM monitoring_logs GROUP BY tree_id")
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	last := make(map[string]time.Time)
+	for rows.Next() {
+		var treeID string
+		var at time.Time
+		if err := rows.Scan(&treeID, &at); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		last[treeID] = at
+	}
+	return last, rows.Err()
+}
+
+// Claim records a reminder; false if it already exists
+func (r *InspectionRepository) Claim(ctx context.Context, scheduleID string, treeID string, dueDate time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO inspection_reminders (schedule_id, tree_id, due_date)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, scheduleID, treeID, dueDate)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Release deletes a reminder so the next run retries it
+func (r *InspectionRepository) Release(ctx context.Context, scheduleID string, treeID string, dueDate time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"DELETE FROM inspection_reminders WHERE schedule_id = $1 AND tree_id = $2 AND due_date = $3",
+		scheduleID, treeID, dueDate,
+	)
+	return err
+}
+
+// SetTask links reminders to their generated task
+func (r *InspectionRepository) SetTask(ctx context.Context, scheduleID string, treeIDs []string, dueDate time.Time, taskID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE inspection_reminders SET task_id = $4
+		WHERE schedule_id = $1 AND tree_id = ANY($2) AND due_date = $3
+	`, scheduleID, pq.Array(treeIDs), dueDate, taskID)
+	return err
+}
+
+// PurgeBefore deletes reminders for inspections due before cutoff
+func (r *InspectionRepository) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM inspection_reminders WHERE due_date < $1", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete inspection reminders: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSchedule(row rowScanner) (*inspection.Schedule, error) {
+	var s inspection.Schedule
+	var status string
+	err := row.Scan(
+		&s.ID, &s.Name, &status, &s.SpeciesID, &s.LocationID, &s.TreeCode, &s.IntervalDays, &s.LeadDays,
+		&s.AssigneeID, &s.CreateTasks, &s.Enabled, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+	s.Status = tree.TreeStatus(status)
+	return &s, nil
+}
+
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return inspection.ErrScheduleNotFound
+	}
+	return nil
+}
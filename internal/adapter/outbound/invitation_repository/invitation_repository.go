@@ -0,0 +1,139 @@
+package invitation_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/auth"
+)
+
+// InvitationRepository stores hashed user invitations in PostgreSQL
+type InvitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{
+		db: db,
+	}
+}
+
+const invitationColumns = `
+	SELECT id, email, full_name, role, token_hash, COALESCE(invited_by, ''), expires_at,
+	       accepted_at, COALESCE(accepted_user_id, ''), revoked_at, created_at
+	FROM user_invitations`
+
+// FindAll returns every invitation, newest first
+func (r *InvitationRepository) FindAll(ctx context.Context) ([]*auth.Invitation, error) {
+	rows, err := r.db.QueryContext(ctx, invitationColumns+` ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*auth.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// FindByID retrieves one invitation
+func (r *InvitationRepository) FindByID(ctx context.Context, id string) (*auth.Invitation, error) {
+	return scanInvitation(r.db.QueryRowContext(ctx, invitationColumns+` WHERE id = $1`, id))
+}
+
+// FindByHash retrieves an invitation by the hash of its token
+func (r *InvitationRepository) FindByHash(ctx context.Context, tokenHash string) (*auth.Invitation, error) {
+	return scanInvitation(r.db.QueryRowContext(ctx, invitationColumns+` WHERE token_hash = $1`, tokenHash))
+}
+
+// Create inserts an invitation
+func (r *InvitationRepository) Create(ctx context.Context, i *auth.Invitation) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_invitations (id, email, full_name, role, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+	`, i.ID, i.Email, i.FullName, string(i.Role), i.TokenHash, i.InvitedBy, i.ExpiresAt, i.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert invitation: %w", err)
+	}
+	return nil
+}
+
+// UpdateToken replaces the token and expiry of an open invitation
+func (r *InvitationRepository) UpdateToken(ctx context.Context, id string, tokenHash string, expiresAt time.Time) error {
+	return r.updateOpen(ctx, `token_hash = $2, expires_at = $3`, id, tokenHash, expiresAt)
+}
+
+// MarkAccepted consumes an invitation; zero affected rows means it was already used or revoked
+func (r *InvitationRepository) MarkAccepted(ctx context.Context, id string, userID string) error {
+	return r.updateOpen(ctx, `accepted_at = $2, accepted_user_id = $3`, id, time.Now(), userID)
+}
+
+// Revoke cancels an open invitation
+func (r *InvitationRepository) Revoke(ctx context.Context, id string) error {
+	return r.updateOpen(ctx, `revoked_at = $2`, id, time.Now())
+}
+
+// RevokePendingForEmail cancels all open invitations for an address
+func (r *InvitationRepository) RevokePendingForEmail(ctx context.Context, email string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_invitations SET revoked_at = $2
+		WHERE email = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, email, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitations: %w", err)
+	}
+	return nil
+}
+
+// updateOpen updates an invitation that is neither accepted nor revoked
+func (r *InvitationRepository) updateOpen(ctx context.Context, set string, id string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_invitations SET `+set+`
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("invitation already accepted or revoked")
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInvitation(row rowScanner) (*auth.Invitation, error) {
+	i := &auth.Invitation{}
+	var role string
+	var acceptedAt, revokedAt sql.NullTime
+	err := row.Scan(&i.ID, &i.Email, &i.FullName, &role, &i.TokenHash, &i.InvitedBy, &i.ExpiresAt,
+		&acceptedAt, &i.AcceptedUserID, &revokedAt, &i.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("invitation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan invitation: %w", err)
+	}
+	i.Role = auth.UserRole(role)
+	if acceptedAt.Valid {
+		i.AcceptedAt = &acceptedAt.Time
+	}
+	if revokedAt.Valid {
+		i.RevokedAt = &revokedAt.Time
+	}
+	return i, nil
+}
@@ -0,0 +1,29 @@
+package location_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// LocationRepository reads plantation blocks
+// Always PostgreSQL, also in SawitDB hybrid mode.
+type LocationRepository struct {
+	db *sql.DB
+}
+
+func NewLocationRepository(db *sql.DB) *LocationRepository {
+	return &LocationRepository{
+		db: db,
+	}
+}
+
+// Exists reports whether a location ID is registered
+func (r *LocationRepository) Exists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM locations WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("query error: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,164 @@
+package maintenance_rule_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"prabogo/internal/domain/tree"
+
+	"github.com/lib/pq"
+)
+
+// MaintenanceRuleRepository stores maintenance rules in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode.
+type MaintenanceRuleRepository struct {
+	db *sql.DB
+}
+
+func NewMaintenanceRuleRepository(db *sql.DB) *MaintenanceRuleRepository {
+	return &MaintenanceRuleRepository{
+		db: db,
+	}
+}
+
+const selectRule = `
+	SELECT id, code, kind, statuses, threshold, weight, species_id, location_id,
+		reason, enabled, created_at, updated_at
+	FROM maintenance_rules
+`
+
+// FindAll returns every rule, ordered by code then scope
+func (r *MaintenanceRuleRepository) FindAll(ctx context.Context) ([]*tree.MaintenanceRule, error) {
+	rows, err := r.db.QueryContext(ctx, selectRule+" ORDER BY code, species_id, location_id")
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*tree.MaintenanceRule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return rules, nil
+}
+
+// FindByID returns one rule
+func (r *MaintenanceRuleRepository) FindByID(ctx context.Context, id string) (*tree.MaintenanceRule, error) {
+	rule, err := scanRule(r.db.QueryRowContext(ctx, selectRule+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, tree.ErrMaintenanceRuleNotFound
+	}
+	return rule, err
+}
+
+// Create inserts a rule
+func (r *MaintenanceRuleRepository) Create(ctx context.Context, rule *tree.MaintenanceRule) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO maintenance_rules (
+			id, code, kind, statuses, threshold, weight, species_id, location_id,
+			reason, enabled, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		rule.ID, rule.Code, string(rule.Kind), pq.Array(statusStrings(rule.Statuses)), rule.Threshold, rule.Weight,
+		rule.SpeciesID, rule.LocationID, rule.Reason, rule.Enabled, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return tree.ErrMaintenanceRuleExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert maintenance rule: %w", err)
+	}
+	return nil
+}
+
+// Update replaces every field but the ID and creation time
+func (r *MaintenanceRuleRepository) Update(ctx context.Context, rule *tree.MaintenanceRule) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE maintenance_rules
+		SET code = $2, kind = $3, statuses = $4, threshold = $5, weight = $6,
+			species_id = $7, location_id = $8, reason = $9, enabled = $10, updated_at = $11
+		WHERE id = $1
+	`,
+		rule.ID, rule.Code, string(rule.Kind), pq.Array(statusStrings(rule.Statuses)), rule.Threshold, rule.Weight,
+		rule.SpeciesID, rule.LocationID, rule.Reason, rule.Enabled, rule.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return tree.ErrMaintenanceRuleExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance rule: %w", err)
+	}
+	return requireRow(result)
+}
+
+// Delete removes a rule
+func (r *MaintenanceRuleRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM maintenance_rules WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance rule: %w", err)
+	}
+	return requireRow(result)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRule(row rowScanner) (*tree.MaintenanceRule, error) {
+	var rule tree.MaintenanceRule
+	var kind string
+	var statuses []string
+	err := row.Scan(
+		&rule.ID, &rule.Code, &kind, pq.Array(&statuses), &rule.Threshold, &rule.Weight,
+		&rule.SpeciesID, &rule.LocationID, &rule.Reason, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	rule.Kind = tree.MaintenanceRuleKind(kind)
+	for _, s := range statuses {
+		rule.Statuses = append(rule.Statuses, tree.TreeStatus(s))
+	}
+	return &rule, nil
+}
+
+func statusStrings(statuses []tree.TreeStatus) []string {
+	out := make([]string, len(statuses))
+	for i, s := range statuses {
+		out[i] = string(s)
+	}
+	return out
+}
+
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return tree.ErrMaintenanceRuleNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports a duplicate code/species/location
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
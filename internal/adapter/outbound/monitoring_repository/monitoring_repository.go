@@ -152,6 +152,14 @@ func (r *MonitoringRepository) CreateLog(ctx context.Context, log *tree.Monitori
 	return nil
 }
 
+// DeleteLogsByTreeID removes the whole history of a tree (used when purging)
+func (r *MonitoringRepository) DeleteLogsByTreeID(ctx context.Context, treeID string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM monitoring_logs WHERE tree_id = $1", treeID); err != nil {
+		return fmt.Errorf("failed to delete monitoring logs: %w", err)
+	}
+	return nil
+}
+
 // GetLogsByTreeID gets logs directly by TreeID (Hybrid support for SawitDB trees)
 func (r *MonitoringRepository) GetLogsByTreeID(ctx context.Context, treeID string) ([]MonitoringLog, error) {
 	// Query monitoring logs - returns UTC timestamps, browser handles local display
@@ -198,3 +206,50 @@ func (r *MonitoringRepository) GetLogsByTreeID(ctx context.Context, treeID strin
 
 	return logs, nil
 }
+
+// StreamLogsByTreeID calls fn for each log of a tree without buffering the result set
+func (r *MonitoringRepository) StreamLogsByTreeID(ctx context.Context, treeID string, fn func(*MonitoringLog) error) error {
+	query := `
+		SELECT id, tree_id, monitor_date, status, health_score, 
+		       height_meters, diameter_cm, observations, actions_taken,
+		       monitored_by, created_at
+		FROM monitoring_logs
+		WHERE tree_id = $1
+		ORDER BY monitor_date DESC, created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, treeID)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log MonitoringLog
+		err := rows.Scan(
+			&log.ID,
+			&log.TreeID,
+			&log.MonitorDate,
+			&log.Status,
+			&log.HealthScore,
+			&log.HeightMeters,
+			&log.DiameterCm,
+			&log.Observations,
+			&log.ActionsTaken,
+			&log.MonitoredBy,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	return nil
+}
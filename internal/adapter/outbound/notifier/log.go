@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"prabogo/internal/domain/notification"
+)
+
+// LogNotifier writes messages to stdout or a file instead of sending them
+// Meant for development and offline installs: read the reset link from the log.
+type LogNotifier struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewLogNotifier appends to path, or writes to stdout when path is empty
+func NewLogNotifier(path string) (*LogNotifier, error) {
+	if path == "" {
+		return &LogNotifier{out: os.Stdout}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notifier log %s: %w", path, err)
+	}
+	return &LogNotifier{out: f}, nil
+}
+
+// Send writes the message to the log
+func (n *LogNotifier) Send(ctx context.Context, msg notification.Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.out, "📧 [%s] To: %s\nSubject: %s\n\n%s\n----\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,27 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+
+	"prabogo/internal/domain/notification"
+)
+
+// NewFromEnv picks the notifier configured by NOTIFIER_DRIVER (smtp|log)
+// Defaults to the log notifier so password resets work without a mail server.
+func NewFromEnv() (notification.Notifier, error) {
+	switch driver := os.Getenv("NOTIFIER_DRIVER"); driver {
+	case "smtp":
+		return NewSMTPNotifier(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	case "", "log":
+		return NewLogNotifier(os.Getenv("NOTIFIER_LOG_FILE"))
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER_DRIVER %q (use smtp or log)", driver)
+	}
+}
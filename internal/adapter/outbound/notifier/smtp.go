@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/notification"
+)
+
+// SMTPConfig holds mail server settings
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string // Optional, PLAIN auth is skipped when empty
+	Password string
+	From     string
+}
+
+// SMTPNotifier sends messages as plain-text email
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier validates config and creates an SMTP notifier
+func NewSMTPNotifier(config SMTPConfig) (*SMTPNotifier, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP_HOST is required for the smtp notifier")
+	}
+	if config.From == "" {
+		return nil, errors.New("SMTP_FROM is required for the smtp notifier")
+	}
+	if config.Port == "" {
+		config.Port = "587"
+	}
+	return &SMTPNotifier{config: config}, nil
+}
+
+// Send delivers the message through the configured server
+// net/smtp upgrades to STARTTLS when the server offers it.
+func (n *SMTPNotifier) Send(ctx context.Context, msg notification.Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+	}
+
+	addr := net.JoinHostPort(n.config.Host, n.config.Port)
+	if err := smtp.SendMail(addr, auth, n.config.From, []string{msg.To}, n.buildMessage(msg)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) buildMessage(msg notification.Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", stripNewlines(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", stripNewlines(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// stripNewlines prevents header injection through user-controlled subjects
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
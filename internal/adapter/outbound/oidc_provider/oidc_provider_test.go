@@ -0,0 +1,120 @@
+package oidc_provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"prabogo/internal/adapter/outbound/oidc_provider/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Server) {
+	t.Helper()
+	idp := oidctest.NewServer()
+	t.Cleanup(idp.Close)
+
+	p, err := NewProvider(context.Background(), Config{
+		Issuer:      idp.URL,
+		ClientID:    oidctest.ClientID,
+		RedirectURL: oidctest.RedirectURL,
+		Scopes:      []string{"openid", "profile", "email", "groups"},
+		GroupsClaim: "groups",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	return p, idp
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// login runs /authorize for verifier and returns the code
+func login(t *testing.T, p *Provider, idp *oidctest.Server, verifier string) string {
+	t.Helper()
+	code, state, err := idp.Authorize(p.AuthCodeURL("state-1", "nonce-1", challenge(verifier)))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if state != "state-1" {
+		t.Fatalf("state = %q, want state-1", state)
+	}
+	return code
+}
+
+func TestExchangeReturnsVerifiedClaims(t *testing.T) {
+	p, idp := newTestProvider(t)
+	idp.SetIdentity(oidctest.Identity{
+		Subject:       "sub-42",
+		Email:         "Forester@Example.com",
+		EmailVerified: true,
+		Username:      "forester",
+		Groups:        []string{"foresters", "staff"},
+	})
+
+	code := login(t, p, idp, "verifier-1")
+	claims, err := p.Exchange(context.Background(), code, "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if claims.Issuer != idp.URL || claims.Subject != "sub-42" || claims.Nonce != "nonce-1" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if claims.Email != "forester@example.com" || !claims.EmailVerified {
+		t.Errorf("email = %q verified=%v, want lowercased verified email", claims.Email, claims.EmailVerified)
+	}
+	if len(claims.Groups) != 2 || claims.Groups[0] != "foresters" {
+		t.Errorf("groups = %v", claims.Groups)
+	}
+}
+
+func TestExchangeRejectsBadSignature(t *testing.T) {
+	p, idp := newTestProvider(t)
+	idp.SetIdentity(oidctest.Identity{Subject: "sub-1", WrongKey: true})
+
+	code := login(t, p, idp, "verifier-1")
+	_, err := p.Exchange(context.Background(), code, "verifier-1")
+	if err == nil || !strings.Contains(err.Error(), "invalid id_token") {
+		t.Fatalf("err = %v, want invalid id_token", err)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	p, idp := newTestProvider(t)
+
+	code := login(t, p, idp, "verifier-1")
+	if _, err := p.Exchange(context.Background(), code, "another-verifier"); err == nil {
+		t.Fatal("Exchange with a wrong PKCE verifier succeeded")
+	}
+}
+
+func TestExchangeRejectsReusedCode(t *testing.T) {
+	p, idp := newTestProvider(t)
+
+	code := login(t, p, idp, "verifier-1")
+	if _, err := p.Exchange(context.Background(), code, "verifier-1"); err != nil {
+		t.Fatalf("first Exchange: %v", err)
+	}
+	if _, err := p.Exchange(context.Background(), code, "verifier-1"); err == nil {
+		t.Fatal("second Exchange with the same code succeeded")
+	}
+}
+
+func TestNewProviderRejectsIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer()
+	defer idp.Close()
+
+	_, err := NewProvider(context.Background(), Config{
+		Issuer:      idp.URL + "/",
+		ClientID:    oidctest.ClientID,
+		RedirectURL: oidctest.RedirectURL,
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("err = %v, want issuer mismatch", err)
+	}
+}
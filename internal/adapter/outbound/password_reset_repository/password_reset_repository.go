@@ -0,0 +1,85 @@
+package password_reset_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/auth"
+)
+
+// PasswordResetRepository stores hashed password reset tokens in PostgreSQL
+type PasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		db: db,
+	}
+}
+
+// Create inserts a reset token
+func (r *PasswordResetRepository) Create(ctx context.Context, t *auth.PasswordResetToken) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at, ip)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, t.ID, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedAt, t.IP)
+	if err != nil {
+		return fmt.Errorf("failed to insert reset token: %w", err)
+	}
+	return nil
+}
+
+// FindByHash retrieves a reset token by the hash of its value
+func (r *PasswordResetRepository) FindByHash(ctx context.Context, tokenHash string) (*auth.PasswordResetToken, error) {
+	t := &auth.PasswordResetToken{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at, COALESCE(ip, '')
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+	`, tokenHash).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &usedAt, &t.CreatedAt, &t.IP)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("reset token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reset token: %w", err)
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+	return t, nil
+}
+
+// MarkUsed consumes a token; zero affected rows means it was already used
+func (r *PasswordResetRepository) MarkUsed(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = $2
+		WHERE id = $1 AND used_at IS NULL
+	`, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to consume reset token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to consume reset token: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("reset token already used")
+	}
+	return nil
+}
+
+// InvalidateForUser consumes all outstanding tokens of a user
+func (r *PasswordResetRepository) InvalidateForUser(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE password_reset_tokens SET used_at = $2
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+	return nil
+}
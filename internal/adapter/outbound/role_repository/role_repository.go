@@ -0,0 +1,138 @@
+package role_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"prabogo/internal/domain/auth"
+)
+
+// RoleRepository stores roles and role_permissions in PostgreSQL
+type RoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{
+		db: db,
+	}
+}
+
+// FindAll returns every role with its permissions, ordered by name
+func (r *RoleRepository) FindAll(ctx context.Context) ([]*auth.Role, error) {
+	return r.query(ctx, "")
+}
+
+// FindByName returns one role
+func (r *RoleRepository) FindByName(ctx context.Context, name auth.UserRole) (*auth.Role, error) {
+	roles, err := r.query(ctx, "WHERE r.name = $1", string(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("role %s not found", name)
+	}
+	return roles[0], nil
+}
+
+func (r *RoleRepository) query(ctx context.Context, where string, args ...interface{}) ([]*auth.Role, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT r.name, r.description, r.built_in, r.created_at, r.updated_at, p.permission
+		FROM roles r
+		LEFT JOIN role_permissions p ON p.role_name = r.name
+		`+where+`
+		ORDER BY r.name, p.permission
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*auth.Role
+	var current *auth.Role
+	for rows.Next() {
+		var role auth.Role
+		var permission sql.NullString
+		if err := rows.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, &role.UpdatedAt, &permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+
+		// One row per permission: start a new role when the name changes
+		if current == nil || current.Name != role.Name {
+			current = &role
+			current.Permissions = []auth.Permission{}
+			roles = append(roles, current)
+		}
+		if permission.Valid {
+			current.Permissions = append(current.Permissions, auth.Permission(permission.String))
+		}
+	}
+	return roles, rows.Err()
+}
+
+// Create inserts a role and its permissions
+func (r *RoleRepository) Create(ctx context.Context, role *auth.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO roles (name, description, built_in, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, string(role.Name), role.Description, role.BuiltIn, role.CreatedAt, role.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPermissions(ctx, tx, role); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update replaces description and permissions
+func (r *RoleRepository) Update(ctx context.Context, role *auth.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE roles SET description = $2, updated_at = $3 WHERE name = $1
+	`, string(role.Name), role.Description, role.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("role %s not found", role.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_name = $1`, string(role.Name)); err != nil {
+		return err
+	}
+	if err := insertPermissions(ctx, tx, role); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertPermissions(ctx context.Context, tx *sql.Tx, role *auth.Role) error {
+	for _, p := range role.Permissions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO role_permissions (role_name, permission) VALUES ($1, $2)
+		`, string(role.Name), string(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a role; its permissions cascade
+func (r *RoleRepository) Delete(ctx context.Context, name auth.UserRole) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM roles WHERE name = $1`, string(name))
+	return err
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"prabogo/internal/adapter/outbound/sawit_client"
 	"prabogo/internal/domain/tree"
+	aqlutil "prabogo/utils/aql"
 )
 
 // TreeRepository implements tree.TreeRepository using SawitDB
 type TreeRepository struct {
 	client *sawit_client.SawitClient
+	// writeMu serializes version check + write so Update is compare-and-set
+	// within this process (AQL has no conditional update with row counts)
+	writeMu sync.Mutex
 }
 
 // NewTreeRepository creates a new SawitDB tree repository
@@ -31,16 +36,16 @@ func (r *TreeRepository) Create(ctx context.Context, t *tree.Tree) error {
 		TANAM KE trees (
 			id, code, species_id, location_id, planting_date,
 			age_years, height_meters, diameter_cm, status,
-			health_score, notes, registered_by, created_at, updated_at
+			health_score, notes, registered_by, version, created_at, updated_at
 		) BIBIT (
 			'%s', '%s', '%s', '%s', '%s',
 			%d, %.2f, %.2f, '%s',
-			%d, '%s', '%s', '%s', '%s'
+			%d, '%s', '%s', %d, '%s', '%s'
 		)
 	`,
 		t.ID, t.Code, t.SpeciesID, t.LocationID, t.PlantingDate.Format("2006-01-02"),
 		t.AgeYears, t.HeightMeters, t.DiameterCm, string(t.Status),
-		t.HealthScore, t.Notes, t.RegisteredBy,
+		t.HealthScore, t.Notes, t.RegisteredBy, t.Version,
 		t.CreatedAt.UTC().Format(time.RFC3339),
 		t.UpdatedAt.UTC().Format(time.RFC3339),
 	)
@@ -69,7 +74,7 @@ func (r *TreeRepository) FindByCode(ctx context.Context, code string) (*tree.Tre
 	}
 
 	if len(trees) == 0 {
-		return nil, fmt.Errorf("tree with code %s not found", code)
+		return nil, fmt.Errorf("%w: code %s", tree.ErrTreeNotFound, code)
 	}
 
 	return trees[0], nil
@@ -90,7 +95,7 @@ func (r *TreeRepository) FindByID(ctx context.Context, id string) (*tree.Tree, e
 	}
 
 	if len(trees) == 0 {
-		return nil, fmt.Errorf("tree with id %s not found", id)
+		return nil, fmt.Errorf("%w: id %s", tree.ErrTreeNotFound, id)
 	}
 
 	return trees[0], nil
@@ -119,10 +124,17 @@ func (r *TreeRepository) FindAll(ctx context.Context, filter tree.TreeFilter) ([
 	var filtered []*tree.Tree
 
 	for _, t := range allTrees {
+		// Trash is only listed when explicitly requested
+		if t.IsDeleted() != filter.Trashed {
+			continue
+		}
 		// Filter by Location
 		if filter.LocationID != "" && t.LocationID != filter.LocationID {
 			continue
 		}
+		if !filter.AllowsLocation(t.LocationID) {
+			continue
+		}
 		// Filter by Species
 		if filter.SpeciesID != "" && t.SpeciesID != filter.SpeciesID {
 			continue
@@ -141,14 +153,62 @@ func (r *TreeRepository) FindAll(ctx context.Context, filter tree.TreeFilter) ([
 		return []*tree.Tree{}, nil
 	}
 
+	// No limit returns every match, as the Postgres repository does
 	end := start + filter.Limit
-	if end > total {
+	if filter.Limit <= 0 || end > total {
 		end = total
 	}
 
 	return filtered[start:end], nil
 }
 
+// StreamAll calls fn for each tree matching filter
+// SawitDB returns the whole result in one response, so rows are mapped and
+// handed over one at a time instead of building a filtered copy.
+func (r *TreeRepository) StreamAll(ctx context.Context, filter tree.TreeFilter, fn func(*tree.Tree) error) error {
+	result, err := r.client.Query(ctx, "PANEN * DARI trees")
+	if err != nil {
+		return fmt.Errorf("failed to query trees: %w", err)
+	}
+
+	allTrees, err := r.parseTreeResults(result)
+	if err != nil {
+		return err
+	}
+
+	skipped, sent := 0, 0
+	for _, t := range allTrees {
+		if t.IsDeleted() != filter.Trashed {
+			continue
+		}
+		if filter.LocationID != "" && t.LocationID != filter.LocationID {
+			continue
+		}
+		if !filter.AllowsLocation(t.LocationID) {
+			continue
+		}
+		if filter.SpeciesID != "" && t.SpeciesID != filter.SpeciesID {
+			continue
+		}
+		if filter.Status != "" && t.Status != filter.Status {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		if filter.Limit > 0 && sent >= filter.Limit {
+			break
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+		sent++
+	}
+
+	return nil
+}
+
 // UpdateStatus updates tree status and health score
 func (r *TreeRepository) UpdateStatus(ctx context.Context, id string, status tree.TreeStatus, healthScore int) error {
 	aql := fmt.Sprintf(`
@@ -220,7 +280,7 @@ func (r *TreeRepository) CountByLocation(ctx context.Context, locationID string)
 		return 0, err
 	}
 
-	return int64(len(trees)), nil
+	return countActive(trees), nil
 }
 
 // CountByStatus counts trees by status
@@ -237,11 +297,22 @@ func (r *TreeRepository) CountByStatus(ctx context.Context, status tree.TreeStat
 		return 0, err
 	}
 
-	return int64(len(trees)), nil
+	return countActive(trees), nil
 }
 
-// Update updates an existing tree
+// Update updates an existing tree if its version still matches
 func (r *TreeRepository) Update(ctx context.Context, t *tree.Tree) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	current, err := r.FindByID(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	if current.Version != t.Version {
+		return tree.ErrVersionConflict
+	}
+
 	aql := fmt.Sprintf(`
 		PUPUK trees DENGAN
 			species_id='%s',
@@ -253,25 +324,71 @@ func (r *TreeRepository) Update(ctx context.Context, t *tree.Tree) error {
 			status='%s',
 			health_score=%d,
 			notes='%s',
+			version=%d,
 			updated_at='%s'
 		DIMANA id='%s'
 	`,
-		t.SpeciesID, t.LocationID, t.PlantingDate.Format("2006-01-02"),
+		aqlutil.EscapeString(t.SpeciesID), aqlutil.EscapeString(t.LocationID), t.PlantingDate.Format("2006-01-02"),
 		t.AgeYears, t.HeightMeters, t.DiameterCm,
-		string(t.Status), t.HealthScore, t.Notes,
+		aqlutil.EscapeString(string(t.Status)), t.HealthScore, aqlutil.EscapeString(t.Notes),
+		t.Version+1,
 		time.Now().UTC().Format(time.RFC3339),
-		t.ID,
+		aqlutil.EscapeString(t.ID),
 	)
 
-	_, err := r.client.Query(ctx, aql)
+	_, err = r.client.Query(ctx, aql)
 	if err != nil {
 		return fmt.Errorf("failed to update tree: %w", err)
 	}
 
+	t.Version++
+	return nil
+}
+
+// SoftDelete moves a tree to the trash
+func (r *TreeRepository) SoftDelete(ctx context.Context, id string, deletedBy string, reason string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	aql := fmt.Sprintf(`
+		PUPUK trees DENGAN
+			deleted_at='%s',
+			deleted_by='%s',
+			delete_reason='%s',
+			updated_at='%s'
+		DIMANA id='%s'
+	`,
+		now, deletedBy, aqlutil.EscapeString(reason), now, id,
+	)
+
+	_, err := r.client.Query(ctx, aql)
+	if err != nil {
+		return fmt.Errorf("failed to delete tree: %w", err)
+	}
+
 	return nil
 }
 
-// Delete removes a tree
+// Restore clears the trash markers (empty deleted_at means active)
+func (r *TreeRepository) Restore(ctx context.Context, id string) error {
+	aql := fmt.Sprintf(`
+		PUPUK trees DENGAN
+			deleted_at='',
+			deleted_by='',
+			delete_reason='',
+			updated_at='%s'
+		DIMANA id='%s'
+	`,
+		time.Now().UTC().Format(time.RFC3339), id,
+	)
+
+	_, err := r.client.Query(ctx, aql)
+	if err != nil {
+		return fmt.Errorf("failed to restore tree: %w", err)
+	}
+
+	return nil
+}
+
+// Delete permanently removes a tree
 func (r *TreeRepository) Delete(ctx context.Context, id string) error {
 	aql := fmt.Sprintf("GUSUR DARI trees DIMANA id='%s'", id)
 
@@ -367,6 +484,19 @@ func (r *TreeRepository) mapToTree(data map[string]interface{}) (*tree.Tree, err
 		updatedAt, _ = time.Parse(time.RFC3339, ua)
 	}
 
+	// Trees created before versioning have no version field yet
+	version := getInt("version")
+	if version == 0 {
+		version = 1
+	}
+
+	var deletedAt *time.Time
+	if da := getString("deleted_at"); da != "" {
+		if parsed, err := time.Parse(time.RFC3339, da); err == nil {
+			deletedAt = &parsed
+		}
+	}
+
 	return &tree.Tree{
 		ID:           getString("id"),
 		Code:         getString("code"),
@@ -380,7 +510,22 @@ func (r *TreeRepository) mapToTree(data map[string]interface{}) (*tree.Tree, err
 		HealthScore:  getInt("health_score"),
 		Notes:        getString("notes"),
 		RegisteredBy: getString("registered_by"),
+		Version:      version,
 		CreatedAt:    createdAt,
 		UpdatedAt:    updatedAt,
+		DeletedAt:    deletedAt,
+		DeletedBy:    getString("deleted_by"),
+		DeleteReason: getString("delete_reason"),
 	}, nil
 }
+
+// countActive counts trees that are not in the trash
+func countActive(trees []*tree.Tree) int64 {
+	var count int64
+	for _, t := range trees {
+		if !t.IsDeleted() {
+			count++
+		}
+	}
+	return count
+}
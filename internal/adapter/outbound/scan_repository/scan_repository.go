@@ -0,0 +1,233 @@
+package scan_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/analytics"
+
+	"github.com/lib/pq"
+)
+
+// ScanRepository stores tree scan events and rollups in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode (like monitoring logs)
+type ScanRepository struct {
+	db *sql.DB
+}
+
+func NewScanRepository(db *sql.DB) *ScanRepository {
+	return &ScanRepository{
+		db: db,
+	}
+}
+
+// Record inserts the event and bumps its hour and day rollups in one transaction
+func (r *ScanRepository) Record(ctx context.Context, e *analytics.ScanEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tree_scans (
+			id, tree_id, tree_code, location_id, user_id,
+			latitude, longitude, user_agent, scanned_at
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+	`,
+		e.ID, e.TreeID, e.TreeCode, e.LocationID, e.UserID,
+		e.Latitude, e.Longitude, e.UserAgent, e.ScannedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert scan event: %w", err)
+	}
+
+	authenticated := 0
+	if e.UserID != "" {
+		authenticated = 1
+	}
+
+	rollup := `
+		INSERT INTO tree_scan_rollups (
+			granularity, bucket_start, tree_id, tree_code, location_id, scans, authenticated_scans
+		)
+		VALUES ($1, $2, $3, $4, $5, 1, $6)
+		ON CONFLICT (granularity, bucket_start, tree_id) DO UPDATE SET
+			scans = tree_scan_rollups.scans + 1,
+			authenticated_scans = tree_scan_rollups.authenticated_scans + EXCLUDED.authenticated_scans,
+			location_id = EXCLUDED.location_id
+	`
+	for _, g := range []analytics.Granularity{analytics.GranularityHour, analytics.GranularityDay} {
+		_, err := tx.ExecContext(ctx, rollup,
+			string(g), g.Truncate(e.ScannedAt), e.TreeID, e.TreeCode, e.LocationID, authenticated,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update %s scan rollup: %w", g, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Series sums rollups per bucket, oldest first
+func (r *ScanRepository) Series(ctx context.Context, filter analytics.SeriesFilter) ([]analytics.SeriesPoint, error) {
+	w := newWhere()
+	w.add("granularity = $%d", string(filter.Granularity))
+	w.add("bucket_start >= $%d", filter.From)
+	w.add("bucket_start < $%d", filter.To)
+	w.scope(filter.TreeCode, filter.LocationID, filter.LocationIDs)
+
+	query := `
+		SELECT bucket_start, SUM(scans), SUM(authenticated_scans)
+		FROM tree_scan_rollups
+		WHERE ` + w.String() + `
+		GROUP BY bucket_start
+		ORDER BY bucket_start
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var points []analytics.SeriesPoint
+	for rows.Next() {
+		var p analytics.SeriesPoint
+		if err := rows.Scan(&p.BucketStart, &p.Scans, &p.Authenticated); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		points = append(points, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return points, nil
+}
+
+// TopTrees ranks trees by summed daily rollups
+func (r *ScanRepository) TopTrees(ctx context.Context, filter analytics.TopFilter) ([]analytics.TopTree, error) {
+	w := newWhere()
+	w.add("granularity = $%d", string(analytics.GranularityDay))
+	w.add("bucket_start >= $%d", filter.From)
+	w.add("bucket_start < $%d", filter.To)
+	w.scope("", filter.LocationID, filter.LocationIDs)
+
+	// A tree moved between locations is ranked once, under its latest location
+	query := `
+		SELECT tree_id, MAX(tree_code), (ARRAY_AGG(location_id ORDER BY bucket_start DESC))[1], SUM(scans) AS total
+		FROM tree_scan_rollups
+		WHERE ` + w.String() + `
+		GROUP BY tree_id
+		ORDER BY total DESC, tree_id
+		LIMIT ` + fmt.Sprintf("%d", filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	trees := []analytics.TopTree{}
+	for rows.Next() {
+		var t analytics.TopTree
+		if err := rows.Scan(&t.TreeID, &t.TreeCode, &t.LocationID, &t.Scans); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		trees = append(trees, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return trees, nil
+}
+
+// Heatmap groups raw events with coordinates into a rounded lat/lng grid
+func (r *ScanRepository) Heatmap(ctx context.Context, filter analytics.HeatmapFilter) ([]analytics.HeatmapCell, error) {
+	w := newWhere()
+	w.add("scanned_at >= $%d", filter.From)
+	w.add("scanned_at < $%d", filter.To)
+	w.scope(filter.TreeCode, filter.LocationID, filter.LocationIDs)
+
+	query := fmt.Sprintf(`
+		SELECT ROUND(latitude::numeric, %d)::float8 AS lat, ROUND(longitude::numeric, %d)::float8 AS lng, COUNT(*)
+		FROM tree_scans
+		WHERE latitude IS NOT NULL AND %s
+		GROUP BY lat, lng
+		ORDER BY COUNT(*) DESC
+		LIMIT 5000
+	`, filter.Precision, filter.Precision, w.String())
+
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	cells := []analytics.HeatmapCell{}
+	for rows.Next() {
+		var cell analytics.HeatmapCell
+		if err := rows.Scan(&cell.Latitude, &cell.Longitude, &cell.Scans); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		cells = append(cells, cell)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return cells, nil
+}
+
+// PurgeBefore deletes raw events older than cutoff
+func (r *ScanRepository) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM tree_scans WHERE scanned_at < $1", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete scan events: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// where builds a parameterized WHERE clause
+type where struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newWhere() *where {
+	return &where{}
+}
+
+func (w *where) add(cond string, value interface{}) {
+	w.args = append(w.args, value)
+	w.conditions = append(w.conditions, fmt.Sprintf(cond, len(w.args)))
+}
+
+// scope narrows to a tree, a location and the caller's locations (nil = any)
+func (w *where) scope(treeCode, locationID string, locationIDs []string) {
+	if treeCode != "" {
+		w.add("tree_code = $%d", treeCode)
+	}
+	if locationID != "" {
+		w.add("location_id = $%d", locationID)
+	}
+	if locationIDs != nil {
+		w.add("location_id = ANY($%d)", pq.Array(locationIDs))
+	}
+}
+
+func (w *where) String() string {
+	if len(w.conditions) == 0 {
+		return "1=1"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
@@ -0,0 +1,155 @@
+package session_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/auth"
+)
+
+// SessionRepository stores hashed refresh tokens in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode (like users)
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{
+		db: db,
+	}
+}
+
+// Create inserts a refresh token
+func (r *SessionRepository) Create(ctx context.Context, t *auth.RefreshToken) error {
+	return insertToken(ctx, r.db, t)
+}
+
+// FindByHash retrieves a refresh token by the hash of its value
+func (r *SessionRepository) FindByHash(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	query := `
+		SELECT id, family_id, user_id, token_hash, expires_at, created_at,
+		       revoked_at, COALESCE(replaced_by, ''), COALESCE(ip, ''), COALESCE(user_agent, '')
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	t := &auth.RefreshToken{}
+	var revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&t.ID, &t.FamilyID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt,
+		&revokedAt, &t.ReplacedBy, &t.IP, &t.UserAgent,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("refresh token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+
+	return t, nil
+}
+
+// Rotate revokes oldID and inserts next in one transaction
+// The conditional UPDATE makes two concurrent refreshes with the same token
+// race safely: only one wins, the other sees zero rows and reports reuse.
+func (r *SessionRepository) Rotate(ctx context.Context, oldID string, next *auth.RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = $2, replaced_by = $3
+		WHERE id = $1 AND revoked_at IS NULL
+	`, oldID, time.Now(), next.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if affected == 0 {
+		return auth.ErrRefreshTokenReused
+	}
+
+	if err := insertToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeFamily revokes every token of one session
+func (r *SessionRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $2
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes every token of every session of a user
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// IsActive reports whether a session has an unrevoked, unexpired token
+func (r *SessionRepository) IsActive(ctx context.Context, familyID string, userID string) (bool, error) {
+	var active bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens
+			WHERE family_id = $1 AND user_id = $2
+			  AND revoked_at IS NULL AND expires_at > $3
+		)
+	`, familyID, userID, time.Now()).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return active, nil
+}
+
+// DeleteExpired removes tokens whose session has ended
+func (r *SessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertToken(ctx context.Context, db execer, t *auth.RefreshToken) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (
+			id, family_id, user_id, token_hash, expires_at, created_at, ip, user_agent
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, t.ID, t.FamilyID, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedAt, t.IP, t.UserAgent)
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package species_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type SpeciesRepository struct {
+	db *sql.DB
+}
+
+func NewSpeciesRepository(db *sql.DB) *SpeciesRepository {
+	return &SpeciesRepository{
+		db: db,
+	}
+}
+
+// CommonNames returns species ID -> "Common (Scientific)" display names
+func (r *SpeciesRepository) CommonNames(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, common_name, scientific_name FROM tree_species")
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, common, scientific string
+		if err := rows.Scan(&id, &common, &scientific); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		names[id] = fmt.Sprintf("%s (%s)", common, scientific)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return names, nil
+}
+
+// Exists reports whether a species ID is registered
+func (r *SpeciesRepository) Exists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tree_species WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("query error: %w", err)
+	}
+	return exists, nil
+}
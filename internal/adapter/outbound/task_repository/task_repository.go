@@ -0,0 +1,301 @@
+package task_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/task"
+	"prabogo/internal/domain/tree"
+
+	"github.com/lib/pq"
+)
+
+// TaskRepository stores work orders in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode.
+type TaskRepository struct {
+	db *sql.DB
+}
+
+func NewTaskRepository(db *sql.DB) *TaskRepository {
+	return &TaskRepository{
+		db: db,
+	}
+}
+
+const selectTask = `
+	SELECT id, type, title, description, assignee_id, due_date, status, result_status,
+		created_by, created_at, updated_at, started_at, completed_at, completed_by,
+		completion_notes, verified_at, verified_by, verification_notes
+	FROM tasks t
+`
+
+// Create inserts the task and its trees in one transaction
+func (r *TaskRepository) Create(ctx context.Context, t *task.Task) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tasks (
+			id, type, title, description, assignee_id, due_date, status, result_status,
+			created_by, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`,
+		t.ID, string(t.Type), t.Title, t.Description, t.AssigneeID, t.DueDate, string(t.Status), string(t.ResultStatus),
+		t.CreatedBy, t.CreatedAt, t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert task: %w", err)
+	}
+
+	for _, tt := range t.Trees {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO task_trees (task_id, tree_id, tree_code, location_id)
+			VALUES ($1, $2, $3, $4)
+		`, t.ID, tt.TreeID, tt.TreeCode, tt.LocationID)
+		if err != nil {
+			return fmt.Errorf("failed to insert task tree: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindByID returns one task with its trees
+func (r *TaskRepository) FindByID(ctx context.Context, id string) (*task.Task, error) {
+	t, err := scanTask(r.db.QueryRowContext(ctx, selectTask+" WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, task.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTrees(ctx, []*task.Task{t}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FindAll returns a page of tasks, earliest due first, and the total matching
+func (r *TaskRepository) FindAll(ctx context.Context, filter task.Filter) ([]*task.Task, int, error) {
+	w := newWhere()
+	if filter.Status != "" {
+		w.add("t.status = $%d", string(filter.Status))
+	}
+	if filter.Type != "" {
+		w.add("t.type = $%d", string(filter.Type))
+	}
+	if filter.AssigneeID != "" {
+		w.add("t.assignee_id = $%d", filter.AssigneeID)
+	}
+	if filter.TreeCode != "" {
+		w.add("EXISTS (SELECT 1 FROM task_trees tt WHERE tt.task_id = t.id AND tt.tree_code = $%d)", filter.TreeCode)
+	}
+	if filter.LocationID != "" {
+		w.add("EXISTS (SELECT 1 FROM task_trees tt WHERE tt.task_id = t.id AND tt.location_id = $%d)", filter.LocationID)
+	}
+	if filter.Overdue {
+		// Due dates are inclusive: overdue from the day after
+		w.add("t.status IN ('open', 'in_progress') AND t.due_date < $%d", time.Now().UTC().Truncate(24*time.Hour))
+	}
+	if filter.LocationIDs != nil {
+		w.args = append(w.args, pq.Array(filter.LocationIDs), filter.VisibleTo)
+		w.conditions = append(w.conditions, fmt.Sprintf(
+			"(EXISTS (SELECT 1 FROM task_trees tt WHERE tt.task_id = t.id AND tt.location_id = ANY($%d)) OR t.assignee_id = $%d)",
+			len(w.args)-1, len(w.args),
+		))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks t WHERE "+w.String(), w.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count error: %w", err)
+	}
+
+	query := selectTask + " WHERE " + w.String() +
+		fmt.Sprintf(" ORDER BY t.due_date, t.created_at LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	rows, err := r.db.QueryContext(ctx, query, w.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []*task.Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		tasks = append(tasks, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error: %w", err)
+	}
+
+	if err := r.loadTrees(ctx, tasks); err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// Update saves the task if its status is still expected
+func (r *TaskRepository) Update(ctx context.Context, t *task.Task, expected task.Status) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE tasks
+		SET title = $3, description = $4, assignee_id = $5, due_date = $6, status = $7,
+			updated_at = $8, started_at = $9, completed_at = $10, completed_by = $11,
+			completion_notes = $12, verified_at = $13, verified_by = $14, verification_notes = $15
+		WHERE id = $1 AND status = $2
+	`,
+		t.ID, string(expected), t.Title, t.Description, t.AssigneeID, t.DueDate, string(t.Status),
+		t.UpdatedAt, t.StartedAt, t.CompletedAt, t.CompletedBy,
+		t.CompletionNotes, t.VerifiedAt, t.VerifiedBy, t.VerificationNotes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return task.ErrTaskConflict
+	}
+	return nil
+}
+
+// MarkTreeDone claims one tree unless it is already marked
+func (r *TaskRepository) MarkTreeDone(ctx context.Context, taskID string, treeID string, at time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE task_trees SET completed_at = $3 WHERE task_id = $1 AND tree_id = $2 AND completed_at IS NULL",
+		taskID, treeID, at,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// UnmarkTreeDone clears the completion mark of one tree
+func (r *TaskRepository) UnmarkTreeDone(ctx context.Context, taskID string, treeID string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE task_trees SET completed_at = NULL WHERE task_id = $1 AND tree_id = $2",
+		taskID, treeID,
+	)
+	return err
+}
+
+// ResetTrees clears every completion mark of a task
+func (r *TaskRepository) ResetTrees(ctx context.Context, taskID string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE task_trees SET completed_at = NULL WHERE task_id = $1", taskID)
+	return err
+}
+
+// loadTrees fills Trees of every task with one query
+func (r *TaskRepository) loadTrees(ctx context.Context, tasks []*task.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	byID := make(map[string]*task.Task, len(tasks))
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		t.Trees = []task.TaskTree{}
+		byID[t.ID] = t
+		ids = append(ids, t.ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT task_id, tree_id, tree_code, location_id, completed_at
+		FROM task_trees
+		WHERE task_id = ANY($1)
+		ORDER BY tree_code
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID string
+		var tt task.TaskTree
+		var completedAt sql.NullTime
+		if err := rows.Scan(&taskID, &tt.TreeID, &tt.TreeCode, &tt.LocationID, &completedAt); err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		if completedAt.Valid {
+			tt.CompletedAt = &completedAt.Time
+		}
+		byID[taskID].Trees = append(byID[taskID].Trees, tt)
+	}
+	return rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (*task.Task, error) {
+	var t task.Task
+	var taskType, status, resultStatus string
+	var startedAt, completedAt, verifiedAt sql.NullTime
+	err := row.Scan(
+		&t.ID, &taskType, &t.Title, &t.Description, &t.AssigneeID, &t.DueDate, &status, &resultStatus,
+		&t.CreatedBy, &t.CreatedAt, &t.UpdatedAt, &startedAt, &completedAt, &t.CompletedBy,
+		&t.CompletionNotes, &verifiedAt, &t.VerifiedBy, &t.VerificationNotes,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan error: %w", err)
+	}
+
+	t.Type = task.Type(taskType)
+	t.Status = task.Status(status)
+	t.ResultStatus = tree.TreeStatus(resultStatus)
+	if startedAt.Valid {
+		t.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		t.CompletedAt = &completedAt.Time
+	}
+	if verifiedAt.Valid {
+		t.VerifiedAt = &verifiedAt.Time
+	}
+	return &t, nil
+}
+
+// where builds a parameterized WHERE clause
+type where struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newWhere() *where {
+	return &where{}
+}
+
+func (w *where) add(cond string, value interface{}) {
+	w.args = append(w.args, value)
+	w.conditions = append(w.conditions, fmt.Sprintf(cond, len(w.args)))
+}
+
+func (w *where) String() string {
+	if len(w.conditions) == 0 {
+		return "1=1"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
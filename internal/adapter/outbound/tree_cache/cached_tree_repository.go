@@ -0,0 +1,314 @@
+package tree_cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"prabogo/internal/domain/tree"
+
+	"github.com/google/uuid"
+)
+
+const keyPrefix = "treecache:"
+
+// listGenerationKey is bumped on every mutation; list page keys embed it,
+// so one increment retires every cached page
+const listGenerationKey = keyPrefix + "lists:gen"
+
+// Config holds cache lifetimes
+type Config struct {
+	TTL         time.Duration // Trees by code or ID
+	NegativeTTL time.Duration // Remembered "not found" answers
+	ListTTL     time.Duration // List pages
+}
+
+// GetConfig reads TREE_CACHE_TTL, TREE_CACHE_NEGATIVE_TTL and TREE_CACHE_LIST_TTL
+func GetConfig() Config {
+	return Config{
+		TTL:         durationFromEnv("TREE_CACHE_TTL", 5*time.Minute),
+		NegativeTTL: durationFromEnv("TREE_CACHE_NEGATIVE_TTL", 30*time.Second),
+		ListTTL:     durationFromEnv("TREE_CACHE_LIST_TTL", time.Minute),
+	}
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// entry is one cached lookup; Missing marks a negative entry
+type entry struct {
+	Tree    *tree.Tree `json:"tree,omitempty"`
+	Missing bool       `json:"missing,omitempty"`
+}
+
+// CachedTreeRepository is a read-through cache around any tree.TreeRepository
+// FindByCode, FindByID and FindAll pages are cached (unknown trees negatively);
+// every mutation evicts the tree and all list pages and is broadcast so other
+// instances do the same. Cache failures fall through to the wrapped repository.
+type CachedTreeRepository struct {
+	inner       tree.TreeRepository
+	store       Store
+	broadcaster Broadcaster // Optional
+	config      Config
+	origin      string
+}
+
+// NewCachedTreeRepository wraps inner; broadcaster may be nil for a single instance
+func NewCachedTreeRepository(inner tree.TreeRepository, store Store, broadcaster Broadcaster, config Config) *CachedTreeRepository {
+	return &CachedTreeRepository{
+		inner:       inner,
+		store:       store,
+		broadcaster: broadcaster,
+		config:      config,
+		origin:      uuid.New().String(),
+	}
+}
+
+// Listen applies invalidations published by other instances until ctx is done
+// With the shared Redis store their eviction already happened; applying it again
+// is harmless and keeps per-instance stores coherent.
+func (r *CachedTreeRepository) Listen(ctx context.Context) {
+	if r.broadcaster == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		err := r.broadcaster.Listen(ctx, func(inv Invalidation) {
+			if inv.Origin != r.origin {
+				r.evict(ctx, inv)
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			fmt.Printf("⚠️ Warning: Tree cache invalidation listener stopped: %v (retrying in 5s)\n", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}
+}
+
+func codeKey(code string) string { return keyPrefix + "code:" + code }
+func idKey(id string) string     { return keyPrefix + "id:" + id }
+
+// FindByCode returns the cached tree or loads and caches it
+func (r *CachedTreeRepository) FindByCode(ctx context.Context, code string) (*tree.Tree, error) {
+	return r.findOne(ctx, codeKey(code), "code "+code, func() (*tree.Tree, error) {
+		return r.inner.FindByCode(ctx, code)
+	})
+}
+
+// FindByID returns the cached tree or loads and caches it
+func (r *CachedTreeRepository) FindByID(ctx context.Context, id string) (*tree.Tree, error) {
+	return r.findOne(ctx, idKey(id), "id "+id, func() (*tree.Tree, error) {
+		return r.inner.FindByID(ctx, id)
+	})
+}
+
+// findOne skips the fill when a mutation happened during the load: the loaded
+// tree may predate it, and caching it would undo that mutation's eviction
+func (r *CachedTreeRepository) findOne(ctx context.Context, key string, what string, load func() (*tree.Tree, error)) (*tree.Tree, error) {
+	if raw, found, err := r.store.Get(ctx, key); err == nil && found {
+		var e entry
+		if json.Unmarshal(raw, &e) == nil {
+			if e.Missing {
+				return nil, fmt.Errorf("%w: %s", tree.ErrTreeNotFound, what)
+			}
+			if e.Tree != nil {
+				return e.Tree, nil
+			}
+		}
+	}
+
+	before, genErr := r.store.Counter(ctx, listGenerationKey)
+	t, err := load()
+	if genErr != nil {
+		return t, err
+	}
+	if after, genErr := r.store.Counter(ctx, listGenerationKey); genErr != nil || after != before {
+		return t, err
+	}
+	switch {
+	case err == nil:
+		r.put(ctx, key, entry{Tree: t}, r.config.TTL)
+	case errors.Is(err, tree.ErrTreeNotFound):
+		r.put(ctx, key, entry{Missing: true}, r.config.NegativeTTL)
+	}
+	return t, err
+}
+
+// FindAll caches pages per filter and list generation
+func (r *CachedTreeRepository) FindAll(ctx context.Context, filter tree.TreeFilter) ([]*tree.Tree, error) {
+	key, ok := r.listKey(ctx, filter)
+	if ok {
+		if raw, found, err := r.store.Get(ctx, key); err == nil && found {
+			var trees []*tree.Tree
+			if json.Unmarshal(raw, &trees) == nil {
+				return trees, nil
+			}
+		}
+	}
+
+	trees, err := r.inner.FindAll(ctx, filter)
+	if err == nil && ok {
+		r.put(ctx, key, trees, r.config.ListTTL)
+	}
+	return trees, err
+}
+
+// listKey hashes the filter (scope included) under the current generation
+func (r *CachedTreeRepository) listKey(ctx context.Context, filter tree.TreeFilter) (string, bool) {
+	gen, err := r.store.Counter(ctx, listGenerationKey)
+	if err != nil {
+		return "", false
+	}
+	raw, err := json.Marshal(filter)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf("%slist:%d:%s", keyPrefix, gen, hex.EncodeToString(sum[:12])), true
+}
+
+func (r *CachedTreeRepository) put(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	_ = r.store.Set(ctx, key, raw, ttl) // Best effort: the next read retries
+}
+
+// StreamAll is not cached: exports read everything once
+func (r *CachedTreeRepository) StreamAll(ctx context.Context, filter tree.TreeFilter, fn func(*tree.Tree) error) error {
+	return r.inner.StreamAll(ctx, filter, fn)
+}
+
+// Create drops negative entries for the new code and ID
+func (r *CachedTreeRepository) Create(ctx context.Context, t *tree.Tree) error {
+	if err := r.inner.Create(ctx, t); err != nil {
+		return err
+	}
+	r.invalidate(ctx, t.ID, t.Code)
+	return nil
+}
+
+// Update also evicts on a version conflict: the cached copy is older than the stored row
+func (r *CachedTreeRepository) Update(ctx context.Context, t *tree.Tree) error {
+	if err := r.inner.Update(ctx, t); err != nil {
+		if errors.Is(err, tree.ErrVersionConflict) {
+			r.invalidate(ctx, t.ID, t.Code)
+		}
+		return err
+	}
+	r.invalidate(ctx, t.ID, t.Code)
+	return nil
+}
+
+func (r *CachedTreeRepository) UpdateStatus(ctx context.Context, id string, status tree.TreeStatus, healthScore int) error {
+	code := r.codeOf(ctx, id)
+	if err := r.inner.UpdateStatus(ctx, id, status, healthScore); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id, code)
+	return nil
+}
+
+func (r *CachedTreeRepository) SoftDelete(ctx context.Context, id string, deletedBy string, reason string) error {
+	code := r.codeOf(ctx, id)
+	if err := r.inner.SoftDelete(ctx, id, deletedBy, reason); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id, code)
+	return nil
+}
+
+func (r *CachedTreeRepository) Restore(ctx context.Context, id string) error {
+	code := r.codeOf(ctx, id)
+	if err := r.inner.Restore(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id, code)
+	return nil
+}
+
+func (r *CachedTreeRepository) Delete(ctx context.Context, id string) error {
+	code := r.codeOf(ctx, id)
+	if err := r.inner.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id, code)
+	return nil
+}
+
+// Uncached returns the wrapped repository for reads that must see the stored row
+func (r *CachedTreeRepository) Uncached() tree.TreeRepository {
+	return r.inner
+}
+
+// GetNextCode is never cached: codes must be fresh
+func (r *CachedTreeRepository) GetNextCode(ctx context.Context) (string, error) {
+	return r.inner.GetNextCode(ctx)
+}
+
+func (r *CachedTreeRepository) CountByLocation(ctx context.Context, locationID string) (int64, error) {
+	return r.inner.CountByLocation(ctx, locationID)
+}
+
+func (r *CachedTreeRepository) CountByStatus(ctx context.Context, status tree.TreeStatus) (int64, error) {
+	return r.inner.CountByStatus(ctx, status)
+}
+
+// codeOf finds the code of a tree about to change, so its code entry can be evicted
+func (r *CachedTreeRepository) codeOf(ctx context.Context, id string) string {
+	t, err := r.FindByID(ctx, id)
+	if err != nil {
+		return ""
+	}
+	return t.Code
+}
+
+// invalidate evicts locally and tells the other instances
+func (r *CachedTreeRepository) invalidate(ctx context.Context, id string, code string) {
+	inv := Invalidation{Origin: r.origin}
+	if id != "" {
+		inv.IDs = []string{id}
+	}
+	if code != "" {
+		inv.Codes = []string{code}
+	}
+
+	r.evict(ctx, inv)
+
+	if r.broadcaster != nil {
+		if err := r.broadcaster.Publish(ctx, inv); err != nil {
+			fmt.Printf("⚠️ Warning: Failed to broadcast tree cache invalidation: %v\n", err)
+		}
+	}
+}
+
+func (r *CachedTreeRepository) evict(ctx context.Context, inv Invalidation) {
+	keys := make([]string, 0, len(inv.IDs)+len(inv.Codes))
+	for _, id := range inv.IDs {
+		keys = append(keys, idKey(id))
+	}
+	for _, code := range inv.Codes {
+		keys = append(keys, codeKey(code))
+	}
+
+	if len(keys) > 0 {
+		if err := r.store.Del(ctx, keys...); err != nil {
+			fmt.Printf("⚠️ Warning: Failed to evict tree cache entries: %v\n", err)
+		}
+	}
+	if _, err := r.store.Incr(ctx, listGenerationKey); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to retire cached tree lists: %v\n", err)
+	}
+}
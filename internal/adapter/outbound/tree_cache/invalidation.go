@@ -0,0 +1,91 @@
+package tree_cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// invalidationChannel carries tree cache invalidations between API instances
+const invalidationChannel = "tree-cache:invalidate"
+
+// Invalidation names the trees whose entries must be dropped
+// List pages are always dropped; Origin lets an instance skip its own messages.
+type Invalidation struct {
+	Origin string   `json:"origin"`
+	IDs    []string `json:"ids,omitempty"`
+	Codes  []string `json:"codes,omitempty"`
+}
+
+// Broadcaster fans invalidations out to the other API instances
+type Broadcaster interface {
+	Publish(ctx context.Context, inv Invalidation) error
+
+	// Listen calls fn for every invalidation until ctx is done
+	Listen(ctx context.Context, fn func(Invalidation)) error
+}
+
+// RedisBroadcaster uses Redis pub/sub
+// gibrun has no pub/sub, so it keeps its own go-redis connection.
+type RedisBroadcaster struct {
+	client *redis.Client
+}
+
+// NewRedisBroadcaster connects to REDIS_ADDR (same server as the cache)
+func NewRedisBroadcaster() *RedisBroadcaster {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	return &RedisBroadcaster{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: os.Getenv("REDIS_PASSWORD"),
+		}),
+	}
+}
+
+func (b *RedisBroadcaster) Publish(ctx context.Context, inv Invalidation) error {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, invalidationChannel, payload).Err()
+}
+
+// Listen blocks until ctx is done; go-redis restores the subscription after connection loss
+func (b *RedisBroadcaster) Listen(ctx context.Context, fn func(Invalidation)) error {
+	pubsub := b.client.Subscribe(ctx, invalidationChannel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", invalidationChannel, err)
+	}
+
+	ch := pubsub.Channel(redis.WithChannelHealthCheckInterval(30 * time.Second))
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var inv Invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				fmt.Printf("⚠️ Warning: Ignoring malformed tree cache invalidation: %v\n", err)
+				continue
+			}
+			fn(inv)
+		}
+	}
+}
+
+// Close releases the pub/sub connection
+func (b *RedisBroadcaster) Close() error {
+	return b.client.Close()
+}
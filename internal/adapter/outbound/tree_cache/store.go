@@ -0,0 +1,18 @@
+package tree_cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds serialized cache entries (cache.Tiered in production)
+type Store interface {
+	// Get returns the entry, or found=false on a miss
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, keys ...string) error
+
+	// Counter reads a counter (0 when missing); Incr bumps it
+	Counter(ctx context.Context, key string) (int64, error)
+	Incr(ctx context.Context, key string) (int64, error)
+}
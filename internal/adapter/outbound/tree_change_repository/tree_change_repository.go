@@ -0,0 +1,86 @@
+package tree_change_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"prabogo/internal/domain/tree"
+)
+
+// TreeChangeRepository stores tree field changes in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode (like monitoring logs)
+type TreeChangeRepository struct {
+	db *sql.DB
+}
+
+func NewTreeChangeRepository(db *sql.DB) *TreeChangeRepository {
+	return &TreeChangeRepository{
+		db: db,
+	}
+}
+
+// CreateChanges inserts one change set atomically
+func (r *TreeChangeRepository) CreateChanges(ctx context.Context, changes []tree.FieldChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO tree_changes (
+			id, change_set_id, tree_id, tree_code, field,
+			old_value, new_value, changed_by, changed_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	for _, c := range changes {
+		_, err := tx.ExecContext(ctx, query,
+			c.ID, c.ChangeSetID, c.TreeID, c.TreeCode, c.Field,
+			c.OldValue, c.NewValue, c.ChangedBy, c.ChangedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert tree change: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindByTreeID returns changes of a tree, newest first
+func (r *TreeChangeRepository) FindByTreeID(ctx context.Context, treeID string) ([]*tree.FieldChange, error) {
+	query := `
+		SELECT id, change_set_id, tree_id, tree_code, field,
+		       COALESCE(old_value, ''), COALESCE(new_value, ''), COALESCE(changed_by, ''), changed_at
+		FROM tree_changes
+		WHERE tree_id = $1
+		ORDER BY changed_at DESC, field
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, treeID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*tree.FieldChange
+	for rows.Next() {
+		var c tree.FieldChange
+		err := rows.Scan(
+			&c.ID, &c.ChangeSetID, &c.TreeID, &c.TreeCode, &c.Field,
+			&c.OldValue, &c.NewValue, &c.ChangedBy, &c.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		changes = append(changes, &c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return changes, nil
+}
@@ -4,12 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"prabogo/internal/domain/tree"
 	"prabogo/internal/safeaql"
+	"prabogo/utils/aql"
 )
 
+// treeSelectQuery lists columns explicitly so schema additions don't shift scan order
+const treeSelectQuery = `
+		SELECT t.id, t.code, t.species_id, t.location_id, t.planting_date, t.age_years,
+		       t.height_meters, t.diameter_cm, t.status, t.health_score, t.notes,
+		       t.registered_by, t.version, t.created_at, t.updated_at,
+		       t.deleted_at, t.deleted_by, t.delete_reason,
+		       u.username as registered_by_username 
+		FROM trees t 
+		LEFT JOIN users u ON t.registered_by = u.id`
+
 // TreeRepositoryAdapter implements TreeRepository using AQL
 type TreeRepositoryAdapter struct {
 	safeExec *safeaql.SafeExecutor
@@ -26,20 +38,15 @@ func NewTreeRepository(db *sql.DB) tree.TreeRepository {
 func (r *TreeRepositoryAdapter) Create(ctx context.Context, t *tree.Tree) error {
 	return r.safeExec.Insert(ctx, "trees",
 		[]string{"id", "code", "species_id", "location_id", "planting_date", "age_years",
-			"height_meters", "diameter_cm", "status", "health_score", "notes", "registered_by"},
+			"height_meters", "diameter_cm", "status", "health_score", "notes", "registered_by", "version"},
 		[]interface{}{t.ID, t.Code, t.SpeciesID, t.LocationID, t.PlantingDate.Format("2006-01-02"), t.AgeYears,
-			t.HeightMeters, t.DiameterCm, string(t.Status), t.HealthScore, t.Notes, t.RegisteredBy})
+			t.HeightMeters, t.DiameterCm, string(t.Status), t.HealthScore, t.Notes, t.RegisteredBy, t.Version})
 }
 
 // FindByCode retrieves tree by C-code with username JOIN
 func (r *TreeRepositoryAdapter) FindByCode(ctx context.Context, code string) (*tree.Tree, error) {
 	// Use raw SQL to JOIN users table
-	query := `
-		SELECT t.*, u.username as registered_by_username 
-		FROM trees t 
-		LEFT JOIN users u ON t.registered_by = u.id 
-		WHERE t.code = $1
-	`
+	query := treeSelectQuery + " WHERE t.code = $1"
 	rows, err := r.safeExec.DB().QueryContext(ctx, query, code)
 	if err != nil {
 		return nil, err
@@ -47,7 +54,7 @@ func (r *TreeRepositoryAdapter) FindByCode(ctx context.Context, code string) (*t
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("tree with code %s not found", code)
+		return nil, fmt.Errorf("%w: code %s", tree.ErrTreeNotFound, code)
 	}
 
 	return r.scanTreeWithUsername(rows)
@@ -56,12 +63,7 @@ func (r *TreeRepositoryAdapter) FindByCode(ctx context.Context, code string) (*t
 // FindByID retrieves tree by ID with username JOIN
 func (r *TreeRepositoryAdapter) FindByID(ctx context.Context, id string) (*tree.Tree, error) {
 	// Use raw SQL to JOIN users table
-	query := `
-		SELECT t.*, u.username as registered_by_username 
-		FROM trees t 
-		LEFT JOIN users u ON t.registered_by = u.id 
-		WHERE t.id = $1
-	`
+	query := treeSelectQuery + " WHERE t.id = $1"
 	rows, err := r.safeExec.DB().QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, err
@@ -69,7 +71,7 @@ func (r *TreeRepositoryAdapter) FindByID(ctx context.Context, id string) (*tree.
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("tree with id %s not found", id)
+		return nil, fmt.Errorf("%w: id %s", tree.ErrTreeNotFound, id)
 	}
 
 	return r.scanTreeWithUsername(rows)
@@ -80,11 +82,7 @@ func (r *TreeRepositoryAdapter) FindAll(ctx context.Context, filter tree.TreeFil
 	where := r.buildWhereClause(filter)
 
 	// Build query with JOIN
-	query := `
-		SELECT t.*, u.username as registered_by_username 
-		FROM trees t 
-		LEFT JOIN users u ON t.registered_by = u.id 
-		WHERE ` + where
+	query := treeSelectQuery + " WHERE " + where
 
 	// Add LIMIT and OFFSET
 	if filter.Limit > 0 {
@@ -112,17 +110,58 @@ func (r *TreeRepositoryAdapter) FindAll(ctx context.Context, filter tree.TreeFil
 	return trees, nil
 }
 
-// Update modifies tree using PUPUK
+// StreamAll iterates trees matching filter row by row
+func (r *TreeRepositoryAdapter) StreamAll(ctx context.Context, filter tree.TreeFilter, fn func(*tree.Tree) error) error {
+	where := r.buildWhereClause(filter)
+
+	query := treeSelectQuery + " WHERE " + where + " ORDER BY t.code"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", filter.Offset)
+	}
+
+	rows, err := r.safeExec.DB().QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := r.scanTreeWithUsername(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Update modifies tree using PUPUK, guarded by the version the caller read
 func (r *TreeRepositoryAdapter) Update(ctx context.Context, t *tree.Tree) error {
 	set := fmt.Sprintf("species_id='%s', location_id='%s', planting_date='%s', "+
 		"age_years=%d, height_meters=%.2f, diameter_cm=%.2f, status='%s', "+
-		"health_score=%d, notes='%s', updated_at=CURRENT_TIMESTAMP",
-		t.SpeciesID, t.LocationID, t.PlantingDate.Format("2006-01-02"),
-		t.AgeYears, t.HeightMeters, t.DiameterCm, string(t.Status),
-		t.HealthScore, t.Notes)
+		"health_score=%d, notes='%s', version=version+1, updated_at=CURRENT_TIMESTAMP",
+		aql.EscapeString(t.SpeciesID), aql.EscapeString(t.LocationID), t.PlantingDate.Format("2006-01-02"),
+		t.AgeYears, t.HeightMeters, t.DiameterCm, aql.EscapeString(string(t.Status)),
+		t.HealthScore, aql.EscapeString(t.Notes))
 
-	where := fmt.Sprintf("id='%s'", t.ID)
-	return r.safeExec.Update(ctx, "trees", set, where)
+	where := fmt.Sprintf("id='%s' AND version=%d", aql.EscapeString(t.ID), t.Version)
+	affected, err := r.safeExec.UpdateAffected(ctx, "trees", set, where)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return tree.ErrVersionConflict
+	}
+
+	t.Version++
+	return nil
 }
 
 // UpdateStatus changes tree status using PUPUK
@@ -133,6 +172,21 @@ func (r *TreeRepositoryAdapter) UpdateStatus(ctx context.Context, id string, sta
 	return r.safeExec.Update(ctx, "trees", set, where)
 }
 
+// SoftDelete moves tree to trash using PUPUK
+func (r *TreeRepositoryAdapter) SoftDelete(ctx context.Context, id string, deletedBy string, reason string) error {
+	set := fmt.Sprintf("deleted_at=CURRENT_TIMESTAMP, deleted_by='%s', delete_reason='%s', updated_at=CURRENT_TIMESTAMP",
+		deletedBy, aql.EscapeString(reason))
+	where := fmt.Sprintf("id='%s'", id)
+	return r.safeExec.Update(ctx, "trees", set, where)
+}
+
+// Restore clears the trash markers using PUPUK
+func (r *TreeRepositoryAdapter) Restore(ctx context.Context, id string) error {
+	set := "deleted_at=NULL, deleted_by=NULL, delete_reason=NULL, updated_at=CURRENT_TIMESTAMP"
+	where := fmt.Sprintf("id='%s'", id)
+	return r.safeExec.Update(ctx, "trees", set, where)
+}
+
 // Delete removes tree using GUSUR
 func (r *TreeRepositoryAdapter) Delete(ctx context.Context, id string) error {
 	where := fmt.Sprintf("id='%s'", id)
@@ -165,23 +219,32 @@ func (r *TreeRepositoryAdapter) GetNextCode(ctx context.Context) (string, error)
 	return fmt.Sprintf("C%03d", num), nil
 }
 
-// CountByLocation counts trees in location
+// CountByLocation counts trees in location (trash excluded)
 func (r *TreeRepositoryAdapter) CountByLocation(ctx context.Context, locationID string) (int64, error) {
-	return r.safeExec.Count(ctx, "trees", fmt.Sprintf("location_id='%s'", locationID))
+	return r.safeExec.Count(ctx, "trees", fmt.Sprintf("location_id='%s' AND deleted_at IS NULL", locationID))
 }
 
-// CountByStatus counts trees by status
+// CountByStatus counts trees by status (trash excluded)
 func (r *TreeRepositoryAdapter) CountByStatus(ctx context.Context, status tree.TreeStatus) (int64, error) {
-	return r.safeExec.Count(ctx, "trees", fmt.Sprintf("status='%s'", string(status)))
+	return r.safeExec.Count(ctx, "trees", fmt.Sprintf("status='%s' AND deleted_at IS NULL", string(status)))
 }
 
 // Helper: Build WHERE clause from filter
 func (r *TreeRepositoryAdapter) buildWhereClause(filter tree.TreeFilter) string {
 	conditions := []string{}
 
+	if filter.Trashed {
+		conditions = append(conditions, "t.deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "t.deleted_at IS NULL")
+	}
+
 	if filter.LocationID != "" {
 		conditions = append(conditions, fmt.Sprintf("location_id='%s'", filter.LocationID))
 	}
+	if filter.LocationIDs != nil {
+		conditions = append(conditions, locationInClause(filter.LocationIDs))
+	}
 	if filter.SpeciesID != "" {
 		conditions = append(conditions, fmt.Sprintf("species_id='%s'", filter.SpeciesID))
 	}
@@ -189,10 +252,6 @@ func (r *TreeRepositoryAdapter) buildWhereClause(filter tree.TreeFilter) string
 		conditions = append(conditions, fmt.Sprintf("status='%s'", string(filter.Status)))
 	}
 
-	if len(conditions) == 0 {
-		return "1=1"
-	}
-
 	where := conditions[0]
 	for i := 1; i < len(conditions); i++ {
 		where += " AND " + conditions[i]
@@ -201,6 +260,18 @@ func (r *TreeRepositoryAdapter) buildWhereClause(filter tree.TreeFilter) string
 	return where
 }
 
+// Helper: Build location_id IN (...) for a location scope (empty scope matches nothing)
+func locationInClause(locationIDs []string) string {
+	if len(locationIDs) == 0 {
+		return "1=0"
+	}
+	quoted := make([]string, len(locationIDs))
+	for i, id := range locationIDs {
+		quoted[i] = "'" + strings.ReplaceAll(id, "'", "''") + "'"
+	}
+	return "t.location_id IN (" + strings.Join(quoted, ",") + ")"
+}
+
 // Helper: Scan database row to Tree entity (legacy - without username)
 func (r *TreeRepositoryAdapter) scanTree(rows *sql.Rows) (*tree.Tree, error) {
 	var t tree.Tree
@@ -229,11 +300,14 @@ func (r *TreeRepositoryAdapter) scanTreeWithUsername(rows *sql.Rows) (*tree.Tree
 	var statusStr string
 	var plantingDate string
 	var username sql.NullString // May be NULL if user deleted
+	var deletedAt sql.NullTime
+	var deletedBy, deleteReason sql.NullString
 
 	err := rows.Scan(
 		&t.ID, &t.Code, &t.SpeciesID, &t.LocationID, &plantingDate, &t.AgeYears,
 		&t.HeightMeters, &t.DiameterCm, &statusStr, &t.HealthScore, &t.Notes,
-		&t.RegisteredBy, &t.CreatedAt, &t.UpdatedAt,
+		&t.RegisteredBy, &t.Version, &t.CreatedAt, &t.UpdatedAt,
+		&deletedAt, &deletedBy, &deleteReason,
 		&username, // registered_by_username from JOIN
 	)
 	if err != nil {
@@ -244,6 +318,13 @@ func (r *TreeRepositoryAdapter) scanTreeWithUsername(rows *sql.Rows) (*tree.Tree
 	t.PlantingDate, _ = time.Parse("2006-01-02", plantingDate)
 	t.Status = tree.TreeStatus(statusStr)
 
+	// Trash markers
+	if deletedAt.Valid {
+		t.DeletedAt = &deletedAt.Time
+		t.DeletedBy = deletedBy.String
+		t.DeleteReason = deleteReason.String
+	}
+
 	// Set username if available
 	if username.Valid {
 		t.RegisteredByUsername = username.String
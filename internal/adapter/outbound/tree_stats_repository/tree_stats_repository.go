@@ -0,0 +1,210 @@
+package tree_stats_repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/tree"
+
+	"github.com/lib/pq"
+)
+
+// generationKey is bumped on every write; cached snapshots embed it
+const generationKey = "treestats:gen"
+
+// Cache is the subset of cache.Tiered used to serve snapshots
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Counter(ctx context.Context, key string) (int64, error)
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// TreeStatsRepository stores materialized tree statistics in PostgreSQL
+// Always PostgreSQL, also in SawitDB hybrid mode. Snapshots are cached per
+// location scope until the next write.
+type TreeStatsRepository struct {
+	db    *sql.DB
+	cache Cache // Optional
+	ttl   time.Duration
+}
+
+func NewTreeStatsRepository(db *sql.DB, cache Cache) *TreeStatsRepository {
+	return &TreeStatsRepository{
+		db:    db,
+		cache: cache,
+		ttl:   10 * time.Minute,
+	}
+}
+
+const upsertCount = `
+	INSERT INTO tree_stat_counts (location_id, species_id, status, planting_month, tree_count)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (location_id, species_id, status, planting_month)
+	DO UPDATE SET tree_count = tree_stat_counts.tree_count + EXCLUDED.tree_count
+`
+
+// Apply adds deltas in one transaction
+func (r *TreeStatsRepository) Apply(ctx context.Context, deltas map[tree.StatsKey]int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, n := range deltas {
+		_, err := tx.ExecContext(ctx, upsertCount, key.LocationID, key.SpeciesID, string(key.Status), key.PlantingMonth, n)
+		if err != nil {
+			return fmt.Errorf("failed to apply statistics delta: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tree_stat_state SET updated_at = CURRENT_TIMESTAMP WHERE id = 1"); err != nil {
+		return fmt.Errorf("failed to update statistics state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate(ctx)
+	return nil
+}
+
+// Replace swaps all counts for a rebuild in one transaction
+func (r *TreeStatsRepository) Replace(ctx context.Context, counts map[tree.StatsKey]int64, rebuiltAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tree_stat_counts"); err != nil {
+		return fmt.Errorf("failed to clear statistics: %w", err)
+	}
+
+	for key, n := range counts {
+		_, err := tx.ExecContext(ctx, upsertCount, key.LocationID, key.SpeciesID, string(key.Status), key.PlantingMonth, n)
+		if err != nil {
+			return fmt.Errorf("failed to insert statistics count: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tree_stat_state (id, updated_at, rebuilt_at) VALUES (1, $1, $1)
+		ON CONFLICT (id) DO UPDATE SET updated_at = EXCLUDED.updated_at, rebuilt_at = EXCLUDED.rebuilt_at
+	`, rebuiltAt)
+	if err != nil {
+		return fmt.Errorf("failed to update statistics state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate(ctx)
+	return nil
+}
+
+// Load returns non-zero cells in locationIDs (nil means all), cached until the next write
+func (r *TreeStatsRepository) Load(ctx context.Context, locationIDs []string) (*tree.StatsSnapshot, error) {
+	key, cacheable := r.cacheKey(ctx, locationIDs)
+	if cacheable {
+		if raw, found, err := r.cache.Get(ctx, key); err == nil && found {
+			var snapshot tree.StatsSnapshot
+			if json.Unmarshal(raw, &snapshot) == nil {
+				return &snapshot, nil
+			}
+		}
+	}
+
+	snapshot, err := r.load(ctx, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		if raw, err := json.Marshal(snapshot); err == nil {
+			_ = r.cache.Set(ctx, key, raw, r.ttl) // Best effort
+		}
+	}
+	return snapshot, nil
+}
+
+func (r *TreeStatsRepository) load(ctx context.Context, locationIDs []string) (*tree.StatsSnapshot, error) {
+	snapshot := &tree.StatsSnapshot{Counts: []tree.StatsCount{}}
+
+	var rebuiltAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, "SELECT updated_at, rebuilt_at FROM tree_stat_state WHERE id = 1").
+		Scan(&snapshot.AsOf, &rebuiltAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to read statistics state: %w", err)
+	}
+	if rebuiltAt.Valid {
+		snapshot.RebuiltAt = &rebuiltAt.Time
+	}
+
+	query := "SELECT location_id, species_id, status, planting_month, tree_count FROM tree_stat_counts WHERE tree_count <> 0"
+	args := []interface{}{}
+	if locationIDs != nil {
+		query += " AND location_id = ANY($1)"
+		args = append(args, pq.Array(locationIDs))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c tree.StatsCount
+		var status string
+		if err := rows.Scan(&c.LocationID, &c.SpeciesID, &status, &c.PlantingMonth, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		c.Status = tree.TreeStatus(status)
+		c.PlantingMonth = strings.TrimSpace(c.PlantingMonth)
+		snapshot.Counts = append(snapshot.Counts, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// cacheKey names the snapshot of one location scope under the current generation
+func (r *TreeStatsRepository) cacheKey(ctx context.Context, locationIDs []string) (string, bool) {
+	if r.cache == nil {
+		return "", false
+	}
+	gen, err := r.cache.Counter(ctx, generationKey)
+	if err != nil {
+		return "", false
+	}
+
+	scope := "all"
+	if locationIDs != nil {
+		sorted := append([]string{}, locationIDs...)
+		sort.Strings(sorted)
+		sum := sha256.Sum256([]byte(strings.Join(sorted, "\x1f")))
+		scope = hex.EncodeToString(sum[:12])
+	}
+	return fmt.Sprintf("treestats:%d:%s", gen, scope), true
+}
+
+func (r *TreeStatsRepository) invalidate(ctx context.Context) {
+	if r.cache == nil {
+		return
+	}
+	if _, err := r.cache.Incr(ctx, generationKey); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to invalidate cached statistics: %v\n", err)
+	}
+}
@@ -0,0 +1,174 @@
+package two_factor_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/auth"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactorRepository stores TOTP enrolments and recovery codes in PostgreSQL
+// Secrets are encrypted before they reach the database.
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db: db,
+	}
+}
+
+// Find returns the user's enrolment, or nil when there is none
+func (r *TwoFactorRepository) Find(ctx context.Context, userID string) (*auth.TwoFactor, error) {
+	tf := &auth.TwoFactor{UserID: userID}
+	var sealed string
+	var enabledAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT secret_encrypted, enabled_at, last_used_step, created_at
+		FROM user_two_factor
+		WHERE user_id = $1
+	`, userID).Scan(&sealed, &enabledAt, &tf.LastUsedStep, &tf.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load two-factor settings: %w", err)
+	}
+
+	if tf.Secret, err = auth.OpenTOTPSecret(sealed); err != nil {
+		return nil, err
+	}
+	if enabledAt.Valid {
+		tf.EnabledAt = &enabledAt.Time
+	}
+	return tf, nil
+}
+
+// Save creates or replaces a pending enrolment
+func (r *TwoFactorRepository) Save(ctx context.Context, tf *auth.TwoFactor) error {
+	sealed, err := auth.SealTOTPSecret(tf.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO user_two_factor (user_id, secret_encrypted, enabled_at, last_used_step, created_at)
+		VALUES ($1, $2, NULL, 0, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted,
+		    enabled_at = NULL,
+		    last_used_step = 0,
+		    created_at = EXCLUDED.created_at
+	`, tf.UserID, sealed, tf.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor settings: %w", err)
+	}
+	return nil
+}
+
+// Enable confirms a pending enrolment
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID string, at time.Time, step int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_two_factor SET enabled_at = $2, last_used_step = $3
+		WHERE user_id = $1
+	`, userID, at, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor: %w", err)
+	}
+	return nil
+}
+
+// UseStep stores step only if it is newer than the last accepted one
+// The conditional UPDATE makes replaying a code within its window fail.
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_two_factor SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// Delete removes the enrolment and all recovery codes
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete two-factor settings: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes swaps all recovery codes of a user atomically
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES ($1, $2, $3, $4)
+		`, uuid.New().String(), userID, hash, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode consumes a matching unused code
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID string, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_recovery_codes SET used_at = $3
+		WHERE id = (
+			SELECT id FROM user_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL
+	`, userID, codeHash, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// CountRecoveryCodes returns how many unused codes remain
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
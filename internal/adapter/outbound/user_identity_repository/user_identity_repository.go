@@ -0,0 +1,56 @@
+package user_identity_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"prabogo/internal/domain/auth"
+)
+
+// UserIdentityRepository stores single sign-on account links in PostgreSQL
+type UserIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewUserIdentityRepository(db *sql.DB) *UserIdentityRepository {
+	return &UserIdentityRepository{
+		db: db,
+	}
+}
+
+// FindUserID returns the user linked to issuer + subject, or "" if none
+func (r *UserIdentityRepository) FindUserID(ctx context.Context, issuer string, subject string) (string, error) {
+	var userID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2
+	`, issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find identity: %w", err)
+	}
+	return userID, nil
+}
+
+// Create links a provider account to a user
+func (r *UserIdentityRepository) Create(ctx context.Context, identity *auth.UserIdentity) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_identities (issuer, subject, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, identity.Issuer, identity.Subject, identity.UserID, identity.Email, identity.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert identity: %w", err)
+	}
+	return nil
+}
+
+// DeleteForUser removes all links of a user
+func (r *UserIdentityRepository) DeleteForUser(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_identities WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete identities: %w", err)
+	}
+	return nil
+}
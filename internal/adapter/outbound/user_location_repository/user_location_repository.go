@@ -0,0 +1,60 @@
+package user_location_repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// UserLocationRepository stores user_locations in PostgreSQL
+type UserLocationRepository struct {
+	db *sql.DB
+}
+
+func NewUserLocationRepository(db *sql.DB) *UserLocationRepository {
+	return &UserLocationRepository{
+		db: db,
+	}
+}
+
+// FindByUser returns the location IDs assigned to a user, ordered by ID
+func (r *UserLocationRepository) FindByUser(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT location_id FROM user_locations WHERE user_id = $1 ORDER BY location_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user locations: %w", err)
+	}
+	defer rows.Close()
+
+	locationIDs := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user location: %w", err)
+		}
+		locationIDs = append(locationIDs, id)
+	}
+	return locationIDs, rows.Err()
+}
+
+// Replace sets the user's locations in one transaction
+func (r *UserLocationRepository) Replace(ctx context.Context, userID string, locationIDs []string, assignedBy string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_locations WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, id := range locationIDs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_locations (user_id, location_id, assigned_by) VALUES ($1, $2, $3)
+		`, userID, id, assignedBy); err != nil {
+			return fmt.Errorf("failed to assign location %s: %w", id, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -16,10 +16,13 @@ type UserRepository interface {
 	FindByUsername(ctx context.Context, username string) (*auth.User, error)
 	FindByEmail(ctx context.Context, email string) (*auth.User, error)
 	Update(ctx context.Context, user *auth.User) error
-	Delete(ctx context.Context, id string) error
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
 	FindAll(ctx context.Context) ([]*auth.User, error)
 }
 
+// userColumns is the column list scanUser expects
+const userColumns = "id, username, email, password_hash, full_name, role, is_active, created_at, updated_at, deleted_at"
+
 // UserRepositoryAdapter implements UserRepository using AQL
 type UserRepositoryAdapter struct {
 	safeExec *safeaql.SafeExecutor
@@ -41,7 +44,7 @@ func (r *UserRepositoryAdapter) Create(ctx context.Context, u *auth.User) error
 
 // FindByID retrieves user by ID
 func (r *UserRepositoryAdapter) FindByID(ctx context.Context, id string) (*auth.User, error) {
-	rows, err := r.safeExec.Select(ctx, "users", "*", fmt.Sprintf("id='%s'", id))
+	rows, err := r.safeExec.Select(ctx, "users", userColumns, fmt.Sprintf("id='%s'", id))
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +59,7 @@ func (r *UserRepositoryAdapter) FindByID(ctx context.Context, id string) (*auth.
 
 // FindByUsername retrieves user by username
 func (r *UserRepositoryAdapter) FindByUsername(ctx context.Context, username string) (*auth.User, error) {
-	rows, err := r.safeExec.Select(ctx, "users", "*", fmt.Sprintf("username='%s'", username))
+	rows, err := r.safeExec.Select(ctx, "users", userColumns, fmt.Sprintf("username='%s'", username))
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +74,7 @@ func (r *UserRepositoryAdapter) FindByUsername(ctx context.Context, username str
 
 // FindByEmail retrieves user by email
 func (r *UserRepositoryAdapter) FindByEmail(ctx context.Context, email string) (*auth.User, error) {
-	rows, err := r.safeExec.Select(ctx, "users", "*", fmt.Sprintf("email='%s'", email))
+	rows, err := r.safeExec.Select(ctx, "users", userColumns, fmt.Sprintf("email='%s'", email))
 	if err != nil {
 		return nil, err
 	}
@@ -86,20 +89,27 @@ func (r *UserRepositoryAdapter) FindByEmail(ctx context.Context, email string) (
 
 // Update modifies user
 func (r *UserRepositoryAdapter) Update(ctx context.Context, u *auth.User) error {
-	set := fmt.Sprintf("email='%s', full_name='%s', role='%s', is_active=%t, updated_at=CURRENT_TIMESTAMP",
-		u.Email, u.FullName, string(u.Role), u.IsActive)
+	// Tombstones keep the time they were first deleted
+	deletedAt := "NULL"
+	if u.DeletedAt != nil {
+		deletedAt = "COALESCE(deleted_at, CURRENT_TIMESTAMP)"
+	}
+	set := fmt.Sprintf("email='%s', full_name='%s', role='%s', is_active=%t, deleted_at=%s, updated_at=CURRENT_TIMESTAMP",
+		u.Email, u.FullName, string(u.Role), u.IsActive, deletedAt)
 	where := fmt.Sprintf("id='%s'", u.ID)
 	return r.safeExec.Update(ctx, "users", set, where)
 }
 
-// Delete removes user
-func (r *UserRepositoryAdapter) Delete(ctx context.Context, id string) error {
-	return r.safeExec.Delete(ctx, "users", fmt.Sprintf("id='%s'", id))
+// UpdatePassword replaces a user's bcrypt password hash
+func (r *UserRepositoryAdapter) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	set := fmt.Sprintf("password_hash='%s', updated_at=CURRENT_TIMESTAMP", passwordHash)
+	where := fmt.Sprintf("id='%s'", id)
+	return r.safeExec.Update(ctx, "users", set, where)
 }
 
 // FindAll retrieves all users
 func (r *UserRepositoryAdapter) FindAll(ctx context.Context) ([]*auth.User, error) {
-	rows, err := r.safeExec.Select(ctx, "users", "*", "")
+	rows, err := r.safeExec.Select(ctx, "users", userColumns, "")
 	if err != nil {
 		return nil, err
 	}
@@ -120,15 +130,19 @@ func (r *UserRepositoryAdapter) FindAll(ctx context.Context) ([]*auth.User, erro
 func (r *UserRepositoryAdapter) scanUser(rows *sql.Rows) (*auth.User, error) {
 	var u auth.User
 	var roleStr string
+	var deletedAt sql.NullTime
 
 	err := rows.Scan(
 		&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.FullName,
-		&roleStr, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+		&roleStr, &u.IsActive, &u.CreatedAt, &u.UpdatedAt, &deletedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	u.Role = auth.UserRole(roleStr)
+	if deletedAt.Valid {
+		u.DeletedAt = &deletedAt.Time
+	}
 	return &u, nil
 }
@@ -10,11 +10,16 @@ import (
 )
 
 var (
-	// Global cache client
+	// Global cache client (Redis only; may be unreachable)
 	Client *gibrun.Client
+
+	// Default is the tiered cache: memory always, Redis when reachable
+	Default *Tiered
 )
 
 // InitGibRun initializes Gib.Run Redis cache
+// The client and the tiered cache are always created; an error only means
+// Redis is down for now. Start Default.Watch to pick it up later.
 func InitGibRun() error {
 	addr := os.Getenv("REDIS_ADDR")
 	if addr == "" {
@@ -29,70 +34,34 @@ func InitGibRun() error {
 		Password: password,
 		DB:       0, // Use DB 0
 	})
+	Default = NewTiered(Client, GetTieredConfig())
 
 	// Test connection
-	ctx := context.Background()
-	err := Client.Gib(ctx, "test:ping").
-		Value("pong").
-		TTL(1 * time.Second).
-		Exec()
-
-	if err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := Default.Connect(ctx); err != nil {
 		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	// Cleanup test key
-	Client.Del(ctx, "test:ping")
-
 	fmt.Println("✅ Gib.Run cache initialized")
 	return nil
 }
 
-// CacheTree stores tree data in cache
-func CacheTree(ctx context.Context, treeCode string, data interface{}, ttl time.Duration) error {
-	if Client == nil {
-		return nil // Gracefully skip if Redis unavailable
-	}
-	key := fmt.Sprintf("tree:%s", treeCode)
-	return Client.Gib(ctx, key).
-		Value(data).
-		TTL(ttl).
-		Exec()
-}
-
-// GetCachedTree retrieves tree from cache
-func GetCachedTree(ctx context.Context, treeCode string, dest interface{}) (bool, error) {
-	if Client == nil {
-		return false, nil // Cache miss if Redis unavailable
-	}
-	key := fmt.Sprintf("tree:%s", treeCode)
-	found, err := Client.Run(ctx, key).Bind(dest)
-	return found, err
-}
-
-// InvalidateTree removes tree from cache
-func InvalidateTree(ctx context.Context, treeCode string) error {
-	if Client == nil {
-		return nil // Gracefully skip if Redis unavailable
-	}
-	key := fmt.Sprintf("tree:%s", treeCode)
-	return Client.Del(ctx, key)
-}
-
 // IncrementScanCount tracks tree scan statistics
+// Buffered in memory while Redis is down and flushed when it returns.
 func IncrementScanCount(ctx context.Context, treeCode string) (int64, error) {
-	if Client == nil {
-		return 0, nil // Return 0 if Redis unavailable
+	if Default == nil {
+		return 0, nil
 	}
 	key := fmt.Sprintf("scans:%s", treeCode)
-	return Client.Sprint(ctx, key).Incr()
+	return Default.Incr(ctx, key)
 }
 
 // GetScanCount gets total scans for a tree
 func GetScanCount(ctx context.Context, treeCode string) (int64, error) {
-	if Client == nil {
-		return 0, nil // Return 0 if Redis unavailable
+	if Default == nil {
+		return 0, nil
 	}
 	key := fmt.Sprintf("scans:%s", treeCode)
-	return Client.Sprint(ctx, key).Get()
+	return Default.Counter(ctx, key)
 }
@@ -0,0 +1,312 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arielfikru/gibrun"
+)
+
+// TieredConfig tunes the local tier and the reconnect loop
+type TieredConfig struct {
+	LocalCapacity     int           // Entries kept in process
+	LocalTTL          time.Duration // Cap on local copies while Redis is up (bounds cross-instance staleness)
+	ReconnectInterval time.Duration // How often Redis health is checked
+}
+
+// GetTieredConfig reads CACHE_LOCAL_CAPACITY, CACHE_LOCAL_TTL and CACHE_RECONNECT_INTERVAL
+func GetTieredConfig() TieredConfig {
+	config := TieredConfig{
+		LocalCapacity:     10000,
+		LocalTTL:          30 * time.Second,
+		ReconnectInterval: 5 * time.Second,
+	}
+	if n, err := strconv.Atoi(os.Getenv("CACHE_LOCAL_CAPACITY")); err == nil && n > 0 {
+		config.LocalCapacity = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("CACHE_LOCAL_TTL")); err == nil && d > 0 {
+		config.LocalTTL = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("CACHE_RECONNECT_INTERVAL")); err == nil && d > 0 {
+		config.ReconnectInterval = d
+	}
+	return config
+}
+
+// Stats is a snapshot of cache metrics
+type Stats struct {
+	RedisOnline     bool  `json:"redis_online"`
+	Reconnects      int64 `json:"reconnects"`
+	LocalEntries    int   `json:"local_entries"`
+	LocalHits       int64 `json:"local_hits"`
+	LocalMisses     int64 `json:"local_misses"`
+	Evictions       int64 `json:"evictions"`
+	Expired         int64 `json:"expired"`
+	RedisHits       int64 `json:"redis_hits"`
+	RedisMisses     int64 `json:"redis_misses"`
+	RedisErrors     int64 `json:"redis_errors"`
+	PendingCounters int   `json:"pending_counters"` // Counters waiting to be flushed to Redis
+	PendingDeletes  int   `json:"pending_deletes"`  // Evictions waiting to be replayed on Redis
+}
+
+// Tiered reads through an in-process LRU to Redis
+// While Redis is down everything is served locally: counter increments and
+// deletes are buffered and replayed once the background health check sees
+// Redis again. Errors never reach callers; a failed Redis call only flips
+// the cache offline.
+type Tiered struct {
+	local  *LRU
+	remote *gibrun.Client
+	config TieredConfig
+
+	online     atomic.Bool
+	everOnline atomic.Bool
+	reconnects atomic.Int64
+
+	redisHits   atomic.Int64
+	redisMisses atomic.Int64
+	redisErrors atomic.Int64
+
+	mu             sync.Mutex
+	counters       map[string]int64 // Last value seen in Redis
+	pending        map[string]int64 // Increments not yet in Redis
+	pendingDeletes map[string]struct{}
+}
+
+// NewTiered starts offline; call Connect or Watch to bring Redis in
+func NewTiered(remote *gibrun.Client, config TieredConfig) *Tiered {
+	return &Tiered{
+		local:          NewLRU(config.LocalCapacity),
+		remote:         remote,
+		config:         config,
+		counters:       make(map[string]int64),
+		pending:        make(map[string]int64),
+		pendingDeletes: make(map[string]struct{}),
+	}
+}
+
+// Online reports whether Redis is currently in use
+func (t *Tiered) Online() bool {
+	return t.online.Load()
+}
+
+// Connect pings Redis and, on success, flushes buffered writes and goes online
+func (t *Tiered) Connect(ctx context.Context) error {
+	if err := t.remote.Ping(ctx); err != nil {
+		t.markOffline(err)
+		return err
+	}
+	if t.online.Load() {
+		return nil
+	}
+	if err := t.flush(ctx); err != nil {
+		t.markOffline(err)
+		return err
+	}
+
+	// Local copies written during the outage missed other instances' invalidations
+	t.local.Purge()
+	t.online.Store(true)
+	if t.everOnline.Swap(true) {
+		t.reconnects.Add(1)
+		fmt.Println("✅ Redis cache reconnected")
+	}
+	return nil
+}
+
+// Watch checks Redis every ReconnectInterval until ctx is done
+func (t *Tiered) Watch(ctx context.Context) {
+	ticker := time.NewTicker(t.config.ReconnectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, t.config.ReconnectInterval)
+			t.Connect(pingCtx)
+			cancel()
+		}
+	}
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, ok := t.local.Get(key); ok {
+		return value, true, nil
+	}
+	if !t.online.Load() {
+		return nil, false, nil
+	}
+
+	value, found, err := t.remote.Run(ctx, key).Bytes()
+	if err != nil {
+		t.markOffline(err)
+		return nil, false, nil
+	}
+	if !found {
+		t.redisMisses.Add(1)
+		return nil, false, nil
+	}
+	t.redisHits.Add(1)
+	t.local.Set(key, value, t.config.LocalTTL)
+	return value, true, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if !t.online.Load() {
+		t.local.Set(key, value, ttl)
+		return nil
+	}
+
+	localTTL := t.config.LocalTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	t.local.Set(key, value, localTTL)
+
+	if err := t.remote.Gib(ctx, key).Value(value).TTL(ttl).Exec(); err != nil {
+		t.markOffline(err)
+	}
+	return nil
+}
+
+func (t *Tiered) Del(ctx context.Context, keys ...string) error {
+	t.local.Delete(keys...)
+
+	if t.online.Load() {
+		err := t.remote.Del(ctx, keys...)
+		if err == nil {
+			return nil
+		}
+		t.markOffline(err)
+	}
+
+	// Replayed on reconnect so Redis does not serve the stale copies
+	t.mu.Lock()
+	for _, key := range keys {
+		t.pendingDeletes[key] = struct{}{}
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// Counter returns the Redis value, or the last seen value plus buffered increments
+func (t *Tiered) Counter(ctx context.Context, key string) (int64, error) {
+	if t.online.Load() {
+		raw, found, err := t.remote.Run(ctx, key).Raw()
+		if err == nil {
+			var value int64
+			if found {
+				value, _ = strconv.ParseInt(raw, 10, 64)
+			}
+			t.mu.Lock()
+			t.counters[key] = value
+			t.mu.Unlock()
+			return value, nil
+		}
+		t.markOffline(err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counters[key] + t.pending[key], nil
+}
+
+// Incr bumps the counter in Redis, or buffers the increment while offline
+func (t *Tiered) Incr(ctx context.Context, key string) (int64, error) {
+	if t.online.Load() {
+		value, err := t.remote.Sprint(ctx, key).Incr()
+		if err == nil {
+			t.mu.Lock()
+			t.counters[key] = value
+			t.mu.Unlock()
+			return value, nil
+		}
+		t.markOffline(err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[key]++
+	return t.counters[key] + t.pending[key], nil
+}
+
+// Stats returns a snapshot of the metrics
+func (t *Tiered) Stats() Stats {
+	t.mu.Lock()
+	pendingCounters, pendingDeletes := len(t.pending), len(t.pendingDeletes)
+	t.mu.Unlock()
+
+	return Stats{
+		RedisOnline:     t.online.Load(),
+		Reconnects:      t.reconnects.Load(),
+		LocalEntries:    t.local.Len(),
+		LocalHits:       t.local.hits.Load(),
+		LocalMisses:     t.local.misses.Load(),
+		Evictions:       t.local.evictions.Load(),
+		Expired:         t.local.expired.Load(),
+		RedisHits:       t.redisHits.Load(),
+		RedisMisses:     t.redisMisses.Load(),
+		RedisErrors:     t.redisErrors.Load(),
+		PendingCounters: pendingCounters,
+		PendingDeletes:  pendingDeletes,
+	}
+}
+
+// flush replays buffered deletes and increments; whatever fails stays buffered
+func (t *Tiered) flush(ctx context.Context) error {
+	t.mu.Lock()
+	deletes := make([]string, 0, len(t.pendingDeletes))
+	for key := range t.pendingDeletes {
+		deletes = append(deletes, key)
+	}
+	increments := make(map[string]int64, len(t.pending))
+	for key, n := range t.pending {
+		increments[key] = n
+	}
+	t.mu.Unlock()
+
+	if len(deletes) > 0 {
+		if err := t.remote.Del(ctx, deletes...); err != nil {
+			return fmt.Errorf("failed to replay cache deletes: %w", err)
+		}
+		t.mu.Lock()
+		for _, key := range deletes {
+			delete(t.pendingDeletes, key)
+		}
+		t.mu.Unlock()
+	}
+
+	for key, n := range increments {
+		value, err := t.remote.Sprint(ctx, key).IncrBy(n)
+		if err != nil {
+			return fmt.Errorf("failed to flush counter %s: %w", key, err)
+		}
+		t.mu.Lock()
+		// Increments made during the flush stay pending for the next one
+		t.pending[key] -= n
+		if t.pending[key] == 0 {
+			delete(t.pending, key)
+		}
+		t.counters[key] = value
+		t.mu.Unlock()
+	}
+
+	if len(deletes) > 0 || len(increments) > 0 {
+		fmt.Printf("✅ Flushed %d buffered cache deletes and %d counters to Redis\n", len(deletes), len(increments))
+	}
+	return nil
+}
+
+func (t *Tiered) markOffline(err error) {
+	t.redisErrors.Add(1)
+	if t.online.Swap(false) {
+		fmt.Printf("⚠️ Warning: Redis cache unavailable, serving from memory: %v\n", err)
+	}
+}
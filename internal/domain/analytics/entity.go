@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidRange is returned for empty or oversized query ranges
+var ErrInvalidRange = errors.New("invalid time range")
+
+// ScanEvent is one view of the public tree page (usually a QR tag scan)
+// UserID is empty for anonymous visitors.
+type ScanEvent struct {
+	ID         string    `json:"id"`
+	TreeID     string    `json:"tree_id"`
+	TreeCode   string    `json:"tree_code"`
+	LocationID string    `json:"location_id"`
+	UserID     string    `json:"user_id,omitempty"`
+	Latitude   *float64  `json:"latitude,omitempty"`
+	Longitude  *float64  `json:"longitude,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	ScannedAt  time.Time `json:"scanned_at"`
+}
+
+// Validate checks if scan event is valid
+func (e *ScanEvent) Validate() error {
+	if e.TreeID == "" || e.TreeCode == "" {
+		return fmt.Errorf("scan tree is required")
+	}
+	if (e.Latitude == nil) != (e.Longitude == nil) {
+		return fmt.Errorf("latitude and longitude must be given together")
+	}
+	if e.Latitude != nil && (*e.Latitude < -90 || *e.Latitude > 90 || *e.Longitude < -180 || *e.Longitude > 180) {
+		return fmt.Errorf("coordinates out of range")
+	}
+	return nil
+}
+
+// Granularity of a rolled-up series
+type Granularity string
+
+const (
+	GranularityHour Granularity = "hour"
+	GranularityDay  Granularity = "day"
+)
+
+// IsValid checks if granularity is valid
+func (g Granularity) IsValid() bool {
+	return g == GranularityHour || g == GranularityDay
+}
+
+// Truncate returns the UTC start of the bucket containing t
+func (g Granularity) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if g == GranularityDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// step is the bucket width
+func (g Granularity) step() time.Duration {
+	if g == GranularityDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// maxPoints caps series length (a month of hours, a year of days)
+func (g Granularity) maxPoints() int {
+	if g == GranularityDay {
+		return 366
+	}
+	return 24 * 31
+}
+
+// SeriesPoint is the scan count of one bucket
+type SeriesPoint struct {
+	BucketStart   time.Time `json:"bucket_start"`
+	Scans         int64     `json:"scans"`
+	Authenticated int64     `json:"authenticated"`
+}
+
+// SeriesFilter selects a per-tree or per-location series (neither means all trees)
+// LocationIDs narrows to the caller's scope; an empty non-nil list matches nothing.
+type SeriesFilter struct {
+	Granularity Granularity
+	TreeCode    string
+	LocationID  string
+	LocationIDs []string
+	From        time.Time
+	To          time.Time
+}
+
+// TopTree is a tree ranked by scans in a range
+type TopTree struct {
+	TreeID     string `json:"tree_id"`
+	TreeCode   string `json:"tree_code"`
+	LocationID string `json:"location_id"`
+	Scans      int64  `json:"scans"`
+}
+
+// TopFilter selects the ranking range
+type TopFilter struct {
+	LocationID  string
+	LocationIDs []string
+	From        time.Time
+	To          time.Time
+	Limit       int
+}
+
+// HeatmapCell counts scans reported inside one grid cell
+// Latitude/Longitude are the cell's corner, rounded to Precision decimals.
+type HeatmapCell struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Scans     int64   `json:"scans"`
+}
+
+// HeatmapFilter selects raw events with coordinates (so only within retention)
+// Precision is decimal places: 3 is roughly a 110 m grid.
+type HeatmapFilter struct {
+	TreeCode    string
+	LocationID  string
+	LocationIDs []string
+	From        time.Time
+	To          time.Time
+	Precision   int
+}
+
+// GetScanRetention returns how long raw scan events are kept (rollups are kept)
+func GetScanRetention() time.Duration {
+	days := 90 // Default
+	if v, err := strconv.Atoi(os.Getenv("SCAN_EVENT_RETENTION_DAYS")); err == nil && v > 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,219 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/paging"
+	"prabogo/internal/domain/tree"
+	"prabogo/utils/activity"
+
+	"github.com/google/uuid"
+)
+
+// ScanRepository stores scan events and their hourly/daily rollups
+type ScanRepository interface {
+	// Record inserts the event and bumps its hour and day rollups atomically
+	Record(ctx context.Context, event *ScanEvent) error
+
+	// Series returns non-empty buckets in range, oldest first
+	Series(ctx context.Context, filter SeriesFilter) ([]SeriesPoint, error)
+
+	TopTrees(ctx context.Context, filter TopFilter) ([]TopTree, error)
+	Heatmap(ctx context.Context, filter HeatmapFilter) ([]HeatmapCell, error)
+
+	// PurgeBefore deletes raw events older than cutoff
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ScanService records tree scans and answers analytics queries
+type ScanService struct {
+	repo ScanRepository
+}
+
+// NewScanService creates a new scan service
+func NewScanService(repo ScanRepository) *ScanService {
+	return &ScanService{
+		repo: repo,
+	}
+}
+
+// Record stores a scan, filling user and user agent from ctx
+// Failures are logged, never returned: analytics must not break the tree page.
+func (s *ScanService) Record(ctx context.Context, event ScanEvent) {
+	event.ID = uuid.New().String()
+	event.ScannedAt = time.Now().UTC()
+	if event.UserID == "" {
+		event.UserID, _ = activity.GetActorID(ctx)
+	}
+	if event.UserAgent == "" {
+		event.UserAgent, _ = activity.GetUserAgent(ctx)
+	}
+	if len(event.UserAgent) > 255 {
+		event.UserAgent = event.UserAgent[:255]
+	}
+
+	if err := event.Validate(); err != nil {
+		// Bad coordinates from the client should not lose the scan itself
+		event.Latitude, event.Longitude = nil, nil
+		if err := event.Validate(); err != nil {
+			fmt.Printf("❌ ERROR: Invalid scan event for %s: %v\n", event.TreeCode, err)
+			return
+		}
+	}
+
+	if err := s.repo.Record(ctx, &event); err != nil {
+		fmt.Printf("❌ ERROR: Failed to record scan of %s: %v\n", event.TreeCode, err)
+	}
+}
+
+// Series returns a gap-free series (empty buckets are zero)
+// Defaults to the last 7 days hourly or 30 days daily.
+func (s *ScanService) Series(ctx context.Context, filter SeriesFilter) ([]SeriesPoint, error) {
+	if filter.Granularity == "" {
+		filter.Granularity = GranularityDay
+	}
+	if !filter.Granularity.IsValid() {
+		return nil, fmt.Errorf("%w: granularity must be hour or day", ErrInvalidRange)
+	}
+
+	g := filter.Granularity
+	defaultSpan := 30 * 24 * time.Hour
+	if g == GranularityHour {
+		defaultSpan = 7 * 24 * time.Hour
+	}
+	from, to, err := resolveRange(filter.From, filter.To, defaultSpan)
+	if err != nil {
+		return nil, err
+	}
+	filter.From, filter.To = g.Truncate(from), to
+	if points := int(filter.To.Sub(filter.From) / g.step()); points > g.maxPoints() {
+		return nil, fmt.Errorf("%w: at most %d %s buckets", ErrInvalidRange, g.maxPoints(), g)
+	}
+
+	if filter.LocationIDs, err = scopeLocations(ctx, filter.LocationID); err != nil {
+		return nil, err
+	}
+
+	points, err := s.repo.Series(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan series: %w", err)
+	}
+
+	byBucket := make(map[time.Time]SeriesPoint, len(points))
+	for _, p := range points {
+		byBucket[p.BucketStart.UTC()] = p
+	}
+
+	series := []SeriesPoint{}
+	for t := filter.From; t.Before(filter.To); t = t.Add(g.step()) {
+		p, ok := byBucket[t]
+		if !ok {
+			p = SeriesPoint{BucketStart: t}
+		}
+		series = append(series, p)
+	}
+	return series, nil
+}
+
+// TopTrees ranks trees by scans (default last 30 days, top 10)
+func (s *ScanService) TopTrees(ctx context.Context, filter TopFilter) ([]TopTree, error) {
+	var err error
+	if filter.From, filter.To, err = resolveRange(filter.From, filter.To, 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	filter.Limit = paging.Limit(filter.Limit, 10, paging.MaxLimit)
+	if filter.LocationIDs, err = scopeLocations(ctx, filter.LocationID); err != nil {
+		return nil, err
+	}
+
+	// Rollups are daily; round out to whole days
+	filter.From = GranularityDay.Truncate(filter.From)
+
+	trees, err := s.repo.TopTrees(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank scanned trees: %w", err)
+	}
+	return trees, nil
+}
+
+// Heatmap groups located scans into a grid (default last 30 days, precision 3)
+func (s *ScanService) Heatmap(ctx context.Context, filter HeatmapFilter) ([]HeatmapCell, error) {
+	var err error
+	if filter.From, filter.To, err = resolveRange(filter.From, filter.To, 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if filter.Precision < 1 || filter.Precision > 5 {
+		filter.Precision = 3
+	}
+	if filter.LocationIDs, err = scopeLocations(ctx, filter.LocationID); err != nil {
+		return nil, err
+	}
+
+	cells, err := s.repo.Heatmap(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scan heatmap: %w", err)
+	}
+	return cells, nil
+}
+
+// PurgeExpired deletes raw events past GetScanRetention
+func (s *ScanService) PurgeExpired(ctx context.Context) (int64, error) {
+	cutoff := time.Now().UTC().Add(-GetScanRetention())
+	n, err := s.repo.PurgeBefore(ctx, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge scan events: %w", err)
+	}
+	return n, nil
+}
+
+// RunRetention purges expired events every interval until ctx is done
+func (s *ScanService) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.PurgeExpired(ctx)
+			if err != nil {
+				fmt.Printf("⚠️ Warning: %v\n", err)
+			} else if n > 0 {
+				fmt.Printf("🧹 Purged %d expired scan events\n", n)
+			}
+		}
+	}
+}
+
+// resolveRange defaults to the span ending now and rejects empty ranges
+func resolveRange(from, to time.Time, span time.Duration) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-span)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("%w: from must be before to", ErrInvalidRange)
+	}
+	return from.UTC(), to.UTC(), nil
+}
+
+// scopeLocations applies the caller's location scope
+// Returns nil when unrestricted, or the locations to match otherwise.
+func scopeLocations(ctx context.Context, locationID string) ([]string, error) {
+	if err := tree.CheckLocationScope(ctx, locationID); locationID != "" && err != nil {
+		return nil, err
+	}
+	scoped, ok := tree.LocationScope(ctx)
+	if !ok {
+		return nil, nil
+	}
+	if locationID != "" {
+		return []string{locationID}, nil
+	}
+	return append([]string{}, scoped...), nil
+}
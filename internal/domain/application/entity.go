@@ -0,0 +1,198 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrApplicationNotFound is returned for unknown application IDs
+	ErrApplicationNotFound = errors.New("application record not found")
+
+	// ErrInvalidRange is returned for an unusable usage report period
+	ErrInvalidRange = errors.New("invalid date range")
+)
+
+// Category is the kind of input applied
+type Category string
+
+const (
+	CategoryFertilizer Category = "fertilizer"
+	CategoryPesticide  Category = "pesticide"
+	CategoryFungicide  Category = "fungicide"
+	CategoryHerbicide  Category = "herbicide"
+	CategoryOther      Category = "other"
+)
+
+// IsValid checks if category is valid
+func (c Category) IsValid() bool {
+	switch c {
+	case CategoryFertilizer, CategoryPesticide, CategoryFungicide, CategoryHerbicide, CategoryOther:
+		return true
+	}
+	return false
+}
+
+// Method is how the input was applied
+type Method string
+
+const (
+	MethodBroadcast  Method = "broadcast"   // Spread over the ground
+	MethodRing       Method = "ring"        // Around the trunk
+	MethodFoliar     Method = "foliar"      // Sprayed on leaves
+	MethodSoilDrench Method = "soil_drench" // Poured into the soil
+	MethodInjection  Method = "injection"   // Into the trunk
+	MethodOther      Method = "other"
+)
+
+// IsValid checks if method is valid
+func (m Method) IsValid() bool {
+	switch m {
+	case MethodBroadcast, MethodRing, MethodFoliar, MethodSoilDrench, MethodInjection, MethodOther:
+		return true
+	}
+	return false
+}
+
+// Unit measures a dose
+type Unit string
+
+const (
+	UnitKilogram   Unit = "kg"
+	UnitGram       Unit = "g"
+	UnitLiter      Unit = "l"
+	UnitMilliliter Unit = "ml"
+)
+
+// IsValid checks if unit is valid
+func (u Unit) IsValid() bool {
+	switch u {
+	case UnitKilogram, UnitGram, UnitLiter, UnitMilliliter:
+		return true
+	}
+	return false
+}
+
+// MaxTrees limits one application record (a large block)
+const MaxTrees = 20000
+
+// AppliedTree is one tree that received the input
+type AppliedTree struct {
+	TreeID     string `json:"tree_id"`
+	TreeCode   string `json:"tree_code"`
+	LocationID string `json:"location_id"`
+}
+
+// Application records one input applied to one or more trees
+type Application struct {
+	ID            string        `json:"id"`
+	Product       string        `json:"product"`
+	Category      Category      `json:"category"`
+	DosePerTree   float64       `json:"dose_per_tree"`
+	Unit          Unit          `json:"unit"`
+	Method        Method        `json:"method"`
+	BatchNumber   string        `json:"batch_number"`
+	OperatorID    string        `json:"operator_id"`
+	AppliedAt     time.Time     `json:"applied_at"` // Date of application
+	Notes         string        `json:"notes"`
+	TreeCount     int           `json:"tree_count"`
+	TotalQuantity float64       `json:"total_quantity"` // DosePerTree x TreeCount, in Unit
+	LocationIDs   []string      `json:"location_ids"`   // Blocks covered
+	Trees         []AppliedTree `json:"trees,omitempty"`
+	CreatedBy     string        `json:"created_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// Validate checks if application entity is valid
+func (a *Application) Validate() error {
+	if strings.TrimSpace(a.Product) == "" || len(a.Product) > 100 {
+		return errors.New("product is required (max 100 characters)")
+	}
+	if !a.Category.IsValid() {
+		return fmt.Errorf("invalid category: %s", a.Category)
+	}
+	if !a.Method.IsValid() {
+		return fmt.Errorf("invalid method: %s", a.Method)
+	}
+	if !a.Unit.IsValid() {
+		return fmt.Errorf("invalid unit: %s (kg, g, l, ml)", a.Unit)
+	}
+	if a.DosePerTree <= 0 || a.DosePerTree > 100000 {
+		return errors.New("dose per tree must be positive")
+	}
+	if len(a.BatchNumber) > 50 {
+		return errors.New("batch number is too long (max 50 characters)")
+	}
+	if len(a.Notes) > 2000 {
+		return errors.New("notes are too long (max 2000 characters)")
+	}
+	if a.OperatorID == "" {
+		return errors.New("operator is required")
+	}
+	if a.AppliedAt.IsZero() {
+		return errors.New("application date is required")
+	}
+	if a.AppliedAt.After(time.Now().UTC()) {
+		return errors.New("application date cannot be in the future")
+	}
+	if len(a.Trees) == 0 {
+		return errors.New("no trees to apply to")
+	}
+	if len(a.Trees) > MaxTrees {
+		return fmt.Errorf("one record can cover at most %d trees", MaxTrees)
+	}
+	return nil
+}
+
+// Filter narrows application lists
+type Filter struct {
+	LocationID  string
+	TreeCode    string
+	Product     string
+	Category    Category
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Offset      int
+	LocationIDs []string // Scope (nil = any)
+}
+
+// Period groups the usage report
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+// IsValid checks if period is valid
+func (p Period) IsValid() bool {
+	return p == PeriodDay || p == PeriodWeek || p == PeriodMonth
+}
+
+// UsageFilter selects the usage report
+type UsageFilter struct {
+	Period      Period
+	LocationID  string
+	Product     string
+	Category    Category
+	From        time.Time
+	To          time.Time
+	LocationIDs []string // Scope (nil = any)
+}
+
+// UsageRow is the quantity of one product used in one block in one period
+type UsageRow struct {
+	PeriodStart   time.Time `json:"period_start"`
+	LocationID    string    `json:"location_id"`
+	Product       string    `json:"product"`
+	Category      Category  `json:"category"`
+	Unit          Unit      `json:"unit"`
+	Applications  int       `json:"applications"`
+	Trees         int       `json:"trees"`
+	TotalQuantity float64   `json:"total_quantity"`
+	Batches       []string  `json:"batches"`
+}
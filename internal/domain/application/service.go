@@ -0,0 +1,315 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/paging"
+	"prabogo/internal/domain/tree"
+
+	"github.com/google/uuid"
+)
+
+// Repository stores application records
+type Repository interface {
+	// Create inserts the record with its trees atomically
+	Create(ctx context.Context, a *Application) error
+
+	// FindByID returns the record with its trees
+	FindByID(ctx context.Context, id string) (*Application, error)
+
+	// FindAll returns a page of records (newest first, without trees) and the total matching
+	FindAll(ctx context.Context, filter Filter) ([]*Application, int, error)
+
+	// Usage sums quantities per period, block and product
+	Usage(ctx context.Context, filter UsageFilter) ([]UsageRow, error)
+
+	Delete(ctx context.Context, id string) error
+}
+
+// Trees is the subset of tree.TreeUseCase applications act through
+type Trees interface {
+	GetTreeByCode(ctx context.Context, code string) (*tree.TreeResponse, error)
+	ExportTrees(ctx context.Context, filter tree.TreeFilter, fn func(*tree.TreeResponse) error) error
+	UpdateTreeStatus(ctx context.Context, code string, status tree.TreeStatus, healthScore int, notes string, userID string, expectedVersion int) error
+}
+
+// Users looks up operators
+type Users interface {
+	FindByID(ctx context.Context, id string) (*auth.User, error)
+}
+
+// RecordRequest is one application, to listed trees or a whole block
+type RecordRequest struct {
+	Product     string
+	Category    Category
+	DosePerTree float64
+	Unit        Unit
+	Method      Method
+	BatchNumber string
+	OperatorID  string
+	AppliedAt   time.Time
+	Notes       string
+	TreeCodes   []string // Either these trees...
+	LocationID  string   // ...or every living tree of this block
+	SpeciesID   string   // Optional narrowing of the block
+	SetStatus   bool     // Fertilizer only: mark trees DIPUPUK (writes monitoring logs)
+}
+
+// ApplicationService records inputs applied to trees
+type ApplicationService struct {
+	repo    Repository
+	trees   Trees
+	users   Users
+	auditor audit.Recorder
+}
+
+// NewApplicationService creates a new application service
+func NewApplicationService(repo Repository, trees Trees, users Users, auditor audit.Recorder) *ApplicationService {
+	return &ApplicationService{
+		repo:    repo,
+		trees:   trees,
+		users:   users,
+		auditor: auditor,
+	}
+}
+
+// Record stores one application; userID is the recording user
+// Dead trees are skipped when a whole block is given and rejected when listed.
+// With SetStatus the trees are marked DIPUPUK after the record is stored; a
+// tree failing that step is reported in the returned warnings.
+func (s *ApplicationService) Record(ctx context.Context, req RecordRequest, userID string) (*Application, []string, error) {
+	a := &Application{
+		ID:          uuid.New().String(),
+		Product:     strings.TrimSpace(req.Product),
+		Category:    req.Category,
+		DosePerTree: req.DosePerTree,
+		Unit:        req.Unit,
+		Method:      req.Method,
+		BatchNumber: strings.TrimSpace(req.BatchNumber),
+		OperatorID:  req.OperatorID,
+		AppliedAt:   req.AppliedAt,
+		Notes:       req.Notes,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if a.OperatorID == "" {
+		a.OperatorID = userID
+	}
+	if req.SetStatus && a.Category != CategoryFertilizer {
+		return nil, nil, fmt.Errorf("only fertilizer applications can set status %s", tree.StatusDipupuk)
+	}
+
+	if err := s.collectTrees(ctx, a, req); err != nil {
+		return nil, nil, err
+	}
+	if err := a.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if operator, err := s.users.FindByID(ctx, a.OperatorID); err != nil || operator == nil || operator.DeletedAt != nil {
+		return nil, nil, fmt.Errorf("operator %s not found", a.OperatorID)
+	}
+
+	a.TreeCount = len(a.Trees)
+	a.TotalQuantity = a.DosePerTree * float64(a.TreeCount)
+	locations := make(map[string]bool)
+	for _, t := range a.Trees {
+		locations[t.LocationID] = true
+	}
+	for id := range locations {
+		a.LocationIDs = append(a.LocationIDs, id)
+	}
+	sort.Strings(a.LocationIDs)
+
+	if err := s.repo.Create(ctx, a); err != nil {
+		return nil, nil, fmt.Errorf("failed to store application: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "application.record",
+		TargetType: audit.TargetApplication,
+		TargetID:   a.ID,
+		After: audit.Snapshot(map[string]interface{}{
+			"product":        a.Product,
+			"batch_number":   a.BatchNumber,
+			"dose_per_tree":  a.DosePerTree,
+			"unit":           a.Unit,
+			"tree_count":     a.TreeCount,
+			"total_quantity": a.TotalQuantity,
+			"location_ids":   a.LocationIDs,
+		}),
+	})
+
+	var warnings []string
+	if req.SetStatus {
+		notes := fmt.Sprintf("Dipupuk: %s %g %s/pohon (%s)", a.Product, a.DosePerTree, a.Unit, a.Method)
+		if a.BatchNumber != "" {
+			notes += ", batch " + a.BatchNumber
+		}
+		for _, t := range a.Trees {
+			resp, err := s.trees.GetTreeByCode(ctx, t.TreeCode)
+			if err == nil {
+				err = s.trees.UpdateTreeStatus(ctx, t.TreeCode, tree.StatusDipupuk, resp.HealthScore, notes, userID, 0)
+			}
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: %v", t.TreeCode, err))
+			}
+		}
+	}
+	return a, warnings, nil
+}
+
+// collectTrees resolves listed tree codes or the trees of a block
+func (s *ApplicationService) collectTrees(ctx context.Context, a *Application, req RecordRequest) error {
+	if len(req.TreeCodes) > 0 && req.LocationID != "" {
+		return fmt.Errorf("give either tree_codes or location_id, not both")
+	}
+
+	if req.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, req.LocationID); err != nil {
+			return err
+		}
+		filter := tree.TreeFilter{LocationID: req.LocationID, SpeciesID: req.SpeciesID}
+		return s.trees.ExportTrees(ctx, filter, func(t *tree.TreeResponse) error {
+			if t.Status == string(tree.StatusMati) {
+				return nil
+			}
+			if len(a.Trees) >= MaxTrees {
+				return fmt.Errorf("block has more than %d trees; split the record by species", MaxTrees)
+			}
+			a.Trees = append(a.Trees, AppliedTree{TreeID: t.ID, TreeCode: t.Code, LocationID: t.LocationID})
+			return nil
+		})
+	}
+
+	seen := make(map[string]bool, len(req.TreeCodes))
+	for _, code := range req.TreeCodes {
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		if len(seen) > MaxTrees {
+			return fmt.Errorf("one record can cover at most %d trees", MaxTrees)
+		}
+
+		t, err := s.trees.GetTreeByCode(ctx, code)
+		if err != nil {
+			return fmt.Errorf("tree %s: %w", code, err)
+		}
+		if t.Status == string(tree.StatusMati) {
+			return fmt.Errorf("tree %s is dead", code)
+		}
+		a.Trees = append(a.Trees, AppliedTree{TreeID: t.ID, TreeCode: t.Code, LocationID: t.LocationID})
+	}
+	return nil
+}
+
+// Get returns one record with its trees, if it touches the caller's locations
+func (s *ApplicationService) Get(ctx context.Context, id string) (*Application, error) {
+	a, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScope(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// List returns a page of records within the caller's location scope
+func (s *ApplicationService) List(ctx context.Context, filter Filter) ([]*Application, int, error) {
+	if filter.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return nil, 0, err
+		}
+	}
+	if filter.Category != "" && !filter.Category.IsValid() {
+		return nil, 0, fmt.Errorf("invalid category: %s", filter.Category)
+	}
+	filter.Limit, filter.Offset = paging.Normalize(filter.Limit, filter.Offset)
+	filter.LocationIDs, _ = tree.LocationScope(ctx)
+
+	applications, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list applications: %w", err)
+	}
+	return applications, total, nil
+}
+
+// Usage reports quantities per period, block and product for reconciliation
+// Defaults to monthly over the last 12 months.
+func (s *ApplicationService) Usage(ctx context.Context, filter UsageFilter) ([]UsageRow, error) {
+	if filter.Period == "" {
+		filter.Period = PeriodMonth
+	}
+	if !filter.Period.IsValid() {
+		return nil, fmt.Errorf("%w: period must be day, week or month", ErrInvalidRange)
+	}
+	if filter.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return nil, err
+		}
+	}
+	if filter.To.IsZero() {
+		filter.To = time.Now().UTC()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(-1, 0, 0)
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidRange)
+	}
+	if filter.Period == PeriodDay && filter.To.Sub(filter.From) > 366*24*time.Hour {
+		return nil, fmt.Errorf("%w: daily usage covers at most one year", ErrInvalidRange)
+	}
+	filter.LocationIDs, _ = tree.LocationScope(ctx)
+
+	rows, err := s.repo.Usage(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report usage: %w", err)
+	}
+	return rows, nil
+}
+
+// Delete removes a mistaken record; tree statuses it set stay
+func (s *ApplicationService) Delete(ctx context.Context, id string) error {
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "application.delete",
+		TargetType: audit.TargetApplication,
+		TargetID:   id,
+		Before: audit.Snapshot(map[string]interface{}{
+			"product":        a.Product,
+			"batch_number":   a.BatchNumber,
+			"tree_count":     a.TreeCount,
+			"total_quantity": a.TotalQuantity,
+			"applied_at":     a.AppliedAt,
+		}),
+	})
+	return nil
+}
+
+// checkScope allows records touching at least one of the caller's locations
+func checkScope(ctx context.Context, a *Application) error {
+	if _, scoped := tree.LocationScope(ctx); !scoped {
+		return nil
+	}
+	for _, id := range a.LocationIDs {
+		if tree.CheckLocationScope(ctx, id) == nil {
+			return nil
+		}
+	}
+	return tree.ErrOutOfScope
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Target types
+const (
+	TargetTree               = "tree"
+	TargetUser               = "user"
+	TargetSession            = "session"
+	TargetRequest            = "request"
+	TargetRole               = "role"
+	TargetClient             = "client"
+	TargetInvitation         = "invitation"
+	TargetMaintenanceRule    = "maintenance_rule"
+	TargetTask               = "task"
+	TargetInspectionSchedule = "inspection_schedule"
+	TargetApplication        = "application"
+	TargetCatalogEntry       = "catalog_entry"
+	TargetHarvest            = "harvest"
+)
+
+// Entry is one append-only audit record
+// Entries form a hash chain: Hash covers the entry fields plus PrevHash,
+// so editing or removing any row breaks every hash after it.
+type Entry struct {
+	ID            string    `json:"id"`
+	Seq           int64     `json:"seq"`
+	ActorID       string    `json:"actor_id"`
+	ActorName     string    `json:"actor_name"`
+	Action        string    `json:"action"`
+	TargetType    string    `json:"target_type"`
+	TargetID      string    `json:"target_id"`
+	Before        string    `json:"before,omitempty"` // JSON snapshot before the change
+	After         string    `json:"after,omitempty"`  // JSON snapshot after the change
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	TransactionID string    `json:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	PrevHash      string    `json:"prev_hash"`
+	Hash          string    `json:"hash"`
+}
+
+// Validate checks if audit entry is valid
+func (e *Entry) Validate() error {
+	if e.Action == "" {
+		return fmt.Errorf("audit action is required")
+	}
+	if e.TargetType == "" {
+		return fmt.Errorf("audit target type is required")
+	}
+	return nil
+}
+
+// ComputeHash returns the chain hash for this entry given its PrevHash
+// CreatedAt is hashed at microsecond precision to match PostgreSQL TIMESTAMP.
+func (e *Entry) ComputeHash() string {
+	fields := []string{
+		e.PrevHash,
+		fmt.Sprintf("%d", e.Seq),
+		e.ID,
+		e.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		e.ActorID,
+		e.ActorName,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Before,
+		e.After,
+		e.IP,
+		e.UserAgent,
+		e.TransactionID,
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Filter for querying audit entries
+type Filter struct {
+	ActorID    string
+	Action     string // Prefix match, e.g. "tree." for all tree actions
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
+
+// VerifyResult reports the outcome of a hash chain check
+type VerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	BrokenAt int64  `json:"broken_at,omitempty"` // Seq of the first entry that fails
+	Reason   string `json:"reason,omitempty"`
+}
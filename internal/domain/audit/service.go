@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/paging"
+	"prabogo/utils/activity"
+
+	"github.com/google/uuid"
+)
+
+// AuditRepository interface for the append-only audit log
+type AuditRepository interface {
+	// Append assigns Seq/PrevHash/Hash under a lock and inserts the entry
+	Append(ctx context.Context, entry *Entry) error
+
+	// Find retrieves entries with filter, newest first
+	Find(ctx context.Context, filter Filter) ([]*Entry, error)
+
+	// StreamChain calls fn for every entry in Seq order
+	StreamChain(ctx context.Context, fn func(*Entry) error) error
+}
+
+// Recorder is what other domains depend on to write audit entries
+type Recorder interface {
+	Record(ctx context.Context, entry Entry)
+}
+
+// AuditService handles audit business logic
+type AuditService struct {
+	repo AuditRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(repo AuditRepository) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+// Record appends an entry, filling actor/IP/user agent/transaction from ctx
+// Failures are logged, never returned: auditing must not break the audited action.
+func (s *AuditService) Record(ctx context.Context, entry Entry) {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	if entry.ActorID == "" {
+		entry.ActorID, _ = activity.GetActorID(ctx)
+	}
+	if entry.IP == "" {
+		entry.IP, _ = activity.GetClientIP(ctx)
+	}
+	if entry.UserAgent == "" {
+		entry.UserAgent, _ = activity.GetUserAgent(ctx)
+	}
+	if entry.TransactionID == "" {
+		entry.TransactionID, _ = activity.GetTransactionID(ctx)
+	}
+
+	if err := entry.Validate(); err != nil {
+		fmt.Printf("❌ ERROR: Invalid audit entry %s: %v\n", entry.Action, err)
+		return
+	}
+
+	if err := s.repo.Append(ctx, &entry); err != nil {
+		fmt.Printf("❌ ERROR: Failed to write audit entry %s on %s/%s: %v\n",
+			entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+// Query retrieves audit entries with filter
+func (s *AuditService) Query(ctx context.Context, filter Filter) ([]*Entry, error) {
+	filter.Limit = paging.Limit(filter.Limit, 50, 500)
+	entries, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Verify walks the whole chain and reports the first tampered entry
+func (s *AuditService) Verify(ctx context.Context) (*VerifyResult, error) {
+	result := &VerifyResult{Valid: true}
+	prevHash := ""
+	var expectedSeq int64 = 1
+
+	errBroken := fmt.Errorf("chain broken")
+	err := s.repo.StreamChain(ctx, func(e *Entry) error {
+		switch {
+		case e.Seq != expectedSeq:
+			result.Reason = fmt.Sprintf("missing entry: expected seq %d, found %d", expectedSeq, e.Seq)
+		case e.PrevHash != prevHash:
+			result.Reason = "prev_hash does not match previous entry"
+		case e.ComputeHash() != e.Hash:
+			result.Reason = "entry content does not match its hash"
+		default:
+			result.Checked++
+			prevHash = e.Hash
+			expectedSeq++
+			return nil
+		}
+		result.Valid = false
+		result.BrokenAt = e.Seq
+		return errBroken
+	})
+	if err != nil && err != errBroken {
+		return nil, fmt.Errorf("failed to verify audit log: %w", err)
+	}
+
+	return result, nil
+}
+
+// Snapshot marshals a value for Entry.Before/After (empty string for nil)
+func Snapshot(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%q", fmt.Sprintf("unserializable: %v", err))
+	}
+	return string(b)
+}
@@ -0,0 +1,395 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+)
+
+// Scopes only API keys carry; users read trees and history just by logging in
+const (
+	ScopeTreeRead       Permission = "tree:read"
+	ScopeMonitoringRead Permission = "monitoring:read"
+)
+
+// APIKeyScopes is the catalog of scopes an API key may be granted
+// Machine clients never get user, role, trash or audit permissions.
+var APIKeyScopes = []PermissionInfo{
+	{ScopeTreeRead, "List and read trees and their change log"},
+	{ScopeMonitoringRead, "Read monitoring history"},
+	{PermTreeCreate, "Register new trees"},
+	{PermTreeUpdate, "Update tree status and correct registration data"},
+	{PermReportView, "Read statistics"},
+	{PermReportExport, "Export trees and monitoring history"},
+}
+
+// IsKnownAPIKeyScope reports whether p is in the API key scope catalog
+func IsKnownAPIKeyScope(p Permission) bool {
+	for _, info := range APIKeyScopes {
+		if info.Name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeyPrefix marks raw keys so they can be told apart from JWTs
+const apiKeyPrefix = "tlk_"
+
+const (
+	defaultAPIKeyTTLDays   = 365
+	maxAPIKeyTTLDays       = 3650
+	defaultAPIKeyRateLimit = 60
+	maxAPIKeyRateLimit     = 6000
+
+	// apiKeyLastUsedInterval limits last_used_at writes to one per key per interval
+	apiKeyLastUsedInterval = time.Minute
+)
+
+var (
+	// ErrAPIKeyNotFound is returned for unknown client IDs
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	// ErrInvalidAPIKey is returned for unknown, expired or revoked keys
+	ErrInvalidAPIKey = errors.New("invalid, expired or revoked api key")
+)
+
+// APIKey is a machine client (drone, soil sensor, ERP) stored in the clients table
+// Only the SHA-256 of the key is persisted; Prefix identifies it in listings.
+type APIKey struct {
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"-"`
+	Scopes     []Permission `json:"scopes"`
+	RateLimit  int          `json:"rate_limit"` // Requests per minute
+	ExpiresAt  *time.Time   `json:"expires_at"`
+	RevokedAt  *time.Time   `json:"revoked_at"`
+	LastUsedAt *time.Time   `json:"last_used_at"`
+	CreatedBy  string       `json:"created_by"` // Admin the key acts on behalf of
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// IsUsable returns false once the key is revoked or expired
+func (k *APIKey) IsUsable() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
+
+// ScopeSet returns the key's scopes as a permission set
+func (k *APIKey) ScopeSet() PermissionSet {
+	set := PermissionSet{}
+	for _, s := range k.Scopes {
+		set[s] = true
+	}
+	return set
+}
+
+// ActorID identifies the key in audit entries
+func (k *APIKey) ActorID() string {
+	return "client:" + strconv.Itoa(k.ID)
+}
+
+// APIKeyRepository persists API keys
+type APIKeyRepository interface {
+	FindAll(ctx context.Context) ([]*APIKey, error)
+	FindByID(ctx context.Context, id int) (*APIKey, error)
+	FindByHash(ctx context.Context, keyHash string) (*APIKey, error)
+
+	// Create inserts the key and sets its ID
+	Create(ctx context.Context, key *APIKey) error
+
+	// Update replaces name, key hash, scopes, rate limit and expiry
+	Update(ctx context.Context, key *APIKey) error
+
+	Revoke(ctx context.Context, id int, at time.Time) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
+
+// APIKeyRequest is the body for issuing or editing an API key
+type APIKeyRequest struct {
+	Name          string       `json:"name"`
+	Scopes        []Permission `json:"scopes"`
+	RateLimit     int          `json:"rate_limit"`
+	ExpiresInDays int          `json:"expires_in_days"`
+}
+
+// Validate checks the request and fills the default rate limit
+// ExpiresInDays 0 means the default lifetime on create and "unchanged" on update.
+func (r *APIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 100 {
+		return errors.New("name is required (max 100 characters)")
+	}
+	if len(r.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, s := range r.Scopes {
+		if !IsKnownAPIKeyScope(s) {
+			return fmt.Errorf("unknown scope %q", s)
+		}
+	}
+
+	if r.RateLimit == 0 {
+		r.RateLimit = defaultAPIKeyRateLimit
+	}
+	if r.RateLimit < 1 || r.RateLimit > maxAPIKeyRateLimit {
+		return fmt.Errorf("rate_limit must be between 1 and %d requests per minute", maxAPIKeyRateLimit)
+	}
+
+	if r.ExpiresInDays < 0 || r.ExpiresInDays > maxAPIKeyTTLDays {
+		return fmt.Errorf("expires_in_days must be between 0 and %d", maxAPIKeyTTLDays)
+	}
+	return nil
+}
+
+// IssuedAPIKey is returned once when a key is created or rotated
+type IssuedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// newAPIKeySecret generates a raw key and its stored hash and display prefix
+func newAPIKeySecret() (raw string, keyHash string, prefix string, err error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	raw = apiKeyPrefix + token
+	return raw, hashToken(raw), raw[:len(apiKeyPrefix)+8], nil
+}
+
+// IsAPIKey reports whether a bearer credential looks like an API key
+func IsAPIKey(raw string) bool {
+	return strings.HasPrefix(raw, apiKeyPrefix)
+}
+
+func expiryInDays(days int) *time.Time {
+	at := time.Now().AddDate(0, 0, days)
+	return &at
+}
+
+// ListAPIKeys returns every API key, revoked ones included
+func (s *AuthService) ListAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	keys, err := s.apiKeyRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateAPIKey issues a new key acting on behalf of createdBy
+func (s *AuthService) CreateAPIKey(ctx context.Context, req APIKeyRequest, createdBy string) (*IssuedAPIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	raw, keyHash, prefix, err := newAPIKeySecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = defaultAPIKeyTTLDays
+	}
+
+	now := time.Now()
+	key := &APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    dedupePermissions(req.Scopes),
+		RateLimit: req.RateLimit,
+		ExpiresAt: expiryInDays(req.ExpiresInDays),
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "client.create",
+		TargetType: audit.TargetClient,
+		TargetID:   strconv.Itoa(key.ID),
+		After:      audit.Snapshot(key),
+	})
+	return &IssuedAPIKey{APIKey: key, Key: raw}, nil
+}
+
+// UpdateAPIKey replaces a key's name, scopes and rate limit (and expiry when given)
+func (s *AuthService) UpdateAPIKey(ctx context.Context, id int, req APIKeyRequest) (*APIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	key, err := s.apiKeyRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	before := *key
+
+	key.Name = req.Name
+	key.Scopes = dedupePermissions(req.Scopes)
+	key.RateLimit = req.RateLimit
+	if req.ExpiresInDays > 0 {
+		key.ExpiresAt = expiryInDays(req.ExpiresInDays)
+	}
+	key.UpdatedAt = time.Now()
+	if err := s.apiKeyRepo.Update(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to update api key: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "client.update",
+		TargetType: audit.TargetClient,
+		TargetID:   strconv.Itoa(id),
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(key),
+	})
+	return key, nil
+}
+
+// RotateAPIKey replaces the secret of a key; the old secret stops working at once
+// Scopes and rate limit are kept, the expiry restarts at expiresInDays (0 = default).
+func (s *AuthService) RotateAPIKey(ctx context.Context, id int, expiresInDays int) (*IssuedAPIKey, error) {
+	if expiresInDays == 0 {
+		expiresInDays = defaultAPIKeyTTLDays
+	}
+	if expiresInDays < 1 || expiresInDays > maxAPIKeyTTLDays {
+		return nil, fmt.Errorf("validation error: expires_in_days must be between 1 and %d", maxAPIKeyTTLDays)
+	}
+
+	key, err := s.apiKeyRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: revoked keys cannot be rotated", ErrInvalidAPIKey)
+	}
+	previousPrefix := key.Prefix
+
+	raw, keyHash, prefix, err := newAPIKeySecret()
+	if err != nil {
+		return nil, err
+	}
+	key.Prefix = prefix
+	key.KeyHash = keyHash
+	key.ExpiresAt = expiryInDays(expiresInDays)
+	key.UpdatedAt = time.Now()
+	if err := s.apiKeyRepo.Update(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to rotate api key: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "client.rotate",
+		TargetType: audit.TargetClient,
+		TargetID:   strconv.Itoa(id),
+		Before:     audit.Snapshot(map[string]string{"prefix": previousPrefix}),
+		After:      audit.Snapshot(map[string]string{"prefix": prefix}),
+	})
+	return &IssuedAPIKey{APIKey: key, Key: raw}, nil
+}
+
+// RevokeAPIKey disables a key permanently
+func (s *AuthService) RevokeAPIKey(ctx context.Context, id int) error {
+	key, err := s.apiKeyRepo.FindByID(ctx, id)
+	if err != nil {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	if err := s.apiKeyRepo.Revoke(ctx, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "client.revoke",
+		TargetType: audit.TargetClient,
+		TargetID:   strconv.Itoa(id),
+		Before:     audit.Snapshot(key),
+	})
+	return nil
+}
+
+// ValidateAPIKey resolves a raw key to a usable API key
+func (s *AuthService) ValidateAPIKey(ctx context.Context, raw string) (*APIKey, error) {
+	if !IsAPIKey(raw) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.FindByHash(ctx, hashToken(raw))
+	if err != nil || !key.IsUsable() {
+		return nil, ErrInvalidAPIKey
+	}
+	// Writes are attributed to the creator, so a key only works while they can sign in
+	if key.CreatedBy == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	creator, err := s.userRepo.FindByID(ctx, key.CreatedBy)
+	if err != nil || !creator.IsActive || creator.IsDeleted() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyLastUsedInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			fmt.Printf("⚠️ Warning: Failed to update last use of api key %d: %v\n", key.ID, err)
+		}
+	}
+	return key, nil
+}
+
+// APIKeyRateLimitedError is returned when a key exceeds its requests per minute
+type APIKeyRateLimitedError struct {
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *APIKeyRateLimitedError) Error() string {
+	return "api key rate limit exceeded"
+}
+
+// APIKeyLimiter counts requests per key in fixed one-minute windows
+type APIKeyLimiter struct {
+	store AttemptStore
+}
+
+// NewAPIKeyLimiter creates a limiter sharing the login throttle's counter store
+func NewAPIKeyLimiter(store AttemptStore) *APIKeyLimiter {
+	return &APIKeyLimiter{
+		store: store,
+	}
+}
+
+// Allow counts one request and returns the remaining budget for this minute
+// Store errors fail open, like the login throttle.
+func (l *APIKeyLimiter) Allow(ctx context.Context, key *APIKey) (int, error) {
+	window := time.Now().Truncate(time.Minute)
+	counterKey := fmt.Sprintf("apikey:%d:%d", key.ID, window.Unix())
+
+	count, err := l.store.Incr(ctx, counterKey, time.Minute)
+	if err != nil {
+		fmt.Printf("⚠️ Warning: Rate limit check failed for api key %d: %v\n", key.ID, err)
+		return key.RateLimit, nil
+	}
+
+	if count > int64(key.RateLimit) {
+		return 0, &APIKeyRateLimitedError{
+			Limit:      key.RateLimit,
+			RetryAfter: time.Until(window.Add(time.Minute)),
+		}
+	}
+	return key.RateLimit - int(count), nil
+}
@@ -0,0 +1,86 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"prabogo/internal/domain/auth"
+)
+
+// memoryAPIKeys is an in-memory auth.APIKeyRepository
+type memoryAPIKeys struct {
+	auth.APIKeyRepository
+	mu   sync.Mutex
+	keys []*auth.APIKey
+}
+
+func (r *memoryAPIKeys) Create(ctx context.Context, key *auth.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = len(r.keys) + 1
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *memoryAPIKeys) FindByHash(ctx context.Context, keyHash string) (*auth.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash {
+			copied := *k
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("api key not found")
+}
+
+func (r *memoryAPIKeys) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	return nil
+}
+
+// issueKey creates a tree:update key on behalf of an active admin
+func issueKey(t *testing.T) (*memoryUsers, *auth.AuthService, string) {
+	t.Helper()
+	users := &memoryUsers{users: map[string]*auth.User{}}
+	users.Create(context.Background(), &auth.User{ID: "admin", Username: "admin", Role: auth.RoleAdmin, IsActive: true})
+	service := auth.NewAuthService(users, nil, nil, nil, nil, nil, &memoryAPIKeys{}, nil, nil, nil, nil, discardAudit{})
+
+	issued, err := service.CreateAPIKey(context.Background(), auth.APIKeyRequest{
+		Name:   "sensor gateway",
+		Scopes: []auth.Permission{auth.PermTreeUpdate},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if _, err := service.ValidateAPIKey(context.Background(), issued.Key); err != nil {
+		t.Fatalf("ValidateAPIKey of a new key: %v", err)
+	}
+	return users, service, issued.Key
+}
+
+func TestValidateAPIKeyRejectsInactiveCreator(t *testing.T) {
+	users, service, raw := issueKey(t)
+	users.users["admin"].IsActive = false
+
+	if _, err := service.ValidateAPIKey(context.Background(), raw); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Fatalf("err = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestValidateAPIKeyRejectsDeletedCreator(t *testing.T) {
+	users, service, raw := issueKey(t)
+	now := time.Now()
+	users.users["admin"].DeletedAt = &now
+
+	if _, err := service.ValidateAPIKey(context.Background(), raw); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Fatalf("err = %v, want ErrInvalidAPIKey", err)
+	}
+
+	delete(users.users, "admin")
+	if _, err := service.ValidateAPIKey(context.Background(), raw); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Fatalf("err = %v after removing the creator, want ErrInvalidAPIKey", err)
+	}
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -16,11 +18,13 @@ type User struct {
 	IsActive     bool
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	DeletedAt    *time.Time // Set on tombstones: the row stays so attribution keeps resolving
 }
 
-// UserRole represents user permission level
+// UserRole is the name of a role (see Role); permissions come from the roles table
 type UserRole string
 
+// Built-in roles, seeded by migration
 const (
 	RoleAdmin  UserRole = "admin"
 	RoleEditor UserRole = "editor"
@@ -35,41 +39,33 @@ func (u *User) Validate() error {
 	if u.Email == "" {
 		return errors.New("email is required")
 	}
-	if !u.IsValidRole() {
-		return errors.New("invalid user role")
+	if u.Role == "" {
+		return errors.New("role is required")
 	}
 	return nil
 }
 
-// IsValidRole checks if role is valid
-func (u *User) IsValidRole() bool {
-	validRoles := []UserRole{RoleAdmin, RoleEditor, RoleViewer}
-	for _, role := range validRoles {
-		if u.Role == role {
-			return true
-		}
-	}
-	return false
-}
-
-// IsAdmin returns true if user is admin
+// IsAdmin returns true if user has the built-in admin role
 func (u *User) IsAdmin() bool {
 	return u.Role == RoleAdmin
 }
 
-// CanEdit returns true if user can edit (admin or editor)
-func (u *User) CanEdit() bool {
-	return u.Role == RoleAdmin || u.Role == RoleEditor
+// IsDeleted returns true for tombstones of deleted users
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
 }
 
-// CanDelete returns true if user can delete (admin only)
-func (u *User) CanDelete() bool {
-	return u.Role == RoleAdmin
+// DisplayName is the name shown for registered_by, monitored_by and similar lookups
+func (u *User) DisplayName() string {
+	if u.IsDeleted() {
+		return u.Username + " (deleted)"
+	}
+	return u.Username
 }
 
 // ToResponse converts User to safe response (without password)
 func (u *User) ToResponse() *UserResponse {
-	return &UserResponse{
+	resp := &UserResponse{
 		ID:        u.ID,
 		Username:  u.Username,
 		Email:     u.Email,
@@ -78,6 +74,10 @@ func (u *User) ToResponse() *UserResponse {
 		IsActive:  u.IsActive,
 		CreatedAt: u.CreatedAt.Format(time.RFC3339),
 	}
+	if u.DeletedAt != nil {
+		resp.DeletedAt = u.DeletedAt.Format(time.RFC3339)
+	}
+	return resp
 }
 
 // UserResponse for API responses (no password)
@@ -89,6 +89,7 @@ type UserResponse struct {
 	Role      string `json:"role"`
 	IsActive  bool   `json:"is_active"`
 	CreatedAt string `json:"created_at"`
+	DeletedAt string `json:"deleted_at,omitempty"`
 }
 
 // RegisterRequest for user registration
@@ -107,10 +108,60 @@ func (r *RegisterRequest) Validate() error {
 	if r.Email == "" {
 		return errors.New("email is required")
 	}
-	if r.Password == "" {
+	return ValidatePassword(r.Password)
+}
+
+// ProfileRequest edits a user's profile; nil fields stay unchanged
+type ProfileRequest struct {
+	FullName *string `json:"full_name"`
+	Email    *string `json:"email"`
+}
+
+// Validate profile request
+func (r *ProfileRequest) Validate() error {
+	if r.FullName == nil && r.Email == nil {
+		return errors.New("nothing to update")
+	}
+	if r.Email != nil {
+		if err := validateEmail(*r.Email); err != nil {
+			return err
+		}
+	}
+	if r.FullName != nil {
+		return validateUserText("full name", *r.FullName)
+	}
+	return nil
+}
+
+// validateEmail performs a basic shape check on an email address
+func validateEmail(email string) error {
+	if email == "" {
+		return errors.New("email is required")
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 || strings.ContainsAny(email, " \t\r\n") {
+		return errors.New("email is invalid")
+	}
+	return validateUserText("email", email)
+}
+
+// validateUserText keeps quotes out of values the user repository interpolates into AQL
+func validateUserText(field string, value string) error {
+	if strings.ContainsAny(value, `'\`) {
+		return fmt.Errorf("%s must not contain quotes or backslashes", field)
+	}
+	if len(value) > 100 {
+		return fmt.Errorf("%s must be at most 100 characters", field)
+	}
+	return nil
+}
+
+// ValidatePassword applies the password policy
+func ValidatePassword(password string) error {
+	if password == "" {
 		return errors.New("password is required")
 	}
-	if len(r.Password) < 6 {
+	if len(password) < 6 {
 		return errors.New("password must be at least 6 characters")
 	}
 	return nil
@@ -134,7 +185,52 @@ func (lr *LoginRequest) Validate() error {
 }
 
 // LoginResponse for successful login
+// Either the tokens or (with 2FA) a pre-auth token for the second step are set.
 type LoginResponse struct {
-	Token string        `json:"token"`
-	User  *UserResponse `json:"user"`
+	Token              string        `json:"token,omitempty"` // Access token
+	RefreshToken       string        `json:"refresh_token,omitempty"`
+	ExpiresIn          int           `json:"expires_in,omitempty"` // Access token lifetime in seconds
+	User               *UserResponse `json:"user,omitempty"`
+	TwoFactorRequired  bool          `json:"two_factor_required,omitempty"`
+	EnrollmentRequired bool          `json:"enrollment_required,omitempty"`
+	PreAuthToken       string        `json:"pre_auth_token,omitempty"`
+	RecoveryCodes      []string      `json:"recovery_codes,omitempty"` // Shown once, after enrolment
+}
+
+// RefreshRequest for renewing an access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ChangePasswordRequest for changing one's own password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Validate change password request
+func (r *ChangePasswordRequest) Validate() error {
+	if r.CurrentPassword == "" {
+		return errors.New("current password is required")
+	}
+	return ValidatePassword(r.NewPassword)
+}
+
+// ForgotPasswordRequest starts the reset flow
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest completes the reset flow
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// Validate reset password request
+func (r *ResetPasswordRequest) Validate() error {
+	if r.Token == "" {
+		return errors.New("token is required")
+	}
+	return ValidatePassword(r.NewPassword)
 }
@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/notification"
+	"prabogo/utils/activity"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvitationNotFound is returned for unknown invitation IDs
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrInvalidInvitation is returned for unknown, accepted, revoked or expired invitation tokens
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+)
+
+// Invitation statuses, derived from the timestamps
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation lets someone create their own account through an emailed link
+// The role is fixed by the admin who invited them; only the token hash is persisted.
+type Invitation struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	FullName       string     `json:"full_name"`
+	Role           UserRole   `json:"role"`
+	TokenHash      string     `json:"-"`
+	InvitedBy      string     `json:"invited_by"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	AcceptedUserID string     `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Status returns pending, accepted, revoked or expired
+func (i *Invitation) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case !time.Now().Before(i.ExpiresAt):
+		return InvitationExpired
+	}
+	return InvitationPending
+}
+
+// IsUsable returns true while the invitation can still be accepted
+func (i *Invitation) IsUsable() bool {
+	return i.Status() == InvitationPending
+}
+
+// InvitationResponse adds the derived status for API responses
+type InvitationResponse struct {
+	*Invitation
+	Status string `json:"status"`
+}
+
+// ToResponse converts Invitation to its API response
+func (i *Invitation) ToResponse() *InvitationResponse {
+	return &InvitationResponse{Invitation: i, Status: i.Status()}
+}
+
+// InvitationRepository persists invitations
+type InvitationRepository interface {
+	FindAll(ctx context.Context) ([]*Invitation, error)
+	FindByID(ctx context.Context, id string) (*Invitation, error)
+	FindByHash(ctx context.Context, tokenHash string) (*Invitation, error)
+	Create(ctx context.Context, invitation *Invitation) error
+
+	// UpdateToken replaces the token and expiry of a pending invitation (resend)
+	UpdateToken(ctx context.Context, id string, tokenHash string, expiresAt time.Time) error
+
+	// MarkAccepted consumes a pending invitation, failing if it was already used
+	MarkAccepted(ctx context.Context, id string, userID string) error
+
+	// Revoke cancels a pending invitation
+	Revoke(ctx context.Context, id string) error
+
+	// RevokePendingForEmail cancels every pending invitation for an address
+	RevokePendingForEmail(ctx context.Context, email string) error
+}
+
+// InviteRequest is the body for inviting a new user
+type InviteRequest struct {
+	Email    string   `json:"email"`
+	FullName string   `json:"full_name"`
+	Role     UserRole `json:"role"`
+}
+
+// Validate invite request
+func (r *InviteRequest) Validate() error {
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	r.FullName = strings.TrimSpace(r.FullName)
+	if err := validateEmail(r.Email); err != nil {
+		return err
+	}
+	if err := validateUserText("full name", r.FullName); err != nil {
+		return err
+	}
+	if r.Role == "" {
+		r.Role = RoleViewer
+	}
+	return nil
+}
+
+// AcceptInvitationRequest completes an invitation
+type AcceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	FullName string `json:"full_name"` // Optional, defaults to the name on the invitation
+}
+
+// Validate accept invitation request
+func (r *AcceptInvitationRequest) Validate() error {
+	r.Username = strings.TrimSpace(r.Username)
+	r.FullName = strings.TrimSpace(r.FullName)
+	if r.Token == "" {
+		return errors.New("token is required")
+	}
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	if len(r.Username) > 50 || strings.ContainsAny(r.Username, " \t'\\") {
+		return errors.New("username must be at most 50 characters without spaces, quotes or backslashes")
+	}
+	if err := validateUserText("full name", r.FullName); err != nil {
+		return err
+	}
+	return ValidatePassword(r.Password)
+}
+
+// InvitationPreview is what the accept page shows before the account exists
+type InvitationPreview struct {
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name"`
+	Role      UserRole  `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// GetInvitationExpiration returns how long an invitation link stays valid
+func GetInvitationExpiration() time.Duration {
+	return durationFromEnv("INVITATION_EXPIRATION", 7*24*time.Hour)
+}
+
+// invitationURL builds the link to the web accept page
+func invitationURL(token string) string {
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = "http://localhost:7000"
+	}
+	return strings.TrimRight(base, "/") + "/#/accept-invite/" + token
+}
+
+// ListInvitations returns all invitations, newest first
+func (s *AuthService) ListInvitations(ctx context.Context) ([]*Invitation, error) {
+	invitations, err := s.invitationRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// InviteUser emails an invitation link (admin action)
+// Earlier pending invitations for the same address stop working.
+func (s *AuthService) InviteUser(ctx context.Context, req InviteRequest, invitedBy string) (*Invitation, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if err := s.ensureRoleExists(ctx, req.Role); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.FindByEmail(ctx, req.Email); err == nil {
+		return nil, errors.New("email already exists")
+	}
+
+	if err := s.invitationRepo.RevokePendingForEmail(ctx, req.Email); err != nil {
+		return nil, fmt.Errorf("failed to revoke earlier invitations: %w", err)
+	}
+
+	raw, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	now := time.Now()
+	invitation := &Invitation{
+		ID:        uuid.New().String(),
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Role:      req.Role,
+		TokenHash: hashToken(raw),
+		InvitedBy: invitedBy,
+		ExpiresAt: now.Add(GetInvitationExpiration()),
+		CreatedAt: now,
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to store invitation: %w", err)
+	}
+
+	if err := s.sendInvitation(ctx, invitation, raw); err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.invite",
+		TargetType: audit.TargetInvitation,
+		TargetID:   invitation.ID,
+		After:      audit.Snapshot(invitation),
+	})
+	return invitation, nil
+}
+
+// ResendInvitation emails a fresh link for a pending or expired invitation
+// The previous link stops working and the expiry restarts.
+func (s *AuthService) ResendInvitation(ctx context.Context, id string) (*Invitation, error) {
+	invitation, err := s.invitationRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrInvitationNotFound
+	}
+	if status := invitation.Status(); status == InvitationAccepted || status == InvitationRevoked {
+		return nil, fmt.Errorf("invitation is already %s", status)
+	}
+	if _, err := s.userRepo.FindByEmail(ctx, invitation.Email); err == nil {
+		return nil, errors.New("email already exists")
+	}
+
+	raw, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	invitation.TokenHash = hashToken(raw)
+	invitation.ExpiresAt = time.Now().Add(GetInvitationExpiration())
+	if err := s.invitationRepo.UpdateToken(ctx, invitation.ID, invitation.TokenHash, invitation.ExpiresAt); err != nil {
+		return nil, fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	if err := s.sendInvitation(ctx, invitation, raw); err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.invite_resend",
+		TargetType: audit.TargetInvitation,
+		TargetID:   invitation.ID,
+		After:      audit.Snapshot(map[string]time.Time{"expires_at": invitation.ExpiresAt}),
+	})
+	return invitation, nil
+}
+
+// RevokeInvitation cancels a pending invitation (admin action)
+func (s *AuthService) RevokeInvitation(ctx context.Context, id string) error {
+	invitation, err := s.invitationRepo.FindByID(ctx, id)
+	if err != nil {
+		return ErrInvitationNotFound
+	}
+	if invitation.AcceptedAt != nil {
+		return errors.New("invitation is already accepted")
+	}
+	if err := s.invitationRepo.Revoke(ctx, id); err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.invite_revoke",
+		TargetType: audit.TargetInvitation,
+		TargetID:   id,
+		Before:     audit.Snapshot(invitation),
+	})
+	return nil
+}
+
+// GetInvitation returns what an invitation token grants, for the accept page
+func (s *AuthService) GetInvitation(ctx context.Context, token string) (*InvitationPreview, error) {
+	invitation, err := s.invitationRepo.FindByHash(ctx, hashToken(token))
+	if err != nil || !invitation.IsUsable() {
+		return nil, ErrInvalidInvitation
+	}
+	return &InvitationPreview{
+		Email:     invitation.Email,
+		FullName:  invitation.FullName,
+		Role:      invitation.Role,
+		ExpiresAt: invitation.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvitation creates the invited user with the role from the invitation
+// The new user then logs in normally, so the 2FA policy for their role applies.
+func (s *AuthService) AcceptInvitation(ctx context.Context, req AcceptInvitationRequest) (*User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	invitation, err := s.invitationRepo.FindByHash(ctx, hashToken(req.Token))
+	if err != nil || !invitation.IsUsable() {
+		return nil, ErrInvalidInvitation
+	}
+	if _, err := s.userRepo.FindByUsername(ctx, req.Username); err == nil {
+		return nil, errors.New("username already exists")
+	}
+	if _, err := s.userRepo.FindByEmail(ctx, invitation.Email); err == nil {
+		return nil, errors.New("email already exists")
+	}
+	if err := s.ensureRoleExists(ctx, invitation.Role); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	fullName := req.FullName
+	if fullName == "" {
+		fullName = invitation.FullName
+	}
+
+	user := &User{
+		ID:           "USR" + uuid.New().String()[:8],
+		Username:     req.Username,
+		Email:        invitation.Email,
+		PasswordHash: string(hashedPassword),
+		FullName:     fullName,
+		Role:         invitation.Role,
+		IsActive:     true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// users.email is unique, so a concurrent second attempt with the same token fails here
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if err := s.invitationRepo.MarkAccepted(ctx, invitation.ID, user.ID); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to mark invitation %s accepted: %v\n", invitation.ID, err)
+	}
+
+	ctx = activity.WithActorID(ctx, user.ID)
+	s.auditor.Record(ctx, audit.Entry{
+		ActorName:  user.Username,
+		Action:     "user.create",
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		After:      audit.Snapshot(map[string]interface{}{"user": user.ToResponse(), "invitation_id": invitation.ID}),
+	})
+	return user, nil
+}
+
+func (s *AuthService) sendInvitation(ctx context.Context, invitation *Invitation, raw string) error {
+	greeting := invitation.FullName
+	if greeting == "" {
+		greeting = invitation.Email
+	}
+
+	msg := notification.Message{
+		To:      invitation.Email,
+		Subject: "You're invited to Tree-ID",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"You have been invited to Tree-ID as %s.\n"+
+			"Open the link below within %s to choose a username and password:\n\n%s\n\n"+
+			"If you weren't expecting this, ignore this email.\n",
+			greeting, invitation.Role, GetInvitationExpiration(), invitationURL(raw)),
+	}
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send invitation email: %w", err)
+	}
+	return nil
+}
@@ -6,16 +6,28 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID   string   `json:"user_id"`
-	Username string   `json:"username"`
-	Role     UserRole `json:"role"`
+	UserID    string   `json:"user_id"`
+	Username  string   `json:"username"`
+	Role      UserRole `json:"role"`
+	SessionID string   `json:"sid"`               // Refresh token family, checked for revocation
+	Purpose   string   `json:"purpose,omitempty"` // Set on pre-auth tokens; empty for access tokens
 	jwt.RegisteredClaims
 }
 
+// Pre-auth token purposes (second login step)
+const (
+	PurposeTwoFactor       = "2fa"        // Password accepted, TOTP code pending
+	PurposeTwoFactorEnroll = "2fa_enroll" // Password accepted, role requires 2FA enrolment first
+)
+
+// preAuthExpiration is how long the second login step may take
+const preAuthExpiration = 5 * time.Minute
+
 // GetJWTSecret returns JWT secret from environment
 func GetJWTSecret() string {
 	secret := os.Getenv("JWT_SECRET")
@@ -25,21 +37,37 @@ func GetJWTSecret() string {
 	return secret
 }
 
-// GetJWTExpiration returns token expiration duration
+// GetJWTExpiration returns access token expiration duration
+// Access tokens are short-lived; clients renew them with a refresh token.
 func GetJWTExpiration() time.Duration {
-	// Default 24 hours
-	return 24 * time.Hour
+	return durationFromEnv("JWT_EXPIRATION", 15*time.Minute)
+}
+
+// GetRefreshExpiration returns refresh token (session) lifetime
+func GetRefreshExpiration() time.Duration {
+	return durationFromEnv("JWT_REFRESH_EXPIRATION", 7*24*time.Hour)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
 }
 
-// GenerateToken creates a JWT token for user
-func GenerateToken(user *User) (string, error) {
+// GenerateToken creates a JWT access token for user bound to a session
+func GenerateToken(user *User, sessionID string) (string, error) {
 	expirationTime := time.Now().Add(GetJWTExpiration())
 
 	claims := &Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -94,3 +122,34 @@ func GetUserRoleFromToken(tokenString string) (UserRole, error) {
 	}
 	return claims.Role, nil
 }
+
+// GeneratePreAuthToken creates a short-lived token that only proves the password step
+// It carries no session, so AuthMiddleware never accepts it as an access token.
+func GeneratePreAuthToken(user *User, purpose string) (string, error) {
+	claims := &Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Purpose:  purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(preAuthExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(GetJWTSecret()))
+}
+
+// ValidatePreAuthToken parses a pre-auth token and checks its purpose
+func ValidatePreAuthToken(tokenString string, purpose string) (*Claims, error) {
+	claims, err := ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, errors.New("invalid pre-auth token")
+	}
+	return claims, nil
+}
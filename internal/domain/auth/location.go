@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"prabogo/internal/domain/audit"
+)
+
+// UserLocationRepository stores which locations a field user may work in
+type UserLocationRepository interface {
+	FindByUser(ctx context.Context, userID string) ([]string, error)
+
+	// Replace sets the user's locations to exactly locationIDs
+	Replace(ctx context.Context, userID string, locationIDs []string, assignedBy string) error
+}
+
+// LocationsRequest is the body for assigning locations to a user
+type LocationsRequest struct {
+	LocationIDs []string `json:"location_ids"`
+}
+
+// GetUserLocations returns the locations assigned to a user
+// Users whose role holds tree:all_locations are not restricted by them.
+func (s *AuthService) GetUserLocations(ctx context.Context, userID string) ([]string, error) {
+	locationIDs, err := s.locationRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user locations: %w", err)
+	}
+	if locationIDs == nil {
+		locationIDs = []string{}
+	}
+	return locationIDs, nil
+}
+
+// SetUserLocations replaces a user's assigned locations (admin action)
+func (s *AuthService) SetUserLocations(ctx context.Context, userID string, locationIDs []string, assignedBy string) ([]string, error) {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	before, err := s.GetUserLocations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	after := dedupeLocations(locationIDs)
+	if err := s.locationRepo.Replace(ctx, userID, after, assignedBy); err != nil {
+		return nil, fmt.Errorf("failed to assign locations: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.update_locations",
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+		Before:     audit.Snapshot(map[string][]string{"location_ids": before}),
+		After:      audit.Snapshot(map[string][]string{"location_ids": after}),
+	})
+	return after, nil
+}
+
+func dedupeLocations(locationIDs []string) []string {
+	seen := map[string]bool{}
+	list := []string{}
+	for _, id := range locationIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		list = append(list, id)
+	}
+	sort.Strings(list)
+	return list
+}
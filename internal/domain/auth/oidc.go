@@ -0,0 +1,437 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrOIDCDisabled is returned when no identity provider is configured
+	ErrOIDCDisabled = errors.New("single sign-on is not configured")
+
+	// ErrOIDCState is returned when the callback does not belong to a login we started
+	ErrOIDCState = errors.New("invalid or expired single sign-on state, please start again")
+
+	// ErrOIDCNoAccount is returned when no local user matches and provisioning is off
+	ErrOIDCNoAccount = errors.New("no account is linked to this identity")
+)
+
+// OIDCClaims are the verified ID token claims the identity provider returns
+type OIDCClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string // preferred_username
+	Name          string
+	Groups        []string
+	Nonce         string
+}
+
+// IdentityProvider runs the authorization-code flow against an OIDC provider
+type IdentityProvider interface {
+	// AuthCodeURL returns the provider's authorization URL for one login
+	AuthCodeURL(state string, nonce string, codeChallenge string) string
+
+	// Exchange redeems code with the PKCE verifier and returns the verified ID token claims
+	Exchange(ctx context.Context, code string, codeVerifier string) (*OIDCClaims, error)
+}
+
+// UserIdentity links a provider account (issuer + subject) to a local user
+type UserIdentity struct {
+	Issuer    string
+	Subject   string
+	UserID    string
+	Email     string
+	CreatedAt time.Time
+}
+
+// UserIdentityRepository persists provider account links
+type UserIdentityRepository interface {
+	// FindUserID returns the linked user ID, or "" when the identity is unknown
+	FindUserID(ctx context.Context, issuer string, subject string) (string, error)
+	Create(ctx context.Context, identity *UserIdentity) error
+
+	// DeleteForUser removes every link of a user (account deletion)
+	DeleteForUser(ctx context.Context, userID string) error
+}
+
+// GroupRole maps a provider group to a local role
+type GroupRole struct {
+	Group string
+	Role  UserRole
+}
+
+// OIDCConfig controls how provider identities become local users
+type OIDCConfig struct {
+	AutoProvision bool        // Create unknown users on first login
+	DefaultRole   UserRole    // Role of provisioned users no group maps
+	GroupRoles    []GroupRole // First matching group wins; empty disables role sync
+}
+
+// GetOIDCConfig reads OIDC_* settings from environment
+// OIDC_GROUP_ROLES is "group=role,group=role", highest priority first.
+func GetOIDCConfig() OIDCConfig {
+	config := OIDCConfig{
+		AutoProvision: os.Getenv("OIDC_AUTO_PROVISION") != "false",
+		DefaultRole:   RoleViewer,
+	}
+	if role := os.Getenv("OIDC_DEFAULT_ROLE"); role != "" {
+		config.DefaultRole = UserRole(role)
+	}
+	for _, pair := range strings.Split(os.Getenv("OIDC_GROUP_ROLES"), ",") {
+		group, role, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && group != "" && role != "" {
+			config.GroupRoles = append(config.GroupRoles, GroupRole{Group: group, Role: UserRole(role)})
+		}
+	}
+	return config
+}
+
+// roleForGroups returns the role of the first mapping whose group the user is in
+func (c OIDCConfig) roleForGroups(groups []string) (UserRole, bool) {
+	member := map[string]bool{}
+	for _, g := range groups {
+		member[g] = true
+	}
+	for _, m := range c.GroupRoles {
+		if member[m.Group] {
+			return m.Role, true
+		}
+	}
+	return "", false
+}
+
+// OIDCLogin bundles the provider with its account mapping
+type OIDCLogin struct {
+	provider   IdentityProvider
+	identities UserIdentityRepository
+	states     AttemptStore // Counts callbacks per state so each login completes once
+	config     OIDCConfig
+}
+
+// NewOIDCLogin creates single sign-on support for AuthService
+func NewOIDCLogin(provider IdentityProvider, identities UserIdentityRepository, states AttemptStore, config OIDCConfig) *OIDCLogin {
+	return &OIDCLogin{
+		provider:   provider,
+		identities: identities,
+		states:     states,
+		config:     config,
+	}
+}
+
+// oidcStateExpiration is how long the user may spend at the provider
+const oidcStateExpiration = 10 * time.Minute
+
+// oidcStateClaims travel in a signed cookie between login start and callback
+type oidcStateClaims struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"cv"`
+	jwt.RegisteredClaims
+}
+
+// OIDCEnabled reports whether single sign-on is configured
+func (s *AuthService) OIDCEnabled() bool {
+	return s.oidc != nil
+}
+
+// StartOIDCLogin returns the provider URL and the signed state the client must keep
+// The state token holds the PKCE verifier and nonce; it is only readable by us
+// because the browser keeps it in an HttpOnly cookie.
+func (s *AuthService) StartOIDCLogin(ctx context.Context) (authURL string, stateToken string, err error) {
+	if s.oidc == nil {
+		return "", "", ErrOIDCDisabled
+	}
+
+	var state, nonce, verifier string
+	for _, v := range []*string{&state, &nonce, &verifier} {
+		if *v, err = generateOpaqueToken(); err != nil {
+			return "", "", fmt.Errorf("failed to start single sign-on: %w", err)
+		}
+	}
+
+	claims := &oidcStateClaims{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "oidc_state",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(oidcStateExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	stateToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(GetJWTSecret()))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign single sign-on state: %w", err)
+	}
+
+	return s.oidc.provider.AuthCodeURL(state, nonce, pkceChallenge(verifier)), stateToken, nil
+}
+
+// pkceChallenge derives the S256 code challenge from a verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func parseOIDCState(stateToken string) (*oidcStateClaims, error) {
+	claims := &oidcStateClaims{}
+	token, err := jwt.ParseWithClaims(stateToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return []byte(GetJWTSecret()), nil
+	})
+	if err != nil || !token.Valid || claims.Subject != "oidc_state" {
+		return nil, ErrOIDCState
+	}
+	return claims, nil
+}
+
+// CompleteOIDCLogin redeems the provider callback and opens a session
+// The result is the same as a password login, including the 2FA step.
+func (s *AuthService) CompleteOIDCLogin(ctx context.Context, stateToken string, state string, code string) (*LoginResult, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	pending, err := parseOIDCState(stateToken)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(pending.State), []byte(state)) != 1 {
+		return nil, ErrOIDCState
+	}
+	if code == "" {
+		return nil, errors.New("authorization code is required")
+	}
+
+	// A state is good for one callback; replays of the cookie are rejected
+	used, err := s.oidc.states.Incr(ctx, "oidc_state:"+pending.State, oidcStateExpiration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check single sign-on state: %w", err)
+	}
+	if used > 1 {
+		return nil, ErrOIDCState
+	}
+
+	claims, err := s.oidc.provider.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("single sign-on failed: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(pending.Nonce)) != 1 {
+		return nil, errors.New("single sign-on failed: nonce mismatch")
+	}
+
+	// The user repository builds AQL strings: keep quotes in provider claims out of it
+	if strings.ContainsAny(claims.Email, `'\`) {
+		return nil, errors.New("single sign-on failed: unsupported characters in email")
+	}
+	claims.Name = strings.NewReplacer("'", "", `\`, "").Replace(claims.Name)
+
+	user, err := s.resolveOIDCUser(ctx, claims)
+	if err != nil {
+		s.auditor.Record(ctx, audit.Entry{
+			ActorName:  claims.Email,
+			Action:     "auth.login_failed",
+			TargetType: audit.TargetSession,
+			After:      audit.Snapshot(map[string]string{"method": "oidc", "subject": claims.Subject, "reason": err.Error()}),
+		})
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, errors.New("user account is inactive")
+	}
+
+	if err := s.syncOIDCRole(ctx, user, claims.Groups); err != nil {
+		return nil, err
+	}
+
+	result, err := s.secondStep(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Tokens != nil {
+		s.auditor.Record(ctx, audit.Entry{
+			ActorID:    user.ID,
+			ActorName:  user.Username,
+			Action:     "auth.login",
+			TargetType: audit.TargetSession,
+			TargetID:   user.ID,
+			After:      audit.Snapshot(map[string]string{"method": "oidc", "issuer": claims.Issuer}),
+		})
+	}
+	return result, nil
+}
+
+// resolveOIDCUser finds the linked user, links by verified email, or provisions one
+func (s *AuthService) resolveOIDCUser(ctx context.Context, claims *OIDCClaims) (*User, error) {
+	userID, err := s.oidc.identities.FindUserID(ctx, claims.Issuer, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up identity: %w", err)
+	}
+	if userID != "" {
+		return s.userRepo.FindByID(ctx, userID)
+	}
+
+	// Unverified emails could be set to anyone's address at the provider
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, fmt.Errorf("%w (the provider did not return a verified email)", ErrOIDCNoAccount)
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, claims.Email)
+	if err != nil {
+		if !s.oidc.config.AutoProvision {
+			return nil, ErrOIDCNoAccount
+		}
+		if user, err = s.provisionOIDCUser(ctx, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.oidc.identities.Create(ctx, &UserIdentity{
+		Issuer:    claims.Issuer,
+		Subject:   claims.Subject,
+		UserID:    user.ID,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		Action:     "user.link_identity",
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		After:      audit.Snapshot(map[string]string{"issuer": claims.Issuer, "subject": claims.Subject}),
+	})
+	return user, nil
+}
+
+// usernameInvalidChars strips what local usernames never contain
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// provisionOIDCUser creates a local user for a first-time provider login
+// The random password can only be replaced through the reset flow.
+func (s *AuthService) provisionOIDCUser(ctx context.Context, claims *OIDCClaims) (*User, error) {
+	role := s.oidc.config.DefaultRole
+	if mapped, ok := s.oidc.config.roleForGroups(claims.Groups); ok {
+		role = mapped
+	}
+	if err := s.ensureRoleExists(ctx, role); err != nil {
+		return nil, err
+	}
+
+	username, err := s.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	fullName := claims.Name
+	if fullName == "" {
+		fullName = username
+	}
+
+	user := &User{
+		ID:           "USR" + uuid.New().String()[:8],
+		Username:     username,
+		Email:        claims.Email,
+		PasswordHash: string(hashedPassword),
+		FullName:     fullName,
+		Role:         role,
+		IsActive:     true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		Action:     "user.create",
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		After:      audit.Snapshot(map[string]interface{}{"user": user.ToResponse(), "method": "oidc"}),
+	})
+	return user, nil
+}
+
+// availableUsername derives a free username from preferred_username or the email
+func (s *AuthService) availableUsername(ctx context.Context, claims *OIDCClaims) (string, error) {
+	base := claims.Username
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameInvalidChars.ReplaceAllString(strings.ToLower(base), "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 2; i <= 100; i++ {
+		if _, err := s.userRepo.FindByUsername(ctx, candidate); err != nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	return "", errors.New("could not find a free username")
+}
+
+// syncOIDCRole applies the group mapping on every login, so the provider
+// stays the source of truth; users in no mapped group keep their role
+func (s *AuthService) syncOIDCRole(ctx context.Context, user *User, groups []string) error {
+	role, ok := s.oidc.config.roleForGroups(groups)
+	if !ok || role == user.Role {
+		return nil
+	}
+	if err := s.ensureRoleExists(ctx, role); err != nil {
+		return err
+	}
+
+	before := user.ToResponse()
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		Action:     "user.update_role",
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(map[string]interface{}{"user": user.ToResponse(), "method": "oidc_groups"}),
+	})
+	return nil
+}
@@ -0,0 +1,328 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"prabogo/internal/adapter/outbound/attempt_store"
+	"prabogo/internal/adapter/outbound/oidc_provider"
+	"prabogo/internal/adapter/outbound/oidc_provider/oidctest"
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/auth"
+)
+
+// memoryUsers is an in-memory auth.UserRepository
+type memoryUsers struct {
+	mu    sync.Mutex
+	users map[string]*auth.User
+}
+
+func (r *memoryUsers) Create(ctx context.Context, user *auth.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryUsers) find(match func(*auth.User) bool) (*auth.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id string) (*auth.User, error) {
+	return r.find(func(u *auth.User) bool { return u.ID == id })
+}
+
+func (r *memoryUsers) FindByUsername(ctx context.Context, username string) (*auth.User, error) {
+	return r.find(func(u *auth.User) bool { return u.Username == username })
+}
+
+func (r *memoryUsers) FindByEmail(ctx context.Context, email string) (*auth.User, error) {
+	return r.find(func(u *auth.User) bool { return u.Email == email })
+}
+
+func (r *memoryUsers) FindAll(ctx context.Context) ([]*auth.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []*auth.User
+	for _, u := range r.users {
+		all = append(all, u)
+	}
+	return all, nil
+}
+
+func (r *memoryUsers) Update(ctx context.Context, user *auth.User) error {
+	return r.Create(ctx, user)
+}
+
+func (r *memoryUsers) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[id].PasswordHash = passwordHash
+	return nil
+}
+
+// memoryIdentities is an in-memory auth.UserIdentityRepository
+type memoryIdentities struct {
+	mu    sync.Mutex
+	links map[string]string
+}
+
+func (r *memoryIdentities) FindUserID(ctx context.Context, issuer string, subject string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.links[issuer+"|"+subject], nil
+}
+
+func (r *memoryIdentities) Create(ctx context.Context, identity *auth.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.links[identity.Issuer+"|"+identity.Subject] = identity.UserID
+	return nil
+}
+
+func (r *memoryIdentities) DeleteForUser(ctx context.Context, userID string) error {
+	return nil
+}
+
+// builtInRoles knows the three built-in roles; other methods are not used by login
+type builtInRoles struct {
+	auth.RoleRepository
+}
+
+func (builtInRoles) FindByName(ctx context.Context, name auth.UserRole) (*auth.Role, error) {
+	switch name {
+	case auth.RoleAdmin, auth.RoleEditor, auth.RoleViewer:
+		return &auth.Role{Name: name, BuiltIn: true}, nil
+	}
+	return nil, errors.New("role not found")
+}
+
+// acceptSessions stores nothing; login only creates sessions
+type acceptSessions struct {
+	auth.SessionRepository
+}
+
+func (acceptSessions) Create(ctx context.Context, token *auth.RefreshToken) error { return nil }
+
+func (acceptSessions) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// noTwoFactor reports no enrolment for anyone
+type noTwoFactor struct {
+	auth.TwoFactorRepository
+}
+
+func (noTwoFactor) Find(ctx context.Context, userID string) (*auth.TwoFactor, error) { return nil, nil }
+
+type discardAudit struct{}
+
+func (discardAudit) Record(ctx context.Context, entry audit.Entry) {}
+
+// ssoFixture wires AuthService to a mock provider
+type ssoFixture struct {
+	idp     *oidctest.Server
+	users   *memoryUsers
+	service *auth.AuthService
+}
+
+func newSSOFixture(t *testing.T, config auth.OIDCConfig) *ssoFixture {
+	t.Helper()
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "")
+
+	idp := oidctest.NewServer()
+	t.Cleanup(idp.Close)
+
+	provider, err := oidc_provider.NewProvider(context.Background(), oidc_provider.Config{
+		Issuer:      idp.URL,
+		ClientID:    oidctest.ClientID,
+		RedirectURL: oidctest.RedirectURL,
+		Scopes:      []string{"openid", "email", "groups"},
+		GroupsClaim: "groups",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	users := &memoryUsers{users: map[string]*auth.User{}}
+	login := auth.NewOIDCLogin(provider, &memoryIdentities{links: map[string]string{}}, attempt_store.NewMemoryStore(), config)
+	service := auth.NewAuthService(users, acceptSessions{}, nil, noTwoFactor{}, builtInRoles{}, nil, nil, nil, nil, nil, login, discardAudit{})
+
+	return &ssoFixture{idp: idp, users: users, service: service}
+}
+
+// start begins a login and returns the state cookie with the provider's callback
+func (f *ssoFixture) start(t *testing.T) (stateToken string, state string, code string) {
+	t.Helper()
+	authURL, stateToken, err := f.service.StartOIDCLogin(context.Background())
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("code_challenge_method") != "S256" || u.Query().Get("nonce") == "" {
+		t.Fatalf("authorization URL lacks PKCE or nonce: %s", authURL)
+	}
+
+	code, state, err = f.idp.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	return stateToken, state, code
+}
+
+func (f *ssoFixture) login(t *testing.T) (*auth.LoginResult, error) {
+	t.Helper()
+	stateToken, state, code := f.start(t)
+	return f.service.CompleteOIDCLogin(context.Background(), stateToken, state, code)
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{AutoProvision: true, DefaultRole: auth.RoleViewer})
+
+	result, err := f.login(t)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if result.Tokens == nil || result.Tokens.AccessToken == "" {
+		t.Fatal("login returned no tokens")
+	}
+	if result.User.Email != "mock.user@example.com" || result.User.Username != "mock.user" || result.User.Role != auth.RoleViewer {
+		t.Errorf("provisioned user = %+v", result.User)
+	}
+
+	// The second login finds the linked identity instead of provisioning again
+	again, err := f.login(t)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.User.ID != result.User.ID {
+		t.Errorf("second login user = %s, want %s", again.User.ID, result.User.ID)
+	}
+}
+
+func TestOIDCLoginLinksVerifiedEmail(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{AutoProvision: false, DefaultRole: auth.RoleViewer})
+	existing := &auth.User{ID: "USR1", Username: "mandor", Email: "mock.user@example.com", Role: auth.RoleEditor, IsActive: true}
+	f.users.Create(context.Background(), existing)
+
+	result, err := f.login(t)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if result.User.ID != existing.ID {
+		t.Errorf("logged in as %s, want the existing user %s", result.User.ID, existing.ID)
+	}
+}
+
+func TestOIDCLoginRejectsUnverifiedEmail(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{AutoProvision: true, DefaultRole: auth.RoleViewer})
+	f.users.Create(context.Background(), &auth.User{ID: "USR1", Username: "admin", Email: "admin@example.com", Role: auth.RoleAdmin, IsActive: true})
+	f.idp.SetIdentity(oidctest.Identity{Subject: "attacker", Email: "admin@example.com", EmailVerified: false})
+
+	_, err := f.login(t)
+	if !errors.Is(err, auth.ErrOIDCNoAccount) {
+		t.Fatalf("err = %v, want ErrOIDCNoAccount", err)
+	}
+}
+
+func TestOIDCLoginRejectsBadSignature(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{AutoProvision: true, DefaultRole: auth.RoleViewer})
+	f.idp.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "a@example.com", EmailVerified: true, WrongKey: true})
+
+	_, err := f.login(t)
+	if err == nil || !strings.Contains(err.Error(), "invalid id_token") {
+		t.Fatalf("err = %v, want invalid id_token", err)
+	}
+}
+
+func TestOIDCLoginRejectsWrongNonce(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{AutoProvision: true, DefaultRole: auth.RoleViewer})
+	f.idp.SetIdentity(oidctest.Identity{Subject: "sub-1", Email: "a@example.com", EmailVerified: true, Nonce: "someone-elses-nonce"})
+
+	_, err := f.login(t)
+	if err == nil || !strings.Contains(err.Error(), "nonce mismatch") {
+		t.Fatalf("err = %v, want nonce mismatch", err)
+	}
+}
+
+func TestOIDCLoginRejectsWrongState(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{AutoProvision: true, DefaultRole: auth.RoleViewer})
+
+	stateToken, _, code := f.start(t)
+	_, err := f.service.CompleteOIDCLogin(context.Background(), stateToken, "forged-state", code)
+	if !errors.Is(err, auth.ErrOIDCState) {
+		t.Fatalf("err = %v, want ErrOIDCState", err)
+	}
+}
+
+func TestOIDCLoginRejectsReplayedState(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{AutoProvision: true, DefaultRole: auth.RoleViewer})
+
+	stateToken, state, code := f.start(t)
+	if _, err := f.service.CompleteOIDCLogin(context.Background(), stateToken, state, code); err != nil {
+		t.Fatalf("first callback: %v", err)
+	}
+
+	// A fresh code does not make the used state cookie valid again
+	_, _, other := f.start(t)
+	_, err := f.service.CompleteOIDCLogin(context.Background(), stateToken, state, other)
+	if !errors.Is(err, auth.ErrOIDCState) {
+		t.Fatalf("err = %v, want ErrOIDCState", err)
+	}
+}
+
+func TestOIDCLoginMapsGroupsToRoles(t *testing.T) {
+	f := newSSOFixture(t, auth.OIDCConfig{
+		AutoProvision: true,
+		DefaultRole:   auth.RoleViewer,
+		GroupRoles: []auth.GroupRole{
+			{Group: "it-admins", Role: auth.RoleAdmin},
+			{Group: "foresters", Role: auth.RoleEditor},
+		},
+	})
+	identity := oidctest.Identity{Subject: "sub-7", Email: "ranger@example.com", EmailVerified: true, Groups: []string{"staff", "foresters"}}
+	f.idp.SetIdentity(identity)
+
+	result, err := f.login(t)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if result.User.Role != auth.RoleEditor {
+		t.Errorf("provisioned role = %s, want editor", result.User.Role)
+	}
+
+	// Group changes at the provider apply on the next login; the first mapping wins
+	identity.Groups = []string{"foresters", "it-admins"}
+	f.idp.SetIdentity(identity)
+	if result, err = f.login(t); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if result.User.Role != auth.RoleAdmin {
+		t.Errorf("synced role = %s, want admin", result.User.Role)
+	}
+
+	// Users in no mapped group keep their role
+	identity.Groups = nil
+	f.idp.SetIdentity(identity)
+	if result, err = f.login(t); err != nil {
+		t.Fatalf("third login: %v", err)
+	}
+	if result.User.Role != auth.RoleAdmin {
+		t.Errorf("role without mapped groups = %s, want admin kept", result.User.Role)
+	}
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/notification"
+	"prabogo/utils/activity"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidResetToken is returned for unknown, used or expired reset tokens
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+	// ErrWrongPassword is returned when the current password does not match
+	ErrWrongPassword = errors.New("current password is incorrect")
+)
+
+// PasswordResetToken is a single-use token sent to a user's email
+// Only the hash of the token is persisted.
+type PasswordResetToken struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+	IP        string
+}
+
+// IsUsable returns true if the token has neither been used nor expired
+func (t *PasswordResetToken) IsUsable() bool {
+	return t.UsedAt == nil && time.Now().Before(t.ExpiresAt)
+}
+
+// PasswordResetRepository persists reset tokens
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+
+	// MarkUsed consumes a token, failing if it was already used
+	MarkUsed(ctx context.Context, id string) error
+
+	// InvalidateForUser consumes every outstanding token of a user
+	InvalidateForUser(ctx context.Context, userID string) error
+}
+
+// GetPasswordResetExpiration returns how long a reset link stays valid
+func GetPasswordResetExpiration() time.Duration {
+	return durationFromEnv("PASSWORD_RESET_EXPIRATION", 30*time.Minute)
+}
+
+// passwordResetURL builds the link to the web reset page
+func passwordResetURL(token string) string {
+	base := os.Getenv("PUBLIC_BASE_URL")
+	if base == "" {
+		base = "http://localhost:7000"
+	}
+	return strings.TrimRight(base, "/") + "/#/reset-password/" + token
+}
+
+// ChangePassword updates the password of a signed-in user
+// Every existing session is revoked; the caller receives a fresh one.
+func (s *AuthService) ChangePassword(ctx context.Context, userID string, req ChangePasswordRequest) (*TokenPair, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		s.auditor.Record(ctx, audit.Entry{
+			Action:     "auth.password_change_failed",
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+		})
+		return nil, ErrWrongPassword
+	}
+
+	if err := s.setPassword(ctx, user, req.NewPassword, "auth.password_change"); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// ForgotPassword emails a reset link if the address belongs to an active user
+// Unknown addresses are not reported, so the endpoint cannot be used to probe accounts.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	if email == "" {
+		return errors.New("email is required")
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || !user.IsActive {
+		return nil
+	}
+
+	// Only the newest link works
+	if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+
+	raw, err := generateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	ip, _ := activity.GetClientIP(ctx)
+	now := time.Now()
+	token := &PasswordResetToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(GetPasswordResetExpiration()),
+		CreatedAt: now,
+		IP:        ip,
+	}
+	if err := s.resetRepo.Create(ctx, token); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	msg := notification.Message{
+		To:      user.Email,
+		Subject: "Reset your Tree-ID password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone requested a password reset for your Tree-ID account.\n"+
+			"Open the link below within %s to choose a new password:\n\n%s\n\n"+
+			"If this wasn't you, ignore this email; your password stays unchanged.\n",
+			user.Username, GetPasswordResetExpiration(), passwordResetURL(raw)),
+	}
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send reset email: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		Action:     "auth.password_reset_requested",
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
+	return nil
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword
+func (s *AuthService) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	token, err := s.resetRepo.FindByHash(ctx, hashToken(req.Token))
+	if err != nil || !token.IsUsable() {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil || !user.IsActive {
+		return ErrInvalidResetToken
+	}
+
+	// Consume first: a concurrent second attempt with the same token fails here
+	if err := s.resetRepo.MarkUsed(ctx, token.ID); err != nil {
+		return ErrInvalidResetToken
+	}
+
+	ctx = activity.WithActorID(ctx, user.ID)
+	return s.setPassword(ctx, user, req.NewPassword, "auth.password_reset")
+}
+
+// setPassword stores a new hash and signs the user out everywhere
+func (s *AuthService) setPassword(ctx context.Context, user *User, password string, action string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	user.PasswordHash = string(hashedPassword)
+
+	revoked, err := s.sessionRepo.RevokeAllForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to invalidate reset tokens for %s: %v\n", user.ID, err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		After:      audit.Snapshot(map[string]int64{"revoked_tokens": revoked}),
+	})
+	return nil
+}
@@ -0,0 +1,404 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"prabogo/internal/domain/audit"
+)
+
+// Permission is a named capability such as "tree:create"
+type Permission string
+
+const (
+	PermTreeCreate        Permission = "tree:create"
+	PermTreeUpdate        Permission = "tree:update"
+	PermTreeDelete        Permission = "tree:delete"
+	PermTreeRestore       Permission = "tree:restore"
+	PermTreePurge         Permission = "tree:purge"
+	PermTreeAllLocs       Permission = "tree:all_locations"
+	PermTagPrint          Permission = "tag:print"
+	PermReportView        Permission = "report:view"
+	PermReportExport      Permission = "report:export"
+	PermUserManage        Permission = "user:manage"
+	PermRoleManage        Permission = "role:manage"
+	PermAuditRead         Permission = "audit:read"
+	PermClientManage      Permission = "client:manage"
+	PermMaintenanceManage Permission = "maintenance:manage"
+	PermTaskManage        Permission = "task:manage"
+	PermInspectionManage  Permission = "inspection:manage"
+	PermApplicationRecord Permission = "application:record"
+	PermDiagnosisManage   Permission = "diagnosis:manage"
+	PermHarvestRecord     Permission = "harvest:record"
+)
+
+// PermissionInfo describes a permission for role editors
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+}
+
+// Permissions is the catalog of every permission the API checks
+var Permissions = []PermissionInfo{
+	{PermTreeCreate, "Register new trees"},
+	{PermTreeUpdate, "Update tree status and correct registration data"},
+	{PermTreeDelete, "Move trees to the trash"},
+	{PermTreeRestore, "View the trash and restore trees"},
+	{PermTreePurge, "Permanently delete trees from the trash"},
+	{PermTreeAllLocs, "Access trees in every location (otherwise only assigned locations)"},
+	{PermTagPrint, "Print QR label sheets"},
+	{PermReportView, "View statistics and dashboards"},
+	{PermReportExport, "Export trees and monitoring history"},
+	{PermUserManage, "Create users, change their role, revoke sessions and unlock accounts"},
+	{PermRoleManage, "Create and edit roles"},
+	{PermAuditRead, "Read and verify the audit log"},
+	{PermClientManage, "Issue, rotate and revoke API keys for machine clients"},
+	{PermMaintenanceManage, "Configure maintenance priority rules"},
+	{PermTaskManage, "Create, assign, verify and reject work orders"},
+	{PermInspectionManage, "Configure recurring inspection schedules"},
+	{PermApplicationRecord, "Record and delete fertilizer and pesticide applications"},
+	{PermDiagnosisManage, "Edit the pest and disease catalog and acknowledge outbreak alerts"},
+	{PermHarvestRecord, "Record and delete harvests"},
+}
+
+// IsKnownPermission reports whether p is in the catalog
+func IsKnownPermission(p Permission) bool {
+	for _, info := range Permissions {
+		if info.Name == p {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// ErrRoleNotFound is returned for unknown role names
+	ErrRoleNotFound = errors.New("role not found")
+
+	// ErrRoleBuiltIn is returned when deleting a built-in role or editing admin
+	ErrRoleBuiltIn = errors.New("built-in role cannot be changed")
+
+	// ErrRoleInUse is returned when deleting a role that is still assigned
+	ErrRoleInUse = errors.New("role is assigned to users")
+
+	// ErrOwnRole is returned when users try to change their own role
+	ErrOwnRole = errors.New("cannot change your own role")
+
+	// ErrPrivilegeEscalation is returned when an account change reaches beyond the caller's permissions
+	ErrPrivilegeEscalation = errors.New("cannot manage users or roles with permissions you do not hold")
+)
+
+// Role groups permissions; users reference a role by name
+type Role struct {
+	Name        UserRole     `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	BuiltIn     bool         `json:"built_in"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// RoleRepository persists roles and their permissions
+type RoleRepository interface {
+	FindAll(ctx context.Context) ([]*Role, error)
+	FindByName(ctx context.Context, name UserRole) (*Role, error)
+	Create(ctx context.Context, role *Role) error
+
+	// Update replaces description and permissions
+	Update(ctx context.Context, role *Role) error
+
+	Delete(ctx context.Context, name UserRole) error
+}
+
+// RoleRequest is the body for creating or editing a role
+type RoleRequest struct {
+	Name        UserRole     `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// roleNamePattern fits users.role (VARCHAR(20))
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,19}$`)
+
+// Validate checks the permission list (and the name when creating)
+func (r *RoleRequest) Validate(creating bool) error {
+	if creating && !roleNamePattern.MatchString(string(r.Name)) {
+		return errors.New("role name must be 2-20 lowercase letters, digits, '-' or '_'")
+	}
+	for _, p := range r.Permissions {
+		if !IsKnownPermission(p) {
+			return fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	return nil
+}
+
+// PermissionSet is the resolved set of permissions of one role
+type PermissionSet map[Permission]bool
+
+// Has returns true if every given permission is in the set
+func (s PermissionSet) Has(perms ...Permission) bool {
+	for _, p := range perms {
+		if !s[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// Covers returns true if s holds every permission of other
+func (s PermissionSet) Covers(other PermissionSet) bool {
+	for p := range other {
+		if !s[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the permissions sorted by name
+func (s PermissionSet) List() []Permission {
+	list := make([]Permission, 0, len(s))
+	for p := range s {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// roleCacheTTL bounds how long another instance's role edit takes to apply here
+const roleCacheTTL = 30 * time.Second
+
+// roleCache keeps resolved permission sets so every request doesn't hit the database
+type roleCache struct {
+	mu      sync.RWMutex
+	sets    map[UserRole]PermissionSet
+	expires time.Time
+}
+
+func (c *roleCache) get(role UserRole) (PermissionSet, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if time.Now().After(c.expires) {
+		return nil, false
+	}
+	set, ok := c.sets[role]
+	return set, ok
+}
+
+func (c *roleCache) load(roles []*Role) {
+	sets := make(map[UserRole]PermissionSet, len(roles))
+	for _, r := range roles {
+		sets[r.Name] = permissionSetFor(r)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sets = sets
+	c.expires = time.Now().Add(roleCacheTTL)
+}
+
+func (c *roleCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expires = time.Time{}
+}
+
+// permissionSetFor resolves a role; admin always holds every permission so
+// the system can never lose its last role manager
+func permissionSetFor(role *Role) PermissionSet {
+	set := PermissionSet{}
+	if role.Name == RoleAdmin {
+		for _, info := range Permissions {
+			set[info.Name] = true
+		}
+		return set
+	}
+	for _, p := range role.Permissions {
+		set[p] = true
+	}
+	return set
+}
+
+// PermissionsForRole returns the permission set of a role (empty for unknown roles)
+func (s *AuthService) PermissionsForRole(ctx context.Context, role UserRole) (PermissionSet, error) {
+	if set, ok := s.roles.get(role); ok {
+		return set, nil
+	}
+
+	roles, err := s.roleRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+	s.roles.load(roles)
+
+	if set, ok := s.roles.get(role); ok {
+		return set, nil
+	}
+	return PermissionSet{}, nil
+}
+
+// HasPermission reports whether user's role grants every given permission
+func (s *AuthService) HasPermission(ctx context.Context, user *User, perms ...Permission) bool {
+	set, err := s.PermissionsForRole(ctx, user.Role)
+	if err != nil {
+		fmt.Printf("⚠️ Warning: Permission check failed for %s: %v\n", user.ID, err)
+		return false
+	}
+	return set.Has(perms...)
+}
+
+// Actor is the user managing another account
+type Actor struct {
+	UserID      string
+	Permissions PermissionSet
+}
+
+// ensureWithinPrivilege rejects changes involving roles that hold a
+// permission the actor lacks, so user:manage alone cannot reach admin
+func (s *AuthService) ensureWithinPrivilege(ctx context.Context, actor Actor, roles ...UserRole) error {
+	for _, role := range roles {
+		set, err := s.PermissionsForRole(ctx, role)
+		if err != nil {
+			return err
+		}
+		if !actor.Permissions.Covers(set) {
+			return fmt.Errorf("%w: %s", ErrPrivilegeEscalation, role)
+		}
+	}
+	return nil
+}
+
+// ensureRoleExists rejects role names that are not in the database
+func (s *AuthService) ensureRoleExists(ctx context.Context, role UserRole) error {
+	if _, err := s.roleRepo.FindByName(ctx, role); err != nil {
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, role)
+	}
+	return nil
+}
+
+// ListRoles returns every role with its permissions
+func (s *AuthService) ListRoles(ctx context.Context) ([]*Role, error) {
+	roles, err := s.roleRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roles {
+		if r.Name == RoleAdmin {
+			r.Permissions = permissionSetFor(r).List()
+		}
+	}
+	return roles, nil
+}
+
+// CreateRole adds a new role
+func (s *AuthService) CreateRole(ctx context.Context, req RoleRequest) (*Role, error) {
+	if err := req.Validate(true); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if _, err := s.roleRepo.FindByName(ctx, req.Name); err == nil {
+		return nil, errors.New("role already exists")
+	}
+
+	now := time.Now()
+	role := &Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: dedupePermissions(req.Permissions),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+	s.roles.invalidate()
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "role.create",
+		TargetType: audit.TargetRole,
+		TargetID:   string(role.Name),
+		After:      audit.Snapshot(role),
+	})
+	return role, nil
+}
+
+// UpdateRole replaces a role's description and permissions
+// The admin role is fixed; editor and viewer can be tuned like any other role.
+func (s *AuthService) UpdateRole(ctx context.Context, name UserRole, req RoleRequest) (*Role, error) {
+	if err := req.Validate(false); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if name == RoleAdmin {
+		return nil, ErrRoleBuiltIn
+	}
+
+	role, err := s.roleRepo.FindByName(ctx, name)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+	before := *role
+
+	role.Description = req.Description
+	role.Permissions = dedupePermissions(req.Permissions)
+	role.UpdatedAt = time.Now()
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+	s.roles.invalidate()
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "role.update",
+		TargetType: audit.TargetRole,
+		TargetID:   string(role.Name),
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(role),
+	})
+	return role, nil
+}
+
+// DeleteRole removes a custom role that no user holds
+func (s *AuthService) DeleteRole(ctx context.Context, name UserRole) error {
+	role, err := s.roleRepo.FindByName(ctx, name)
+	if err != nil {
+		return ErrRoleNotFound
+	}
+	if role.BuiltIn {
+		return ErrRoleBuiltIn
+	}
+
+	users, err := s.userRepo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check role usage: %w", err)
+	}
+	for _, u := range users {
+		if u.Role == name {
+			return ErrRoleInUse
+		}
+	}
+
+	if err := s.roleRepo.Delete(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	s.roles.invalidate()
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "role.delete",
+		TargetType: audit.TargetRole,
+		TargetID:   string(name),
+		Before:     audit.Snapshot(role),
+	})
+	return nil
+}
+
+func dedupePermissions(perms []Permission) []Permission {
+	set := PermissionSet{}
+	for _, p := range perms {
+		set[p] = true
+	}
+	return set.List()
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/notification"
+	"prabogo/utils/activity"
+
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -17,19 +22,43 @@ type UserRepository interface {
 	FindByUsername(ctx context.Context, username string) (*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
 	FindAll(ctx context.Context) ([]*User, error)
-	Delete(ctx context.Context, id string) error
 	Update(ctx context.Context, user *User) error
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
 }
 
 // AuthService handles authentication business logic
 type AuthService struct {
-	userRepo UserRepository
+	userRepo       UserRepository
+	sessionRepo    SessionRepository
+	resetRepo      PasswordResetRepository
+	notifier       notification.Notifier
+	twoFactorRepo  TwoFactorRepository
+	roleRepo       RoleRepository
+	roles          *roleCache
+	locationRepo   UserLocationRepository
+	apiKeyRepo     APIKeyRepository
+	invitationRepo InvitationRepository
+	oidc           *OIDCLogin
+	throttle       *LoginThrottle
+	auditor        audit.Recorder
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(userRepo UserRepository) *AuthService {
+func NewAuthService(userRepo UserRepository, sessionRepo SessionRepository, resetRepo PasswordResetRepository, twoFactorRepo TwoFactorRepository, roleRepo RoleRepository, locationRepo UserLocationRepository, apiKeyRepo APIKeyRepository, invitationRepo InvitationRepository, notifier notification.Notifier, throttle *LoginThrottle, oidc *OIDCLogin, auditor audit.Recorder) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		resetRepo:      resetRepo,
+		twoFactorRepo:  twoFactorRepo,
+		roleRepo:       roleRepo,
+		roles:          &roleCache{},
+		locationRepo:   locationRepo,
+		apiKeyRepo:     apiKeyRepo,
+		invitationRepo: invitationRepo,
+		oidc:           oidc,
+		notifier:       notifier,
+		throttle:       throttle,
+		auditor:        auditor,
 	}
 }
 
@@ -74,39 +103,133 @@ func (s *AuthService) Register(ctx context.Context, req RegisterRequest) (*User,
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.create",
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		After:      audit.Snapshot(user.ToResponse()),
+	})
+
 	return user, nil
 }
 
-// Login authenticates user and returns token
-func (s *AuthService) Login(ctx context.Context, req LoginRequest) (string, *User, error) {
+// Login authenticates user and opens a new session
+// With 2FA the result only carries a pre-auth token for the second step.
+func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginResult, error) {
 	// Validate request
 	if err := req.Validate(); err != nil {
-		return "", nil, fmt.Errorf("validation error: %w", err)
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Reject while the username or client IP is blocked
+	ip, _ := activity.GetClientIP(ctx)
+	if err := s.throttle.Check(ctx, req.Username, ip); err != nil {
+		return nil, err
 	}
 
 	// Find user by username
 	user, err := s.userRepo.FindByUsername(ctx, req.Username)
 	if err != nil {
-		return "", nil, errors.New("invalid credentials")
+		s.recordLoginFailure(ctx, req.Username, "", "unknown user")
+		return nil, errors.New("invalid credentials")
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		return "", nil, errors.New("user account is inactive")
+		s.recordLoginFailure(ctx, req.Username, user.ID, "inactive account")
+		return nil, errors.New("user account is inactive")
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return "", nil, errors.New("invalid credentials")
+		s.recordLoginFailure(ctx, req.Username, user.ID, "wrong password")
+		return nil, errors.New("invalid credentials")
+	}
+
+	s.throttle.Succeed(ctx, req.Username)
+
+	// Generate access + refresh token, or a pre-auth token when 2FA applies
+	result, err := s.secondStep(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Tokens != nil {
+		s.auditor.Record(ctx, audit.Entry{
+			ActorID:    user.ID,
+			ActorName:  user.Username,
+			Action:     "auth.login",
+			TargetType: audit.TargetSession,
+			TargetID:   user.ID,
+		})
+	}
+
+	return result, nil
+}
+
+// recordLoginFailure counts and audits a failed login attempt
+func (s *AuthService) recordLoginFailure(ctx context.Context, username string, userID string, reason string) {
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		ActorName:  username,
+		Action:     "auth.login_failed",
+		TargetType: audit.TargetSession,
+		TargetID:   userID,
+		After:      audit.Snapshot(map[string]string{"reason": reason}),
+	})
+
+	ip, _ := activity.GetClientIP(ctx)
+	lockedUser, lockedIP := s.throttle.Fail(ctx, username, ip)
+	if lockedUser {
+		s.auditor.Record(ctx, audit.Entry{
+			ActorID:    userID,
+			ActorName:  username,
+			Action:     "auth.lockout",
+			TargetType: audit.TargetUser,
+			TargetID:   userID,
+			After:      audit.Snapshot(map[string]string{"scope": "username", "username": username}),
+		})
 	}
+	if lockedIP {
+		s.auditor.Record(ctx, audit.Entry{
+			Action:     "auth.lockout",
+			TargetType: audit.TargetSession,
+			TargetID:   ip,
+			After:      audit.Snapshot(map[string]string{"scope": "ip", "ip": ip}),
+		})
+	}
+}
 
-	// Generate JWT token
-	token, err := GenerateToken(user)
+// UnlockUser clears a user's failed login counter and lockout (admin action)
+func (s *AuthService) UnlockUser(ctx context.Context, id string) error {
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+		return fmt.Errorf("user not found: %w", err)
 	}
+	if err := s.throttle.UnlockUser(ctx, user.Username); err != nil {
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.unlock",
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+	})
+	return nil
+}
 
-	return token, user, nil
+// UnlockIP clears a client IP's failed login counter and lockout (admin action)
+func (s *AuthService) UnlockIP(ctx context.Context, ip string) error {
+	if err := s.throttle.UnlockIP(ctx, ip); err != nil {
+		return fmt.Errorf("failed to unlock ip: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "auth.unlock_ip",
+		TargetType: audit.TargetSession,
+		TargetID:   ip,
+	})
+	return nil
 }
 
 // GetUserByID retrieves user by ID
@@ -120,42 +243,262 @@ func (s *AuthService) GetUserByID(ctx context.Context, id string) (*User, error)
 
 // ValidateToken validates JWT token and returns user
 func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (*User, error) {
+	user, _, err := s.ValidateAccessToken(ctx, tokenString)
+	return user, err
+}
+
+// ValidateAccessToken validates JWT token and its session, returning user and claims
+func (s *AuthService) ValidateAccessToken(ctx context.Context, tokenString string) (*User, *Claims, error) {
 	// Validate token
 	claims, err := ValidateToken(tokenString)
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		return nil, nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	// Tokens without a session predate refresh tokens and cannot be revoked;
+	// pre-auth tokens (second login step) are never valid access tokens
+	if claims.SessionID == "" || claims.Purpose != "" {
+		return nil, nil, ErrSessionRevoked
+	}
+	active, err := s.sessionRepo.IsActive(ctx, claims.SessionID, claims.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if !active {
+		return nil, nil, ErrSessionRevoked
 	}
 
 	// Get user
 	user, err := s.userRepo.FindByID(ctx, claims.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, nil, fmt.Errorf("user not found: %w", err)
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		return nil, errors.New("user account is inactive")
+		return nil, nil, errors.New("user account is inactive")
 	}
 
-	return user, nil
+	return user, claims, nil
 }
 
 // GetAllUsers retrieves all users
-func (s *AuthService) GetAllUsers(ctx context.Context) ([]*User, error) {
-	return s.userRepo.FindAll(ctx)
+// Tombstones of deleted users are left out unless includeDeleted is set.
+func (s *AuthService) GetAllUsers(ctx context.Context, includeDeleted bool) ([]*User, error) {
+	users, err := s.userRepo.FindAll(ctx)
+	if err != nil || includeDeleted {
+		return users, err
+	}
+
+	active := make([]*User, 0, len(users))
+	for _, u := range users {
+		if !u.IsDeleted() {
+			active = append(active, u)
+		}
+	}
+	return active, nil
 }
 
-// DeleteUser removes a user
+// DeleteUser turns a user into a tombstone
+// The row stays so registered_by, monitored_by and audit lookups keep resolving
+// to the username; email, full name and password are scrubbed and sessions,
+// 2FA and single sign-on links are removed.
 func (s *AuthService) DeleteUser(ctx context.Context, id string) error {
-	return s.userRepo.Delete(ctx, id)
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.IsDeleted() {
+		return errors.New("user is already deleted")
+	}
+	before := user.ToResponse()
+
+	now := time.Now()
+	user.Email = "deleted+" + user.ID + "@invalid"
+	user.FullName = ""
+	user.IsActive = false
+	user.DeletedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	// "!" is never a valid bcrypt hash, so no password matches
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, "!"); err != nil {
+		return fmt.Errorf("failed to scrub password: %w", err)
+	}
+
+	if _, err := s.sessionRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to revoke sessions of deleted user %s: %v\n", user.ID, err)
+	}
+	if err := s.twoFactorRepo.Delete(ctx, user.ID); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to remove 2FA of deleted user %s: %v\n", user.ID, err)
+	}
+	if s.oidc != nil {
+		if err := s.oidc.identities.DeleteForUser(ctx, user.ID); err != nil {
+			fmt.Printf("⚠️ Warning: Failed to unlink identities of deleted user %s: %v\n", user.ID, err)
+		}
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.delete",
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(user.ToResponse()),
+	})
+	return nil
+}
+
+// DeactivateUser blocks a user from logging in and signs them out everywhere
+func (s *AuthService) DeactivateUser(ctx context.Context, id string) error {
+	return s.setUserActive(ctx, id, false)
+}
+
+// ReactivateUser lets a deactivated user log in again
+func (s *AuthService) ReactivateUser(ctx context.Context, id string) error {
+	return s.setUserActive(ctx, id, true)
+}
+
+func (s *AuthService) setUserActive(ctx context.Context, id string, active bool) error {
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.IsDeleted() {
+		return errors.New("user is deleted")
+	}
+	if user.IsActive == active {
+		return nil
+	}
+
+	before := user.ToResponse()
+	user.IsActive = active
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	action := "user.reactivate"
+	if !active {
+		action = "user.deactivate"
+		// Access tokens are checked against is_active, refresh tokens against their session
+		if _, err := s.sessionRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(user.ToResponse()),
+	})
+	return nil
+}
+
+// UpdateProfile changes a user's full name and/or email
+// The user's role must be within the actor's permissions: the email is where
+// password reset links go.
+func (s *AuthService) UpdateProfile(ctx context.Context, actor Actor, id string, req ProfileRequest) (*User, error) {
+	if req.Email != nil {
+		trimmed := strings.ToLower(strings.TrimSpace(*req.Email))
+		req.Email = &trimmed
+	}
+	if req.FullName != nil {
+		trimmed := strings.TrimSpace(*req.FullName)
+		req.FullName = &trimmed
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if user.IsDeleted() {
+		return nil, errors.New("user is deleted")
+	}
+	if err := s.ensureWithinPrivilege(ctx, actor, user.Role); err != nil {
+		return nil, err
+	}
+	before := user.ToResponse()
+
+	emailChanged := req.Email != nil && *req.Email != user.Email
+	if emailChanged {
+		if other, err := s.userRepo.FindByEmail(ctx, *req.Email); err == nil && other.ID != user.ID {
+			return nil, errors.New("email already exists")
+		}
+		// Links already sent to the old address must not outlive the change
+		if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("failed to invalidate reset tokens: %w", err)
+		}
+		user.Email = *req.Email
+	}
+	if req.FullName != nil {
+		user.FullName = *req.FullName
+	}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+	if emailChanged {
+		s.notifyEmailChanged(ctx, before.Email, user)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.update_profile",
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(user.ToResponse()),
+	})
+	return user, nil
+}
+
+// notifyEmailChanged tells the previous address, so an unexpected change can be reported
+func (s *AuthService) notifyEmailChanged(ctx context.Context, oldEmail string, user *User) {
+	msg := notification.Message{
+		To:      oldEmail,
+		Subject: "Your Tree-ID email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"The email address of your Tree-ID account was changed to %s.\n"+
+			"Password reset links sent to this address no longer work.\n\n"+
+			"If you didn't expect this, contact your administrator right away.\n",
+			user.Username, user.Email),
+	}
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to notify %s of email change: %v\n", user.ID, err)
+	}
 }
 
 // UpdateUserRole updates a user's role
-func (s *AuthService) UpdateUserRole(ctx context.Context, id string, role UserRole) error {
+// Both the user's current role and the new one must be within the actor's permissions.
+func (s *AuthService) UpdateUserRole(ctx context.Context, actor Actor, id string, role UserRole) error {
+	if actor.UserID == id {
+		return ErrOwnRole
+	}
+	if err := s.ensureRoleExists(ctx, role); err != nil {
+		return err
+	}
+
 	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := s.ensureWithinPrivilege(ctx, actor, user.Role, role); err != nil {
+		return err
+	}
+	before := user.ToResponse()
 	user.Role = role
-	return s.userRepo.Update(ctx, user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.update_role",
+		TargetType: audit.TargetUser,
+		TargetID:   id,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(user.ToResponse()),
+	})
+	return nil
 }
@@ -0,0 +1,185 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/notification"
+)
+
+// memoryRoles is an in-memory auth.RoleRepository with fixed roles
+type memoryRoles struct {
+	auth.RoleRepository
+	roles []*auth.Role
+}
+
+func (r *memoryRoles) FindAll(ctx context.Context) ([]*auth.Role, error) {
+	return r.roles, nil
+}
+
+func (r *memoryRoles) FindByName(ctx context.Context, name auth.UserRole) (*auth.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, errors.New("role not found")
+}
+
+// countingResets records which users had their reset tokens invalidated
+type countingResets struct {
+	auth.PasswordResetRepository
+	invalidated []string
+}
+
+func (r *countingResets) InvalidateForUser(ctx context.Context, userID string) error {
+	r.invalidated = append(r.invalidated, userID)
+	return nil
+}
+
+// outbox keeps sent notifications
+type outbox struct {
+	sent []notification.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg notification.Message) error {
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+// roleFixture has a "usermgr" role that holds user:manage but not role:manage
+type roleFixture struct {
+	users   *memoryUsers
+	resets  *countingResets
+	outbox  *outbox
+	service *auth.AuthService
+	actor   auth.Actor
+}
+
+func newRoleFixture(t *testing.T) *roleFixture {
+	t.Helper()
+	roles := &memoryRoles{roles: []*auth.Role{
+		{Name: auth.RoleAdmin, BuiltIn: true},
+		{Name: auth.RoleEditor, BuiltIn: true, Permissions: []auth.Permission{auth.PermTreeCreate, auth.PermTreeUpdate, auth.PermReportView}},
+		{Name: auth.RoleViewer, BuiltIn: true, Permissions: []auth.Permission{auth.PermReportView}},
+		{Name: "usermgr", Permissions: []auth.Permission{auth.PermUserManage, auth.PermTreeCreate, auth.PermTreeUpdate, auth.PermReportView}},
+	}}
+	users := &memoryUsers{users: map[string]*auth.User{}}
+	for _, u := range []*auth.User{
+		{ID: "manager", Username: "manager", Role: "usermgr", IsActive: true},
+		{ID: "viewer", Username: "viewer", Email: "viewer@example.com", Role: auth.RoleViewer, IsActive: true},
+		{ID: "admin", Username: "admin", Email: "admin@example.com", Role: auth.RoleAdmin, IsActive: true},
+	} {
+		users.Create(context.Background(), u)
+	}
+	resets, sent := &countingResets{}, &outbox{}
+	service := auth.NewAuthService(users, nil, resets, nil, roles, nil, nil, nil, sent, nil, nil, discardAudit{})
+
+	perms, err := service.PermissionsForRole(context.Background(), "usermgr")
+	if err != nil {
+		t.Fatalf("PermissionsForRole: %v", err)
+	}
+	return &roleFixture{users: users, resets: resets, outbox: sent, service: service, actor: auth.Actor{UserID: "manager", Permissions: perms}}
+}
+
+func (f *roleFixture) role(t *testing.T, id string) auth.UserRole {
+	t.Helper()
+	user, err := f.users.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	return user.Role
+}
+
+func TestUpdateUserRoleWithinPrivilege(t *testing.T) {
+	f := newRoleFixture(t)
+
+	if err := f.service.UpdateUserRole(context.Background(), f.actor, "viewer", auth.RoleEditor); err != nil {
+		t.Fatalf("UpdateUserRole: %v", err)
+	}
+	if role := f.role(t, "viewer"); role != auth.RoleEditor {
+		t.Errorf("role = %s, want editor", role)
+	}
+}
+
+func TestUpdateUserRoleRejectsRoleBeyondCaller(t *testing.T) {
+	f := newRoleFixture(t)
+
+	err := f.service.UpdateUserRole(context.Background(), f.actor, "viewer", auth.RoleAdmin)
+	if !errors.Is(err, auth.ErrPrivilegeEscalation) {
+		t.Fatalf("err = %v, want ErrPrivilegeEscalation", err)
+	}
+	if role := f.role(t, "viewer"); role != auth.RoleViewer {
+		t.Errorf("role = %s, want viewer unchanged", role)
+	}
+}
+
+func TestUpdateUserRoleRejectsUserAboveCaller(t *testing.T) {
+	f := newRoleFixture(t)
+
+	err := f.service.UpdateUserRole(context.Background(), f.actor, "admin", auth.RoleViewer)
+	if !errors.Is(err, auth.ErrPrivilegeEscalation) {
+		t.Fatalf("err = %v, want ErrPrivilegeEscalation", err)
+	}
+	if role := f.role(t, "admin"); role != auth.RoleAdmin {
+		t.Errorf("role = %s, want admin unchanged", role)
+	}
+}
+
+func TestUpdateUserRoleRejectsOwnRole(t *testing.T) {
+	f := newRoleFixture(t)
+
+	err := f.service.UpdateUserRole(context.Background(), f.actor, "manager", auth.RoleAdmin)
+	if !errors.Is(err, auth.ErrOwnRole) {
+		t.Fatalf("err = %v, want ErrOwnRole", err)
+	}
+	if role := f.role(t, "manager"); role != "usermgr" {
+		t.Errorf("role = %s, want usermgr unchanged", role)
+	}
+}
+
+func TestUpdateProfileRejectsUserAboveCaller(t *testing.T) {
+	f := newRoleFixture(t)
+	email := "attacker@example.com"
+
+	_, err := f.service.UpdateProfile(context.Background(), f.actor, "admin", auth.ProfileRequest{Email: &email})
+	if !errors.Is(err, auth.ErrPrivilegeEscalation) {
+		t.Fatalf("err = %v, want ErrPrivilegeEscalation", err)
+	}
+	if user, _ := f.users.FindByID(context.Background(), "admin"); user.Email != "admin@example.com" {
+		t.Errorf("email = %s, want admin@example.com unchanged", user.Email)
+	}
+	if len(f.resets.invalidated) != 0 || len(f.outbox.sent) != 0 {
+		t.Errorf("rejected change invalidated %v and sent %d messages", f.resets.invalidated, len(f.outbox.sent))
+	}
+}
+
+func TestUpdateProfileEmailChangeRevokesResetsAndNotifiesOldAddress(t *testing.T) {
+	f := newRoleFixture(t)
+	email := "new.viewer@example.com"
+
+	user, err := f.service.UpdateProfile(context.Background(), f.actor, "viewer", auth.ProfileRequest{Email: &email})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if user.Email != email {
+		t.Errorf("email = %s, want %s", user.Email, email)
+	}
+	if len(f.resets.invalidated) != 1 || f.resets.invalidated[0] != "viewer" {
+		t.Errorf("invalidated = %v, want [viewer]", f.resets.invalidated)
+	}
+	if len(f.outbox.sent) != 1 || f.outbox.sent[0].To != "viewer@example.com" {
+		t.Fatalf("sent = %+v, want one message to the old address", f.outbox.sent)
+	}
+
+	// A name-only edit leaves reset links alone
+	name := "Viewer"
+	if _, err := f.service.UpdateProfile(context.Background(), f.actor, "viewer", auth.ProfileRequest{FullName: &name}); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if len(f.resets.invalidated) != 1 || len(f.outbox.sent) != 1 {
+		t.Errorf("name change invalidated %v and sent %d messages", f.resets.invalidated, len(f.outbox.sent))
+	}
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/utils/activity"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrRefreshTokenReused is returned when an already rotated token is presented again.
+	// The whole session is revoked because the token has probably been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+
+	// ErrSessionRevoked is returned for access tokens of a logged-out session
+	ErrSessionRevoked = errors.New("session has been revoked")
+)
+
+// RefreshToken is one link in a session's rotation chain
+// Only the hash of the token is persisted.
+type RefreshToken struct {
+	ID         string
+	FamilyID   string // Session ID, shared by every rotation of one login
+	UserID     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string // ID of the token issued when this one was rotated
+	IP         string
+	UserAgent  string
+}
+
+// IsExpired returns true if the refresh token can no longer be used
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// TokenPair is what a client receives on login and refresh
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+}
+
+// SessionRepository persists refresh tokens
+type SessionRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+
+	// Rotate revokes oldID in favour of next, failing with ErrRefreshTokenReused
+	// if oldID was already revoked (e.g. by a concurrent refresh)
+	Rotate(ctx context.Context, oldID string, next *RefreshToken) error
+
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID string) (int64, error)
+
+	// IsActive reports whether the session still has a usable refresh token
+	IsActive(ctx context.Context, familyID string, userID string) (bool, error)
+
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// hashToken returns the stored form of a raw opaque token
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateOpaqueToken returns 256 random bits, URL-safe encoded
+func generateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// newRefreshToken generates a random token and its record for a session
+func newRefreshToken(ctx context.Context, user *User, familyID string) (string, *RefreshToken, error) {
+	raw, err := generateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	ip, _ := activity.GetClientIP(ctx)
+	userAgent, _ := activity.GetUserAgent(ctx)
+
+	now := time.Now()
+	return raw, &RefreshToken{
+		ID:        uuid.New().String(),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(GetRefreshExpiration()),
+		CreatedAt: now,
+		IP:        ip,
+		UserAgent: userAgent,
+	}, nil
+}
+
+// newTokenPair signs an access token for the session of record
+func newTokenPair(user *User, raw string, record *RefreshToken) (*TokenPair, error) {
+	accessToken, err := GenerateToken(user, record.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: raw,
+		ExpiresIn:    int(GetJWTExpiration().Seconds()),
+	}, nil
+}
+
+// startSession opens a new session (refresh token family) for user
+func (s *AuthService) startSession(ctx context.Context, user *User) (*TokenPair, error) {
+	// Opportunistic cleanup keeps the table from growing with dead rotations
+	if _, err := s.sessionRepo.DeleteExpired(ctx, time.Now()); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to clean expired refresh tokens: %v\n", err)
+	}
+
+	raw, record, err := newRefreshToken(ctx, user, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return newTokenPair(user, raw, record)
+}
+
+// Refresh rotates a refresh token and issues a new access token
+func (s *AuthService) Refresh(ctx context.Context, rawToken string) (*TokenPair, *User, error) {
+	if rawToken == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	current, err := s.sessionRepo.FindByHash(ctx, hashToken(rawToken))
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		if current.ReplacedBy != "" {
+			s.revokeReusedSession(ctx, current)
+			return nil, nil, ErrRefreshTokenReused
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if current.IsExpired() {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, current.UserID)
+	if err != nil || !user.IsActive {
+		if err := s.sessionRepo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			fmt.Printf("❌ ERROR: Failed to revoke session %s: %v\n", current.FamilyID, err)
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	raw, next, err := newRefreshToken(ctx, user, current.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Rotation keeps the session's original expiry: refreshing never extends a login forever
+	next.ExpiresAt = current.ExpiresAt
+
+	if err := s.sessionRepo.Rotate(ctx, current.ID, next); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			s.revokeReusedSession(ctx, current)
+			return nil, nil, ErrRefreshTokenReused
+		}
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	pair, err := newTokenPair(user, raw, next)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, user, nil
+}
+
+// revokeReusedSession kills a session whose rotated token was replayed
+func (s *AuthService) revokeReusedSession(ctx context.Context, token *RefreshToken) {
+	if err := s.sessionRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		fmt.Printf("❌ ERROR: Failed to revoke session %s: %v\n", token.FamilyID, err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    token.UserID,
+		Action:     "auth.refresh_reuse",
+		TargetType: audit.TargetSession,
+		TargetID:   token.FamilyID,
+		After:      audit.Snapshot(map[string]string{"token_id": token.ID}),
+	})
+}
+
+// Logout revokes the session the access token belongs to
+func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
+	if err := s.sessionRepo.RevokeFamily(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "auth.logout",
+		TargetType: audit.TargetSession,
+		TargetID:   sessionID,
+	})
+	return nil
+}
+
+// LogoutAll revokes every session of the current user
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) (int64, error) {
+	return s.revokeUserSessions(ctx, userID, "auth.logout_all")
+}
+
+// RevokeUserSessions revokes every session of a user (admin action)
+func (s *AuthService) RevokeUserSessions(ctx context.Context, userID string) (int64, error) {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return 0, fmt.Errorf("user not found: %w", err)
+	}
+	return s.revokeUserSessions(ctx, userID, "user.revoke_sessions")
+}
+
+func (s *AuthService) revokeUserSessions(ctx context.Context, userID string, action string) (int64, error) {
+	revoked, err := s.sessionRepo.RevokeAllForUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+		After:      audit.Snapshot(map[string]int64{"revoked_tokens": revoked}),
+	})
+	return revoked, nil
+}
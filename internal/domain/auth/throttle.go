@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoginThrottledError is returned while a username or IP is blocked
+// The message and RetryAfter depend only on the attempt counters, never on
+// whether the username exists, so the response cannot be used to probe accounts.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many login attempts, try again later"
+}
+
+// AttemptStore keeps failure counters and blocks (Redis or in-memory)
+type AttemptStore interface {
+	// Incr adds one failure and returns the count within window
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+
+	// Block rejects attempts for key until the given time
+	Block(ctx context.Context, key string, until time.Time) error
+
+	// BlockedUntil returns the end of an active block (zero time when not blocked)
+	BlockedUntil(ctx context.Context, key string) (time.Time, error)
+
+	// Reset clears counters and blocks
+	Reset(ctx context.Context, keys ...string) error
+}
+
+// ThrottleConfig for login brute-force protection
+type ThrottleConfig struct {
+	MaxUserFailures int64         // Failures per username before lockout
+	MaxIPFailures   int64         // Failures per client IP before lockout
+	Window          time.Duration // Failures older than this are forgotten
+	Lockout         time.Duration // Block duration once a limit is reached
+	BaseDelay       time.Duration // First progressive delay, doubled per failure
+	MaxDelay        time.Duration
+}
+
+// GetThrottleConfig reads LOGIN_* settings from environment
+func GetThrottleConfig() ThrottleConfig {
+	return ThrottleConfig{
+		MaxUserFailures: intFromEnv("LOGIN_MAX_FAILURES", 5),
+		MaxIPFailures:   intFromEnv("LOGIN_MAX_IP_FAILURES", 20),
+		Window:          durationFromEnv("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		Lockout:         durationFromEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+	}
+}
+
+func intFromEnv(key string, fallback int64) int64 {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
+// LoginThrottle applies progressive delays and lockouts to login attempts
+type LoginThrottle struct {
+	store  AttemptStore
+	config ThrottleConfig
+}
+
+// NewLoginThrottle creates a new login throttle
+func NewLoginThrottle(store AttemptStore, config ThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{
+		store:  store,
+		config: config,
+	}
+}
+
+func userKey(username string) string {
+	return "login:user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+// Check returns a LoginThrottledError if username or ip is currently blocked
+// Store errors fail open: an unavailable counter store must not lock everybody out.
+func (t *LoginThrottle) Check(ctx context.Context, username string, ip string) error {
+	var until time.Time
+	for _, key := range t.keys(username, ip) {
+		blocked, err := t.store.BlockedUntil(ctx, key)
+		if err != nil {
+			fmt.Printf("⚠️ Warning: Login throttle check failed for %s: %v\n", key, err)
+			continue
+		}
+		if blocked.After(until) {
+			until = blocked
+		}
+	}
+
+	if wait := time.Until(until); wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail records a failed attempt; it reports which keys reached the lockout limit
+func (t *LoginThrottle) Fail(ctx context.Context, username string, ip string) (lockedUser bool, lockedIP bool) {
+	lockedUser = t.fail(ctx, userKey(username), t.config.MaxUserFailures)
+	if ip != "" {
+		lockedIP = t.fail(ctx, ipKey(ip), t.config.MaxIPFailures)
+	}
+	return lockedUser, lockedIP
+}
+
+func (t *LoginThrottle) fail(ctx context.Context, key string, limit int64) bool {
+	count, err := t.store.Incr(ctx, key, t.config.Window)
+	if err != nil {
+		fmt.Printf("⚠️ Warning: Login throttle update failed for %s: %v\n", key, err)
+		return false
+	}
+
+	locked := count >= limit
+	block := t.delay(count)
+	if locked {
+		block = t.config.Lockout
+	}
+	if block <= 0 {
+		return false
+	}
+
+	if err := t.store.Block(ctx, key, time.Now().Add(block)); err != nil {
+		fmt.Printf("⚠️ Warning: Login throttle block failed for %s: %v\n", key, err)
+	}
+	// Only the failure that crosses the limit counts as a new lockout
+	return count == limit
+}
+
+// delay grows 1s, 2s, 4s, ... from the second failure on
+func (t *LoginThrottle) delay(failures int64) time.Duration {
+	if failures < 2 {
+		return 0
+	}
+	d := t.config.BaseDelay << uint(failures-2)
+	if d <= 0 || d > t.config.MaxDelay {
+		d = t.config.MaxDelay
+	}
+	return d
+}
+
+// Succeed clears the username counter after a successful login
+// The IP counter is kept so one valid account cannot launder a stuffing run.
+func (t *LoginThrottle) Succeed(ctx context.Context, username string) {
+	if err := t.store.Reset(ctx, userKey(username)); err != nil {
+		fmt.Printf("⚠️ Warning: Login throttle reset failed: %v\n", err)
+	}
+}
+
+// UnlockUser clears failures and lockout for a username
+func (t *LoginThrottle) UnlockUser(ctx context.Context, username string) error {
+	return t.store.Reset(ctx, userKey(username))
+}
+
+// UnlockIP clears failures and lockout for a client IP
+func (t *LoginThrottle) UnlockIP(ctx context.Context, ip string) error {
+	return t.store.Reset(ctx, ipKey(ip))
+}
+
+func (t *LoginThrottle) keys(username string, ip string) []string {
+	keys := []string{userKey(username)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	return keys
+}
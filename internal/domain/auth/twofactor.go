@@ -0,0 +1,516 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/utils/activity"
+	"prabogo/utils/label"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for your role")
+	ErrInvalidTwoFactorCode    = errors.New("invalid authentication code")
+)
+
+// recoveryCodeCount is how many single-use recovery codes a user gets
+const recoveryCodeCount = 10
+
+// TwoFactor holds a user's TOTP enrolment
+// EnabledAt is nil while enrolment is pending (secret shown, code not yet confirmed).
+type TwoFactor struct {
+	UserID       string
+	Secret       string // Plain base32 secret; the repository encrypts it at rest
+	EnabledAt    *time.Time
+	LastUsedStep int64 // Highest accepted time step, prevents code replay
+	CreatedAt    time.Time
+}
+
+// IsEnabled returns true once enrolment has been confirmed
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// TwoFactorRepository persists TOTP enrolments and recovery codes
+type TwoFactorRepository interface {
+	// Find returns nil, nil when the user has no enrolment
+	Find(ctx context.Context, userID string) (*TwoFactor, error)
+
+	// Save creates or replaces a pending enrolment
+	Save(ctx context.Context, tf *TwoFactor) error
+	Enable(ctx context.Context, userID string, at time.Time, step int64) error
+
+	// UseStep records step if it is newer than the last accepted one
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+
+	// Delete removes the enrolment and all recovery codes
+	Delete(ctx context.Context, userID string) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID string, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+}
+
+// TwoFactorSetup is returned when enrolment starts
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          string `json:"qr_code"` // PNG data URI
+}
+
+// TwoFactorStatus describes a user's 2FA state
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorCodeRequest carries a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	PreAuthToken string `json:"pre_auth_token,omitempty"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+	Password     string `json:"password,omitempty"` // Required to disable 2FA
+}
+
+// LoginResult is either a full session or a pending second step
+type LoginResult struct {
+	Tokens             *TokenPair
+	User               *User
+	PreAuthToken       string
+	TwoFactorRequired  bool // Submit a code to /2fa/verify
+	EnrollmentRequired bool // Role requires 2FA; enrol via /2fa/enroll first
+}
+
+// TwoFactorRequiredForRole applies TWO_FACTOR_REQUIRED_ROLES (comma separated)
+func TwoFactorRequiredForRole(role UserRole) bool {
+	for _, r := range strings.Split(os.Getenv("TWO_FACTOR_REQUIRED_ROLES"), ",") {
+		if strings.TrimSpace(r) == string(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// twoFactorKey derives the AES key protecting TOTP secrets at rest
+func twoFactorKey() []byte {
+	secret := os.Getenv("TOTP_ENCRYPTION_KEY")
+	if secret == "" {
+		secret = GetJWTSecret()
+	}
+	sum := sha256.Sum256([]byte("totp:" + secret))
+	return sum[:]
+}
+
+// SealTOTPSecret encrypts a TOTP secret for storage
+func SealTOTPSecret(plain string) (string, error) {
+	gcm, err := newTOTPCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenTOTPSecret decrypts a stored TOTP secret
+func OpenTOTPSecret(stored string) (string, error) {
+	gcm, err := newTOTPCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", errors.New("malformed TOTP secret")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+func newTOTPCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(twoFactorKey())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// normalizeRecoveryCode makes "ABCDE-12345", "abcde12345" and "abcde 12345" equal
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
+
+// generateRecoveryCodes returns plain codes for the user and their hashes for storage
+func generateRecoveryCodes() ([]string, []string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(buf))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// GetTwoFactorStatus returns whether 2FA is enabled/required for a user
+func (s *AuthService) GetTwoFactorStatus(ctx context.Context, user *User) (*TwoFactorStatus, error) {
+	tf, err := s.twoFactorRepo.Find(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{
+		Enabled:  tf.IsEnabled(),
+		Required: TwoFactorRequiredForRole(user.Role),
+	}
+	if status.Enabled {
+		if status.RecoveryCodesRemaining, err = s.twoFactorRepo.CountRecoveryCodes(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// SetupTwoFactor starts (or restarts) enrolment with a fresh secret
+func (s *AuthService) SetupTwoFactor(ctx context.Context, userID string) (*TwoFactorSetup, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	existing, err := s.twoFactorRepo.Find(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.IsEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	if err := s.twoFactorRepo.Save(ctx, &TwoFactor{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	uri := totpProvisioningURI("Tree-ID", user.Username, secret)
+	png, err := label.QRCodePNG(uri, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// EnableTwoFactor confirms enrolment with a first code and returns recovery codes
+// The recovery codes are only ever shown in this response.
+func (s *AuthService) EnableTwoFactor(ctx context.Context, userID string, code string) ([]string, error) {
+	tf, err := s.twoFactorRepo.Find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, errors.New("start two-factor setup first")
+	}
+	if tf.IsEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := verifyTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	if err := s.twoFactorRepo.Enable(ctx, userID, time.Now(), step); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "auth.2fa_enable",
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+	return codes, nil
+}
+
+// DisableTwoFactor removes 2FA after re-checking password and a current code
+func (s *AuthService) DisableTwoFactor(ctx context.Context, userID string, req TwoFactorCodeRequest) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if TwoFactorRequiredForRole(user.Role) {
+		return ErrTwoFactorRequired
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return ErrWrongPassword
+	}
+
+	tf, err := s.twoFactorRepo.Find(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !tf.IsEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, tf, req); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "auth.2fa_disable",
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error) {
+	tf, err := s.twoFactorRepo.Find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !tf.IsEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, tf, TwoFactorCodeRequest{Code: code}); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "auth.2fa_recovery_codes",
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+	return codes, nil
+}
+
+// ResetTwoFactor removes a user's 2FA, e.g. after a lost phone (admin action)
+// All sessions are revoked so the user has to sign in (and re-enrol) again.
+func (s *AuthService) ResetTwoFactor(ctx context.Context, userID string) error {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if err := s.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("failed to reset two-factor: %w", err)
+	}
+	if _, err := s.sessionRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "user.2fa_reset",
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+	})
+	return nil
+}
+
+// secondStep decides what a password-verified user gets from Login
+func (s *AuthService) secondStep(ctx context.Context, user *User) (*LoginResult, error) {
+	tf, err := s.twoFactorRepo.Find(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load two-factor settings: %w", err)
+	}
+
+	purpose := ""
+	switch {
+	case tf.IsEnabled():
+		purpose = PurposeTwoFactor
+	case TwoFactorRequiredForRole(user.Role):
+		purpose = PurposeTwoFactorEnroll
+	default:
+		pair, err := s.startSession(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{Tokens: pair, User: user}, nil
+	}
+
+	preAuth, err := GeneratePreAuthToken(user, purpose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pre-auth token: %w", err)
+	}
+	return &LoginResult{
+		User:               user,
+		PreAuthToken:       preAuth,
+		TwoFactorRequired:  purpose == PurposeTwoFactor,
+		EnrollmentRequired: purpose == PurposeTwoFactorEnroll,
+	}, nil
+}
+
+// VerifyTwoFactorLogin completes login with a TOTP or recovery code
+func (s *AuthService) VerifyTwoFactorLogin(ctx context.Context, req TwoFactorCodeRequest) (*LoginResult, error) {
+	claims, err := ValidatePreAuthToken(req.PreAuthToken, PurposeTwoFactor)
+	if err != nil {
+		return nil, errors.New("invalid or expired pre-auth token, please login again")
+	}
+
+	ip, _ := activity.GetClientIP(ctx)
+	if err := s.throttle.Check(ctx, claims.Username, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil || !user.IsActive {
+		return nil, errors.New("invalid or expired pre-auth token, please login again")
+	}
+
+	tf, err := s.twoFactorRepo.Find(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !tf.IsEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if err := s.checkSecondFactor(ctx, tf, req); err != nil {
+		s.recordLoginFailure(ctx, user.Username, user.ID, "wrong 2fa code")
+		return nil, err
+	}
+	s.throttle.Succeed(ctx, user.Username)
+
+	pair, err := s.startSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		Action:     "auth.login",
+		TargetType: audit.TargetSession,
+		TargetID:   user.ID,
+		After:      audit.Snapshot(map[string]bool{"two_factor": true, "recovery_code": req.RecoveryCode != ""}),
+	})
+	return &LoginResult{Tokens: pair, User: user}, nil
+}
+
+// SetupTwoFactorEnrollment starts mandatory enrolment during login
+func (s *AuthService) SetupTwoFactorEnrollment(ctx context.Context, preAuthToken string) (*TwoFactorSetup, error) {
+	claims, err := ValidatePreAuthToken(preAuthToken, PurposeTwoFactorEnroll)
+	if err != nil {
+		return nil, errors.New("invalid or expired pre-auth token, please login again")
+	}
+	return s.SetupTwoFactor(ctx, claims.UserID)
+}
+
+// CompleteTwoFactorEnrollment confirms mandatory enrolment and opens the session
+func (s *AuthService) CompleteTwoFactorEnrollment(ctx context.Context, req TwoFactorCodeRequest) (*LoginResult, []string, error) {
+	claims, err := ValidatePreAuthToken(req.PreAuthToken, PurposeTwoFactorEnroll)
+	if err != nil {
+		return nil, nil, errors.New("invalid or expired pre-auth token, please login again")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil || !user.IsActive {
+		return nil, nil, errors.New("invalid or expired pre-auth token, please login again")
+	}
+
+	ctx = activity.WithActorID(ctx, user.ID)
+	codes, err := s.EnableTwoFactor(ctx, user.ID, req.Code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := s.startSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		Action:     "auth.login",
+		TargetType: audit.TargetSession,
+		TargetID:   user.ID,
+		After:      audit.Snapshot(map[string]bool{"two_factor": true, "enrolled": true}),
+	})
+	return &LoginResult{Tokens: pair, User: user}, codes, nil
+}
+
+// checkSecondFactor accepts a fresh TOTP code or an unused recovery code
+func (s *AuthService) checkSecondFactor(ctx context.Context, tf *TwoFactor, req TwoFactorCodeRequest) error {
+	if req.RecoveryCode != "" {
+		used, err := s.twoFactorRepo.UseRecoveryCode(ctx, tf.UserID, hashToken(normalizeRecoveryCode(req.RecoveryCode)))
+		if err != nil {
+			return fmt.Errorf("failed to check recovery code: %w", err)
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	step, ok := verifyTOTP(tf.Secret, req.Code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	// A code can only be used once, even within its 30 second window
+	fresh, err := s.twoFactorRepo.UseStep(ctx, tf.UserID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record code use: %w", err)
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package diagnosis
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/tree"
+)
+
+var (
+	// ErrEntryNotFound is returned for unknown catalog entries
+	ErrEntryNotFound = errors.New("pest or disease not found")
+
+	// ErrDuplicateCode is returned when a catalog code is already used
+	ErrDuplicateCode = errors.New("catalog code already exists")
+
+	// ErrEntryInUse is returned when deleting an entry that has diagnoses
+	ErrEntryInUse = errors.New("pest or disease has diagnoses; deactivate it instead")
+
+	// ErrAlertNotFound is returned for unknown outbreak alerts
+	ErrAlertNotFound = errors.New("outbreak alert not found")
+)
+
+// Kind separates pests from diseases
+type Kind string
+
+const (
+	KindPest    Kind = "pest"
+	KindDisease Kind = "disease"
+)
+
+// IsValid checks if kind is valid
+func (k Kind) IsValid() bool {
+	return k == KindPest || k == KindDisease
+}
+
+// SeverityLevel is one step of an entry's severity scale, 1 being the mildest
+type SeverityLevel struct {
+	Level     int    `json:"level"`
+	Label     string `json:"label"`
+	Treatment string `json:"treatment,omitempty"` // Overrides the entry's treatment at this level
+}
+
+// CatalogEntry is a pest or disease trees can be diagnosed with
+// An outbreak alert fires when AlertThreshold or more trees of one block are
+// diagnosed with the entry within AlertWindowDays.
+type CatalogEntry struct {
+	ID              string          `json:"id"`
+	Code            string          `json:"code"` // e.g. GANODERMA
+	Name            string          `json:"name"`
+	Kind            Kind            `json:"kind"`
+	Description     string          `json:"description"`
+	Treatment       string          `json:"treatment"` // Recommended treatment
+	SeverityScale   []SeverityLevel `json:"severity_scale"`
+	AlertThreshold  int             `json:"alert_threshold"`   // Trees per block, 0 = never alert
+	AlertWindowDays int             `json:"alert_window_days"` // Counting window for the threshold
+	Active          bool            `json:"active"`            // Inactive entries cannot be diagnosed
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_]{2,30}$`)
+
+// Validate checks if catalog entry is valid
+func (e *CatalogEntry) Validate() error {
+	if !codePattern.MatchString(e.Code) {
+		return errors.New("code must be 2-30 uppercase letters, digits or underscores")
+	}
+	if strings.TrimSpace(e.Name) == "" || len(e.Name) > 100 {
+		return errors.New("name is required (max 100 characters)")
+	}
+	if !e.Kind.IsValid() {
+		return fmt.Errorf("invalid kind: %s (pest or disease)", e.Kind)
+	}
+	if len(e.SeverityScale) < 2 || len(e.SeverityScale) > 10 {
+		return errors.New("severity scale needs 2 to 10 levels")
+	}
+	for i, level := range e.SeverityScale {
+		if level.Level != i+1 {
+			return errors.New("severity levels must be numbered 1, 2, 3, ... in order")
+		}
+		if strings.TrimSpace(level.Label) == "" {
+			return fmt.Errorf("severity level %d needs a label", level.Level)
+		}
+	}
+	if e.AlertThreshold < 0 {
+		return errors.New("alert threshold cannot be negative")
+	}
+	if e.AlertThreshold > 0 && (e.AlertWindowDays < 1 || e.AlertWindowDays > 365) {
+		return errors.New("alert window must be between 1 and 365 days")
+	}
+	return nil
+}
+
+// MaxSeverity returns the highest level of the scale
+func (e *CatalogEntry) MaxSeverity() int {
+	return len(e.SeverityScale)
+}
+
+// TreatmentFor returns the recommended treatment at a severity level
+func (e *CatalogEntry) TreatmentFor(severity int) string {
+	if severity >= 1 && severity <= len(e.SeverityScale) && e.SeverityScale[severity-1].Treatment != "" {
+		return e.SeverityScale[severity-1].Treatment
+	}
+	return e.Treatment
+}
+
+// Diagnosis is one pest or disease found on a tree during a monitoring entry
+type Diagnosis struct {
+	ID            string    `json:"id"`
+	LogID         string    `json:"log_id"` // Monitoring log it was recorded with
+	TreeID        string    `json:"tree_id"`
+	TreeCode      string    `json:"tree_code"`
+	LocationID    string    `json:"location_id"`
+	EntryID       string    `json:"entry_id"`
+	EntryCode     string    `json:"entry_code"`
+	EntryName     string    `json:"entry_name"`
+	Severity      int       `json:"severity"`
+	SeverityLabel string    `json:"severity_label"`
+	Treatment     string    `json:"treatment"` // Recommended at this severity
+	Notes         string    `json:"notes"`
+	DiagnosedBy   string    `json:"diagnosed_by"`
+	DiagnosedAt   time.Time `json:"diagnosed_at"`
+}
+
+// Finding is one diagnosis in a monitoring entry request
+type Finding struct {
+	EntryID  string // Catalog ID or code
+	Severity int
+	Notes    string
+}
+
+// MaxFindings limits the diagnoses of one monitoring entry
+const MaxFindings = 10
+
+// DiagnoseRequest is a monitoring entry with diagnoses
+// Empty Status and nil HealthScore keep the tree's current values.
+type DiagnoseRequest struct {
+	TreeCode        string
+	Status          tree.TreeStatus
+	HealthScore     *int
+	Notes           string
+	Findings        []Finding
+	ExpectedVersion int
+}
+
+// Period groups the spread report
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+// IsValid checks if period is valid
+func (p Period) IsValid() bool {
+	return p == PeriodDay || p == PeriodWeek || p == PeriodMonth
+}
+
+// SpreadFilter selects the outbreak spread report
+type SpreadFilter struct {
+	Period      Period
+	EntryID     string
+	LocationID  string
+	From        time.Time
+	To          time.Time
+	LocationIDs []string // Scope (nil = any)
+}
+
+// SpreadRow counts cases of one pest or disease in one block in one period
+type SpreadRow struct {
+	PeriodStart time.Time `json:"period_start"`
+	LocationID  string    `json:"location_id"`
+	EntryID     string    `json:"entry_id"`
+	EntryCode   string    `json:"entry_code"`
+	Cases       int       `json:"cases"` // Diagnoses
+	Trees       int       `json:"trees"` // Distinct trees
+	AvgSeverity float64   `json:"avg_severity"`
+	MaxSeverity int       `json:"max_severity"`
+}
+
+// Alert records a block crossing an entry's outbreak threshold
+type Alert struct {
+	ID             string     `json:"id"`
+	EntryID        string     `json:"entry_id"`
+	EntryCode      string     `json:"entry_code"`
+	LocationID     string     `json:"location_id"`
+	Trees          int        `json:"trees"` // Trees diagnosed within the window
+	Threshold      int        `json:"threshold"`
+	WindowStart    time.Time  `json:"window_start"`
+	TriggeredAt    time.Time  `json:"triggered_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+}
+
+// AlertFilter narrows the alert list
+type AlertFilter struct {
+	EntryID        string
+	LocationID     string
+	Unacknowledged bool
+	Limit          int
+	Offset         int
+	LocationIDs    []string // Scope (nil = any)
+}
+
+// GetAlertRecipients returns who is emailed about outbreaks (OUTBREAK_ALERT_EMAILS, comma-separated)
+func GetAlertRecipients() []string {
+	var recipients []string
+	for _, email := range strings.Split(os.Getenv("OUTBREAK_ALERT_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			recipients = append(recipients, email)
+		}
+	}
+	return recipients
+}
@@ -0,0 +1,441 @@
+package diagnosis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/notification"
+	"prabogo/internal/domain/paging"
+	"prabogo/internal/domain/tree"
+
+	"github.com/google/uuid"
+)
+
+// CatalogRepository stores the pest and disease catalog
+type CatalogRepository interface {
+	Create(ctx context.Context, e *CatalogEntry) error
+	FindByID(ctx context.Context, id string) (*CatalogEntry, error)
+	FindByCode(ctx context.Context, code string) (*CatalogEntry, error)
+
+	// FindAll returns entries ordered by kind and name
+	FindAll(ctx context.Context, activeOnly bool) ([]*CatalogEntry, error)
+
+	Update(ctx context.Context, e *CatalogEntry) error
+
+	// Delete fails with ErrEntryInUse if the entry has diagnoses
+	Delete(ctx context.Context, id string) error
+}
+
+// Repository stores diagnoses and outbreak alerts
+type Repository interface {
+	CreateDiagnoses(ctx context.Context, diagnoses []*Diagnosis) error
+
+	// FindByTree returns a tree's diagnoses, newest first
+	FindByTree(ctx context.Context, treeID string) ([]*Diagnosis, error)
+
+	// Spread counts cases per period, block and entry
+	Spread(ctx context.Context, filter SpreadFilter) ([]SpreadRow, error)
+
+	// CountTrees counts distinct trees of a block diagnosed with an entry since a time
+	CountTrees(ctx context.Context, entryID string, locationID string, since time.Time) (int, error)
+
+	// CreateAlert stores the alert unless the block already has one for the
+	// entry triggered since alert.WindowStart; reports whether it was stored
+	CreateAlert(ctx context.Context, alert *Alert) (bool, error)
+
+	FindAlertByID(ctx context.Context, id string) (*Alert, error)
+	FindAlerts(ctx context.Context, filter AlertFilter) ([]*Alert, int, error)
+	AcknowledgeAlert(ctx context.Context, id string, userID string, at time.Time) error
+}
+
+// Trees is the subset of tree.TreeUseCase diagnoses are recorded through
+type Trees interface {
+	GetTreeByCode(ctx context.Context, code string) (*tree.TreeResponse, error)
+	RecordTreeCondition(ctx context.Context, code string, status tree.TreeStatus, healthScore int, notes string, userID string, expectedVersion int) (string, error)
+}
+
+// DiagnosisService manages the pest and disease catalog, diagnoses and outbreak alerts
+type DiagnosisService struct {
+	catalog   CatalogRepository
+	diagnoses Repository
+	trees     Trees
+	notifier  notification.Notifier
+	auditor   audit.Recorder
+}
+
+// NewDiagnosisService creates a new diagnosis service
+func NewDiagnosisService(catalog CatalogRepository, diagnoses Repository, trees Trees, notifier notification.Notifier, auditor audit.Recorder) *DiagnosisService {
+	return &DiagnosisService{
+		catalog:   catalog,
+		diagnoses: diagnoses,
+		trees:     trees,
+		notifier:  notifier,
+		auditor:   auditor,
+	}
+}
+
+// Diagnose writes a monitoring entry for a tree with one or more diagnoses
+// Findings are checked before the tree is touched. Blocks crossing an entry's
+// outbreak threshold raise an alert.
+func (s *DiagnosisService) Diagnose(ctx context.Context, req DiagnoseRequest, userID string) ([]*Diagnosis, error) {
+	if len(req.Findings) == 0 {
+		return nil, errors.New("at least one diagnosis is required")
+	}
+	if len(req.Findings) > MaxFindings {
+		return nil, fmt.Errorf("one monitoring entry can have at most %d diagnoses", MaxFindings)
+	}
+
+	entries := make([]*CatalogEntry, len(req.Findings))
+	seen := make(map[string]bool, len(req.Findings))
+	for i, f := range req.Findings {
+		entry, err := s.resolve(ctx, f.EntryID)
+		if err != nil {
+			return nil, err
+		}
+		if !entry.Active {
+			return nil, fmt.Errorf("%s is inactive", entry.Code)
+		}
+		if seen[entry.ID] {
+			return nil, fmt.Errorf("%s is listed twice", entry.Code)
+		}
+		seen[entry.ID] = true
+		if f.Severity < 1 || f.Severity > entry.MaxSeverity() {
+			return nil, fmt.Errorf("severity of %s must be between 1 and %d", entry.Code, entry.MaxSeverity())
+		}
+		if len(f.Notes) > 1000 {
+			return nil, errors.New("diagnosis notes are too long (max 1000 characters)")
+		}
+		entries[i] = entry
+	}
+
+	t, err := s.trees.GetTreeByCode(ctx, req.TreeCode)
+	if err != nil {
+		return nil, err
+	}
+	status := req.Status
+	if status == "" {
+		status = tree.TreeStatus(t.Status)
+	}
+	health := t.HealthScore
+	if req.HealthScore != nil {
+		health = *req.HealthScore
+	}
+	notes := req.Notes
+	if notes == "" {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = fmt.Sprintf("%s (%s)", entry.Name, entry.SeverityScale[req.Findings[i].Severity-1].Label)
+		}
+		notes = "Diagnosa: " + strings.Join(names, ", ")
+	}
+
+	logID, err := s.trees.RecordTreeCondition(ctx, t.Code, status, health, notes, userID, req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if logID == "" {
+		return nil, errors.New("tree updated but the monitoring log could not be written; diagnoses not stored")
+	}
+
+	now := time.Now().UTC()
+	diagnoses := make([]*Diagnosis, len(entries))
+	for i, entry := range entries {
+		diagnoses[i] = &Diagnosis{
+			ID:          uuid.New().String(),
+			LogID:       logID,
+			TreeID:      t.ID,
+			TreeCode:    t.Code,
+			LocationID:  t.LocationID,
+			EntryID:     entry.ID,
+			Severity:    req.Findings[i].Severity,
+			Notes:       req.Findings[i].Notes,
+			DiagnosedBy: userID,
+			DiagnosedAt: now,
+		}
+		describe(diagnoses[i], entry)
+	}
+	if err := s.diagnoses.CreateDiagnoses(ctx, diagnoses); err != nil {
+		return nil, fmt.Errorf("tree updated but diagnoses not stored: %w", err)
+	}
+
+	for _, entry := range entries {
+		s.checkOutbreak(ctx, entry, t.LocationID, now)
+	}
+	return diagnoses, nil
+}
+
+// checkOutbreak raises an alert when a block crosses the entry's threshold
+// A block gets at most one alert per entry and window; failures are logged.
+func (s *DiagnosisService) checkOutbreak(ctx context.Context, entry *CatalogEntry, locationID string, now time.Time) {
+	if entry.AlertThreshold == 0 {
+		return
+	}
+	since := now.AddDate(0, 0, -entry.AlertWindowDays)
+	count, err := s.diagnoses.CountTrees(ctx, entry.ID, locationID, since)
+	if err != nil {
+		fmt.Printf("⚠️ Warning: Failed to count %s cases in %s: %v\n", entry.Code, locationID, err)
+		return
+	}
+	if count < entry.AlertThreshold {
+		return
+	}
+
+	alert := &Alert{
+		ID:          uuid.New().String(),
+		EntryID:     entry.ID,
+		EntryCode:   entry.Code,
+		LocationID:  locationID,
+		Trees:       count,
+		Threshold:   entry.AlertThreshold,
+		WindowStart: since,
+		TriggeredAt: now,
+	}
+	created, err := s.diagnoses.CreateAlert(ctx, alert)
+	if err != nil {
+		fmt.Printf("⚠️ Warning: Failed to store outbreak alert for %s in %s: %v\n", entry.Code, locationID, err)
+		return
+	}
+	if !created {
+		return
+	}
+	fmt.Printf("🚨 Outbreak alert: %s in %s (%d trees in %d days)\n", entry.Code, locationID, count, entry.AlertWindowDays)
+
+	for _, to := range GetAlertRecipients() {
+		msg := notification.Message{
+			To:      to,
+			Subject: fmt.Sprintf("Tree-ID: %s outbreak in %s", entry.Name, locationID),
+			Body: fmt.Sprintf("%d trees in block %s were diagnosed with %s (%s) in the last %d days, "+
+				"crossing the alert threshold of %d.\n\nRecommended treatment: %s\n",
+				count, locationID, entry.Name, entry.Code, entry.AlertWindowDays, entry.AlertThreshold, entry.Treatment),
+		}
+		if err := s.notifier.Send(ctx, msg); err != nil {
+			fmt.Printf("⚠️ Warning: Failed to send outbreak alert to %s: %v\n", to, err)
+		}
+	}
+}
+
+// TreeDiagnoses returns a tree's diagnoses, newest first
+func (s *DiagnosisService) TreeDiagnoses(ctx context.Context, code string) ([]*Diagnosis, error) {
+	t, err := s.trees.GetTreeByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	diagnoses, err := s.diagnoses.FindByTree(ctx, t.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load diagnoses: %w", err)
+	}
+
+	entries, err := s.catalog.FindAll(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog: %w", err)
+	}
+	byID := make(map[string]*CatalogEntry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+	for _, d := range diagnoses {
+		if entry, ok := byID[d.EntryID]; ok {
+			describe(d, entry)
+		}
+	}
+	return diagnoses, nil
+}
+
+// describe fills the catalog details of a diagnosis
+func describe(d *Diagnosis, entry *CatalogEntry) {
+	d.EntryCode = entry.Code
+	d.EntryName = entry.Name
+	if d.Severity >= 1 && d.Severity <= entry.MaxSeverity() {
+		d.SeverityLabel = entry.SeverityScale[d.Severity-1].Label
+	}
+	d.Treatment = entry.TreatmentFor(d.Severity)
+}
+
+// Spread reports cases per period, block and entry within the caller's scope
+// Defaults to weekly over the last 12 weeks.
+func (s *DiagnosisService) Spread(ctx context.Context, filter SpreadFilter) ([]SpreadRow, error) {
+	if filter.Period == "" {
+		filter.Period = PeriodWeek
+	}
+	if !filter.Period.IsValid() {
+		return nil, errors.New("period must be day, week or month")
+	}
+	if filter.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return nil, err
+		}
+	}
+	if filter.EntryID != "" {
+		entry, err := s.resolve(ctx, filter.EntryID)
+		if err != nil {
+			return nil, err
+		}
+		filter.EntryID = entry.ID
+	}
+	if filter.To.IsZero() {
+		filter.To = time.Now().UTC()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(0, 0, -84)
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, errors.New("from must be before to")
+	}
+	filter.LocationIDs, _ = tree.LocationScope(ctx)
+
+	rows, err := s.diagnoses.Spread(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report spread: %w", err)
+	}
+	return rows, nil
+}
+
+// Alerts returns a page of outbreak alerts within the caller's scope, newest first
+func (s *DiagnosisService) Alerts(ctx context.Context, filter AlertFilter) ([]*Alert, int, error) {
+	if filter.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return nil, 0, err
+		}
+	}
+	if filter.EntryID != "" {
+		entry, err := s.resolve(ctx, filter.EntryID)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.EntryID = entry.ID
+	}
+	filter.Limit, filter.Offset = paging.Normalize(filter.Limit, filter.Offset)
+	filter.LocationIDs, _ = tree.LocationScope(ctx)
+
+	alerts, total, err := s.diagnoses.FindAlerts(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	return alerts, total, nil
+}
+
+// AcknowledgeAlert marks an alert as handled
+func (s *DiagnosisService) AcknowledgeAlert(ctx context.Context, id string, userID string) (*Alert, error) {
+	alert, err := s.diagnoses.FindAlertByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.CheckLocationScope(ctx, alert.LocationID); err != nil {
+		return nil, err
+	}
+	if alert.AcknowledgedAt != nil {
+		return alert, nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.diagnoses.AcknowledgeAlert(ctx, id, userID, now); err != nil {
+		return nil, err
+	}
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedBy = userID
+	return alert, nil
+}
+
+// resolve finds a catalog entry by ID or code
+func (s *DiagnosisService) resolve(ctx context.Context, idOrCode string) (*CatalogEntry, error) {
+	if idOrCode == "" {
+		return nil, errors.New("pest or disease is required")
+	}
+	if codePattern.MatchString(idOrCode) {
+		if entry, err := s.catalog.FindByCode(ctx, idOrCode); err == nil {
+			return entry, nil
+		} else if !errors.Is(err, ErrEntryNotFound) {
+			return nil, err
+		}
+	}
+	return s.catalog.FindByID(ctx, idOrCode)
+}
+
+// ListCatalog returns the catalog, optionally only active entries
+func (s *DiagnosisService) ListCatalog(ctx context.Context, activeOnly bool) ([]*CatalogEntry, error) {
+	return s.catalog.FindAll(ctx, activeOnly)
+}
+
+// GetEntry returns one catalog entry by ID or code
+func (s *DiagnosisService) GetEntry(ctx context.Context, idOrCode string) (*CatalogEntry, error) {
+	return s.resolve(ctx, idOrCode)
+}
+
+// CreateEntry adds a pest or disease to the catalog
+func (s *DiagnosisService) CreateEntry(ctx context.Context, entry CatalogEntry) (*CatalogEntry, error) {
+	entry.ID = uuid.New().String()
+	entry.Code = strings.ToUpper(strings.TrimSpace(entry.Code))
+	entry.Name = strings.TrimSpace(entry.Name)
+	entry.CreatedAt = time.Now().UTC()
+	entry.UpdatedAt = entry.CreatedAt
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.catalog.Create(ctx, &entry); err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "catalog_entry.create",
+		TargetType: audit.TargetCatalogEntry,
+		TargetID:   entry.ID,
+		After:      audit.Snapshot(entry),
+	})
+	return &entry, nil
+}
+
+// UpdateEntry replaces a catalog entry's settings
+// Shrinking the severity scale does not touch recorded diagnoses.
+func (s *DiagnosisService) UpdateEntry(ctx context.Context, id string, update CatalogEntry) (*CatalogEntry, error) {
+	before, err := s.catalog.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := update
+	entry.ID = before.ID
+	entry.Code = strings.ToUpper(strings.TrimSpace(entry.Code))
+	entry.Name = strings.TrimSpace(entry.Name)
+	entry.CreatedAt = before.CreatedAt
+	entry.UpdatedAt = time.Now().UTC()
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.catalog.Update(ctx, &entry); err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "catalog_entry.update",
+		TargetType: audit.TargetCatalogEntry,
+		TargetID:   entry.ID,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(entry),
+	})
+	return &entry, nil
+}
+
+// DeleteEntry removes an entry that was never diagnosed
+func (s *DiagnosisService) DeleteEntry(ctx context.Context, id string) error {
+	before, err := s.catalog.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.catalog.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "catalog_entry.delete",
+		TargetType: audit.TargetCatalogEntry,
+		TargetID:   id,
+		Before:     audit.Snapshot(before),
+	})
+	return nil
+}
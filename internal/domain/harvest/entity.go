@@ -0,0 +1,215 @@
+package harvest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrHarvestNotFound is returned for unknown harvest IDs
+	ErrHarvestNotFound = errors.New("harvest record not found")
+)
+
+// Grade is the mill sorting grade of a harvest
+type Grade string
+
+const (
+	GradeA      Grade = "A"      // Ripe, standard bunches
+	GradeB      Grade = "B"      // Under- or overripe
+	GradeC      Grade = "C"      // Low quality (unripe, long stalks, damaged)
+	GradeReject Grade = "REJECT" // Refused by the mill
+)
+
+// IsValid checks if grade is valid
+func (g Grade) IsValid() bool {
+	switch g {
+	case GradeA, GradeB, GradeC, GradeReject:
+		return true
+	}
+	return false
+}
+
+// MaxBatch limits the records of one batch request (a harvest day sheet)
+const MaxBatch = 500
+
+// Harvest records fresh fruit bunches taken from one tree or, without a tree, from a block
+type Harvest struct {
+	ID          string    `json:"id"`
+	TreeID      string    `json:"tree_id,omitempty"`
+	TreeCode    string    `json:"tree_code,omitempty"` // Empty for block-level records
+	LocationID  string    `json:"location_id"`
+	Bunches     int       `json:"bunches"` // Fresh fruit bunches (TBS)
+	WeightKg    float64   `json:"weight_kg"`
+	Grade       Grade     `json:"grade"`
+	HarvesterID string    `json:"harvester_id"`
+	HarvestedAt time.Time `json:"harvested_at"`
+	Notes       string    `json:"notes"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate checks if harvest entity is valid
+func (h *Harvest) Validate() error {
+	if h.LocationID == "" {
+		return errors.New("location is required")
+	}
+	if h.Bunches < 0 || h.Bunches > 100000 {
+		return errors.New("bunches cannot be negative")
+	}
+	if h.WeightKg <= 0 || h.WeightKg > 10000000 {
+		return errors.New("weight must be positive")
+	}
+	if h.TreeCode != "" && h.Bunches > 50 {
+		return fmt.Errorf("%d bunches from one tree is not plausible", h.Bunches)
+	}
+	if !h.Grade.IsValid() {
+		return fmt.Errorf("invalid grade: %s (A, B, C, REJECT)", h.Grade)
+	}
+	if h.HarvesterID == "" {
+		return errors.New("harvester is required")
+	}
+	if h.HarvestedAt.IsZero() {
+		return errors.New("harvest date is required")
+	}
+	if h.HarvestedAt.After(time.Now().UTC()) {
+		return errors.New("harvest date cannot be in the future")
+	}
+	if len(h.Notes) > 1000 {
+		return errors.New("notes are too long (max 1000 characters)")
+	}
+	return nil
+}
+
+// Filter narrows harvest lists and exports
+type Filter struct {
+	TreeCode    string
+	LocationID  string
+	HarvesterID string
+	Grade       Grade
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Offset      int
+	LocationIDs []string // Scope (nil = any)
+}
+
+// Period groups the yield series
+type Period string
+
+const (
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+	PeriodYear  Period = "year"
+)
+
+// IsValid checks if period is valid
+func (p Period) IsValid() bool {
+	return p == PeriodWeek || p == PeriodMonth || p == PeriodYear
+}
+
+// YieldFilter selects the yield series of a block, a tree or the whole plantation
+type YieldFilter struct {
+	Period     Period
+	LocationID string
+	TreeCode   string // One tree's series; block-level records are left out
+	From       time.Time
+	To         time.Time
+}
+
+// YieldQuery is a YieldFilter resolved for the repository
+type YieldQuery struct {
+	Period      Period
+	LocationID  string
+	TreeID      string
+	From        time.Time
+	To          time.Time
+	LocationIDs []string // Scope (nil = any)
+}
+
+// YieldRow sums the harvests of one block in one period
+type YieldRow struct {
+	PeriodStart time.Time
+	LocationID  string
+	Records     int
+	Bunches     int
+	WeightKg    float64
+	Trees       int // Distinct trees with tree-level records
+}
+
+// HealthRow counts the monitoring logs of one status in one block in one period
+type HealthRow struct {
+	PeriodStart    time.Time
+	LocationID     string
+	Status         string
+	Logs           int
+	HealthScoreSum int
+}
+
+// YieldPoint is the yield of one block in one period next to the trees' condition
+type YieldPoint struct {
+	PeriodStart     time.Time          `json:"period_start"`
+	LocationID      string             `json:"location_id"`
+	Records         int                `json:"records"`
+	Bunches         int                `json:"bunches"`
+	WeightKg        float64            `json:"weight_kg"`
+	AvgBunchKg      float64            `json:"avg_bunch_kg"`
+	ProductiveTrees int                `json:"productive_trees"` // Living trees of the block today
+	KgPerTree       float64            `json:"kg_per_tree"`
+	AreaHectare     float64            `json:"area_hectare,omitempty"`
+	KgPerHectare    *float64           `json:"kg_per_hectare"` // nil without a block area
+	AvgHealthScore  *float64           `json:"avg_health_score"`
+	StatusShare     map[string]float64 `json:"status_share"` // Share of the period's monitoring logs per status
+	MonitoringLogs  int                `json:"monitoring_logs"`
+}
+
+// StatusYield is the yield of tree-level harvests by the tree's status when harvested
+type StatusYield struct {
+	Status      string  `json:"status"` // Latest monitoring status before the harvest, "" if unknown
+	Records     int     `json:"records"`
+	Trees       int     `json:"trees"`
+	Bunches     int     `json:"bunches"`
+	WeightKg    float64 `json:"weight_kg"`
+	KgPerRecord float64 `json:"kg_per_record"`
+}
+
+// Correlation relates yield to health across the series points
+type Correlation struct {
+	HealthVsKgPerTree *float64 `json:"health_vs_kg_per_tree"` // Pearson r, nil with fewer than 3 points
+	Points            int      `json:"points"`
+}
+
+// YieldReport is the yield series with its correlation to tree condition
+type YieldReport struct {
+	Period      Period        `json:"period"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Series      []YieldPoint  `json:"series"`
+	ByStatus    []StatusYield `json:"by_status"`
+	Correlation Correlation   `json:"correlation"`
+}
+
+// Totals sums harvests over a range
+type Totals struct {
+	Records          int
+	Bunches          int
+	WeightKg         float64
+	WeightByLocation map[string]float64 // Blocks harvested
+}
+
+// Summary is the production block of the statistics response
+type Summary struct {
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Records      int       `json:"records"`
+	Bunches      int       `json:"bunches"`
+	WeightKg     float64   `json:"weight_kg"`
+	AvgBunchKg   float64   `json:"avg_bunch_kg"`
+	KgPerHectare *float64  `json:"kg_per_hectare"` // Over the harvested blocks' area
+}
+
+// Production summarizes the last 30 days and the year to date
+type Production struct {
+	Last30Days Summary `json:"last_30_days"`
+	YearToDate Summary `json:"year_to_date"`
+}
@@ -0,0 +1,531 @@
+package harvest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/paging"
+	"prabogo/internal/domain/tree"
+
+	"github.com/google/uuid"
+)
+
+// Repository stores harvests and computes yield
+type Repository interface {
+	// CreateMany inserts the records atomically
+	CreateMany(ctx context.Context, harvests []*Harvest) error
+
+	FindByID(ctx context.Context, id string) (*Harvest, error)
+
+	// FindAll returns a page of records, latest harvest first, and the total matching
+	FindAll(ctx context.Context, filter Filter) ([]*Harvest, int, error)
+
+	// Stream calls fn for every matching record, oldest first
+	Stream(ctx context.Context, filter Filter, fn func(*Harvest) error) error
+
+	Delete(ctx context.Context, id string) error
+
+	// Yield sums harvests per period and block
+	Yield(ctx context.Context, q YieldQuery) ([]YieldRow, error)
+
+	// YieldByStatus sums tree-level harvests by the tree's latest monitoring status before each harvest
+	YieldByStatus(ctx context.Context, q YieldQuery) ([]StatusYield, error)
+
+	// Health counts monitoring logs per period, block and status for the given trees
+	// treeIDs and locationIDs are parallel: the block each tree is counted under.
+	Health(ctx context.Context, period Period, from, to time.Time, treeIDs, locationIDs []string) ([]HealthRow, error)
+
+	// Areas returns the area in hectares of blocks that have one
+	Areas(ctx context.Context, locationIDs []string) (map[string]float64, error)
+
+	// Totals sums harvests in [from, to) within scope (nil = any)
+	Totals(ctx context.Context, from, to time.Time, scope []string) (*Totals, error)
+}
+
+// Trees is the subset of tree.TreeUseCase harvests are checked against
+type Trees interface {
+	GetTreeByCode(ctx context.Context, code string) (*tree.TreeResponse, error)
+	ExportTrees(ctx context.Context, filter tree.TreeFilter, fn func(*tree.TreeResponse) error) error
+}
+
+// Users looks up harvesters
+type Users interface {
+	FindByID(ctx context.Context, id string) (*auth.User, error)
+}
+
+// RecordRequest is one harvest of a tree (TreeCode) or a block (LocationID only)
+type RecordRequest struct {
+	TreeCode    string
+	LocationID  string
+	Bunches     int
+	WeightKg    float64
+	Grade       Grade
+	HarvesterID string // Defaults to the recording user
+	HarvestedAt time.Time
+	Notes       string
+}
+
+// HarvestService records harvests and reports yield
+type HarvestService struct {
+	repo    Repository
+	trees   Trees
+	users   Users
+	auditor audit.Recorder
+}
+
+// NewHarvestService creates a new harvest service
+func NewHarvestService(repo Repository, trees Trees, users Users, auditor audit.Recorder) *HarvestService {
+	return &HarvestService{
+		repo:    repo,
+		trees:   trees,
+		users:   users,
+		auditor: auditor,
+	}
+}
+
+// Record stores one or more harvests; nothing is stored if any is invalid
+func (s *HarvestService) Record(ctx context.Context, reqs []RecordRequest, userID string) ([]*Harvest, error) {
+	if len(reqs) == 0 {
+		return nil, errors.New("no harvest records")
+	}
+	if len(reqs) > MaxBatch {
+		return nil, fmt.Errorf("one request can hold at most %d records", MaxBatch)
+	}
+
+	harvesters := make(map[string]bool)
+	harvests := make([]*Harvest, len(reqs))
+	now := time.Now().UTC()
+	for i, req := range reqs {
+		h, err := s.build(ctx, req, userID, now, harvesters)
+		if err != nil {
+			if len(reqs) == 1 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		harvests[i] = h
+	}
+
+	if err := s.repo.CreateMany(ctx, harvests); err != nil {
+		return nil, fmt.Errorf("failed to store harvests: %w", err)
+	}
+
+	for _, h := range harvests {
+		s.auditor.Record(ctx, audit.Entry{
+			Action:     "harvest.record",
+			TargetType: audit.TargetHarvest,
+			TargetID:   h.ID,
+			After:      audit.Snapshot(h),
+		})
+	}
+	return harvests, nil
+}
+
+// build resolves and validates one record; harvesters caches known harvester IDs
+func (s *HarvestService) build(ctx context.Context, req RecordRequest, userID string, now time.Time, harvesters map[string]bool) (*Harvest, error) {
+	h := &Harvest{
+		ID:          uuid.New().String(),
+		LocationID:  req.LocationID,
+		Bunches:     req.Bunches,
+		WeightKg:    req.WeightKg,
+		Grade:       Grade(strings.ToUpper(string(req.Grade))),
+		HarvesterID: req.HarvesterID,
+		HarvestedAt: req.HarvestedAt,
+		Notes:       req.Notes,
+		CreatedBy:   userID,
+		CreatedAt:   now,
+	}
+	if h.HarvesterID == "" {
+		h.HarvesterID = userID
+	}
+	if h.Grade == "" {
+		h.Grade = GradeA
+	}
+
+	if req.TreeCode != "" {
+		t, err := s.trees.GetTreeByCode(ctx, req.TreeCode)
+		if err != nil {
+			return nil, fmt.Errorf("tree %s: %w", req.TreeCode, err)
+		}
+		if t.Status == string(tree.StatusMati) {
+			return nil, fmt.Errorf("tree %s is dead", t.Code)
+		}
+		if h.LocationID != "" && h.LocationID != t.LocationID {
+			return nil, fmt.Errorf("tree %s is in %s, not %s", t.Code, t.LocationID, h.LocationID)
+		}
+		h.TreeID, h.TreeCode, h.LocationID = t.ID, t.Code, t.LocationID
+	} else if h.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, h.LocationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !harvesters[h.HarvesterID] {
+		harvester, err := s.users.FindByID(ctx, h.HarvesterID)
+		if err != nil || harvester == nil || harvester.DeletedAt != nil {
+			return nil, fmt.Errorf("harvester %s not found", h.HarvesterID)
+		}
+		harvesters[h.HarvesterID] = true
+	}
+	return h, nil
+}
+
+// Get returns one record within the caller's location scope
+func (s *HarvestService) Get(ctx context.Context, id string) (*Harvest, error) {
+	h, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.CheckLocationScope(ctx, h.LocationID); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// List returns a page of records within the caller's location scope
+func (s *HarvestService) List(ctx context.Context, filter Filter) ([]*Harvest, int, error) {
+	if err := s.checkFilter(ctx, &filter); err != nil {
+		return nil, 0, err
+	}
+	filter.Limit, filter.Offset = paging.Normalize(filter.Limit, filter.Offset)
+
+	harvests, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list harvests: %w", err)
+	}
+	return harvests, total, nil
+}
+
+// Export streams every matching record within the caller's location scope
+func (s *HarvestService) Export(ctx context.Context, filter Filter, fn func(*Harvest) error) error {
+	if err := s.checkFilter(ctx, &filter); err != nil {
+		return err
+	}
+	return s.repo.Stream(ctx, filter, fn)
+}
+
+func (s *HarvestService) checkFilter(ctx context.Context, filter *Filter) error {
+	if filter.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return err
+		}
+	}
+	if filter.Grade != "" && !filter.Grade.IsValid() {
+		return fmt.Errorf("invalid grade: %s", filter.Grade)
+	}
+	filter.LocationIDs, _ = tree.LocationScope(ctx)
+	return nil
+}
+
+// Delete removes a mistaken record
+func (s *HarvestService) Delete(ctx context.Context, id string) error {
+	h, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "harvest.delete",
+		TargetType: audit.TargetHarvest,
+		TargetID:   id,
+		Before:     audit.Snapshot(h),
+	})
+	return nil
+}
+
+// Yield reports yield per tree and per hectare over time next to health and status
+// Per-tree yield divides by the block's living trees today; health comes from
+// the monitoring logs of those trees in each period. Defaults to monthly over
+// the last 12 months.
+func (s *HarvestService) Yield(ctx context.Context, filter YieldFilter) (*YieldReport, error) {
+	if filter.Period == "" {
+		filter.Period = PeriodMonth
+	}
+	if !filter.Period.IsValid() {
+		return nil, errors.New("period must be week, month or year")
+	}
+	if filter.To.IsZero() {
+		filter.To = time.Now().UTC()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(-1, 0, 0)
+	}
+	if !filter.From.Before(filter.To) {
+		return nil, errors.New("from must be before to")
+	}
+	if filter.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return nil, err
+		}
+	}
+
+	q := YieldQuery{Period: filter.Period, LocationID: filter.LocationID, From: filter.From, To: filter.To}
+	q.LocationIDs, _ = tree.LocationScope(ctx)
+
+	// The trees yield is divided over, with the block each is counted under
+	var treeIDs, treeLocations []string
+	productive := make(map[string]int)
+	if filter.TreeCode != "" {
+		t, err := s.trees.GetTreeByCode(ctx, filter.TreeCode)
+		if err != nil {
+			return nil, err
+		}
+		if filter.LocationID != "" && filter.LocationID != t.LocationID {
+			return nil, fmt.Errorf("tree %s is not in %s", t.Code, filter.LocationID)
+		}
+		q.TreeID = t.ID
+		treeIDs, treeLocations = []string{t.ID}, []string{t.LocationID}
+		productive[t.LocationID] = 1
+	} else {
+		err := s.trees.ExportTrees(ctx, tree.TreeFilter{LocationID: filter.LocationID}, func(t *tree.TreeResponse) error {
+			treeIDs = append(treeIDs, t.ID)
+			treeLocations = append(treeLocations, t.LocationID)
+			if t.Status != string(tree.StatusMati) {
+				productive[t.LocationID]++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load trees: %w", err)
+		}
+	}
+
+	yields, err := s.repo.Yield(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum harvests: %w", err)
+	}
+	health, err := s.repo.Health(ctx, filter.Period, filter.From, filter.To, treeIDs, treeLocations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load monitoring history: %w", err)
+	}
+	byStatus, err := s.repo.YieldByStatus(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum harvests by status: %w", err)
+	}
+	for i := range byStatus {
+		if byStatus[i].Records > 0 {
+			byStatus[i].KgPerRecord = round(byStatus[i].WeightKg / float64(byStatus[i].Records))
+		}
+	}
+
+	var areas map[string]float64
+	if filter.TreeCode == "" {
+		locationIDs := make([]string, 0, len(productive))
+		for id := range productive {
+			locationIDs = append(locationIDs, id)
+		}
+		for _, y := range yields {
+			if _, ok := productive[y.LocationID]; !ok {
+				locationIDs = append(locationIDs, y.LocationID)
+			}
+		}
+		if areas, err = s.repo.Areas(ctx, locationIDs); err != nil {
+			return nil, fmt.Errorf("failed to load block areas: %w", err)
+		}
+	}
+
+	return &YieldReport{
+		Period:      filter.Period,
+		From:        filter.From,
+		To:          filter.To,
+		Series:      buildSeries(yields, health, productive, areas),
+		ByStatus:    byStatus,
+		Correlation: correlate(yields, health, productive),
+	}, nil
+}
+
+type pointKey struct {
+	period   time.Time
+	location string
+}
+
+// buildSeries joins harvest sums and monitoring logs per period and block
+// Periods with logs but no harvest are kept, as zero yield is part of the picture.
+func buildSeries(yields []YieldRow, health []HealthRow, productive map[string]int, areas map[string]float64) []YieldPoint {
+	points := make(map[pointKey]*YieldPoint)
+	point := func(period time.Time, location string) *YieldPoint {
+		key := pointKey{period, location}
+		p, ok := points[key]
+		if !ok {
+			p = &YieldPoint{PeriodStart: period, LocationID: location, StatusShare: map[string]float64{}}
+			points[key] = p
+		}
+		return p
+	}
+
+	for _, y := range yields {
+		p := point(y.PeriodStart, y.LocationID)
+		p.Records, p.Bunches, p.WeightKg = y.Records, y.Bunches, y.WeightKg
+	}
+	healthSums := make(map[pointKey]int)
+	for _, h := range health {
+		p := point(h.PeriodStart, h.LocationID)
+		p.MonitoringLogs += h.Logs
+		p.StatusShare[h.Status] += float64(h.Logs)
+		healthSums[pointKey{h.PeriodStart, h.LocationID}] += h.HealthScoreSum
+	}
+
+	series := make([]YieldPoint, 0, len(points))
+	for key, p := range points {
+		if p.Bunches > 0 {
+			p.AvgBunchKg = round(p.WeightKg / float64(p.Bunches))
+		}
+		p.ProductiveTrees = productive[p.LocationID]
+		if p.ProductiveTrees > 0 {
+			p.KgPerTree = round(p.WeightKg / float64(p.ProductiveTrees))
+		}
+		if area := areas[p.LocationID]; area > 0 {
+			p.AreaHectare = area
+			perHectare := round(p.WeightKg / area)
+			p.KgPerHectare = &perHectare
+		}
+		if p.MonitoringLogs > 0 {
+			avg := round(float64(healthSums[key]) / float64(p.MonitoringLogs))
+			p.AvgHealthScore = &avg
+			for status, n := range p.StatusShare {
+				p.StatusShare[status] = round(n / float64(p.MonitoringLogs))
+			}
+		}
+		series = append(series, *p)
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if !series[i].PeriodStart.Equal(series[j].PeriodStart) {
+			return series[i].PeriodStart.Before(series[j].PeriodStart)
+		}
+		return series[i].LocationID < series[j].LocationID
+	})
+	return series
+}
+
+// correlate computes Pearson's r between average health and kg per tree over
+// the periods and blocks that have both monitoring logs and living trees
+func correlate(yields []YieldRow, health []HealthRow, productive map[string]int) Correlation {
+	weights := make(map[pointKey]float64)
+	for _, y := range yields {
+		weights[pointKey{y.PeriodStart, y.LocationID}] = y.WeightKg
+	}
+	logs := make(map[pointKey][2]int) // {logs, health sum}
+	for _, h := range health {
+		key := pointKey{h.PeriodStart, h.LocationID}
+		l := logs[key]
+		logs[key] = [2]int{l[0] + h.Logs, l[1] + h.HealthScoreSum}
+	}
+
+	var xs, ys []float64
+	for key, l := range logs {
+		trees := productive[key.location]
+		if l[0] == 0 || trees == 0 {
+			continue
+		}
+		xs = append(xs, float64(l[1])/float64(l[0]))
+		ys = append(ys, weights[key]/float64(trees))
+	}
+
+	result := Correlation{Points: len(xs)}
+	if r, ok := pearson(xs, ys); ok {
+		r = round(r)
+		result.HealthVsKgPerTree = &r
+	}
+	return result
+}
+
+func pearson(xs, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+	if len(xs) < 3 {
+		return 0, false
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varX*varY), true
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Production summarizes harvests of the last 30 days and the year to date
+// Used by the statistics response; scoped to the caller's locations.
+func (s *HarvestService) Production(ctx context.Context) (*Production, error) {
+	now := time.Now().UTC()
+	scope, _ := tree.LocationScope(ctx)
+
+	last30, err := s.summary(ctx, now.AddDate(0, 0, -30), now, scope)
+	if err != nil {
+		return nil, err
+	}
+	ytd, err := s.summary(ctx, time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC), now, scope)
+	if err != nil {
+		return nil, err
+	}
+	return &Production{Last30Days: *last30, YearToDate: *ytd}, nil
+}
+
+func (s *HarvestService) summary(ctx context.Context, from, to time.Time, scope []string) (*Summary, error) {
+	totals, err := s.repo.Totals(ctx, from, to, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum harvests: %w", err)
+	}
+
+	summary := &Summary{
+		From:     from,
+		To:       to,
+		Records:  totals.Records,
+		Bunches:  totals.Bunches,
+		WeightKg: round(totals.WeightKg),
+	}
+	if totals.Bunches > 0 {
+		summary.AvgBunchKg = round(totals.WeightKg / float64(totals.Bunches))
+	}
+
+	// Only blocks with a known area count towards kg per hectare
+	if len(totals.WeightByLocation) > 0 {
+		locationIDs := make([]string, 0, len(totals.WeightByLocation))
+		for id := range totals.WeightByLocation {
+			locationIDs = append(locationIDs, id)
+		}
+		areas, err := s.repo.Areas(ctx, locationIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load block areas: %w", err)
+		}
+		var area, weight float64
+		for id, a := range areas {
+			area += a
+			weight += totals.WeightByLocation[id]
+		}
+		if area > 0 {
+			perHectare := round(weight / area)
+			summary.KgPerHectare = &perHectare
+		}
+	}
+	return summary, nil
+}
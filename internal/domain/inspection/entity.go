@@ -0,0 +1,145 @@
+package inspection
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/tree"
+)
+
+// ErrScheduleNotFound is returned for unknown schedule IDs
+var ErrScheduleNotFound = errors.New("inspection schedule not found")
+
+// Schedule says how often matching trees must be inspected
+// Empty criteria match everything. When several enabled schedules match a tree
+// the most specific one applies (tree > status > location > species), ties
+// going to the shortest interval. Dead trees are never due.
+type Schedule struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Status       tree.TreeStatus `json:"status,omitempty"`
+	SpeciesID    string          `json:"species_id,omitempty"`
+	LocationID   string          `json:"location_id,omitempty"`
+	TreeCode     string          `json:"tree_code,omitempty"` // Individual tree
+	IntervalDays int             `json:"interval_days"`
+	LeadDays     int             `json:"lead_days"`             // Remind or create the task this many days ahead
+	AssigneeID   string          `json:"assignee_id,omitempty"` // Who inspects; reminders need one
+	CreateTasks  bool            `json:"create_tasks"`          // Generate work orders instead of emails
+	Enabled      bool            `json:"enabled"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// Validate checks if schedule entity is valid
+func (s *Schedule) Validate() error {
+	if strings.TrimSpace(s.Name) == "" || len(s.Name) > 100 {
+		return errors.New("schedule name is required (max 100 characters)")
+	}
+	if s.Status != "" && !(&tree.Tree{Status: s.Status}).IsValidStatus() {
+		return errors.New("invalid tree status")
+	}
+	if s.Status == tree.StatusMati {
+		return errors.New("dead trees are not inspected")
+	}
+	if s.IntervalDays < 1 || s.IntervalDays > 3650 {
+		return errors.New("interval must be between 1 and 3650 days")
+	}
+	if s.LeadDays < 0 || s.LeadDays >= s.IntervalDays {
+		return errors.New("lead days must be between 0 and the interval")
+	}
+	if s.CreateTasks && s.AssigneeID == "" {
+		return errors.New("an assignee is required to create tasks")
+	}
+	return nil
+}
+
+// specificity orders matching schedules: higher wins
+func (s *Schedule) specificity() int {
+	n := 0
+	if s.TreeCode != "" {
+		n += 8
+	}
+	if s.Status != "" {
+		n += 4
+	}
+	if s.LocationID != "" {
+		n += 2
+	}
+	if s.SpeciesID != "" {
+		n++
+	}
+	return n
+}
+
+func (s *Schedule) appliesTo(t *tree.TreeResponse) bool {
+	return s.Enabled &&
+		(s.TreeCode == "" || s.TreeCode == t.Code) &&
+		(s.Status == "" || string(s.Status) == t.Status) &&
+		(s.LocationID == "" || s.LocationID == t.LocationID) &&
+		(s.SpeciesID == "" || s.SpeciesID == t.SpeciesID)
+}
+
+// selectSchedule returns the schedule that applies to a tree, nil if none
+func selectSchedule(schedules []*Schedule, t *tree.TreeResponse) *Schedule {
+	if t.Status == string(tree.StatusMati) {
+		return nil
+	}
+	var best *Schedule
+	for _, s := range schedules {
+		if !s.appliesTo(t) {
+			continue
+		}
+		if best == nil || s.specificity() > best.specificity() ||
+			(s.specificity() == best.specificity() && s.IntervalDays < best.IntervalDays) {
+			best = s
+		}
+	}
+	return best
+}
+
+// Due is the next inspection of one tree
+type Due struct {
+	TreeID          string     `json:"tree_id"`
+	TreeCode        string     `json:"tree_code"`
+	LocationID      string     `json:"location_id"`
+	SpeciesID       string     `json:"species_id"`
+	Status          string     `json:"status"`
+	ScheduleID      string     `json:"schedule_id"`
+	ScheduleName    string     `json:"schedule_name"`
+	IntervalDays    int        `json:"interval_days"`
+	AssigneeID      string     `json:"assignee_id"`
+	LastInspectedAt *time.Time `json:"last_inspected_at"` // Latest monitoring log, nil if none
+	NextDueAt       time.Time  `json:"next_due_at"`
+	Overdue         bool       `json:"overdue"`
+	DaysOverdue     int        `json:"days_overdue,omitempty"`
+
+	leadDays    int
+	createTasks bool
+}
+
+// AssigneeSummary counts inspections of one assignee ("" = unassigned)
+type AssigneeSummary struct {
+	Due     int `json:"due"`
+	Overdue int `json:"overdue"`
+}
+
+// DueFilter narrows the due list
+type DueFilter struct {
+	AssigneeID string
+	LocationID string
+	SpeciesID  string
+	Overdue    bool // Only overdue
+	WithinDays int  // Also list inspections due in the next N days (default 0: due today or earlier)
+	Limit      int
+	Offset     int
+}
+
+// GetSchedulerInterval returns how often reminders and tasks are generated
+func GetSchedulerInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("INSPECTION_SCHEDULER_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
@@ -0,0 +1,455 @@
+package inspection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/notification"
+	"prabogo/internal/domain/paging"
+	"prabogo/internal/domain/task"
+	"prabogo/internal/domain/tree"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleRepository stores inspection schedules
+type ScheduleRepository interface {
+	FindAll(ctx context.Context) ([]*Schedule, error)
+	FindByID(ctx context.Context, id string) (*Schedule, error)
+	Create(ctx context.Context, schedule *Schedule) error
+	Update(ctx context.Context, schedule *Schedule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// HistoryRepository reads the monitoring history
+type HistoryRepository interface {
+	// LastInspections returns the latest monitoring log time per tree ID
+	LastInspections(ctx context.Context) (map[string]time.Time, error)
+}
+
+// ReminderRepository remembers which inspections were already announced
+type ReminderRepository interface {
+	// Claim records a reminder; false if it was already claimed
+	Claim(ctx context.Context, scheduleID string, treeID string, dueDate time.Time) (bool, error)
+
+	// Release forgets a claim whose task or email failed, so the next run retries
+	Release(ctx context.Context, scheduleID string, treeID string, dueDate time.Time) error
+
+	// SetTask links claimed reminders to the task generated for them
+	SetTask(ctx context.Context, scheduleID string, treeIDs []string, dueDate time.Time, taskID string) error
+
+	// PurgeBefore deletes reminders for inspections due before cutoff
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Trees is the subset of tree.TreeUseCase inspections read
+type Trees interface {
+	ExportTrees(ctx context.Context, filter tree.TreeFilter, fn func(*tree.TreeResponse) error) error
+}
+
+// Tasks creates inspection work orders
+type Tasks interface {
+	Create(ctx context.Context, actor task.Actor, req task.CreateRequest) (*task.Task, error)
+}
+
+// Users looks up assignees for reminder emails
+type Users interface {
+	FindByID(ctx context.Context, id string) (*auth.User, error)
+}
+
+// InspectionService computes due inspections and announces them ahead of time
+type InspectionService struct {
+	schedules ScheduleRepository
+	history   HistoryRepository
+	reminders ReminderRepository
+	trees     Trees
+	tasks     Tasks
+	users     Users
+	notifier  notification.Notifier
+	auditor   audit.Recorder
+}
+
+// NewInspectionService creates a new inspection service
+func NewInspectionService(
+	schedules ScheduleRepository,
+	history HistoryRepository,
+	reminders ReminderRepository,
+	trees Trees,
+	tasks Tasks,
+	users Users,
+	notifier notification.Notifier,
+	auditor audit.Recorder,
+) *InspectionService {
+	return &InspectionService{
+		schedules: schedules,
+		history:   history,
+		reminders: reminders,
+		trees:     trees,
+		tasks:     tasks,
+		users:     users,
+		notifier:  notifier,
+		auditor:   auditor,
+	}
+}
+
+// compute returns the next inspection of every scheduled tree matching filter
+// Next due is the latest monitoring log plus the interval; a tree without any
+// log is due one interval after registration.
+func (s *InspectionService) compute(ctx context.Context, filter tree.TreeFilter) ([]*Due, error) {
+	schedules, err := s.schedules.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inspection schedules: %w", err)
+	}
+	last, err := s.history.LastInspections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load monitoring history: %w", err)
+	}
+
+	now := time.Now().UTC()
+	dues := []*Due{}
+	err = s.trees.ExportTrees(ctx, filter, func(t *tree.TreeResponse) error {
+		if t.DeletedAt != "" {
+			return nil
+		}
+		schedule := selectSchedule(schedules, t)
+		if schedule == nil {
+			return nil
+		}
+
+		d := &Due{
+			TreeID:       t.ID,
+			TreeCode:     t.Code,
+			LocationID:   t.LocationID,
+			SpeciesID:    t.SpeciesID,
+			Status:       t.Status,
+			ScheduleID:   schedule.ID,
+			ScheduleName: schedule.Name,
+			IntervalDays: schedule.IntervalDays,
+			AssigneeID:   schedule.AssigneeID,
+			leadDays:     schedule.LeadDays,
+			createTasks:  schedule.CreateTasks,
+		}
+		base, ok := last[t.ID]
+		if ok {
+			inspected := base
+			d.LastInspectedAt = &inspected
+		} else if base, err = time.Parse(time.RFC3339, t.CreatedAt); err != nil {
+			base = now
+		}
+		d.NextDueAt = base.AddDate(0, 0, schedule.IntervalDays).UTC()
+		if now.After(d.NextDueAt) {
+			d.Overdue = true
+			d.DaysOverdue = int(now.Sub(d.NextDueAt).Hours() / 24)
+		}
+		dues = append(dues, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(dues, func(i, j int) bool {
+		if !dues[i].NextDueAt.Equal(dues[j].NextDueAt) {
+			return dues[i].NextDueAt.Before(dues[j].NextDueAt)
+		}
+		return dues[i].TreeCode < dues[j].TreeCode
+	})
+	return dues, nil
+}
+
+// Due returns a page of due (and soon due) inspections within the caller's
+// location scope, the total matching, and due/overdue counts per assignee
+func (s *InspectionService) Due(ctx context.Context, filter DueFilter) ([]*Due, int, map[string]AssigneeSummary, error) {
+	if filter.LocationID != "" {
+		if err := tree.CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	filter.Limit, filter.Offset = paging.Normalize(filter.Limit, filter.Offset)
+	if filter.WithinDays < 0 {
+		filter.WithinDays = 0
+	}
+
+	dues, err := s.compute(ctx, tree.TreeFilter{LocationID: filter.LocationID, SpeciesID: filter.SpeciesID})
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	horizon := time.Now().UTC().AddDate(0, 0, filter.WithinDays)
+	page := []*Due{}
+	total := 0
+	summary := make(map[string]AssigneeSummary)
+	for _, d := range dues {
+		if d.NextDueAt.After(horizon) {
+			break // Sorted by due date
+		}
+		if filter.AssigneeID != "" && d.AssigneeID != filter.AssigneeID {
+			continue
+		}
+
+		counts := summary[d.AssigneeID]
+		counts.Due++
+		if d.Overdue {
+			counts.Overdue++
+		}
+		summary[d.AssigneeID] = counts
+
+		if filter.Overdue && !d.Overdue {
+			continue
+		}
+		if total >= filter.Offset && len(page) < filter.Limit {
+			page = append(page, d)
+		}
+		total++
+	}
+
+	return page, total, summary, nil
+}
+
+// Generate announces inspections entering their lead window, once per due date
+// Schedules with CreateTasks get one inspect task per schedule and due day;
+// the others email their assignee a list of trees.
+func (s *InspectionService) Generate(ctx context.Context) error {
+	dues, err := s.compute(ctx, tree.TreeFilter{})
+	if err != nil {
+		return err
+	}
+
+	type batchKey struct {
+		scheduleID string
+		dueDate    time.Time
+	}
+	taskBatches := make(map[batchKey][]*Due)
+	emailBatches := make(map[string][]*Due)
+
+	now := time.Now().UTC()
+	for _, d := range dues {
+		if d.AssigneeID == "" || now.Before(d.NextDueAt.AddDate(0, 0, -d.leadDays)) {
+			continue
+		}
+		dueDate := d.NextDueAt.Truncate(24 * time.Hour)
+		claimed, err := s.reminders.Claim(ctx, d.ScheduleID, d.TreeID, dueDate)
+		if err != nil {
+			return fmt.Errorf("failed to claim inspection reminder: %w", err)
+		}
+		if !claimed {
+			continue
+		}
+
+		if d.createTasks {
+			key := batchKey{d.ScheduleID, dueDate}
+			taskBatches[key] = append(taskBatches[key], d)
+		} else {
+			emailBatches[d.AssigneeID] = append(emailBatches[d.AssigneeID], d)
+		}
+	}
+
+	for key, batch := range taskBatches {
+		for start := 0; start < len(batch); start += task.MaxTrees {
+			end := start + task.MaxTrees
+			if end > len(batch) {
+				end = len(batch)
+			}
+			s.createTask(ctx, batch[start:end], key.dueDate)
+		}
+	}
+	for assigneeID, batch := range emailBatches {
+		s.sendReminder(ctx, assigneeID, batch)
+	}
+	return nil
+}
+
+// createTask opens one inspect task; on failure the claims are released for a retry
+func (s *InspectionService) createTask(ctx context.Context, batch []*Due, dueDate time.Time) {
+	first := batch[0]
+	codes := make([]string, len(batch))
+	treeIDs := make([]string, len(batch))
+	for i, d := range batch {
+		codes[i] = d.TreeCode
+		treeIDs[i] = d.TreeID
+	}
+
+	t, err := s.tasks.Create(ctx, task.Actor{UserID: "system", CanManage: true}, task.CreateRequest{
+		Type:        task.TypeInspect,
+		Title:       fmt.Sprintf("Inspeksi: %s", first.ScheduleName),
+		Description: fmt.Sprintf("Generated by inspection schedule %q (every %d days).", first.ScheduleName, first.IntervalDays),
+		TreeCodes:   codes,
+		AssigneeID:  first.AssigneeID,
+		DueDate:     dueDate,
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Warning: Failed to create inspection task for schedule %s: %v\n", first.ScheduleID, err)
+		s.release(ctx, batch)
+		return
+	}
+
+	if err := s.reminders.SetTask(ctx, first.ScheduleID, treeIDs, dueDate, t.ID); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to link inspection task %s: %v\n", t.ID, err)
+	}
+	fmt.Printf("🗓️ Inspection task %s created for %d trees\n", t.ID, len(batch))
+}
+
+// sendReminder emails an assignee the inspections coming due
+func (s *InspectionService) sendReminder(ctx context.Context, assigneeID string, batch []*Due) {
+	user, err := s.users.FindByID(ctx, assigneeID)
+	if err != nil || user == nil || !user.IsActive || user.Email == "" {
+		fmt.Printf("⚠️ Warning: No reachable assignee %s for %d inspection reminders\n", assigneeID, len(batch))
+		s.release(ctx, batch)
+		return
+	}
+
+	var lines strings.Builder
+	for _, d := range batch {
+		fmt.Fprintf(&lines, "- %s (%s): due %s\n", d.TreeCode, d.ScheduleName, d.NextDueAt.Format("2006-01-02"))
+	}
+	msg := notification.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("Tree-ID: %d inspections coming due", len(batch)),
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"The following trees are due for inspection:\n\n%s\n"+
+			"Record each inspection as a status update or monitoring log.\n",
+			user.Username, lines.String()),
+	}
+	if err := s.notifier.Send(ctx, msg); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to send inspection reminder to %s: %v\n", user.Username, err)
+		s.release(ctx, batch)
+	}
+}
+
+func (s *InspectionService) release(ctx context.Context, batch []*Due) {
+	for _, d := range batch {
+		if err := s.reminders.Release(ctx, d.ScheduleID, d.TreeID, d.NextDueAt.Truncate(24*time.Hour)); err != nil {
+			fmt.Printf("⚠️ Warning: Failed to release inspection reminder: %v\n", err)
+		}
+	}
+}
+
+// RunScheduler generates reminders now and then every interval until ctx is done
+// Reminders for inspections due more than a year ago are purged.
+func (s *InspectionService) RunScheduler(ctx context.Context, interval time.Duration) {
+	run := func() {
+		if err := s.Generate(ctx); err != nil {
+			fmt.Printf("⚠️ Warning: Inspection scheduler failed: %v\n", err)
+		}
+		if _, err := s.reminders.PurgeBefore(ctx, time.Now().UTC().AddDate(-1, 0, 0)); err != nil {
+			fmt.Printf("⚠️ Warning: Failed to purge inspection reminders: %v\n", err)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
+
+// ListSchedules returns every schedule
+func (s *InspectionService) ListSchedules(ctx context.Context) ([]*Schedule, error) {
+	schedules, err := s.schedules.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inspection schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// CreateSchedule adds a schedule
+func (s *InspectionService) CreateSchedule(ctx context.Context, schedule Schedule) (*Schedule, error) {
+	schedule.ID = uuid.New().String()
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.CreatedAt = time.Now().UTC()
+	schedule.UpdatedAt = schedule.CreatedAt
+	if err := s.validate(ctx, &schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.schedules.Create(ctx, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to create inspection schedule: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "inspection_schedule.create",
+		TargetType: audit.TargetInspectionSchedule,
+		TargetID:   schedule.ID,
+		After:      audit.Snapshot(schedule),
+	})
+	return &schedule, nil
+}
+
+// UpdateSchedule replaces a schedule's settings
+func (s *InspectionService) UpdateSchedule(ctx context.Context, id string, update Schedule) (*Schedule, error) {
+	before, err := s.schedules.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := update
+	schedule.ID = before.ID
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.CreatedAt = before.CreatedAt
+	schedule.UpdatedAt = time.Now().UTC()
+	if err := s.validate(ctx, &schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.schedules.Update(ctx, &schedule); err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "inspection_schedule.update",
+		TargetType: audit.TargetInspectionSchedule,
+		TargetID:   schedule.ID,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(schedule),
+	})
+	return &schedule, nil
+}
+
+// DeleteSchedule removes a schedule and its reminders
+func (s *InspectionService) DeleteSchedule(ctx context.Context, id string) error {
+	before, err := s.schedules.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.schedules.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "inspection_schedule.delete",
+		TargetType: audit.TargetInspectionSchedule,
+		TargetID:   id,
+		Before:     audit.Snapshot(before),
+	})
+	return nil
+}
+
+// validate checks the schedule and that its assignee can receive work
+func (s *InspectionService) validate(ctx context.Context, schedule *Schedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if schedule.AssigneeID == "" {
+		return nil
+	}
+	user, err := s.users.FindByID(ctx, schedule.AssigneeID)
+	if err != nil || user == nil || user.DeletedAt != nil {
+		return fmt.Errorf("assignee %s not found", schedule.AssigneeID)
+	}
+	if !user.IsActive {
+		return fmt.Errorf("assignee %s is deactivated", user.Username)
+	}
+	return nil
+}
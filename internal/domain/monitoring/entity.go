@@ -40,7 +40,8 @@ func (ml *MonitoringLog) Validate() error {
 	return nil
 }
 
-// IsRecentMonitoring returns true if monitoring was done in last 30 days
-func (ml *MonitoringLog) IsRecentMonitoring() bool {
-	return time.Since(ml.MonitorDate).Hours()/24 <= 30
+// IsRecentMonitoring returns true if monitoring was done within the inspection interval
+// The interval comes from the tree's inspection schedule.
+func (ml *MonitoringLog) IsRecentMonitoring(intervalDays int) bool {
+	return time.Since(ml.MonitorDate).Hours()/24 <= float64(intervalDays)
 }
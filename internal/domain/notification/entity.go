@@ -0,0 +1,29 @@
+package notification
+
+import (
+	"context"
+	"errors"
+)
+
+// Message is a plain-text notification to one recipient
+type Message struct {
+	To      string // Email address
+	Subject string
+	Body    string
+}
+
+// Validate checks if message is deliverable
+func (m *Message) Validate() error {
+	if m.To == "" {
+		return errors.New("recipient is required")
+	}
+	if m.Subject == "" {
+		return errors.New("subject is required")
+	}
+	return nil
+}
+
+// Notifier delivers messages to users (SMTP, log file, ...)
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
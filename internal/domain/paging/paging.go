@@ -0,0 +1,25 @@
+package paging
+
+const (
+	// DefaultLimit is the page size when none or an invalid one is requested
+	DefaultLimit = 20
+
+	// MaxLimit caps the page size of list endpoints
+	MaxLimit = 100
+)
+
+// Limit returns limit, or def when it is not within 1..max
+func Limit(limit int, def int, max int) int {
+	if limit <= 0 || limit > max {
+		return def
+	}
+	return limit
+}
+
+// Normalize applies the list defaults to a requested page
+func Normalize(limit int, offset int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	return Limit(limit, DefaultLimit, MaxLimit), offset
+}
@@ -0,0 +1,183 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/tree"
+)
+
+var (
+	// ErrTaskNotFound is returned for unknown task IDs and tasks the caller may not see
+	ErrTaskNotFound = errors.New("task not found")
+
+	// ErrInvalidTransition is returned when the task's status does not allow the action
+	ErrInvalidTransition = errors.New("task status does not allow this action")
+
+	// ErrTaskConflict is returned when the task changed status meanwhile
+	ErrTaskConflict = errors.New("task was modified by another user")
+)
+
+// Type is the kind of work ordered
+type Type string
+
+const (
+	TypeFertilize Type = "fertilize"
+	TypePrune     Type = "prune"
+	TypeTreatPest Type = "treat_pest"
+	TypeReplant   Type = "replant"
+	TypeInspect   Type = "inspect"
+	TypeOther     Type = "other"
+)
+
+// IsValid checks if task type is valid
+func (t Type) IsValid() bool {
+	switch t {
+	case TypeFertilize, TypePrune, TypeTreatPest, TypeReplant, TypeInspect, TypeOther:
+		return true
+	}
+	return false
+}
+
+// Status is the lifecycle state of a task
+// open -> in_progress -> done -> verified; a rejected task goes back from
+// done to in_progress. Completing straight from open is allowed.
+type Status string
+
+const (
+	StatusOpen       Status = "open"
+	StatusInProgress Status = "in_progress"
+	StatusDone       Status = "done"
+	StatusVerified   Status = "verified"
+)
+
+// IsValid checks if task status is valid
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusOpen, StatusInProgress, StatusDone, StatusVerified:
+		return true
+	}
+	return false
+}
+
+// IsActive returns true while work is still outstanding
+func (s Status) IsActive() bool {
+	return s == StatusOpen || s == StatusInProgress
+}
+
+// MaxTrees limits how many trees one work order covers
+const MaxTrees = 500
+
+// TaskTree is one tree covered by a task
+type TaskTree struct {
+	TreeID      string     `json:"tree_id"`
+	TreeCode    string     `json:"tree_code"`
+	LocationID  string     `json:"location_id"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // Set once its work was recorded
+}
+
+// Task is a work order against one or more trees
+type Task struct {
+	ID                string          `json:"id"`
+	Type              Type            `json:"type"`
+	Title             string          `json:"title"`
+	Description       string          `json:"description"`
+	AssigneeID        string          `json:"assignee_id"`
+	DueDate           time.Time       `json:"due_date"`
+	Status            Status          `json:"status"`
+	ResultStatus      tree.TreeStatus `json:"result_status,omitempty"` // Tree status set on completion, empty keeps it
+	Trees             []TaskTree      `json:"trees"`
+	CreatedBy         string          `json:"created_by"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	StartedAt         *time.Time      `json:"started_at,omitempty"`
+	CompletedAt       *time.Time      `json:"completed_at,omitempty"`
+	CompletedBy       string          `json:"completed_by,omitempty"`
+	CompletionNotes   string          `json:"completion_notes,omitempty"`
+	VerifiedAt        *time.Time      `json:"verified_at,omitempty"`
+	VerifiedBy        string          `json:"verified_by,omitempty"`
+	VerificationNotes string          `json:"verification_notes,omitempty"`
+	Overdue           bool            `json:"overdue"` // Computed on read
+}
+
+// Validate checks if task entity is valid
+func (t *Task) Validate() error {
+	if !t.Type.IsValid() {
+		return fmt.Errorf("invalid task type: %s", t.Type)
+	}
+	if strings.TrimSpace(t.Title) == "" || len(t.Title) > 200 {
+		return errors.New("task title is required (max 200 characters)")
+	}
+	if len(t.Description) > 2000 {
+		return errors.New("task description is too long (max 2000 characters)")
+	}
+	if t.AssigneeID == "" {
+		return errors.New("assignee is required")
+	}
+	if t.DueDate.IsZero() {
+		return errors.New("due date is required")
+	}
+	if t.ResultStatus != "" && !(&tree.Tree{Status: t.ResultStatus}).IsValidStatus() {
+		return fmt.Errorf("invalid result status: %s", t.ResultStatus)
+	}
+	if len(t.Trees) == 0 {
+		return errors.New("at least one tree is required")
+	}
+	if len(t.Trees) > MaxTrees {
+		return fmt.Errorf("a task can cover at most %d trees", MaxTrees)
+	}
+	return nil
+}
+
+// IsOverdue returns true if work is outstanding after the due date
+func (t *Task) IsOverdue(now time.Time) bool {
+	due := t.DueDate.AddDate(0, 0, 1) // Due dates are inclusive
+	return t.Status.IsActive() && now.After(due)
+}
+
+// Filter narrows task lists
+type Filter struct {
+	Status      Status
+	Type        Type
+	AssigneeID  string
+	TreeCode    string
+	LocationID  string
+	Overdue     bool
+	Limit       int
+	Offset      int
+	LocationIDs []string // Scope: tasks touching these locations (nil = any)
+	VisibleTo   string   // With LocationIDs: also tasks assigned to this user
+}
+
+// Actor is the caller working on tasks
+type Actor struct {
+	UserID    string
+	CanManage bool // task:manage
+}
+
+// CreateRequest is a new work order
+type CreateRequest struct {
+	Type         Type
+	Title        string
+	Description  string
+	TreeCodes    []string
+	AssigneeID   string
+	DueDate      time.Time
+	ResultStatus tree.TreeStatus
+}
+
+// UpdateRequest changes an outstanding task; nil fields stay unchanged
+type UpdateRequest struct {
+	Title       *string
+	Description *string
+	AssigneeID  *string
+	DueDate     *time.Time
+}
+
+// CompleteRequest reports finished work
+type CompleteRequest struct {
+	Notes       string
+	HealthScore *int // Recorded on every tree; nil keeps each tree's score
+}
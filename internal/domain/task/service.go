@@ -0,0 +1,445 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/auth"
+	"prabogo/internal/domain/paging"
+	"prabogo/internal/domain/tree"
+
+	"github.com/google/uuid"
+)
+
+// Repository stores tasks and the trees they cover
+type Repository interface {
+	// Create inserts the task with its trees atomically
+	Create(ctx context.Context, t *Task) error
+
+	FindByID(ctx context.Context, id string) (*Task, error)
+
+	// FindAll returns a page of tasks (due date first) and the total matching
+	FindAll(ctx context.Context, filter Filter) ([]*Task, int, error)
+
+	// Update saves task fields if its status is still expected, else ErrTaskConflict
+	Update(ctx context.Context, t *Task, expected Status) error
+
+	// MarkTreeDone claims one tree for a completion; false if it was already
+	// marked, i.e. another completion of the task got there first
+	MarkTreeDone(ctx context.Context, taskID string, treeID string, at time.Time) (bool, error)
+
+	// UnmarkTreeDone releases a claim whose work failed, so a retry applies it
+	UnmarkTreeDone(ctx context.Context, taskID string, treeID string) error
+
+	// ResetTrees clears those marks so the work is recorded again
+	ResetTrees(ctx context.Context, taskID string) error
+}
+
+// Trees is the subset of tree.TreeUseCase tasks act through
+type Trees interface {
+	GetTreeByCode(ctx context.Context, code string) (*tree.TreeResponse, error)
+	RecordTreeCondition(ctx context.Context, code string, status tree.TreeStatus, healthScore int, notes string, userID string, expectedVersion int) (string, error)
+	RecordTreeWork(ctx context.Context, code string, notes string, userID string) error
+}
+
+// Users looks up assignees
+type Users interface {
+	FindByID(ctx context.Context, id string) (*auth.User, error)
+}
+
+// TaskService manages work orders
+type TaskService struct {
+	repo    Repository
+	trees   Trees
+	users   Users
+	auditor audit.Recorder
+}
+
+// NewTaskService creates a new task service
+func NewTaskService(repo Repository, trees Trees, users Users, auditor audit.Recorder) *TaskService {
+	return &TaskService{
+		repo:    repo,
+		trees:   trees,
+		users:   users,
+		auditor: auditor,
+	}
+}
+
+// Create opens a work order for trees the caller can access
+func (s *TaskService) Create(ctx context.Context, actor Actor, req CreateRequest) (*Task, error) {
+	now := time.Now().UTC()
+	t := &Task{
+		ID:           uuid.New().String(),
+		Type:         req.Type,
+		Title:        strings.TrimSpace(req.Title),
+		Description:  req.Description,
+		AssigneeID:   req.AssigneeID,
+		DueDate:      req.DueDate,
+		Status:       StatusOpen,
+		ResultStatus: req.ResultStatus,
+		CreatedBy:    actor.UserID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	seen := make(map[string]bool, len(req.TreeCodes))
+	for _, code := range req.TreeCodes {
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		if len(seen) > MaxTrees {
+			return nil, fmt.Errorf("a task can cover at most %d trees", MaxTrees)
+		}
+
+		resp, err := s.trees.GetTreeByCode(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("tree %s: %w", code, err)
+		}
+		if err := s.checkTransition(resp, t.ResultStatus); err != nil {
+			return nil, err
+		}
+		t.Trees = append(t.Trees, TaskTree{TreeID: resp.ID, TreeCode: resp.Code, LocationID: resp.LocationID})
+	}
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkAssignee(ctx, t.AssigneeID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, t); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "task.create",
+		TargetType: audit.TargetTask,
+		TargetID:   t.ID,
+		After:      audit.Snapshot(t),
+	})
+	t.Overdue = t.IsOverdue(now)
+	return t, nil
+}
+
+// Get returns a task the caller may see
+func (s *TaskService) Get(ctx context.Context, actor Actor, id string) (*Task, error) {
+	t, err := s.find(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Overdue = t.IsOverdue(time.Now())
+	return t, nil
+}
+
+// List returns a page of tasks and the total matching
+// Without task:manage only the caller's own tasks are listed; managers see
+// tasks touching their locations plus any assigned to them.
+func (s *TaskService) List(ctx context.Context, actor Actor, filter Filter) ([]*Task, int, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, 0, fmt.Errorf("invalid task status: %s", filter.Status)
+	}
+	if filter.Type != "" && !filter.Type.IsValid() {
+		return nil, 0, fmt.Errorf("invalid task type: %s", filter.Type)
+	}
+	filter.Limit, filter.Offset = paging.Normalize(filter.Limit, filter.Offset)
+
+	if !actor.CanManage {
+		filter.AssigneeID = actor.UserID
+	} else if locationIDs, scoped := tree.LocationScope(ctx); scoped {
+		filter.LocationIDs = locationIDs
+		filter.VisibleTo = actor.UserID
+	}
+
+	tasks, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	now := time.Now()
+	for _, t := range tasks {
+		t.Overdue = t.IsOverdue(now)
+	}
+	return tasks, total, nil
+}
+
+// Update changes title, description, assignee or due date of an outstanding task
+func (s *TaskService) Update(ctx context.Context, actor Actor, id string, req UpdateRequest) (*Task, error) {
+	t, err := s.find(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Status.IsActive() {
+		return nil, fmt.Errorf("%w: only open or in-progress tasks can be edited", ErrInvalidTransition)
+	}
+
+	before := *t
+	if req.Title != nil {
+		t.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		t.Description = *req.Description
+	}
+	if req.DueDate != nil {
+		t.DueDate = *req.DueDate
+	}
+	if req.AssigneeID != nil && *req.AssigneeID != t.AssigneeID {
+		if err := s.checkAssignee(ctx, *req.AssigneeID); err != nil {
+			return nil, err
+		}
+		t.AssigneeID = *req.AssigneeID
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	t.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, t, before.Status); err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "task.update",
+		TargetType: audit.TargetTask,
+		TargetID:   t.ID,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(t),
+	})
+	t.Overdue = t.IsOverdue(time.Now())
+	return t, nil
+}
+
+// Start marks an open task as in progress
+func (s *TaskService) Start(ctx context.Context, actor Actor, id string) (*Task, error) {
+	t, err := s.find(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != StatusOpen {
+		return nil, fmt.Errorf("%w: task is %s", ErrInvalidTransition, t.Status)
+	}
+
+	now := time.Now().UTC()
+	t.StartedAt = &now
+	return s.transition(ctx, t, StatusInProgress, "task.start")
+}
+
+// Complete records the work on every tree and marks the task done
+// Each tree gets a monitoring log; with a result status (or health score) the
+// tree's condition is updated through TreeService, which writes that log.
+// Trees are checked before anything is written. If a tree still fails midway
+// the task stays outstanding and a retry skips trees already recorded.
+func (s *TaskService) Complete(ctx context.Context, actor Actor, id string, req CompleteRequest) (*Task, error) {
+	t, err := s.find(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Status.IsActive() {
+		return nil, fmt.Errorf("%w: task is %s", ErrInvalidTransition, t.Status)
+	}
+	if req.HealthScore != nil && (*req.HealthScore < 0 || *req.HealthScore > 100) {
+		return nil, errors.New("health score must be between 0 and 100")
+	}
+	if len(req.Notes) > 2000 {
+		return nil, errors.New("completion notes are too long (max 2000 characters)")
+	}
+
+	// Pre-flight: every pending tree must still be reachable and allow the transition
+	pending := make(map[int]*tree.TreeResponse, len(t.Trees))
+	for i, tt := range t.Trees {
+		if tt.CompletedAt != nil {
+			continue
+		}
+		resp, err := s.trees.GetTreeByCode(ctx, tt.TreeCode)
+		if err != nil {
+			return nil, fmt.Errorf("tree %s: %w", tt.TreeCode, err)
+		}
+		if err := s.checkTransition(resp, t.ResultStatus); err != nil {
+			return nil, err
+		}
+		pending[i] = resp
+	}
+
+	notes := fmt.Sprintf("[Task %s] %s", t.Type, t.Title)
+	if req.Notes != "" {
+		notes += ": " + req.Notes
+	}
+
+	for i := range t.Trees {
+		resp, ok := pending[i]
+		if !ok {
+			continue
+		}
+		// Claim before writing, so a concurrent completion stops here instead of
+		// logging the same work twice
+		doneAt := time.Now().UTC()
+		claimed, err := s.repo.MarkTreeDone(ctx, t.ID, resp.ID, doneAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record work on tree %s: %w", resp.Code, err)
+		}
+		if !claimed {
+			return nil, ErrTaskConflict
+		}
+		if err := s.applyWork(ctx, t, resp, req.HealthScore, notes, actor.UserID); err != nil {
+			if err := s.repo.UnmarkTreeDone(ctx, t.ID, resp.ID); err != nil {
+				fmt.Printf("⚠️ Warning: Failed to release tree %s of task %s: %v\n", resp.Code, t.ID, err)
+			}
+			return nil, fmt.Errorf("tree %s: %w", resp.Code, err)
+		}
+		t.Trees[i].CompletedAt = &doneAt
+	}
+
+	now := time.Now().UTC()
+	if t.StartedAt == nil {
+		t.StartedAt = &now
+	}
+	t.CompletedAt = &now
+	t.CompletedBy = actor.UserID
+	t.CompletionNotes = req.Notes
+	return s.transition(ctx, t, StatusDone, "task.complete")
+}
+
+// Verify accepts completed work
+func (s *TaskService) Verify(ctx context.Context, actor Actor, id string, notes string) (*Task, error) {
+	t, err := s.find(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != StatusDone {
+		return nil, fmt.Errorf("%w: task is %s", ErrInvalidTransition, t.Status)
+	}
+
+	now := time.Now().UTC()
+	t.VerifiedAt = &now
+	t.VerifiedBy = actor.UserID
+	t.VerificationNotes = notes
+	return s.transition(ctx, t, StatusVerified, "task.verify")
+}
+
+// Reject sends completed work back to the assignee
+// Monitoring logs and status changes already written stay; completing again
+// records the redone work on every tree.
+func (s *TaskService) Reject(ctx context.Context, actor Actor, id string, notes string) (*Task, error) {
+	t, err := s.find(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != StatusDone {
+		return nil, fmt.Errorf("%w: task is %s", ErrInvalidTransition, t.Status)
+	}
+	if strings.TrimSpace(notes) == "" {
+		return nil, errors.New("a reason is required to reject a task")
+	}
+
+	t.CompletedAt = nil
+	t.CompletedBy = ""
+	t.VerificationNotes = notes
+	t, err = s.transition(ctx, t, StatusInProgress, "task.reject")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ResetTrees(ctx, t.ID); err != nil {
+		return nil, fmt.Errorf("failed to reset task trees: %w", err)
+	}
+	for i := range t.Trees {
+		t.Trees[i].CompletedAt = nil
+	}
+	return t, nil
+}
+
+func (s *TaskService) transition(ctx context.Context, t *Task, to Status, action string) (*Task, error) {
+	before := t.Status
+	t.Status = to
+	t.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, t, before); err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetTask,
+		TargetID:   t.ID,
+		Before:     audit.Snapshot(map[string]interface{}{"status": before}),
+		After:      audit.Snapshot(map[string]interface{}{"status": to}),
+	})
+	t.Overdue = t.IsOverdue(time.Now())
+	return t, nil
+}
+
+// applyWork writes the monitoring log of one tree, updating its condition if asked
+// A missing log is an error, so the tree's claim is released and a retry writes it.
+func (s *TaskService) applyWork(ctx context.Context, t *Task, resp *tree.TreeResponse, healthScore *int, notes string, userID string) error {
+	if t.ResultStatus == "" && healthScore == nil {
+		return s.trees.RecordTreeWork(ctx, resp.Code, notes, userID)
+	}
+
+	status := tree.TreeStatus(resp.Status)
+	if t.ResultStatus != "" {
+		status = t.ResultStatus
+	}
+	health := resp.HealthScore
+	if healthScore != nil {
+		health = *healthScore
+	}
+	logID, err := s.trees.RecordTreeCondition(ctx, resp.Code, status, health, notes, userID, 0)
+	if err != nil {
+		return err
+	}
+	if logID == "" {
+		return errors.New("condition updated but the monitoring log could not be written; complete the task again")
+	}
+	return nil
+}
+
+// checkTransition rejects result statuses the tree cannot move to (e.g. a dead tree)
+func (s *TaskService) checkTransition(resp *tree.TreeResponse, to tree.TreeStatus) error {
+	if to == "" {
+		return nil
+	}
+	current := &tree.Tree{Status: tree.TreeStatus(resp.Status)}
+	if err := current.CanUpdateStatus(to); err != nil {
+		return fmt.Errorf("tree %s: %w", resp.Code, err)
+	}
+	return nil
+}
+
+func (s *TaskService) checkAssignee(ctx context.Context, userID string) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil || user == nil || user.DeletedAt != nil {
+		return fmt.Errorf("assignee %s not found", userID)
+	}
+	if !user.IsActive {
+		return fmt.Errorf("assignee %s is deactivated", user.Username)
+	}
+	return nil
+}
+
+// find loads a task the caller may see: managers within their locations, anyone their own
+func (s *TaskService) find(ctx context.Context, actor Actor, id string) (*Task, error) {
+	t, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.AssigneeID == actor.UserID {
+		return t, nil
+	}
+	if !actor.CanManage {
+		return nil, ErrTaskNotFound
+	}
+
+	if _, scoped := tree.LocationScope(ctx); !scoped {
+		return t, nil
+	}
+	for _, tt := range t.Trees {
+		if tree.CheckLocationScope(ctx, tt.LocationID) == nil {
+			return t, nil
+		}
+	}
+	return nil, tree.ErrOutOfScope
+}
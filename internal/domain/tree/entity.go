@@ -2,6 +2,9 @@ package tree
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"strconv"
 	"time"
 )
 
@@ -16,6 +19,22 @@ const (
 	StatusDipantau TreeStatus = "DIPANTAU"
 )
 
+// ErrVersionConflict is returned when a tree changed since the caller read it
+var ErrVersionConflict = errors.New("tree was modified by another user")
+
+// ErrTreeNotFound is returned by repositories when no tree matches the code or ID
+var ErrTreeNotFound = errors.New("tree not found")
+
+// GetTrashRetention returns how long deleted trees stay restorable
+func GetTrashRetention() time.Duration {
+	// Default 30 days
+	days, err := strconv.Atoi(os.Getenv("TREE_TRASH_RETENTION_DAYS"))
+	if err != nil || days < 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 // Tree entity represents a tree in the logbook
 type Tree struct {
 	ID                   string     `json:"id"`
@@ -31,8 +50,12 @@ type Tree struct {
 	Notes                string     `json:"notes"`
 	RegisteredBy         string     `json:"registered_by"`
 	RegisteredByUsername string     `json:"registered_by_username"`
+	Version              int        `json:"version"` // Incremented on every update (optimistic locking)
 	CreatedAt            time.Time  `json:"created_at"`
 	UpdatedAt            time.Time  `json:"updated_at"`
+	DeletedAt            *time.Time `json:"deleted_at,omitempty"` // Set when moved to trash
+	DeletedBy            string     `json:"deleted_by,omitempty"`
+	DeleteReason         string     `json:"delete_reason,omitempty"`
 }
 
 // Validate checks if tree entity is valid
@@ -93,6 +116,22 @@ func (t *Tree) CalculateAge() int {
 	return int(time.Since(t.PlantingDate).Hours() / 24 / 365)
 }
 
+// IsDeleted returns true if tree is in the trash
+func (t *Tree) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
+// CanPurge checks if a trashed tree has passed the retention period
+func (t *Tree) CanPurge(retention time.Duration) error {
+	if !t.IsDeleted() {
+		return errors.New("tree is not in trash")
+	}
+	if time.Since(*t.DeletedAt) < retention {
+		return fmt.Errorf("tree is still within the %d day retention period", int(retention.Hours()/24))
+	}
+	return nil
+}
+
 // IsDead returns true if tree is dead
 func (t *Tree) IsDead() bool {
 	return t.Status == StatusMati
@@ -0,0 +1,489 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"prabogo/internal/domain/audit"
+	"prabogo/internal/domain/paging"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrMaintenanceRuleNotFound is returned for unknown rule IDs
+	ErrMaintenanceRuleNotFound = errors.New("maintenance rule not found")
+
+	// ErrMaintenanceRuleExists is returned when code, species and location are already taken
+	ErrMaintenanceRuleExists = errors.New("a rule with this code already exists for this species and location")
+)
+
+// MaintenanceRuleKind is the condition a rule checks
+type MaintenanceRuleKind string
+
+const (
+	RuleStatus       MaintenanceRuleKind = "status"         // Status is one of Statuses
+	RuleHealthBelow  MaintenanceRuleKind = "health_below"   // Health score below Threshold
+	RuleNoUpdateDays MaintenanceRuleKind = "no_update_days" // Not updated for more than Threshold days
+	RuleAgeAbove     MaintenanceRuleKind = "age_above"      // Older than Threshold years
+)
+
+// IsValid checks if rule kind is valid
+func (k MaintenanceRuleKind) IsValid() bool {
+	switch k {
+	case RuleStatus, RuleHealthBelow, RuleNoUpdateDays, RuleAgeAbove:
+		return true
+	}
+	return false
+}
+
+var ruleCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+// MaintenanceRule adds Weight to a tree's priority when it matches
+// Rules sharing a Code override each other: for every tree only the most
+// specific applicable one counts (species and location > location > species
+// > global), so "low_health" can use a stricter threshold for one species,
+// and a disabled override switches a code off for its species or location.
+// Threshold-based rules never match dead trees.
+type MaintenanceRule struct {
+	ID         string              `json:"id"`
+	Code       string              `json:"code"`
+	Kind       MaintenanceRuleKind `json:"kind"`
+	Statuses   []TreeStatus        `json:"statuses,omitempty"`  // RuleStatus only
+	Threshold  float64             `json:"threshold,omitempty"` // Other kinds
+	Weight     int                 `json:"weight"`
+	SpeciesID  string              `json:"species_id,omitempty"`  // Empty = every species
+	LocationID string              `json:"location_id,omitempty"` // Empty = every location
+	Reason     string              `json:"reason"`                // Shown in the queue
+	Enabled    bool                `json:"enabled"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// Validate checks if maintenance rule is valid
+func (r *MaintenanceRule) Validate() error {
+	if !ruleCodePattern.MatchString(r.Code) {
+		return errors.New("rule code must be 1-50 lowercase letters, digits or underscores")
+	}
+	if !r.Kind.IsValid() {
+		return fmt.Errorf("invalid rule kind: %s", r.Kind)
+	}
+	if r.Kind == RuleStatus {
+		if len(r.Statuses) == 0 {
+			return errors.New("status rule needs at least one status")
+		}
+		for _, s := range r.Statuses {
+			if !(&Tree{Status: s}).IsValidStatus() {
+				return fmt.Errorf("invalid status: %s", s)
+			}
+		}
+	} else if r.Threshold <= 0 {
+		return errors.New("rule threshold must be positive")
+	}
+	if r.Weight < 1 || r.Weight > 1000 {
+		return errors.New("rule weight must be between 1 and 1000")
+	}
+	if r.Reason == "" || len(r.Reason) > 200 {
+		return errors.New("rule reason is required (max 200 characters)")
+	}
+	if strings.ContainsAny(r.Reason, "<>") {
+		return errors.New("rule reason must not contain < or >")
+	}
+	return nil
+}
+
+// specificity orders overriding rules: higher wins
+func (r *MaintenanceRule) specificity() int {
+	n := 0
+	if r.SpeciesID != "" {
+		n++
+	}
+	if r.LocationID != "" {
+		n += 2
+	}
+	return n
+}
+
+func (r *MaintenanceRule) appliesTo(t *Tree) bool {
+	return (r.SpeciesID == "" || r.SpeciesID == t.SpeciesID) &&
+		(r.LocationID == "" || r.LocationID == t.LocationID)
+}
+
+func (r *MaintenanceRule) matches(t *Tree, now time.Time) bool {
+	if r.Kind == RuleStatus {
+		for _, s := range r.Statuses {
+			if t.Status == s {
+				return true
+			}
+		}
+		return false
+	}
+	if t.Status == StatusMati {
+		return false
+	}
+
+	switch r.Kind {
+	case RuleHealthBelow:
+		return float64(t.HealthScore) < r.Threshold
+	case RuleNoUpdateDays:
+		// Approximate using UpdatedAt or PlantingDate if UpdatedAt is zero
+		lastActivity := t.UpdatedAt
+		if lastActivity.IsZero() {
+			lastActivity = t.PlantingDate
+		}
+		return now.Sub(lastActivity).Hours()/24 > r.Threshold
+	case RuleAgeAbove:
+		return float64(t.AgeYears) > r.Threshold
+	}
+	return false
+}
+
+// MaintenanceReason is one matched rule
+type MaintenanceReason struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+	Weight int    `json:"weight"`
+}
+
+// MaintenanceCandidate is a tree in the maintenance queue
+type MaintenanceCandidate struct {
+	TreeID      string              `json:"tree_id"`
+	Code        string              `json:"code"`
+	LocationID  string              `json:"location_id"`
+	SpeciesID   string              `json:"species_id"`
+	Status      TreeStatus          `json:"status"`
+	HealthScore int                 `json:"health_score"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	Score       int                 `json:"score"`
+	Reasons     []MaintenanceReason `json:"reasons"` // Heaviest first
+}
+
+// MaintenanceFilter pages the queue
+type MaintenanceFilter struct {
+	LocationID string
+	SpeciesID  string
+	Limit      int
+	Offset     int
+}
+
+// MaintenanceRuleRepository stores maintenance rules
+type MaintenanceRuleRepository interface {
+	FindAll(ctx context.Context) ([]*MaintenanceRule, error)
+	FindByID(ctx context.Context, id string) (*MaintenanceRule, error)
+
+	// Create and Update return ErrMaintenanceRuleExists on a duplicate code/species/location
+	Create(ctx context.Context, rule *MaintenanceRule) error
+	Update(ctx context.Context, rule *MaintenanceRule) error
+	Delete(ctx context.Context, id string) error
+}
+
+// GetMaintenanceRefreshInterval returns how often the queue is recomputed
+func GetMaintenanceRefreshInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("MAINTENANCE_REFRESH_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Minute
+}
+
+// scoreTree applies the winning rule of every code; nil if nothing matched
+func scoreTree(t *Tree, rules []*MaintenanceRule, now time.Time) *MaintenanceCandidate {
+	winners := make(map[string]*MaintenanceRule)
+	for _, r := range rules {
+		if !r.appliesTo(t) {
+			continue
+		}
+		if w, ok := winners[r.Code]; !ok || r.specificity() > w.specificity() {
+			winners[r.Code] = r
+		}
+	}
+
+	c := &MaintenanceCandidate{
+		TreeID:      t.ID,
+		Code:        t.Code,
+		LocationID:  t.LocationID,
+		SpeciesID:   t.SpeciesID,
+		Status:      t.Status,
+		HealthScore: t.HealthScore,
+		UpdatedAt:   t.UpdatedAt,
+		Reasons:     []MaintenanceReason{},
+	}
+	for _, r := range winners {
+		if r.Enabled && r.matches(t, now) {
+			c.Score += r.Weight
+			c.Reasons = append(c.Reasons, MaintenanceReason{Code: r.Code, Reason: r.Reason, Weight: r.Weight})
+		}
+	}
+	if c.Score == 0 {
+		return nil
+	}
+
+	sort.Slice(c.Reasons, func(i, j int) bool {
+		if c.Reasons[i].Weight != c.Reasons[j].Weight {
+			return c.Reasons[i].Weight > c.Reasons[j].Weight
+		}
+		return c.Reasons[i].Code < c.Reasons[j].Code
+	})
+	return c
+}
+
+// MaintenanceService ranks trees needing attention
+// Scoring every tree is a full scan (slow on SawitDB), so the ranked queue is
+// kept in memory and recomputed every MAINTENANCE_REFRESH_INTERVAL and after
+// rule changes; responses carry its as_of time.
+type MaintenanceService struct {
+	repo    TreeRepository
+	rules   MaintenanceRuleRepository
+	auditor audit.Recorder
+
+	mu         sync.RWMutex
+	queue      []*MaintenanceCandidate // Every location, ranked
+	asOf       time.Time               // Zero when a refresh is needed
+	generation int                     // Bumped by rule changes
+
+	refreshMu sync.Mutex // One refresh at a time
+}
+
+// NewMaintenanceService creates a new maintenance service
+func NewMaintenanceService(repo TreeRepository, rules MaintenanceRuleRepository, auditor audit.Recorder) *MaintenanceService {
+	return &MaintenanceService{
+		repo:    repo,
+		rules:   rules,
+		auditor: auditor,
+	}
+}
+
+// Refresh scores every tree and replaces the queue
+func (s *MaintenanceService) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.refresh(ctx)
+}
+
+// refreshIfStale refreshes unless the queue is current
+// Callers that waited for another refresh find it done and skip the scan.
+func (s *MaintenanceService) refreshIfStale(ctx context.Context) error {
+	if !s.stale() {
+		return nil
+	}
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	if !s.stale() {
+		return nil
+	}
+	return s.refresh(ctx)
+}
+
+func (s *MaintenanceService) stale() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.asOf.IsZero()
+}
+
+// refresh does the scan; callers hold refreshMu
+func (s *MaintenanceService) refresh(ctx context.Context) error {
+	s.mu.RLock()
+	generation := s.generation
+	s.mu.RUnlock()
+
+	rules, err := s.rules.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load maintenance rules: %w", err)
+	}
+
+	startedAt := time.Now()
+	queue := []*MaintenanceCandidate{}
+	err = s.repo.StreamAll(ctx, TreeFilter{}, func(t *Tree) error {
+		if c := scoreTree(t, rules, startedAt); c != nil {
+			queue = append(queue, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to score trees: %w", err)
+	}
+
+	// Highest score first; ties go to the less healthy, then the longer neglected tree
+	sort.Slice(queue, func(i, j int) bool {
+		a, b := queue[i], queue[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.HealthScore != b.HealthScore {
+			return a.HealthScore < b.HealthScore
+		}
+		if !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		}
+		return a.Code < b.Code
+	})
+
+	s.mu.Lock()
+	s.queue = queue
+	if s.generation == generation {
+		s.asOf = startedAt.UTC()
+	} // Otherwise rules changed mid-refresh: stay stale
+	s.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the queue at start and every interval until ctx is done
+func (s *MaintenanceService) Run(ctx context.Context, interval time.Duration) {
+	if err := s.refreshIfStale(ctx); err != nil {
+		fmt.Printf("⚠️ Warning: Maintenance queue refresh failed: %v\n", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				fmt.Printf("⚠️ Warning: Maintenance queue refresh failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// Queue returns a page of the ranked queue within the caller's location scope
+func (s *MaintenanceService) Queue(ctx context.Context, filter MaintenanceFilter) ([]*MaintenanceCandidate, int, time.Time, error) {
+	if filter.LocationID != "" {
+		if err := CheckLocationScope(ctx, filter.LocationID); err != nil {
+			return nil, 0, time.Time{}, err
+		}
+	}
+	filter.Limit, filter.Offset = paging.Normalize(filter.Limit, filter.Offset)
+
+	if err := s.refreshIfStale(ctx); err != nil {
+		return nil, 0, time.Time{}, err
+	}
+
+	s.mu.RLock()
+	queue, asOf := s.queue, s.asOf
+	s.mu.RUnlock()
+
+	scope := scopeFilter(ctx, TreeFilter{LocationID: filter.LocationID})
+	page := []*MaintenanceCandidate{}
+	total := 0
+	for _, c := range queue {
+		if filter.LocationID != "" && c.LocationID != filter.LocationID {
+			continue
+		}
+		if filter.SpeciesID != "" && c.SpeciesID != filter.SpeciesID {
+			continue
+		}
+		if !scope.AllowsLocation(c.LocationID) {
+			continue
+		}
+		if total >= filter.Offset && len(page) < filter.Limit {
+			page = append(page, c)
+		}
+		total++
+	}
+
+	return page, total, asOf, nil
+}
+
+// ListRules returns every rule
+func (s *MaintenanceService) ListRules(ctx context.Context) ([]*MaintenanceRule, error) {
+	rules, err := s.rules.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load maintenance rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateRule adds a rule; the queue is recomputed on next use
+func (s *MaintenanceService) CreateRule(ctx context.Context, rule MaintenanceRule) (*MaintenanceRule, error) {
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now().UTC()
+	rule.UpdatedAt = rule.CreatedAt
+	if rule.Kind != RuleStatus {
+		rule.Statuses = nil
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.rules.Create(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "maintenance_rule.create",
+		TargetType: audit.TargetMaintenanceRule,
+		TargetID:   rule.ID,
+		After:      audit.Snapshot(rule),
+	})
+	return &rule, nil
+}
+
+// UpdateRule replaces a rule's settings
+func (s *MaintenanceService) UpdateRule(ctx context.Context, id string, update MaintenanceRule) (*MaintenanceRule, error) {
+	before, err := s.rules.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := update
+	rule.ID = before.ID
+	rule.CreatedAt = before.CreatedAt
+	rule.UpdatedAt = time.Now().UTC()
+	if rule.Kind != RuleStatus {
+		rule.Statuses = nil
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.rules.Update(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "maintenance_rule.update",
+		TargetType: audit.TargetMaintenanceRule,
+		TargetID:   rule.ID,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(rule),
+	})
+	return &rule, nil
+}
+
+// DeleteRule removes a rule
+func (s *MaintenanceService) DeleteRule(ctx context.Context, id string) error {
+	before, err := s.rules.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.rules.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.invalidate()
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "maintenance_rule.delete",
+		TargetType: audit.TargetMaintenanceRule,
+		TargetID:   id,
+		Before:     audit.Snapshot(before),
+	})
+	return nil
+}
+
+func (s *MaintenanceService) invalidate() {
+	s.mu.Lock()
+	s.asOf = time.Time{}
+	s.generation++
+	s.mu.Unlock()
+}
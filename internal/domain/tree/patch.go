@@ -0,0 +1,131 @@
+package tree
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TreePatch holds a JSON merge patch for editable tree fields
+// nil means "leave unchanged"; status and health go through UpdateTreeCondition.
+type TreePatch struct {
+	SpeciesID    *string
+	LocationID   *string
+	PlantingDate *time.Time
+	HeightMeters *float64
+	DiameterCm   *float64
+	Notes        *string
+}
+
+// FieldErrors maps field name to validation message
+type FieldErrors map[string]string
+
+// Error implements error with a stable, sorted message
+func (fe FieldErrors) Error() string {
+	fields := make([]string, 0, len(fe))
+	for f := range fe {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		parts = append(parts, f+": "+fe[f])
+	}
+	return "validation error: " + strings.Join(parts, "; ")
+}
+
+// FieldChange records one corrected field (old -> new) for auditing
+type FieldChange struct {
+	ID                string    `json:"id"`
+	ChangeSetID       string    `json:"change_set_id"` // Groups fields changed by one PATCH
+	TreeID            string    `json:"tree_id"`
+	TreeCode          string    `json:"tree_code"`
+	Field             string    `json:"field"`
+	OldValue          string    `json:"old_value"`
+	NewValue          string    `json:"new_value"`
+	ChangedBy         string    `json:"changed_by"`
+	ChangedByUsername string    `json:"changed_by_username"` // Populated by handler
+	ChangedAt         time.Time `json:"changed_at"`
+}
+
+// IsEmpty returns true if the patch touches no field
+func (p *TreePatch) IsEmpty() bool {
+	return p.SpeciesID == nil && p.LocationID == nil && p.PlantingDate == nil &&
+		p.HeightMeters == nil && p.DiameterCm == nil && p.Notes == nil
+}
+
+// Validate checks every provided field and reports all failures at once
+func (p *TreePatch) Validate() error {
+	errs := FieldErrors{}
+
+	if p.SpeciesID != nil && strings.TrimSpace(*p.SpeciesID) == "" {
+		errs["species_id"] = "cannot be empty"
+	}
+	if p.LocationID != nil && strings.TrimSpace(*p.LocationID) == "" {
+		errs["location_id"] = "cannot be empty"
+	}
+	if p.PlantingDate != nil {
+		if p.PlantingDate.IsZero() {
+			errs["planting_date"] = "is required"
+		} else if p.PlantingDate.After(time.Now()) {
+			errs["planting_date"] = "cannot be in the future"
+		}
+	}
+	if p.HeightMeters != nil && (*p.HeightMeters < 0 || *p.HeightMeters > 999.99) {
+		errs["height_meters"] = "must be between 0 and 999.99"
+	}
+	if p.DiameterCm != nil && (*p.DiameterCm < 0 || *p.DiameterCm > 999.99) {
+		errs["diameter_cm"] = "must be between 0 and 999.99"
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Apply writes patched fields into t and returns the changed fields
+// Only fields whose value actually differs are reported.
+func (p *TreePatch) Apply(t *Tree) []FieldChange {
+	var changes []FieldChange
+	record := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	if p.SpeciesID != nil {
+		record("species_id", t.SpeciesID, *p.SpeciesID)
+		t.SpeciesID = *p.SpeciesID
+	}
+	if p.LocationID != nil {
+		record("location_id", t.LocationID, *p.LocationID)
+		t.LocationID = *p.LocationID
+	}
+	if p.PlantingDate != nil {
+		record("planting_date", t.PlantingDate.Format("2006-01-02"), p.PlantingDate.Format("2006-01-02"))
+		t.PlantingDate = *p.PlantingDate
+		t.AgeYears = t.CalculateAge()
+	}
+	if p.HeightMeters != nil {
+		record("height_meters", formatMeasure(t.HeightMeters), formatMeasure(*p.HeightMeters))
+		t.HeightMeters = *p.HeightMeters
+	}
+	if p.DiameterCm != nil {
+		record("diameter_cm", formatMeasure(t.DiameterCm), formatMeasure(*p.DiameterCm))
+		t.DiameterCm = *p.DiameterCm
+	}
+	if p.Notes != nil {
+		record("notes", t.Notes, *p.Notes)
+		t.Notes = *p.Notes
+	}
+
+	return changes
+}
+
+// formatMeasure matches the 2-decimal precision stored by the repositories
+func formatMeasure(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
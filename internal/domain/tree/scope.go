@@ -0,0 +1,65 @@
+package tree
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOutOfScope is returned when a tree lies outside the caller's assigned locations
+var ErrOutOfScope = errors.New("tree is outside your assigned locations")
+
+type locationScopeKey struct{}
+
+type locationScope struct {
+	locationIDs []string
+}
+
+// WithLocationScope restricts tree access in ctx to locationIDs
+// Contexts without a scope are unrestricted (full-access roles, background jobs,
+// the public tree page). An empty list means no tree is accessible.
+func WithLocationScope(ctx context.Context, locationIDs []string) context.Context {
+	return context.WithValue(ctx, locationScopeKey{}, locationScope{locationIDs: locationIDs})
+}
+
+// LocationScope returns the caller's locations; ok is false when unrestricted
+func LocationScope(ctx context.Context) (locationIDs []string, ok bool) {
+	scope, ok := ctx.Value(locationScopeKey{}).(locationScope)
+	if !ok {
+		return nil, false
+	}
+	return scope.locationIDs, true
+}
+
+// CheckLocationScope returns ErrOutOfScope if locationID is not accessible in ctx
+func CheckLocationScope(ctx context.Context, locationID string) error {
+	locationIDs, scoped := LocationScope(ctx)
+	if !scoped || containsLocation(locationIDs, locationID) {
+		return nil
+	}
+	return ErrOutOfScope
+}
+
+// scopeFilter narrows filter to the caller's locations
+// A requested location outside the scope yields an empty (non-nil) list, so nothing matches.
+func scopeFilter(ctx context.Context, filter TreeFilter) TreeFilter {
+	locationIDs, scoped := LocationScope(ctx)
+	if !scoped {
+		return filter
+	}
+
+	if filter.LocationID != "" && !containsLocation(locationIDs, filter.LocationID) {
+		filter.LocationIDs = []string{}
+		return filter
+	}
+	filter.LocationIDs = append([]string{}, locationIDs...)
+	return filter
+}
+
+func containsLocation(locationIDs []string, locationID string) bool {
+	for _, id := range locationIDs {
+		if id == locationID {
+			return true
+		}
+	}
+	return false
+}
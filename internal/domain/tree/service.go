@@ -6,16 +6,25 @@ import (
 	"fmt"
 	"time"
 
+	"prabogo/internal/domain/audit"
+
 	"github.com/google/uuid"
 )
 
 // TreeFilter for querying trees
 type TreeFilter struct {
-	LocationID string
-	SpeciesID  string
-	Status     TreeStatus
-	Limit      int
-	Offset     int
+	LocationID  string
+	LocationIDs []string // Location scope; nil means any location, empty means none
+	SpeciesID   string
+	Status      TreeStatus
+	Trashed     bool // Only soft-deleted trees; by default they are excluded
+	Limit       int
+	Offset      int
+}
+
+// AllowsLocation reports whether a tree in locationID passes the location scope
+func (f TreeFilter) AllowsLocation(locationID string) bool {
+	return f.LocationIDs == nil || containsLocation(f.LocationIDs, locationID)
 }
 
 // TreeRepository interface for tree data operations
@@ -32,13 +41,23 @@ type TreeRepository interface {
 	// FindAll retrieves trees with filter (PANEN)
 	FindAll(ctx context.Context, filter TreeFilter) ([]*Tree, error)
 
-	// Update modifies existing tree (PUPUK)
+	// StreamAll calls fn for every tree matching filter without buffering the result set (PANEN)
+	StreamAll(ctx context.Context, filter TreeFilter, fn func(*Tree) error) error
+
+	// Update modifies existing tree only if tree.Version still matches the stored version (PUPUK)
+	// Returns ErrVersionConflict otherwise; on success tree.Version is incremented.
 	Update(ctx context.Context, tree *Tree) error
 
 	// UpdateStatus changes tree status (PUPUK)
 	UpdateStatus(ctx context.Context, id string, status TreeStatus, healthScore int) error
 
-	// Delete removes a tree (GUSUR)
+	// SoftDelete moves a tree to the trash (PUPUK deleted_at)
+	SoftDelete(ctx context.Context, id string, deletedBy string, reason string) error
+
+	// Restore brings a tree back from the trash (PUPUK deleted_at)
+	Restore(ctx context.Context, id string) error
+
+	// Delete permanently removes a tree (GUSUR) - only used by purge
 	Delete(ctx context.Context, id string) error
 
 	// GetNextCode generates next C-code (C001, C002, etc.)
@@ -53,13 +72,39 @@ type TreeRepository interface {
 
 // TreeService handles tree business logic
 type TreeService struct {
-	repo           TreeRepository
+	repo           TreeRepository // Wrapped so every mutation updates statsRepo
+	fresh          TreeRepository // Uncached reads of the row a write is based on
 	monitoringRepo MonitoringRepository
+	changeRepo     TreeChangeRepository
+	statsRepo      StatsRepository
+	speciesRepo    ReferenceRepository
+	locationRepo   ReferenceRepository
+	maintenance    *MaintenanceService
+	auditor        audit.Recorder
+}
+
+// TreeChangeRepository stores field-level corrections made via EditTree
+type TreeChangeRepository interface {
+	CreateChanges(ctx context.Context, changes []FieldChange) error
+	FindByTreeID(ctx context.Context, treeID string) ([]*FieldChange, error)
+}
+
+// CachingRepository is implemented by tree repositories that cache reads
+// Writes compare versions, so they read through the wrapped repository instead.
+type CachingRepository interface {
+	Uncached() TreeRepository
+}
+
+// ReferenceRepository looks up the species or locations a tree points at
+// SawitDB has no foreign keys, so EditTree checks IDs before writing them.
+type ReferenceRepository interface {
+	Exists(ctx context.Context, id string) (bool, error)
 }
 
 // MonitoringRepository interface for logging tree changes
 type MonitoringRepository interface {
 	CreateLog(ctx context.Context, log *MonitoringLog) error
+	DeleteLogsByTreeID(ctx context.Context, treeID string) error
 }
 
 // MonitoringLog represents a tree monitoring event
@@ -75,10 +120,22 @@ type MonitoringLog struct {
 }
 
 // NewTreeService creates a new tree service
-func NewTreeService(repo TreeRepository, monitoringRepo MonitoringRepository) *TreeService {
+func NewTreeService(repo TreeRepository, monitoringRepo MonitoringRepository, changeRepo TreeChangeRepository, statsRepo StatsRepository, speciesRepo, locationRepo ReferenceRepository, ruleRepo MaintenanceRuleRepository, auditor audit.Recorder) *TreeService {
+	fresh := repo
+	if caching, ok := repo.(CachingRepository); ok {
+		fresh = caching.Uncached()
+	}
+
 	return &TreeService{
-		repo:           repo,
+		repo:           newStatsTrackingRepository(repo, fresh, statsRepo),
+		fresh:          fresh,
 		monitoringRepo: monitoringRepo,
+		changeRepo:     changeRepo,
+		statsRepo:      statsRepo,
+		speciesRepo:    speciesRepo,
+		locationRepo:   locationRepo,
+		maintenance:    NewMaintenanceService(repo, ruleRepo, auditor),
+		auditor:        auditor,
 	}
 }
 
@@ -120,6 +177,11 @@ func (s *TreeService) RegisterNewTree(ctx context.Context, req RegisterTreeReque
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
+	// Field teams may only register trees in their assigned locations
+	if err := CheckLocationScope(ctx, req.LocationID); err != nil {
+		return nil, err
+	}
+
 	// 2. Generate next C-code
 	code, err := s.repo.GetNextCode(ctx)
 	if err != nil {
@@ -140,6 +202,7 @@ func (s *TreeService) RegisterNewTree(ctx context.Context, req RegisterTreeReque
 		HealthScore:  100,         // Default perfect health
 		Notes:        req.Notes,
 		RegisteredBy: req.RegisteredBy,
+		Version:      1,
 		CreatedAt:    time.Now().UTC(),
 		UpdatedAt:    time.Now().UTC(),
 	}
@@ -157,6 +220,13 @@ func (s *TreeService) RegisterNewTree(ctx context.Context, req RegisterTreeReque
 		return nil, fmt.Errorf("failed to create tree: %w", err)
 	}
 
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "tree.create",
+		TargetType: audit.TargetTree,
+		TargetID:   tree.Code,
+		After:      audit.Snapshot(tree),
+	})
+
 	// 7. Create initial monitoring log so tree appears in history immediately
 	now := time.Now().UTC()
 	initialLog := &MonitoringLog{
@@ -182,25 +252,41 @@ func (s *TreeService) RegisterNewTree(ctx context.Context, req RegisterTreeReque
 }
 
 // UpdateTreeCondition updates tree status and health
-// UpdateTreeCondition updates tree status and health
-func (s *TreeService) UpdateTreeCondition(ctx context.Context, code string, newStatus TreeStatus, healthScore int, notes string, userID string) error {
+// expectedVersion > 0 rejects the update with ErrVersionConflict if the tree changed meanwhile.
+func (s *TreeService) UpdateTreeCondition(ctx context.Context, code string, newStatus TreeStatus, healthScore int, notes string, userID string, expectedVersion int) error {
+	_, err := s.RecordTreeCondition(ctx, code, newStatus, healthScore, notes, userID, expectedVersion)
+	return err
+}
+
+// RecordTreeCondition is UpdateTreeCondition returning the ID of the monitoring log
+// The ID is empty if the tree was updated but its log could not be written.
+func (s *TreeService) RecordTreeCondition(ctx context.Context, code string, newStatus TreeStatus, healthScore int, notes string, userID string, expectedVersion int) (string, error) {
 	// 1. Get existing tree
-	tree, err := s.repo.FindByCode(ctx, code)
+	tree, err := s.findForWrite(ctx, code)
 	if err != nil {
-		return fmt.Errorf("tree not found: %w", err)
+		return "", fmt.Errorf("tree not found: %w", err)
+	}
+
+	if tree.IsDeleted() {
+		return "", fmt.Errorf("tree %s is in trash", code)
+	}
+
+	if expectedVersion > 0 && tree.Version != expectedVersion {
+		return "", fmt.Errorf("%w (expected version %d, current %d)", ErrVersionConflict, expectedVersion, tree.Version)
 	}
 
 	// 2. Validate status transition
 	if err := tree.CanUpdateStatus(newStatus); err != nil {
-		return fmt.Errorf("invalid status transition: %w", err)
+		return "", fmt.Errorf("invalid status transition: %w", err)
 	}
 
 	// 3. Validate health score
 	if healthScore < 0 || healthScore > 100 {
-		return errors.New("health score must be between 0 and 100")
+		return "", errors.New("health score must be between 0 and 100")
 	}
 
 	// 4. Update tree
+	before := *tree
 	tree.Status = newStatus
 	tree.HealthScore = healthScore
 	if notes != "" {
@@ -208,11 +294,22 @@ func (s *TreeService) UpdateTreeCondition(ctx context.Context, code string, newS
 	}
 	tree.UpdatedAt = time.Now().UTC()
 
-	// 5. Save changes to trees table
+	// 5. Save changes to trees table (conditional on the version we read)
 	if err := s.repo.Update(ctx, tree); err != nil {
-		return fmt.Errorf("failed to update tree: %w", err)
+		if errors.Is(err, ErrVersionConflict) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to update tree: %w", err)
 	}
 
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "tree.update_status",
+		TargetType: audit.TargetTree,
+		TargetID:   tree.Code,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(tree),
+	})
+
 	// ✅ USER'S BRILLIANT FIX: Use tree.UpdatedAt for monitoring log (same timestamp!)
 	fmt.Printf("⏰ Creating monitoring log for tree %s (status: %s, health: %d)\n", tree.Code, newStatus, healthScore)
 	fmt.Printf("🕐 Using tree.UpdatedAt: %s\n", tree.UpdatedAt.Format("2006-01-02 15:04:05"))
@@ -234,131 +331,464 @@ func (s *TreeService) UpdateTreeCondition(ctx context.Context, code string, newS
 		// Log error but don't fail the update
 		// Tree update succeeded, logging is secondary
 		fmt.Printf("❌ ERROR: Failed to create monitoring log: %v\n", err)
-	} else {
-		fmt.Printf("✅ Successfully created monitoring log for %s\n", tree.Code)
+		return "", nil
 	}
+	fmt.Printf("✅ Successfully created monitoring log for %s\n", tree.Code)
 
+	return monitoringLog.ID, nil
+}
+
+// RecordTreeWork logs work done on a tree without changing its condition
+// The log repeats the current status and health score; unlike status updates a
+// failed log is returned, since the log is the only record of the work.
+func (s *TreeService) RecordTreeWork(ctx context.Context, code string, notes string, userID string) error {
+	tree, err := s.findForWrite(ctx, code)
+	if err != nil {
+		return fmt.Errorf("tree not found: %w", err)
+	}
+	if tree.IsDeleted() {
+		return fmt.Errorf("tree not found: tree with code %s is in trash", code)
+	}
+
+	log := &MonitoringLog{
+		ID:             uuid.New().String(),
+		TreeID:         tree.ID,
+		TreeCode:       tree.Code,
+		Status:         tree.Status,
+		HealthScore:    tree.HealthScore,
+		Notes:          notes,
+		MonitoredBy:    userID,
+		MonitoringDate: time.Now().UTC(),
+	}
+	if err := s.monitoringRepo.CreateLog(ctx, log); err != nil {
+		return fmt.Errorf("failed to create monitoring log: %w", err)
+	}
 	return nil
 }
 
+// checkReferences adds a field error for every patched species or location that does not exist
+func (s *TreeService) checkReferences(ctx context.Context, patch TreePatch, errs FieldErrors) error {
+	check := func(field string, id *string, repo ReferenceRepository) error {
+		if id == nil || errs[field] != "" {
+			return nil
+		}
+		exists, err := repo.Exists(ctx, *id)
+		if err != nil {
+			return fmt.Errorf("failed to look up %s: %w", field, err)
+		}
+		if !exists {
+			errs[field] = "does not exist"
+		}
+		return nil
+	}
+
+	if err := check("species_id", patch.SpeciesID, s.speciesRepo); err != nil {
+		return err
+	}
+	return check("location_id", patch.LocationID, s.locationRepo)
+}
+
+// EditTree applies a merge patch to registration data (species, location, dates, dimensions)
+// Every changed field is written to the change log with old/new values and the editor.
+func (s *TreeService) EditTree(ctx context.Context, code string, patch TreePatch, userID string, expectedVersion int) (*Tree, []FieldChange, error) {
+	if patch.IsEmpty() {
+		return nil, nil, errors.New("patch contains no editable fields")
+	}
+	errs := FieldErrors{}
+	if err := patch.Validate(); err != nil && !errors.As(err, &errs) {
+		return nil, nil, err
+	}
+	if err := s.checkReferences(ctx, patch, errs); err != nil {
+		return nil, nil, err
+	}
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	tree, err := s.findForWrite(ctx, code)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tree not found: %w", err)
+	}
+	if tree.IsDeleted() {
+		return nil, nil, fmt.Errorf("tree %s is in trash", code)
+	}
+	if expectedVersion > 0 && tree.Version != expectedVersion {
+		return nil, nil, fmt.Errorf("%w (expected version %d, current %d)", ErrVersionConflict, expectedVersion, tree.Version)
+	}
+
+	before := *tree
+	changes := patch.Apply(tree)
+	if len(changes) == 0 {
+		return tree, nil, nil
+	}
+
+	if err := tree.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("tree validation error: %w", err)
+	}
+	// Moving a tree is only allowed into a location the caller may access
+	if err := CheckLocationScope(ctx, tree.LocationID); err != nil {
+		return nil, nil, err
+	}
+
+	tree.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(ctx, tree); err != nil {
+		if errors.Is(err, ErrVersionConflict) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to update tree: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "tree.edit",
+		TargetType: audit.TargetTree,
+		TargetID:   tree.Code,
+		Before:     audit.Snapshot(before),
+		After:      audit.Snapshot(tree),
+	})
+
+	changeSetID := uuid.New().String()
+	for i := range changes {
+		changes[i].ID = uuid.New().String()
+		changes[i].ChangeSetID = changeSetID
+		changes[i].TreeID = tree.ID
+		changes[i].TreeCode = tree.Code
+		changes[i].ChangedBy = userID
+		changes[i].ChangedAt = tree.UpdatedAt
+	}
+
+	if err := s.changeRepo.CreateChanges(ctx, changes); err != nil {
+		// Tree update succeeded; surface the audit gap loudly
+		fmt.Printf("❌ ERROR: Failed to record %d field changes for tree %s: %v\n", len(changes), tree.Code, err)
+	}
+
+	return tree, changes, nil
+}
+
+// GetTreeChanges retrieves the field change log of a tree
+func (s *TreeService) GetTreeChanges(ctx context.Context, code string) ([]*FieldChange, error) {
+	tree, err := s.findInScope(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("tree not found: %w", err)
+	}
+	changes, err := s.changeRepo.FindByTreeID(ctx, tree.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes: %w", err)
+	}
+	return changes, nil
+}
+
+// findInScope loads a tree and rejects it if it lies outside the caller's locations
+func (s *TreeService) findInScope(ctx context.Context, code string) (*Tree, error) {
+	return inScope(ctx, s.repo, code)
+}
+
+// findForWrite is findInScope bypassing caches, so versions compared on write are current
+func (s *TreeService) findForWrite(ctx context.Context, code string) (*Tree, error) {
+	return inScope(ctx, s.fresh, code)
+}
+
+func inScope(ctx context.Context, repo TreeRepository, code string) (*Tree, error) {
+	tree, err := repo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckLocationScope(ctx, tree.LocationID); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// GetCurrentTree is GetTreeByCode bypassing caches (used to answer version conflicts)
+func (s *TreeService) GetCurrentTree(ctx context.Context, code string) (*Tree, error) {
+	tree, err := s.findForWrite(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("tree not found: %w", err)
+	}
+	if tree.IsDeleted() {
+		return nil, fmt.Errorf("tree not found: tree with code %s is in trash", code)
+	}
+	return tree, nil
+}
+
 // GetTreeByCode retrieves tree by its C-code
 func (s *TreeService) GetTreeByCode(ctx context.Context, code string) (*Tree, error) {
-	tree, err := s.repo.FindByCode(ctx, code)
+	tree, err := s.findInScope(ctx, code)
 	if err != nil {
 		return nil, fmt.Errorf("tree not found: %w", err)
 	}
+	if tree.IsDeleted() {
+		return nil, fmt.Errorf("tree not found: tree with code %s is in trash", code)
+	}
 	return tree, nil
 }
 
 // ListTrees retrieves trees with optional filters
 func (s *TreeService) ListTrees(ctx context.Context, filter TreeFilter) ([]*Tree, error) {
-	trees, err := s.repo.FindAll(ctx, filter)
+	trees, err := s.repo.FindAll(ctx, scopeFilter(ctx, filter))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list trees: %w", err)
 	}
 	return trees, nil
 }
 
-// DeleteTree removes a tree from the system
-func (s *TreeService) DeleteTree(ctx context.Context, code string) error {
+// ExportTrees streams trees matching filter to fn one at a time
+func (s *TreeService) ExportTrees(ctx context.Context, filter TreeFilter, fn func(*Tree) error) error {
+	if err := s.repo.StreamAll(ctx, scopeFilter(ctx, filter), fn); err != nil {
+		return fmt.Errorf("failed to export trees: %w", err)
+	}
+	return nil
+}
+
+// DeleteTree moves a tree to the trash; it can be restored until purged
+func (s *TreeService) DeleteTree(ctx context.Context, code string, userID string, reason string) error {
+	if reason == "" {
+		return errors.New("delete reason is required")
+	}
+
 	// 1. Get tree first
-	tree, err := s.repo.FindByCode(ctx, code)
+	tree, err := s.findForWrite(ctx, code)
 	if err != nil {
 		return fmt.Errorf("tree not found: %w", err)
 	}
+	if tree.IsDeleted() {
+		return fmt.Errorf("tree %s is already in trash", code)
+	}
 
-	// 2. Delete tree
-	if err := s.repo.Delete(ctx, tree.ID); err != nil {
+	// 2. Soft delete tree (history stays linked to the tree ID)
+	if err := s.repo.SoftDelete(ctx, tree.ID, userID, reason); err != nil {
 		return fmt.Errorf("failed to delete tree: %w", err)
 	}
 
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "tree.delete",
+		TargetType: audit.TargetTree,
+		TargetID:   tree.Code,
+		Before:     audit.Snapshot(tree),
+		After:      audit.Snapshot(map[string]string{"deleted_by": userID, "delete_reason": reason}),
+	})
+
+	s.logLifecycleEvent(ctx, tree, userID, "Pohon dihapus ke trash: "+reason)
 	return nil
 }
 
-// GetTreeStatistics retrieves statistics about trees including growth and maintenance needs
-func (s *TreeService) GetTreeStatistics(ctx context.Context) (*TreeStatistics, error) {
-	stats := &TreeStatistics{
-		MonthlyGrowth: make(map[string]int),
-		Maintenance:   []Tree{},
+// ListTrash retrieves soft-deleted trees
+func (s *TreeService) ListTrash(ctx context.Context, filter TreeFilter) ([]*Tree, error) {
+	filter.Trashed = true
+	trees, err := s.repo.FindAll(ctx, scopeFilter(ctx, filter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
 	}
+	return trees, nil
+}
 
-	// Fetch all trees to perform aggregation (SawitDB works best in memory for stats)
-	allTrees, err := s.repo.FindAll(ctx, TreeFilter{})
+// RestoreTree brings a tree back from the trash
+func (s *TreeService) RestoreTree(ctx context.Context, code string, userID string) error {
+	tree, err := s.findForWrite(ctx, code)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("tree not found: %w", err)
+	}
+	if !tree.IsDeleted() {
+		return fmt.Errorf("tree %s is not in trash", code)
 	}
 
-	now := time.Now()
+	if err := s.repo.Restore(ctx, tree.ID); err != nil {
+		return fmt.Errorf("failed to restore tree: %w", err)
+	}
 
-	stats.TotalCount = len(allTrees)
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "tree.restore",
+		TargetType: audit.TargetTree,
+		TargetID:   tree.Code,
+		Before:     audit.Snapshot(tree),
+	})
 
-	for _, t := range allTrees {
-		// 1. Count by Status
-		switch t.Status {
-		case StatusSehat:
-			stats.HealthyCount++
-		case StatusSakit:
-			stats.SickCount++
-		case StatusMati:
-			stats.DeadCount++
-		case StatusDipupuk:
-			stats.FertilizedCount++
-		case StatusDipantau:
-			stats.MonitoredCount++
-		}
+	s.logLifecycleEvent(ctx, tree, userID, "Pohon dipulihkan dari trash")
+	return nil
+}
 
-		// 2. Monthly Growth (Planting Date)
-		// Format: "YYYY-MM"
-		if !t.PlantingDate.IsZero() {
-			monthKey := t.PlantingDate.Format("2006-01")
-			stats.MonthlyGrowth[monthKey]++
-		}
+// PurgeTree permanently removes a trashed tree and its history
+// Only allowed once the retention period has passed.
+func (s *TreeService) PurgeTree(ctx context.Context, code string) error {
+	tree, err := s.findForWrite(ctx, code)
+	if err != nil {
+		return fmt.Errorf("tree not found: %w", err)
+	}
+	if err := tree.CanPurge(GetTrashRetention()); err != nil {
+		return err
+	}
+	return s.purge(ctx, tree)
+}
 
-		// 3. Identify Maintenance Candidates (Top 5 Priority)
-		// Priority: SAKIT (Any), SEHAT (>30 days since update), Score < 50
-		needsAttention := false
+// PurgeExpiredTrash permanently removes every trashed tree past retention
+func (s *TreeService) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	trashed, err := s.repo.FindAll(ctx, scopeFilter(ctx, TreeFilter{Trashed: true}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list trash: %w", err)
+	}
 
-		// If Sick, always needs attention
-		if t.Status == StatusSakit {
-			needsAttention = true
+	retention := GetTrashRetention()
+	purged := 0
+	for _, t := range trashed {
+		if t.CanPurge(retention) != nil {
+			continue
 		}
-
-		// If Health Score drops below 50
-		if t.HealthScore < 50 && t.Status != StatusMati {
-			needsAttention = true
+		if err := s.purge(ctx, t); err != nil {
+			return purged, err
 		}
+		purged++
+	}
 
-		// If not updated in 30 days (Maintenance overdue)
-		// Approximate using UpdatedAt or PlantingDate if UpdatedAt is zero
-		lastActivity := t.UpdatedAt
-		if lastActivity.IsZero() {
-			lastActivity = t.PlantingDate
+	return purged, nil
+}
+
+// purge deletes history first so hybrid storage never keeps orphaned logs
+func (s *TreeService) purge(ctx context.Context, tree *Tree) error {
+	if err := s.monitoringRepo.DeleteLogsByTreeID(ctx, tree.ID); err != nil {
+		return fmt.Errorf("failed to delete history of %s: %w", tree.Code, err)
+	}
+	if err := s.repo.Delete(ctx, tree.ID); err != nil {
+		return fmt.Errorf("failed to purge tree %s: %w", tree.Code, err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     "tree.purge",
+		TargetType: audit.TargetTree,
+		TargetID:   tree.Code,
+		Before:     audit.Snapshot(tree),
+	})
+	return nil
+}
+
+// logLifecycleEvent records delete/restore in the tree history (best effort)
+func (s *TreeService) logLifecycleEvent(ctx context.Context, tree *Tree, userID string, notes string) {
+	lifecycleLog := &MonitoringLog{
+		ID:             uuid.New().String(),
+		TreeID:         tree.ID,
+		TreeCode:       tree.Code,
+		Status:         tree.Status,
+		HealthScore:    tree.HealthScore,
+		Notes:          notes,
+		MonitoredBy:    userID,
+		MonitoringDate: time.Now().UTC(),
+	}
+	if err := s.monitoringRepo.CreateLog(ctx, lifecycleLog); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to log lifecycle event for tree %s: %v\n", tree.Code, err)
+	}
+}
+
+// GetTreeStatistics returns the materialized statistics
+// Counters are kept up to date by every mutation and rebuilt periodically, so
+// no trees are loaded; the maintenance widget is the head of the ranked queue.
+// Scoped callers only see numbers for their own locations.
+func (s *TreeService) GetTreeStatistics(ctx context.Context) (*TreeStatistics, error) {
+	locationIDs, _ := LocationScope(ctx)
+	snapshot, err := s.statsRepo.Load(ctx, locationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load statistics: %w", err)
+	}
+
+	stats := &TreeStatistics{
+		ByStatus:      make(map[string]int),
+		ByLocation:    make(map[string]int),
+		BySpecies:     make(map[string]int),
+		MonthlyGrowth: make(map[string]int),
+		Maintenance:   []*MaintenanceCandidate{},
+		AsOf:          snapshot.AsOf,
+		RebuiltAt:     snapshot.RebuiltAt,
+	}
+
+	for _, c := range snapshot.Counts {
+		n := int(c.Count)
+		stats.TotalCount += n
+		stats.ByStatus[string(c.Status)] += n
+		stats.ByLocation[c.LocationID] += n
+		stats.BySpecies[c.SpeciesID] += n
+		if c.PlantingMonth != "" {
+			stats.MonthlyGrowth[c.PlantingMonth] += n
 		}
-		daysSince := now.Sub(lastActivity).Hours() / 24
-		if daysSince > 30 && t.Status != StatusMati {
-			needsAttention = true
+	}
+	stats.HealthyCount = stats.ByStatus[string(StatusSehat)]
+	stats.SickCount = stats.ByStatus[string(StatusSakit)]
+	stats.DeadCount = stats.ByStatus[string(StatusMati)]
+	stats.FertilizedCount = stats.ByStatus[string(StatusDipupuk)]
+	stats.MonitoredCount = stats.ByStatus[string(StatusDipantau)]
+
+	// Maintenance widget: top 5 of the maintenance queue
+	top, _, _, err := s.maintenance.Queue(ctx, MaintenanceFilter{Limit: 5})
+	if err != nil {
+		return nil, err
+	}
+	stats.Maintenance = top
+
+	return stats, nil
+}
+
+// RebuildStatistics recounts every tree and replaces the materialized statistics
+// Changes made while the rebuild streams may be counted twice or missed until
+// the next rebuild; the window is one pass over the trees.
+func (s *TreeService) RebuildStatistics(ctx context.Context) (int64, error) {
+	startedAt := time.Now().UTC()
+	counts := make(map[StatsKey]int64)
+	var total int64
+
+	err := s.repo.StreamAll(ctx, TreeFilter{}, func(t *Tree) error {
+		if key, ok := statsKeyOf(t); ok {
+			counts[key]++
+			total++
 		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count trees: %w", err)
+	}
+
+	if err := s.statsRepo.Replace(ctx, counts, startedAt); err != nil {
+		return 0, fmt.Errorf("failed to store statistics: %w", err)
+	}
+	return total, nil
+}
 
-		if needsAttention {
-			// Prepend to list (simple LIFO or just append)
-			// Limit to 5 for dashboard
-			if len(stats.Maintenance) < 5 {
-				stats.Maintenance = append(stats.Maintenance, *t)
-			}
+// RunStatisticsRebuild rebuilds now if statistics were never built, then every interval
+func (s *TreeService) RunStatisticsRebuild(ctx context.Context, interval time.Duration) {
+	rebuild := func() {
+		n, err := s.RebuildStatistics(ctx)
+		if err != nil {
+			fmt.Printf("⚠️ Warning: Tree statistics rebuild failed: %v\n", err)
+			return
 		}
+		fmt.Printf("📊 Tree statistics rebuilt (%d trees)\n", n)
 	}
 
-	return stats, nil
+	if snapshot, err := s.statsRepo.Load(ctx, nil); err != nil || snapshot.RebuiltAt == nil {
+		rebuild()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rebuild()
+		}
+	}
 }
 
 // TreeStatistics represents tree statistics
 type TreeStatistics struct {
-	TotalCount      int            `json:"total"`
-	HealthyCount    int            `json:"healthy"`
-	SickCount       int            `json:"sick"`
-	DeadCount       int            `json:"dead"`
-	FertilizedCount int            `json:"fertilized"`
-	MonitoredCount  int            `json:"monitored"`
-	MonthlyGrowth   map[string]int `json:"monthly_growth"`
-	Maintenance     []Tree         `json:"maintenance"` // List of trees needing attention
+	TotalCount      int                     `json:"total"`
+	HealthyCount    int                     `json:"healthy"`
+	SickCount       int                     `json:"sick"`
+	DeadCount       int                     `json:"dead"`
+	FertilizedCount int                     `json:"fertilized"`
+	MonitoredCount  int                     `json:"monitored"`
+	ByStatus        map[string]int          `json:"by_status"`
+	ByLocation      map[string]int          `json:"by_location"`
+	BySpecies       map[string]int          `json:"by_species"`
+	MonthlyGrowth   map[string]int          `json:"monthly_growth"`
+	Maintenance     []*MaintenanceCandidate `json:"maintenance"` // Highest priority trees
+	AsOf            time.Time               `json:"as_of"`
+	RebuiltAt       *time.Time              `json:"rebuilt_at"`
 }
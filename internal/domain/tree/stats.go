@@ -0,0 +1,200 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+)
+
+// StatsKey is one cell of the materialized statistics
+// Trees in the trash are not counted.
+type StatsKey struct {
+	LocationID    string     `json:"location_id"`
+	SpeciesID     string     `json:"species_id"`
+	Status        TreeStatus `json:"status"`
+	PlantingMonth string     `json:"planting_month"` // YYYY-MM, empty if unknown
+}
+
+// StatsCount is the number of trees in one cell
+type StatsCount struct {
+	StatsKey
+	Count int64 `json:"count"`
+}
+
+// StatsSnapshot is the materialized statistics as stored
+type StatsSnapshot struct {
+	Counts    []StatsCount `json:"counts"`
+	AsOf      time.Time    `json:"as_of"`      // Last incremental update or rebuild
+	RebuiltAt *time.Time   `json:"rebuilt_at"` // Last full rebuild, nil if never
+}
+
+// StatsRepository stores the materialized tree statistics
+type StatsRepository interface {
+	// Apply adds deltas (negative to decrement) atomically
+	Apply(ctx context.Context, deltas map[StatsKey]int64) error
+
+	// Replace swaps in a full rebuild
+	Replace(ctx context.Context, counts map[StatsKey]int64, rebuiltAt time.Time) error
+
+	// Load returns the cells in locationIDs (nil means all locations)
+	Load(ctx context.Context, locationIDs []string) (*StatsSnapshot, error)
+}
+
+// GetStatsRebuildInterval returns how often statistics are rebuilt from scratch
+func GetStatsRebuildInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("TREE_STATS_REBUILD_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 6 * time.Hour
+}
+
+// statsKeyOf returns the cell a tree counts in; ok is false for trashed trees
+func statsKeyOf(t *Tree) (StatsKey, bool) {
+	if t == nil || t.IsDeleted() {
+		return StatsKey{}, false
+	}
+	key := StatsKey{
+		LocationID: t.LocationID,
+		SpeciesID:  t.SpeciesID,
+		Status:     t.Status,
+	}
+	if !t.PlantingDate.IsZero() {
+		key.PlantingMonth = t.PlantingDate.Format("2006-01")
+	}
+	return key, true
+}
+
+// statsTrackingRepository keeps StatsRepository in step with every tree mutation
+// It reads the stored tree (past any cache) before each change to know which
+// cell to decrement, holding a per-tree lock so concurrent changes of one tree
+// on this instance see each other's result. A lost delta (statistics store
+// down, races between instances) is logged or left to the next rebuild.
+type statsTrackingRepository struct {
+	TreeRepository
+	fresh TreeRepository
+	stats StatsRepository
+	locks [64]sync.Mutex
+}
+
+func newStatsTrackingRepository(repo TreeRepository, fresh TreeRepository, stats StatsRepository) TreeRepository {
+	if stats == nil {
+		return repo
+	}
+	return &statsTrackingRepository{
+		TreeRepository: repo,
+		fresh:          fresh,
+		stats:          stats,
+	}
+}
+
+// lock serializes changes of one tree; callers defer the returned unlock
+func (r *statsTrackingRepository) lock(id string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	mu := &r.locks[h.Sum32()%uint32(len(r.locks))]
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (r *statsTrackingRepository) Create(ctx context.Context, t *Tree) error {
+	if err := r.TreeRepository.Create(ctx, t); err != nil {
+		return err
+	}
+	r.track(ctx, nil, t)
+	return nil
+}
+
+func (r *statsTrackingRepository) Update(ctx context.Context, t *Tree) error {
+	defer r.lock(t.ID)()
+	before := r.current(ctx, t.ID)
+	if err := r.TreeRepository.Update(ctx, t); err != nil {
+		return err
+	}
+	if before != nil {
+		r.track(ctx, before, t)
+	}
+	return nil
+}
+
+func (r *statsTrackingRepository) UpdateStatus(ctx context.Context, id string, status TreeStatus, healthScore int) error {
+	defer r.lock(id)()
+	before := r.current(ctx, id)
+	if err := r.TreeRepository.UpdateStatus(ctx, id, status, healthScore); err != nil {
+		return err
+	}
+	if before != nil {
+		after := *before
+		after.Status = status
+		r.track(ctx, before, &after)
+	}
+	return nil
+}
+
+func (r *statsTrackingRepository) SoftDelete(ctx context.Context, id string, deletedBy string, reason string) error {
+	defer r.lock(id)()
+	before := r.current(ctx, id)
+	if err := r.TreeRepository.SoftDelete(ctx, id, deletedBy, reason); err != nil {
+		return err
+	}
+	r.track(ctx, before, nil)
+	return nil
+}
+
+func (r *statsTrackingRepository) Restore(ctx context.Context, id string) error {
+	defer r.lock(id)()
+	before := r.current(ctx, id)
+	if err := r.TreeRepository.Restore(ctx, id); err != nil {
+		return err
+	}
+	if before != nil {
+		r.track(ctx, before, r.current(ctx, id))
+	}
+	return nil
+}
+
+func (r *statsTrackingRepository) Delete(ctx context.Context, id string) error {
+	defer r.lock(id)()
+	before := r.current(ctx, id)
+	if err := r.TreeRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.track(ctx, before, nil)
+	return nil
+}
+
+// current returns a copy of the stored tree, or nil if it cannot be read
+// Without the old state a change is left to the next rebuild rather than guessed.
+func (r *statsTrackingRepository) current(ctx context.Context, id string) *Tree {
+	t, err := r.fresh.FindByID(ctx, id)
+	if err != nil || t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// track moves a tree from its old cell to its new one
+func (r *statsTrackingRepository) track(ctx context.Context, before *Tree, after *Tree) {
+	deltas := make(map[StatsKey]int64, 2)
+	if key, ok := statsKeyOf(before); ok {
+		deltas[key]--
+	}
+	if key, ok := statsKeyOf(after); ok {
+		deltas[key]++
+	}
+	for key, n := range deltas {
+		if n == 0 {
+			delete(deltas, key)
+		}
+	}
+	if len(deltas) == 0 {
+		return
+	}
+
+	if err := r.stats.Apply(ctx, deltas); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to update tree statistics (fixed by next rebuild): %v\n", err)
+	}
+}
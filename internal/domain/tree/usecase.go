@@ -3,17 +3,22 @@ package tree
 import (
 	"context"
 	"time"
+
+	"prabogo/internal/domain/audit"
 )
 
 // TreeUseCase handles tree use cases
+// Every call applies the location scope carried by ctx (see LocationScope), so
+// services acting on trees through it with the caller's context need no scope
+// checks of their own for those trees.
 type TreeUseCase struct {
 	service *TreeService
 }
 
 // NewTreeUseCase creates a new tree use case
-func NewTreeUseCase(repo TreeRepository, monitoringRepo MonitoringRepository) *TreeUseCase {
+func NewTreeUseCase(repo TreeRepository, monitoringRepo MonitoringRepository, changeRepo TreeChangeRepository, statsRepo StatsRepository, speciesRepo, locationRepo ReferenceRepository, ruleRepo MaintenanceRuleRepository, auditor audit.Recorder) *TreeUseCase {
 	return &TreeUseCase{
-		service: NewTreeService(repo, monitoringRepo),
+		service: NewTreeService(repo, monitoringRepo, changeRepo, statsRepo, speciesRepo, locationRepo, ruleRepo, auditor),
 	}
 }
 
@@ -32,8 +37,12 @@ type TreeResponse struct {
 	Notes                string  `json:"notes"`
 	RegisteredBy         string  `json:"registered_by"`
 	RegisteredByUsername string  `json:"registered_by_username"` // Populated by handler
+	Version              int     `json:"version"`
 	CreatedAt            string  `json:"created_at"`
 	UpdatedAt            string  `json:"updated_at"`
+	DeletedAt            string  `json:"deleted_at,omitempty"`
+	DeletedBy            string  `json:"deleted_by,omitempty"`
+	DeleteReason         string  `json:"delete_reason,omitempty"`
 }
 
 // RegisterTree registers a new tree
@@ -54,6 +63,15 @@ func (uc *TreeUseCase) GetTreeByCode(ctx context.Context, code string) (*TreeRes
 	return toTreeResponse(tree), nil
 }
 
+// GetCurrentTreeByCode retrieves the stored tree, bypassing caches
+func (uc *TreeUseCase) GetCurrentTreeByCode(ctx context.Context, code string) (*TreeResponse, error) {
+	tree, err := uc.service.GetCurrentTree(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return toTreeResponse(tree), nil
+}
+
 // ListTrees retrieves trees with filter
 func (uc *TreeUseCase) ListTrees(ctx context.Context, filter TreeFilter) ([]*TreeResponse, error) {
 	trees, err := uc.service.ListTrees(ctx, filter)
@@ -63,14 +81,69 @@ func (uc *TreeUseCase) ListTrees(ctx context.Context, filter TreeFilter) ([]*Tre
 	return toTreeResponses(trees), nil
 }
 
-// UpdateTreeStatus updates tree condition
-func (uc *TreeUseCase) UpdateTreeStatus(ctx context.Context, code string, status TreeStatus, healthScore int, notes string, userID string) error {
-	return uc.service.UpdateTreeCondition(ctx, code, status, healthScore, notes, userID)
+// ExportTrees streams trees with filter as API responses
+func (uc *TreeUseCase) ExportTrees(ctx context.Context, filter TreeFilter, fn func(*TreeResponse) error) error {
+	return uc.service.ExportTrees(ctx, filter, func(t *Tree) error {
+		return fn(toTreeResponse(t))
+	})
+}
+
+// UpdateTreeStatus updates tree condition (expectedVersion 0 skips the If-Match check)
+func (uc *TreeUseCase) UpdateTreeStatus(ctx context.Context, code string, status TreeStatus, healthScore int, notes string, userID string, expectedVersion int) error {
+	return uc.service.UpdateTreeCondition(ctx, code, status, healthScore, notes, userID, expectedVersion)
+}
+
+// RecordTreeCondition updates tree condition and returns the monitoring log ID ("" if the log failed)
+func (uc *TreeUseCase) RecordTreeCondition(ctx context.Context, code string, status TreeStatus, healthScore int, notes string, userID string, expectedVersion int) (string, error) {
+	return uc.service.RecordTreeCondition(ctx, code, status, healthScore, notes, userID, expectedVersion)
+}
+
+// RecordTreeWork adds a monitoring log for work that leaves the tree's condition unchanged
+func (uc *TreeUseCase) RecordTreeWork(ctx context.Context, code string, notes string, userID string) error {
+	return uc.service.RecordTreeWork(ctx, code, notes, userID)
+}
+
+// EditTree applies a merge patch and returns the updated tree with its change set
+func (uc *TreeUseCase) EditTree(ctx context.Context, code string, patch TreePatch, userID string, expectedVersion int) (*TreeResponse, []FieldChange, error) {
+	tree, changes, err := uc.service.EditTree(ctx, code, patch, userID, expectedVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	return toTreeResponse(tree), changes, nil
+}
+
+// GetTreeChanges retrieves the field change log of a tree
+func (uc *TreeUseCase) GetTreeChanges(ctx context.Context, code string) ([]*FieldChange, error) {
+	return uc.service.GetTreeChanges(ctx, code)
+}
+
+// DeleteTree moves a tree to the trash
+func (uc *TreeUseCase) DeleteTree(ctx context.Context, code string, userID string, reason string) error {
+	return uc.service.DeleteTree(ctx, code, userID, reason)
+}
+
+// ListTrash retrieves soft-deleted trees
+func (uc *TreeUseCase) ListTrash(ctx context.Context, filter TreeFilter) ([]*TreeResponse, error) {
+	trees, err := uc.service.ListTrash(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return toTreeResponses(trees), nil
+}
+
+// RestoreTree brings a tree back from the trash
+func (uc *TreeUseCase) RestoreTree(ctx context.Context, code string, userID string) error {
+	return uc.service.RestoreTree(ctx, code, userID)
 }
 
-// DeleteTree removes a tree
-func (uc *TreeUseCase) DeleteTree(ctx context.Context, code string) error {
-	return uc.service.DeleteTree(ctx, code)
+// PurgeTree permanently removes a trashed tree
+func (uc *TreeUseCase) PurgeTree(ctx context.Context, code string) error {
+	return uc.service.PurgeTree(ctx, code)
+}
+
+// PurgeExpiredTrash permanently removes trashed trees past retention
+func (uc *TreeUseCase) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	return uc.service.PurgeExpiredTrash(ctx)
 }
 
 // GetStatistics retrieves tree statistics
@@ -82,6 +155,41 @@ func (uc *TreeUseCase) GetStatistics(ctx context.Context) (*TreeStatisticsRespon
 	return toStatisticsResponse(stats), nil
 }
 
+// RunStatisticsRebuild rebuilds materialized statistics periodically until ctx is done
+func (uc *TreeUseCase) RunStatisticsRebuild(ctx context.Context, interval time.Duration) {
+	uc.service.RunStatisticsRebuild(ctx, interval)
+}
+
+// MaintenanceQueue returns a page of trees ranked by maintenance priority
+func (uc *TreeUseCase) MaintenanceQueue(ctx context.Context, filter MaintenanceFilter) ([]*MaintenanceCandidate, int, time.Time, error) {
+	return uc.service.maintenance.Queue(ctx, filter)
+}
+
+// ListMaintenanceRules returns every maintenance rule
+func (uc *TreeUseCase) ListMaintenanceRules(ctx context.Context) ([]*MaintenanceRule, error) {
+	return uc.service.maintenance.ListRules(ctx)
+}
+
+// CreateMaintenanceRule adds a maintenance rule
+func (uc *TreeUseCase) CreateMaintenanceRule(ctx context.Context, rule MaintenanceRule) (*MaintenanceRule, error) {
+	return uc.service.maintenance.CreateRule(ctx, rule)
+}
+
+// UpdateMaintenanceRule replaces a maintenance rule
+func (uc *TreeUseCase) UpdateMaintenanceRule(ctx context.Context, id string, rule MaintenanceRule) (*MaintenanceRule, error) {
+	return uc.service.maintenance.UpdateRule(ctx, id, rule)
+}
+
+// DeleteMaintenanceRule removes a maintenance rule
+func (uc *TreeUseCase) DeleteMaintenanceRule(ctx context.Context, id string) error {
+	return uc.service.maintenance.DeleteRule(ctx, id)
+}
+
+// RunMaintenanceRefresh recomputes the maintenance queue periodically until ctx is done
+func (uc *TreeUseCase) RunMaintenanceRefresh(ctx context.Context, interval time.Duration) {
+	uc.service.maintenance.Run(ctx, interval)
+}
+
 // TreeStatisticsResponse for API
 type TreeStatisticsResponse struct {
 	Total           int               `json:"total"`
@@ -90,35 +198,40 @@ type TreeStatisticsResponse struct {
 	Dead            int               `json:"dead"`
 	Fertilized      int               `json:"fertilized"`
 	Monitored       int               `json:"monitored"`
+	ByStatus        map[string]int    `json:"by_status"`
+	ByLocation      map[string]int    `json:"by_location"`
+	BySpecies       map[string]int    `json:"by_species"`
 	MonthlyGrowth   map[string]int    `json:"monthly_growth"`   // Chart Data
 	MaintenanceList []MaintenanceItem `json:"maintenance_list"` // Widget Data
+	AsOf            time.Time         `json:"as_of"`            // When the counters were last updated
+	RebuiltAt       *time.Time        `json:"rebuilt_at"`       // Last full recount
 }
 
 type MaintenanceItem struct {
-	Code      string `json:"code"`
-	Status    string `json:"status"`
-	Issue     string `json:"issue"` // "Overdue" or "Sick"
-	UpdatedAt string `json:"updated_at"`
+	Code      string              `json:"code"`
+	Status    string              `json:"status"`
+	Issue     string              `json:"issue"` // Reason of the heaviest matched rule
+	Score     int                 `json:"score"`
+	Reasons   []MaintenanceReason `json:"reasons"`
+	UpdatedAt string              `json:"updated_at"`
 }
 
 // Helper: Convert Tree Statistics
 func toStatisticsResponse(s *TreeStatistics) *TreeStatisticsResponse {
 	maintenance := []MaintenanceItem{}
-	for _, t := range s.Maintenance {
+	for _, c := range s.Maintenance {
 		issue := "Attention Needed"
-		if t.Status == "SAKIT" {
-			issue = "Pohon Sakit"
-		} else if t.HealthScore < 50 {
-			issue = "Kesehatan Buruk"
-		} else {
-			issue = "Perawatan Berkala"
+		if len(c.Reasons) > 0 {
+			issue = c.Reasons[0].Reason
 		}
 
 		maintenance = append(maintenance, MaintenanceItem{
-			Code:      t.Code,
-			Status:    string(t.Status),
+			Code:      c.Code,
+			Status:    string(c.Status),
 			Issue:     issue,
-			UpdatedAt: t.UpdatedAt.Format("2006-01-02 15:04:05"),
+			Score:     c.Score,
+			Reasons:   c.Reasons,
+			UpdatedAt: c.UpdatedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
 
@@ -129,12 +242,17 @@ func toStatisticsResponse(s *TreeStatistics) *TreeStatisticsResponse {
 		Dead:            s.DeadCount,
 		Fertilized:      s.FertilizedCount,
 		Monitored:       s.MonitoredCount,
+		ByStatus:        s.ByStatus,
+		ByLocation:      s.ByLocation,
+		BySpecies:       s.BySpecies,
 		MonthlyGrowth:   s.MonthlyGrowth,
 		MaintenanceList: maintenance,
+		AsOf:            s.AsOf,
+		RebuiltAt:       s.RebuiltAt,
 	}
 }
 func toTreeResponse(t *Tree) *TreeResponse {
-	return &TreeResponse{
+	resp := &TreeResponse{
 		ID:           t.ID,
 		Code:         t.Code,
 		SpeciesID:    t.SpeciesID,
@@ -147,9 +265,16 @@ func toTreeResponse(t *Tree) *TreeResponse {
 		HealthScore:  t.HealthScore,
 		Notes:        t.Notes,
 		RegisteredBy: t.RegisteredBy,
+		Version:      t.Version,
 		CreatedAt:    t.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:    t.UpdatedAt.Format(time.RFC3339),
 	}
+	if t.DeletedAt != nil {
+		resp.DeletedAt = t.DeletedAt.Format(time.RFC3339)
+		resp.DeletedBy = t.DeletedBy
+		resp.DeleteReason = t.DeleteReason
+	}
+	return resp
 }
 
 // Helper: Convert multiple trees
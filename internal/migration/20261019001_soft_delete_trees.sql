@@ -0,0 +1,20 @@
+-- Soft delete for trees: deletes move trees to the trash instead of removing rows
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE trees
+    ADD COLUMN deleted_at TIMESTAMP NULL,
+    ADD COLUMN deleted_by VARCHAR(50),
+    ADD COLUMN delete_reason TEXT;
+
+CREATE INDEX idx_trees_deleted_at ON trees(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_trees_deleted_at;
+
+ALTER TABLE trees
+    DROP COLUMN IF EXISTS delete_reason,
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- Optimistic concurrency: every successful update bumps the tree version
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE trees ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE trees DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- Field-level change log for tree corrections (PATCH /api/trees/:code)
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tree_changes (
+    id VARCHAR(50) PRIMARY KEY,
+    change_set_id VARCHAR(50) NOT NULL,
+    tree_id VARCHAR(50) NOT NULL,
+    tree_code VARCHAR(50) NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    changed_by VARCHAR(50),
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_tree_changes_tree ON tree_changes(tree_id);
+CREATE INDEX idx_tree_changes_changed_at ON tree_changes(changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS tree_changes;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- Append-only, hash-chained audit trail
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_logs (
+    seq BIGINT PRIMARY KEY,
+    id VARCHAR(50) UNIQUE NOT NULL,
+    actor_id VARCHAR(50),
+    actor_name VARCHAR(100),
+    action VARCHAR(100) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id VARCHAR(100),
+    before_value TEXT,
+    after_value TEXT,
+    ip VARCHAR(64),
+    user_agent TEXT,
+    transaction_id VARCHAR(50),
+    created_at TIMESTAMP NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL
+);
+
+CREATE INDEX idx_audit_logs_actor ON audit_logs(actor_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs(action);
+CREATE INDEX idx_audit_logs_target ON audit_logs(target_type, target_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Reject UPDATE/DELETE so the log stays append-only even for direct SQL access
+CREATE OR REPLACE FUNCTION audit_logs_block_mutation() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER trg_audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_block_mutation();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_block_mutation();
+DROP TABLE IF EXISTS audit_logs;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- Rotating refresh tokens. Only the SHA-256 of each token is stored.
+-- All tokens issued from one login share a family_id (the session);
+-- revoking a session revokes every token in its family.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE refresh_tokens (
+    id VARCHAR(50) PRIMARY KEY,
+    family_id VARCHAR(50) NOT NULL,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    replaced_by VARCHAR(50),
+    ip VARCHAR(64),
+    user_agent TEXT
+);
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refresh_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- Roles and their permissions, editable at runtime. users.role references
+-- roles.name; the three original roles are seeded with their former rights.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles (
+    name VARCHAR(20) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE role_permissions (
+    role_name VARCHAR(20) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role_name, permission)
+);
+
+INSERT INTO roles (name, description, built_in) VALUES
+    ('admin', 'Full access', TRUE),
+    ('editor', 'Registers and maintains trees', TRUE),
+    ('viewer', 'Read-only access', TRUE);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'tree:create'),
+    ('admin', 'tree:update'),
+    ('admin', 'tree:delete'),
+    ('admin', 'tree:restore'),
+    ('admin', 'tree:purge'),
+    ('admin', 'tag:print'),
+    ('admin', 'report:view'),
+    ('admin', 'report:export'),
+    ('admin', 'user:manage'),
+    ('admin', 'role:manage'),
+    ('admin', 'audit:read'),
+    ('editor', 'tree:create'),
+    ('editor', 'tree:update'),
+    ('editor', 'tree:restore'),
+    ('editor', 'tag:print'),
+    ('editor', 'report:view'),
+    ('editor', 'report:export'),
+    ('viewer', 'report:view'),
+    ('viewer', 'report:export');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
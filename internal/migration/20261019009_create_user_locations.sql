@@ -0,0 +1,23 @@
+-- Locations assigned to field users. Roles without tree:all_locations only
+-- see trees in their assigned locations; the built-in roles keep full access.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_locations (
+    user_id VARCHAR(50) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    location_id VARCHAR(50) NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    assigned_by VARCHAR(50) NOT NULL DEFAULT '',
+    assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, location_id)
+);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'tree:all_locations'),
+    ('editor', 'tree:all_locations'),
+    ('viewer', 'tree:all_locations');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'tree:all_locations';
+DROP TABLE IF EXISTS user_locations;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- API keys for machine clients (drones, soil sensors, ERP) on the existing
+-- clients table. Only the SHA-256 of a key is stored: legacy plaintext
+-- bearer_key values are hashed and cleared, and start without scopes.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS clients (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100),
+    bearer_key VARCHAR(255) UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE clients
+    ADD COLUMN key_prefix VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN key_hash CHAR(64) UNIQUE,
+    ADD COLUMN rate_limit INTEGER NOT NULL DEFAULT 60,
+    ADD COLUMN expires_at TIMESTAMP,
+    ADD COLUMN revoked_at TIMESTAMP,
+    ADD COLUMN last_used_at TIMESTAMP,
+    ADD COLUMN created_by VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL;
+
+UPDATE clients
+SET key_hash = encode(sha256(convert_to(bearer_key, 'UTF8')), 'hex'),
+    key_prefix = LEFT(bearer_key, 8),
+    bearer_key = NULL
+WHERE bearer_key IS NOT NULL;
+
+CREATE TABLE client_scopes (
+    client_id INTEGER NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    scope VARCHAR(50) NOT NULL,
+    PRIMARY KEY (client_id, scope)
+);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'client:manage');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'client:manage';
+DROP TABLE IF EXISTS client_scopes;
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS key_prefix,
+    DROP COLUMN IF EXISTS key_hash,
+    DROP COLUMN IF EXISTS rate_limit,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS created_by;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- Account lifecycle: deleted users stay as tombstones (deleted_at set, personal
+-- data scrubbed) so registered_by and monitored_by keep resolving, and new
+-- users can be invited by email. Only the SHA-256 of each invitation token is stored.
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE TABLE user_invitations (
+    id VARCHAR(50) PRIMARY KEY,
+    email VARCHAR(100) NOT NULL,
+    full_name VARCHAR(100) NOT NULL DEFAULT '',
+    role VARCHAR(20) NOT NULL REFERENCES roles(name),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    invited_by VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    accepted_user_id VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_invitations_email ON user_invitations(email);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_invitations;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- Tree scan analytics: every view of the public tree page is an event, rolled
+-- up per hour and per day as it is recorded. Raw events are purged after
+-- SCAN_EVENT_RETENTION_DAYS; rollups are kept. No foreign key to trees, which
+-- may live in SawitDB (hybrid mode).
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tree_scans (
+    id VARCHAR(50) PRIMARY KEY,
+    tree_id VARCHAR(50) NOT NULL,
+    tree_code VARCHAR(50) NOT NULL,
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    user_id VARCHAR(50) REFERENCES users(id) ON DELETE SET NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    scanned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_tree_scans_coordinates CHECK ((latitude IS NULL) = (longitude IS NULL))
+);
+
+CREATE INDEX idx_tree_scans_scanned_at ON tree_scans(scanned_at);
+CREATE INDEX idx_tree_scans_tree ON tree_scans(tree_code, scanned_at);
+CREATE INDEX idx_tree_scans_location ON tree_scans(location_id, scanned_at);
+
+CREATE TABLE tree_scan_rollups (
+    granularity VARCHAR(10) NOT NULL,
+    bucket_start TIMESTAMP NOT NULL,
+    tree_id VARCHAR(50) NOT NULL,
+    tree_code VARCHAR(50) NOT NULL,
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    scans BIGINT NOT NULL DEFAULT 0,
+    authenticated_scans BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (granularity, bucket_start, tree_id),
+    CONSTRAINT chk_tree_scan_rollups_granularity CHECK (granularity IN ('hour', 'day'))
+);
+
+CREATE INDEX idx_tree_scan_rollups_tree ON tree_scan_rollups(granularity, tree_code, bucket_start);
+CREATE INDEX idx_tree_scan_rollups_location ON tree_scan_rollups(granularity, location_id, bucket_start);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS tree_scan_rollups;
+DROP TABLE IF EXISTS tree_scans;
+-- +goose StatementEnd
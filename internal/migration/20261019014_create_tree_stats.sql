@@ -0,0 +1,29 @@
+-- Materialized dashboard statistics: tree counts per location, species, status
+-- and planting month. Every tree mutation applies a +1/-1 delta; a periodic
+-- full rebuild (TREE_STATS_REBUILD_INTERVAL) corrects any drift.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tree_stat_counts (
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    species_id VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    planting_month CHAR(7) NOT NULL DEFAULT '',
+    tree_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (location_id, species_id, status, planting_month)
+);
+
+CREATE TABLE tree_stat_state (
+    id INTEGER PRIMARY KEY DEFAULT 1,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    rebuilt_at TIMESTAMP,
+    CONSTRAINT chk_tree_stat_state_single CHECK (id = 1)
+);
+
+INSERT INTO tree_stat_state (id) VALUES (1);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS tree_stat_state;
+DROP TABLE IF EXISTS tree_stat_counts;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- Weighted rules behind the maintenance queue (GET /api/maintenance). A tree's
+-- score is the sum of the weights of the rules it matches. Rules sharing a code
+-- override each other by specificity (species + location > location > species
+-- > global); species_id/location_id use '' for "any" so the unique key holds.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE maintenance_rules (
+    id VARCHAR(50) PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    statuses TEXT[] NOT NULL DEFAULT '{}',
+    threshold DOUBLE PRECISION NOT NULL DEFAULT 0,
+    weight INTEGER NOT NULL,
+    species_id VARCHAR(50) NOT NULL DEFAULT '',
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    reason VARCHAR(200) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_maintenance_rules_scope UNIQUE (code, species_id, location_id),
+    CONSTRAINT chk_maintenance_rules_weight CHECK (weight BETWEEN 1 AND 1000)
+);
+
+-- Defaults reproduce the old widget (sick trees) and add the two common signals
+INSERT INTO maintenance_rules (id, code, kind, statuses, threshold, weight, reason) VALUES
+    ('mr-default-sick', 'sick', 'status', '{SAKIT}', 0, 50, 'Pohon Sakit'),
+    ('mr-default-low-health', 'low_health', 'health_below', '{}', 50, 30, 'Kesehatan Buruk'),
+    ('mr-default-overdue', 'overdue', 'no_update_days', '{}', 30, 20, 'Perawatan Berkala');
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'maintenance:manage');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'maintenance:manage';
+DROP TABLE IF EXISTS maintenance_rules;
+-- +goose StatementEnd
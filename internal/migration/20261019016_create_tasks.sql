@@ -0,0 +1,54 @@
+-- Work orders: supervisors assign maintenance work (fertilize, prune, treat pest,
+-- replant) on one or more trees. Lifecycle open -> in_progress -> done ->
+-- verified; completing writes a monitoring log per tree and, if result_status is
+-- set, changes the tree status. task_trees.completed_at makes completion
+-- resumable when a tree fails midway.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tasks (
+    id VARCHAR(50) PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    assignee_id VARCHAR(50) NOT NULL,
+    due_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    result_status VARCHAR(20) NOT NULL DEFAULT '',
+    created_by VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    completed_by VARCHAR(50) NOT NULL DEFAULT '',
+    completion_notes TEXT NOT NULL DEFAULT '',
+    verified_at TIMESTAMP,
+    verified_by VARCHAR(50) NOT NULL DEFAULT '',
+    verification_notes TEXT NOT NULL DEFAULT '',
+    CONSTRAINT chk_tasks_status CHECK (status IN ('open', 'in_progress', 'done', 'verified'))
+);
+
+CREATE INDEX idx_tasks_assignee_status ON tasks (assignee_id, status);
+CREATE INDEX idx_tasks_status_due ON tasks (status, due_date);
+
+CREATE TABLE task_trees (
+    task_id VARCHAR(50) NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    tree_id VARCHAR(50) NOT NULL,
+    tree_code VARCHAR(50) NOT NULL,
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    completed_at TIMESTAMP,
+    PRIMARY KEY (task_id, tree_id)
+);
+
+CREATE INDEX idx_task_trees_tree_code ON task_trees (tree_code);
+CREATE INDEX idx_task_trees_location ON task_trees (location_id);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'task:manage');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'task:manage';
+DROP TABLE IF EXISTS task_trees;
+DROP TABLE IF EXISTS tasks;
+-- +goose StatementEnd
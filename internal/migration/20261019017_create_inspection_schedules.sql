@@ -0,0 +1,54 @@
+-- Recurring inspections. A tree is due interval_days after its latest
+-- monitoring log; the most specific enabled schedule applies (tree > status >
+-- location > species > global). '' means "any" for the criteria columns.
+-- inspection_reminders records each announced (schedule, tree, due date) so the
+-- scheduler creates one task or email per inspection, lead_days ahead.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE inspection_schedules (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT '',
+    species_id VARCHAR(50) NOT NULL DEFAULT '',
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    tree_code VARCHAR(50) NOT NULL DEFAULT '',
+    interval_days INTEGER NOT NULL,
+    lead_days INTEGER NOT NULL DEFAULT 0,
+    assignee_id VARCHAR(50) NOT NULL DEFAULT '',
+    create_tasks BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_inspection_schedules_interval CHECK (interval_days BETWEEN 1 AND 3650),
+    CONSTRAINT chk_inspection_schedules_lead CHECK (lead_days >= 0 AND lead_days < interval_days)
+);
+
+CREATE TABLE inspection_reminders (
+    schedule_id VARCHAR(50) NOT NULL REFERENCES inspection_schedules (id) ON DELETE CASCADE,
+    tree_id VARCHAR(50) NOT NULL,
+    due_date DATE NOT NULL,
+    task_id VARCHAR(50),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schedule_id, tree_id, due_date)
+);
+
+CREATE INDEX idx_inspection_reminders_due ON inspection_reminders (due_date);
+CREATE INDEX idx_logs_tree_date ON monitoring_logs (tree_id, monitor_date);
+
+-- The former hard-coded 30 days becomes the global default
+INSERT INTO inspection_schedules (id, name, status, interval_days, lead_days) VALUES
+    ('is-default-all', 'Pemeriksaan umum', '', 30, 3),
+    ('is-default-dipantau', 'Pemantauan intensif', 'DIPANTAU', 14, 2),
+    ('is-default-sehat', 'Pemeriksaan rutin', 'SEHAT', 90, 7);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'inspection:manage');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'inspection:manage';
+DROP INDEX IF EXISTS idx_logs_tree_date;
+DROP TABLE IF EXISTS inspection_reminders;
+DROP TABLE IF EXISTS inspection_schedules;
+-- +goose StatementEnd
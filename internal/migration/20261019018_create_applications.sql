@@ -0,0 +1,49 @@
+-- Input applications: what fertilizer or pesticide was applied, how much per
+-- tree, how and when, by whom and from which batch. One record covers listed
+-- trees or a whole block; application_trees keeps the trees it reached so
+-- usage can be reconciled per block against inventory.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE applications (
+    id VARCHAR(50) PRIMARY KEY,
+    product VARCHAR(100) NOT NULL,
+    category VARCHAR(20) NOT NULL,
+    dose_per_tree NUMERIC(12, 3) NOT NULL,
+    unit VARCHAR(5) NOT NULL,
+    method VARCHAR(20) NOT NULL,
+    batch_number VARCHAR(50) NOT NULL DEFAULT '',
+    operator_id VARCHAR(50) NOT NULL,
+    applied_at TIMESTAMP NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    tree_count INTEGER NOT NULL,
+    location_ids TEXT[] NOT NULL DEFAULT '{}',
+    created_by VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_applications_dose CHECK (dose_per_tree > 0)
+);
+
+CREATE INDEX idx_applications_applied_at ON applications (applied_at DESC);
+CREATE INDEX idx_applications_product ON applications (product);
+
+CREATE TABLE application_trees (
+    application_id VARCHAR(50) NOT NULL REFERENCES applications (id) ON DELETE CASCADE,
+    tree_id VARCHAR(50) NOT NULL,
+    tree_code VARCHAR(50) NOT NULL,
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    PRIMARY KEY (application_id, tree_id)
+);
+
+CREATE INDEX idx_application_trees_tree_code ON application_trees (tree_code);
+CREATE INDEX idx_application_trees_location ON application_trees (location_id, application_id);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'application:record'),
+    ('editor', 'application:record');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'application:record';
+DROP TABLE IF EXISTS application_trees;
+DROP TABLE IF EXISTS applications;
+-- +goose StatementEnd
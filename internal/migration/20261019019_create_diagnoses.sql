@@ -0,0 +1,96 @@
+-- Pest and disease catalog with diagnoses. Each catalog entry has a severity
+-- scale (JSON array of {level, label, treatment}) and an outbreak threshold:
+-- alert_threshold trees of one block diagnosed within alert_window_days raise
+-- an outbreak alert, at most one per entry, block and window. Diagnoses belong
+-- to the monitoring log they were recorded with (log_id; purging a tree's
+-- history removes them) and keep the tree's block so spread can be reported
+-- per block over time.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE diagnosis_catalog (
+    id VARCHAR(50) PRIMARY KEY,
+    code VARCHAR(30) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    treatment TEXT NOT NULL DEFAULT '',
+    severity_scale JSONB NOT NULL,
+    alert_threshold INTEGER NOT NULL DEFAULT 0,
+    alert_window_days INTEGER NOT NULL DEFAULT 14,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_diagnosis_catalog_kind CHECK (kind IN ('pest', 'disease'))
+);
+
+CREATE TABLE diagnoses (
+    id VARCHAR(50) PRIMARY KEY,
+    log_id VARCHAR(50) NOT NULL REFERENCES monitoring_logs (id) ON DELETE CASCADE,
+    tree_id VARCHAR(50) NOT NULL,
+    tree_code VARCHAR(50) NOT NULL,
+    location_id VARCHAR(50) NOT NULL DEFAULT '',
+    entry_id VARCHAR(50) NOT NULL REFERENCES diagnosis_catalog (id),
+    severity INTEGER NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    diagnosed_by VARCHAR(50) NOT NULL,
+    diagnosed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_diagnoses_log ON diagnoses (log_id);
+CREATE INDEX idx_diagnoses_tree ON diagnoses (tree_id, diagnosed_at DESC);
+CREATE INDEX idx_diagnoses_entry_location ON diagnoses (entry_id, location_id, diagnosed_at);
+CREATE INDEX idx_diagnoses_diagnosed_at ON diagnoses (diagnosed_at);
+
+CREATE TABLE outbreak_alerts (
+    id VARCHAR(50) PRIMARY KEY,
+    entry_id VARCHAR(50) NOT NULL REFERENCES diagnosis_catalog (id) ON DELETE CASCADE,
+    location_id VARCHAR(50) NOT NULL,
+    trees INTEGER NOT NULL,
+    threshold INTEGER NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    triggered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    acknowledged_at TIMESTAMP,
+    acknowledged_by VARCHAR(50) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_outbreak_alerts_entry_location ON outbreak_alerts (entry_id, location_id, triggered_at);
+
+-- Common oil palm pests and diseases
+INSERT INTO diagnosis_catalog (id, code, name, kind, description, treatment, severity_scale, alert_threshold, alert_window_days) VALUES
+    ('dc-ganoderma', 'GANODERMA', 'Busuk pangkal batang (Ganoderma)', 'disease',
+     'Jamur Ganoderma boninense menyerang pangkal batang; tubuh buah muncul di pangkal.',
+     'Bongkar jaringan terinfeksi, aplikasi Trichoderma, isolasi parit di sekitar pohon.',
+     '[{"level":1,"label":"ringan","treatment":"Aplikasi Trichoderma dan pantau 2 minggu sekali."},{"level":2,"label":"sedang"},{"level":3,"label":"berat"},{"level":4,"label":"kritis","treatment":"Tumbang dan musnahkan pohon, bongkar akar."}]',
+     3, 30),
+    ('dc-ulat-api', 'ULAT_API', 'Ulat api (Setothosea asigna)', 'pest',
+     'Ulat pemakan daun; serangan berat menyebabkan daun tinggal lidi.',
+     'Semprot insektisida berbahan aktif deltametrin atau Bacillus thuringiensis.',
+     '[{"level":1,"label":"ringan (<5 ulat/pelepah)"},{"level":2,"label":"sedang (5-10 ulat/pelepah)"},{"level":3,"label":"berat (>10 ulat/pelepah)"}]',
+     10, 14),
+    ('dc-oryctes', 'ORYCTES', 'Kumbang tanduk (Oryctes rhinoceros)', 'pest',
+     'Kumbang melubangi pucuk; bekas gerekan berbentuk kipas pada daun muda.',
+     'Pasang feromon perangkap, musnahkan tempat berkembang biak di tumpukan batang.',
+     '[{"level":1,"label":"ringan"},{"level":2,"label":"sedang"},{"level":3,"label":"berat"}]',
+     10, 14),
+    ('dc-tikus', 'TIKUS', 'Tikus (Rattus tiomanicus)', 'pest',
+     'Tikus memakan buah dan bunga; bekas gerigitan pada tandan.',
+     'Umpan rodentisida dan pemasangan sarang burung hantu (Tyto alba).',
+     '[{"level":1,"label":"ringan"},{"level":2,"label":"sedang"},{"level":3,"label":"berat"}]',
+     20, 14),
+    ('dc-busuk-tandan', 'BUSUK_TANDAN', 'Busuk tandan (Marasmius palmivorus)', 'disease',
+     'Miselium putih pada tandan buah, terutama saat lembap.',
+     'Sanitasi tandan busuk, perbaiki drainase, aplikasi fungisida bila meluas.',
+     '[{"level":1,"label":"ringan"},{"level":2,"label":"sedang"},{"level":3,"label":"berat"}]',
+     5, 14);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'diagnosis:manage');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'diagnosis:manage';
+DROP TABLE IF EXISTS outbreak_alerts;
+DROP TABLE IF EXISTS diagnoses;
+DROP TABLE IF EXISTS diagnosis_catalog;
+-- +goose StatementEnd
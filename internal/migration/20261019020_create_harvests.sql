@@ -0,0 +1,37 @@
+-- Harvest records: fresh fruit bunches (TBS) taken from one tree or, with an
+-- empty tree_id, from a whole block. Yield per tree and per hectare is derived
+-- from these, the block's living trees and locations.area_hectare, next to the
+-- monitoring history of the same trees.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE harvests (
+    id VARCHAR(50) PRIMARY KEY,
+    tree_id VARCHAR(50) NOT NULL DEFAULT '',
+    tree_code VARCHAR(50) NOT NULL DEFAULT '',
+    location_id VARCHAR(50) NOT NULL,
+    bunches INTEGER NOT NULL DEFAULT 0,
+    weight_kg NUMERIC(12, 2) NOT NULL,
+    grade VARCHAR(10) NOT NULL DEFAULT 'A',
+    harvester_id VARCHAR(50) NOT NULL,
+    harvested_at TIMESTAMP NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_harvests_weight CHECK (weight_kg > 0),
+    CONSTRAINT chk_harvests_bunches CHECK (bunches >= 0)
+);
+
+CREATE INDEX idx_harvests_location_date ON harvests (location_id, harvested_at);
+CREATE INDEX idx_harvests_tree_date ON harvests (tree_id, harvested_at) WHERE tree_id <> '';
+CREATE INDEX idx_harvests_harvested_at ON harvests (harvested_at);
+
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('admin', 'harvest:record'),
+    ('editor', 'harvest:record');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'harvest:record';
+DROP TABLE IF EXISTS harvests;
+-- +goose StatementEnd
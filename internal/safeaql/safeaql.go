@@ -155,6 +155,21 @@ func (s *SafeExecutor) Update(ctx context.Context, table, set, where string) err
 	return err
 }
 
+// UpdateAffected - PUPUK [table] DENGAN [set], returning number of rows changed
+// Used for conditional updates (e.g. optimistic locking on a version column)
+func (s *SafeExecutor) UpdateAffected(ctx context.Context, table, set, where string) (int64, error) {
+	aqlQuery := s.builder.Update(table, set, where)
+	sqlQuery := s.translator.ToSQL(aqlQuery)
+
+	log.WithContext(ctx).Debugf("AQL: %s -> SQL: %s", aqlQuery, sqlQuery)
+
+	res, err := s.db.ExecContext(ctx, sqlQuery)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // Delete - GUSUR DARI [table]
 func (s *SafeExecutor) Delete(ctx context.Context, table, where string) error {
 	aqlQuery := s.builder.Delete(table, where)
@@ -0,0 +1,156 @@
+// Local mock OpenID Connect provider for testing single sign-on
+//
+//   node scripts/mock_oidc_provider.js
+//
+// Then start the API with:
+//   OIDC_ISSUER=http://127.0.0.1:9400
+//   OIDC_CLIENT_ID=tree-id
+//   OIDC_REDIRECT_URL=http://127.0.0.1:7000/
+//   OIDC_GROUP_ROLES=foresters=editor,it-admins=admin
+//
+// /authorize signs in immediately as the mock user (no login form). Pick the
+// identity with query params on the authorization URL or with env vars:
+//   MOCK_OIDC_SUB, MOCK_OIDC_EMAIL, MOCK_OIDC_NAME, MOCK_OIDC_USERNAME,
+//   MOCK_OIDC_GROUPS (comma separated), MOCK_OIDC_EMAIL_VERIFIED (default true)
+// PKCE is enforced: /token rejects a wrong code_verifier.
+const http = require('http');
+const crypto = require('crypto');
+const { URL, URLSearchParams } = require('url');
+
+const PORT = parseInt(process.env.MOCK_OIDC_PORT || '9400', 10);
+const ISSUER = process.env.MOCK_OIDC_ISSUER || `http://127.0.0.1:${PORT}`;
+const CLIENT_ID = process.env.MOCK_OIDC_CLIENT_ID || 'tree-id';
+
+const { publicKey, privateKey } = crypto.generateKeyPairSync('rsa', { modulusLength: 2048 });
+const KID = crypto.randomBytes(8).toString('hex');
+const jwk = { ...publicKey.export({ format: 'jwk' }), kid: KID, use: 'sig', alg: 'RS256' };
+
+// Issued codes, single use, valid for one minute
+const codes = new Map();
+
+function base64url(input) {
+    return Buffer.from(input).toString('base64url');
+}
+
+function signIdToken(claims) {
+    const header = base64url(JSON.stringify({ alg: 'RS256', typ: 'JWT', kid: KID }));
+    const payload = base64url(JSON.stringify(claims));
+    const signature = crypto.sign('sha256', Buffer.from(`${header}.${payload}`), privateKey).toString('base64url');
+    return `${header}.${payload}.${signature}`;
+}
+
+function mockUser(query) {
+    const pick = (name, fallback) => query.get(name) || process.env[`MOCK_OIDC_${name.toUpperCase()}`] || fallback;
+    return {
+        sub: pick('sub', 'mock-user-1'),
+        email: pick('email', 'forester@example.com'),
+        email_verified: pick('email_verified', 'true') === 'true',
+        name: pick('name', 'Mock Forester'),
+        preferred_username: pick('username', 'forester'),
+        groups: pick('groups', 'foresters').split(',').map((g) => g.trim()).filter(Boolean)
+    };
+}
+
+function sendJSON(res, status, body) {
+    res.writeHead(status, { 'Content-Type': 'application/json' });
+    res.end(JSON.stringify(body));
+}
+
+function readBody(req) {
+    return new Promise((resolve) => {
+        let data = '';
+        req.on('data', (chunk) => { data += chunk; });
+        req.on('end', () => resolve(new URLSearchParams(data)));
+    });
+}
+
+async function handleToken(req, res) {
+    const form = await readBody(req);
+    const entry = codes.get(form.get('code'));
+    codes.delete(form.get('code'));
+
+    if (form.get('grant_type') !== 'authorization_code' || !entry || entry.expires < Date.now()) {
+        return sendJSON(res, 400, { error: 'invalid_grant' });
+    }
+    if (form.get('client_id') !== CLIENT_ID || form.get('redirect_uri') !== entry.redirectUri) {
+        return sendJSON(res, 400, { error: 'invalid_client' });
+    }
+    const challenge = crypto.createHash('sha256').update(form.get('code_verifier') || '').digest('base64url');
+    if (challenge !== entry.codeChallenge) {
+        return sendJSON(res, 400, { error: 'invalid_grant', error_description: 'PKCE verification failed' });
+    }
+
+    const now = Math.floor(Date.now() / 1000);
+    const idToken = signIdToken({
+        iss: ISSUER,
+        aud: CLIENT_ID,
+        iat: now,
+        exp: now + 300,
+        nonce: entry.nonce,
+        ...entry.user
+    });
+    console.log(`🔑 Issued id_token for ${entry.user.email} (groups: ${entry.user.groups.join(', ') || '-'})`);
+
+    sendJSON(res, 200, {
+        access_token: crypto.randomBytes(16).toString('hex'),
+        token_type: 'Bearer',
+        expires_in: 300,
+        id_token: idToken
+    });
+}
+
+function handleAuthorize(url, res) {
+    const q = url.searchParams;
+    if (q.get('response_type') !== 'code' || q.get('client_id') !== CLIENT_ID) {
+        return sendJSON(res, 400, { error: 'unauthorized_client' });
+    }
+    if (q.get('code_challenge_method') !== 'S256' || !q.get('code_challenge')) {
+        return sendJSON(res, 400, { error: 'invalid_request', error_description: 'PKCE (S256) is required' });
+    }
+
+    const code = crypto.randomBytes(16).toString('hex');
+    codes.set(code, {
+        redirectUri: q.get('redirect_uri'),
+        codeChallenge: q.get('code_challenge'),
+        nonce: q.get('nonce'),
+        user: mockUser(q),
+        expires: Date.now() + 60 * 1000
+    });
+
+    const target = new URL(q.get('redirect_uri'));
+    target.searchParams.set('code', code);
+    target.searchParams.set('state', q.get('state') || '');
+    res.writeHead(302, { Location: target.toString() });
+    res.end();
+}
+
+const server = http.createServer((req, res) => {
+    const url = new URL(req.url, ISSUER);
+
+    if (req.method === 'GET' && url.pathname === '/.well-known/openid-configuration') {
+        return sendJSON(res, 200, {
+            issuer: ISSUER,
+            authorization_endpoint: `${ISSUER}/authorize`,
+            token_endpoint: `${ISSUER}/token`,
+            jwks_uri: `${ISSUER}/jwks`,
+            response_types_supported: ['code'],
+            subject_types_supported: ['public'],
+            id_token_signing_alg_values_supported: ['RS256'],
+            code_challenge_methods_supported: ['S256']
+        });
+    }
+    if (req.method === 'GET' && url.pathname === '/jwks') {
+        return sendJSON(res, 200, { keys: [jwk] });
+    }
+    if (req.method === 'GET' && url.pathname === '/authorize') {
+        return handleAuthorize(url, res);
+    }
+    if (req.method === 'POST' && url.pathname === '/token') {
+        return handleToken(req, res);
+    }
+    sendJSON(res, 404, { error: 'not_found' });
+});
+
+server.listen(PORT, '127.0.0.1', () => {
+    console.log(`🪪 Mock OIDC provider running at ${ISSUER} (client_id: ${CLIENT_ID})`);
+});
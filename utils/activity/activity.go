@@ -14,10 +14,17 @@ const (
 	ClientID
 	Payload
 	Result
+	ActorID
+	ClientIP
+	UserAgent
 )
 
 func NewContext(action string) context.Context {
-	trxID := uuid.New().String()
+	return NewContextWithTransactionID(action, uuid.New().String())
+}
+
+// NewContextWithTransactionID reuses an existing transaction ID (e.g. one assigned per HTTP request)
+func NewContextWithTransactionID(action string, trxID string) context.Context {
 	ctx := context.WithValue(context.Background(), TransactionID, trxID)
 	return context.WithValue(ctx, Action, action)
 }
@@ -45,6 +52,30 @@ func GetClientID(ctx context.Context) (string, bool) {
 	return clientID, ok
 }
 
+func WithActorID(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, ActorID, actorID)
+}
+
+func GetActorID(ctx context.Context) (string, bool) {
+	actorID, ok := ctx.Value(ActorID).(string)
+	return actorID, ok
+}
+
+func WithClientInfo(ctx context.Context, ip string, userAgent string) context.Context {
+	ctx = context.WithValue(ctx, ClientIP, ip)
+	return context.WithValue(ctx, UserAgent, userAgent)
+}
+
+func GetClientIP(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(ClientIP).(string)
+	return ip, ok
+}
+
+func GetUserAgent(ctx context.Context) (string, bool) {
+	userAgent, ok := ctx.Value(UserAgent).(string)
+	return userAgent, ok
+}
+
 func WithPayload(ctx context.Context, payload interface{}) context.Context {
 	return context.WithValue(ctx, Payload, payload)
 }
@@ -76,6 +107,10 @@ func GetFields(ctx context.Context) map[string]interface{} {
 		fields["client_id"] = clientID
 	}
 
+	if actorID, ok := GetActorID(ctx); ok {
+		fields["actor_id"] = actorID
+	}
+
 	fields["payload"] = GetPayload(ctx)
 	fields["result"] = GetResult(ctx)
 
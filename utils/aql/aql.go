@@ -1,6 +1,9 @@
 package aql
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // QueryBuilder provides AQL (Agricultural Query Language) syntax helpers
 // for SawitDB WowoEngine operations
@@ -78,6 +81,12 @@ func (q *QueryBuilder) Count(table string, where string) string {
 	return query
 }
 
+// EscapeString doubles single quotes so free text can sit inside a quoted literal
+// Example: EscapeString("pohon 'besar'") -> pohon ''besar''
+func EscapeString(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
+
 // Helper functions
 
 func joinStrings(items []string) string {
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// Format represents a supported export file format
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// RowWriter writes tabular rows one at a time so exports never hold the
+// whole dataset in memory
+type RowWriter interface {
+	WriteRow(cells []string) error
+	Close() error
+}
+
+// ParseFormat validates a format query value (defaults to CSV)
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unsupported export format: %s (use csv or xlsx)", value)
+}
+
+// ContentType returns the MIME type for the format
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewRowWriter creates a writer for the given format
+func NewRowWriter(f Format, w io.Writer) (RowWriter, error) {
+	if f == FormatXLSX {
+		return NewXLSXWriter(w, "Sheet1")
+	}
+	return NewCSVWriter(w), nil
+}
+
+// CSVWriter streams rows as CSV
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter creates a CSV row writer
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// WriteRow writes a single CSV record
+func (c *CSVWriter) WriteRow(cells []string) error {
+	return c.w.Write(cells)
+}
+
+// Close flushes buffered records
+func (c *CSVWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,129 @@
+package label
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Label holds the data printed on one tree tag
+type Label struct {
+	Code         string
+	Species      string
+	PlantingDate string
+	URL          string
+}
+
+// Sheet layout for A4 sticker paper (3 x 8 labels of 70 x 37 mm)
+const (
+	sheetColumns  = 3
+	sheetRows     = 8
+	labelWidthMM  = 70.0
+	labelHeightMM = 37.0
+	sheetMarginX  = 0.0
+	sheetMarginY  = 0.5
+	qrSizeMM      = 30.0
+	labelPadMM    = 3.5
+)
+
+// QRCodePNG renders content as a square PNG of size pixels
+func QRCodePNG(content string, size int) ([]byte, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return q.PNG(size)
+}
+
+// QRCodeSVG renders content as a scalable SVG document
+// Each dark module becomes a unit square so the output stays sharp at any print size.
+func QRCodeSVG(content string) (string, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	bitmap := q.Bitmap()
+	n := len(bitmap)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	return b.String(), nil
+}
+
+// WriteLabelSheet renders labels onto A4 sticker sheets as a PDF
+// QR codes are drawn as vector rectangles rather than embedded bitmaps.
+func WriteLabelSheet(w io.Writer, labels []Label) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	perPage := sheetColumns * sheetRows
+	for i, l := range labels {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+
+		slot := i % perPage
+		x := sheetMarginX + float64(slot%sheetColumns)*labelWidthMM
+		y := sheetMarginY + float64(slot/sheetColumns)*labelHeightMM
+
+		if err := drawQRCode(pdf, l.URL, x+labelPadMM, y+(labelHeightMM-qrSizeMM)/2, qrSizeMM); err != nil {
+			return err
+		}
+
+		textX := x + labelPadMM + qrSizeMM + 2
+		textW := labelWidthMM - (textX - x) - labelPadMM
+
+		pdf.SetXY(textX, y+labelPadMM+2)
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.CellFormat(textW, 8, tr(l.Code), "", 2, "L", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.MultiCell(textW, 4, tr(l.Species), "", "L", false)
+		pdf.SetX(textX)
+		pdf.CellFormat(textW, 4, tr("Tanam: "+l.PlantingDate), "", 2, "L", false, 0, "")
+	}
+
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	return pdf.Output(w)
+}
+
+// drawQRCode paints the QR modules for content into a size x size box
+func drawQRCode(pdf *fpdf.Fpdf, content string, x, y, size float64) error {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	bitmap := q.Bitmap()
+	module := size / float64(len(bitmap))
+
+	pdf.SetFillColor(0, 0, 0)
+	for row, cols := range bitmap {
+		for col, dark := range cols {
+			if dark {
+				pdf.Rect(x+float64(col)*module, y+float64(row)*module, module, module, "F")
+			}
+		}
+	}
+
+	return nil
+}
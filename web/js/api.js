@@ -25,11 +25,19 @@ const API = {
     async request(endpoint, options = {}) {
         try {
             const url = `${this.baseURL}${endpoint}`;
-            const response = await fetch(url, {
+            let response = await fetch(url, {
                 ...options,
                 headers: this.getHeaders(options.auth !== false)
             });
 
+            // Access tokens are short-lived: renew once and retry
+            if (response.status === 401 && options.auth !== false && await this.refreshSession()) {
+                response = await fetch(url, {
+                    ...options,
+                    headers: this.getHeaders(true)
+                });
+            }
+
             const data = await response.json();
 
             if (!response.ok) {
@@ -43,6 +51,40 @@ const API = {
         }
     },
 
+    // Exchange the stored refresh token for a new token pair
+    // Concurrent callers share one in-flight refresh (each token is single-use)
+    refreshSession() {
+        const refreshToken = Storage.getRefreshToken();
+        if (!refreshToken) {
+            return Promise.resolve(false);
+        }
+
+        if (!this.refreshing) {
+            this.refreshing = fetch(`${this.baseURL}/auth/refresh`, {
+                method: 'POST',
+                headers: this.getHeaders(false),
+                body: JSON.stringify({ refresh_token: refreshToken })
+            })
+                .then(async (response) => {
+                    if (!response.ok) {
+                        Storage.removeToken();
+                        Storage.removeRefreshToken();
+                        return false;
+                    }
+                    const data = await response.json();
+                    Storage.setToken(data.data.token);
+                    Storage.setRefreshToken(data.data.refresh_token);
+                    return true;
+                })
+                .catch(() => false)
+                .finally(() => {
+                    this.refreshing = null;
+                });
+        }
+
+        return this.refreshing;
+    },
+
     // Auth endpoints
     auth: {
         login: (username, password) =>
@@ -59,7 +101,102 @@ const API = {
                 auth: false
             }),
 
-        me: () => API.request('/auth/me')
+        me: () => API.request('/auth/me'),
+
+        updateMe: (data) => API.request('/auth/me', { method: 'PUT', body: JSON.stringify(data) }),
+
+        // Invitation links (#/accept-invite/<token>)
+        invitation: (token) => API.request(`/auth/invitations/${encodeURIComponent(token)}`, { auth: false }),
+
+        acceptInvitation: (data) =>
+            API.request('/auth/invitations/accept', {
+                method: 'POST',
+                body: JSON.stringify(data),
+                auth: false
+            }),
+
+        // Single sign-on (OpenID Connect); the state cookie needs the page on the API's origin
+        oidcConfig: () => API.request('/auth/oidc', { auth: false }),
+
+        oidcLogin: () => API.request('/auth/oidc/login', { auth: false }),
+
+        oidcCallback: (code, state) =>
+            API.request('/auth/oidc/callback', {
+                method: 'POST',
+                body: JSON.stringify({ code, state }),
+                auth: false
+            }),
+
+        permissions: () => API.request('/auth/me/permissions'),
+
+        logout: () => API.request('/auth/logout', { method: 'POST' }),
+
+        logoutAll: () => API.request('/auth/logout-all', { method: 'POST' }),
+
+        changePassword: (currentPassword, newPassword) =>
+            API.request('/auth/password/change', {
+                method: 'POST',
+                body: JSON.stringify({ current_password: currentPassword, new_password: newPassword })
+            }),
+
+        forgotPassword: (email) =>
+            API.request('/auth/password/forgot', {
+                method: 'POST',
+                body: JSON.stringify({ email }),
+                auth: false
+            }),
+
+        resetPassword: (token, newPassword) =>
+            API.request('/auth/password/reset', {
+                method: 'POST',
+                body: JSON.stringify({ token, new_password: newPassword }),
+                auth: false
+            }),
+
+        // Two-factor authentication
+        twoFactorStatus: () => API.request('/auth/2fa'),
+
+        twoFactorSetup: () => API.request('/auth/2fa/setup', { method: 'POST' }),
+
+        twoFactorEnable: (code) =>
+            API.request('/auth/2fa/enable', {
+                method: 'POST',
+                body: JSON.stringify({ code })
+            }),
+
+        twoFactorDisable: (password, code) =>
+            API.request('/auth/2fa/disable', {
+                method: 'POST',
+                body: JSON.stringify({ password, code })
+            }),
+
+        twoFactorRecoveryCodes: (code) =>
+            API.request('/auth/2fa/recovery-codes', {
+                method: 'POST',
+                body: JSON.stringify({ code })
+            }),
+
+        // Second login step: pass either a code or a recovery code
+        twoFactorVerify: (preAuthToken, code, recoveryCode) =>
+            API.request('/auth/2fa/verify', {
+                method: 'POST',
+                body: JSON.stringify({ pre_auth_token: preAuthToken, code, recovery_code: recoveryCode }),
+                auth: false
+            }),
+
+        twoFactorEnrollSetup: (preAuthToken) =>
+            API.request('/auth/2fa/enroll/setup', {
+                method: 'POST',
+                body: JSON.stringify({ pre_auth_token: preAuthToken }),
+                auth: false
+            }),
+
+        twoFactorEnrollComplete: (preAuthToken, code) =>
+            API.request('/auth/2fa/enroll/complete', {
+                method: 'POST',
+                body: JSON.stringify({ pre_auth_token: preAuthToken, code }),
+                auth: false
+            })
     },
 
     // Tree endpoints
@@ -83,9 +220,10 @@ const API = {
                 body: JSON.stringify(statusData)
             }),
 
-        delete: (code) =>
+        delete: (code, reason) =>
             API.request(`/trees/${code}`, {
-                method: 'DELETE'
+                method: 'DELETE',
+                body: JSON.stringify({ reason })
             }),
 
         history: (code) => API.request(`/trees/${code}/history`)
@@ -98,9 +236,37 @@ const API = {
 
     // User endpoints
     users: {
-        list: () => API.request('/users'),
+        list: (includeDeleted = false) => API.request(includeDeleted ? '/users?include_deleted=true' : '/users'),
         create: (data) => API.request('/users', { method: 'POST', body: JSON.stringify(data) }),
+        update: (id, data) => API.request(`/users/${id}`, { method: 'PUT', body: JSON.stringify(data) }),
+        deactivate: (id) => API.request(`/users/${id}/deactivate`, { method: 'POST' }),
+        reactivate: (id) => API.request(`/users/${id}/reactivate`, { method: 'POST' }),
         delete: (id) => API.request(`/users/${id}`, { method: 'DELETE' }),
-        updateRole: (id, role) => API.request(`/users/${id}/role`, { method: 'PUT', body: JSON.stringify({ role }) })
+        invitations: () => API.request('/users/invitations'),
+        invite: (data) => API.request('/users/invitations', { method: 'POST', body: JSON.stringify(data) }),
+        resendInvitation: (id) => API.request(`/users/invitations/${id}/resend`, { method: 'POST' }),
+        revokeInvitation: (id) => API.request(`/users/invitations/${id}`, { method: 'DELETE' }),
+        updateRole: (id, role) => API.request(`/users/${id}/role`, { method: 'PUT', body: JSON.stringify({ role }) }),
+        locations: (id) => API.request(`/users/${id}/locations`),
+        setLocations: (id, locationIds) => API.request(`/users/${id}/locations`, { method: 'PUT', body: JSON.stringify({ location_ids: locationIds }) })
+    },
+
+    // Role & permission endpoints
+    roles: {
+        list: () => API.request('/roles'),
+        permissions: () => API.request('/permissions'),
+        create: (data) => API.request('/roles', { method: 'POST', body: JSON.stringify(data) }),
+        update: (name, data) => API.request(`/roles/${name}`, { method: 'PUT', body: JSON.stringify(data) }),
+        delete: (name) => API.request(`/roles/${name}`, { method: 'DELETE' })
+    },
+
+    // API keys for machine clients
+    clients: {
+        list: () => API.request('/clients'),
+        scopes: () => API.request('/clients/scopes'),
+        create: (data) => API.request('/clients', { method: 'POST', body: JSON.stringify(data) }),
+        update: (id, data) => API.request(`/clients/${id}`, { method: 'PUT', body: JSON.stringify(data) }),
+        rotate: (id, expiresInDays) => API.request(`/clients/${id}/rotate`, { method: 'POST', body: JSON.stringify({ expires_in_days: expiresInDays || 0 }) }),
+        revoke: (id) => API.request(`/clients/${id}`, { method: 'DELETE' })
     }
 };
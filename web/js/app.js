@@ -12,6 +12,13 @@ const App = {
             Router.init();
             console.log('✅ Router initialized');
 
+            // Returning from the single sign-on provider
+            const params = new URLSearchParams(window.location.search);
+            if (params.get('code') && params.get('state')) {
+                Auth.completeOIDCLogin(params.get('code'), params.get('state'));
+                return;
+            }
+
             // Check authentication on load
             this.checkAuth();
         } catch (error) {
@@ -25,6 +32,11 @@ const App = {
         Router.register('/', renderLandingPage); // Landing/Scanner Page
         Router.register('/login', renderLogin);
         Router.register('/register', renderRegister);
+        Router.register('/forgot-password', renderForgotPassword);
+        Router.register('/reset-password/:token', renderResetPassword);
+        Router.register('/accept-invite/:token', renderAcceptInvite);
+        Router.register('/login/2fa', renderTwoFactorVerify);
+        Router.register('/login/2fa-enroll', renderTwoFactorEnroll);
 
         // Protected Routes (IMPORTANT: Register specific routes BEFORE dynamic routes)
         Router.register('/dashboard', this.requireAuth(renderDashboard));
@@ -32,6 +44,8 @@ const App = {
         Router.register('/trees/new', this.requireAuth(renderTreeForm)); // MUST be before /trees/:code
         Router.register('/trees/:code/edit', this.requireAuth(renderTreeForm)); // MUST be before /trees/:code
         Router.register('/users', this.requireAuth(renderUsersList));
+        Router.register('/account/password', this.requireAuth(renderChangePassword));
+        Router.register('/account/2fa', this.requireAuth(renderTwoFactorSettings));
 
         // Public Tree View (Dynamic route - MUST be registered LAST)
         Router.register('/trees/:code', renderTreeDetail);
@@ -47,6 +61,11 @@ const App = {
                 return;
             }
 
+            // Sessions from before permissions existed (or another tab) may lack them
+            if (Storage.getPermissions() === null) {
+                await Auth.loadPermissions();
+            }
+
             // Verify token is still valid (lightweight check if needed, or rely on API 401)
             // const valid = await Auth.verifyToken();
             // if (!valid) {
@@ -7,10 +7,14 @@ const Auth = {
             const response = await API.auth.login(username, password);
 
             if (response.success) {
-                Storage.setToken(response.data.token);
-                Storage.setUser(response.data.user);
-                showToast('Login successful!', 'success');
-                Router.navigate('/dashboard');
+                // Password accepted, but a second factor is still needed
+                if (response.data.two_factor_required || response.data.enrollment_required) {
+                    TwoFactor.pendingToken = response.data.pre_auth_token;
+                    Router.navigate(response.data.enrollment_required ? '/login/2fa-enroll' : '/login/2fa');
+                    return true;
+                }
+
+                await this.completeLogin(response.data);
                 return true;
             }
 
@@ -23,6 +27,49 @@ const Auth = {
         }
     },
 
+    // Single sign-on: go to the identity provider
+    async loginWithOIDC() {
+        try {
+            showLoading(true);
+            const response = await API.auth.oidcLogin();
+            window.location.href = response.data.authorization_url;
+        } catch (error) {
+            showLoading(false);
+            showToast(error.message || 'Single sign-on is unavailable', 'error');
+        }
+    },
+
+    // Single sign-on: the provider redirected back with ?code=...&state=...
+    async completeOIDCLogin(code, state) {
+        // Drop code and state from the address bar whatever happens
+        window.history.replaceState(null, '', window.location.pathname + window.location.hash);
+        try {
+            showLoading(true);
+            const response = await API.auth.oidcCallback(code, state);
+            if (response.data.two_factor_required || response.data.enrollment_required) {
+                TwoFactor.pendingToken = response.data.pre_auth_token;
+                Router.navigate(response.data.enrollment_required ? '/login/2fa-enroll' : '/login/2fa');
+                return;
+            }
+            await this.completeLogin(response.data);
+        } catch (error) {
+            showToast(error.message || 'Single sign-on failed', 'error');
+            Router.navigate('/login');
+        } finally {
+            showLoading(false);
+        }
+    },
+
+    // Store the session from a login response and enter the app
+    async completeLogin(data) {
+        Storage.setToken(data.token);
+        Storage.setRefreshToken(data.refresh_token);
+        Storage.setUser(data.user);
+        await this.loadPermissions();
+        showToast('Login successful!', 'success');
+        Router.navigate('/dashboard');
+    },
+
     // Register
     async register(userData) {
         try {
@@ -46,8 +93,12 @@ const Auth = {
 
     // Logout
     logout() {
+        // Revoke the session server-side; local sign-out happens regardless
+        API.auth.logout().catch(() => {});
         Storage.removeToken();
+        Storage.removeRefreshToken();
         Storage.removeUser();
+        Storage.removePermissions();
         showToast('Logged out successfully', 'info');
         Router.navigate('/login');
     },
@@ -69,11 +120,23 @@ const Auth = {
         } catch (error) {
             // Token invalid, clear storage
             Storage.removeToken();
+            Storage.removeRefreshToken();
             Storage.removeUser();
+        Storage.removePermissions();
             return false;
         }
     },
 
+    // Cache the role's permissions so the UI can hide what the user can't do
+    async loadPermissions() {
+        try {
+            const response = await API.auth.permissions();
+            Storage.setPermissions(response.data.permissions || []);
+        } catch (error) {
+            Storage.setPermissions([]);
+        }
+    },
+
     // Get current user
     getCurrentUser() {
         return Storage.getUser();
@@ -90,5 +153,9 @@ const Auth = {
 
     isAdmin() {
         return Storage.isAdmin();
+    },
+
+    canManageUsers() {
+        return Storage.canManageUsers();
     }
 };
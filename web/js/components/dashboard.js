@@ -25,6 +25,7 @@ async function renderDashboard() {
                                     <div>
                                         <h1 class="text-3xl font-bold text-white mb-2 tracking-tight">PANTAUPOHON</h1>
                                         <p class="text-emerald-100/90 text-sm font-medium">Real-time plantation monitoring & analytics</p>
+                                        ${stats.as_of ? `<p class="text-emerald-200/70 text-xs mt-1">Statistics as of ${new Date(stats.as_of).toLocaleString()}</p>` : ''}
                                     </div>
                                     <div class="flex items-center gap-3">
                                         <button class="px-5 py-2.5 bg-emerald-500 hover:bg-emerald-400 text-white text-sm font-semibold rounded-xl transition-all shadow-lg shadow-emerald-900/20 active:scale-95 flex items-center gap-2">
@@ -82,11 +83,11 @@ async function renderDashboard() {
                                             <h3 class="text-base font-semibold text-gray-900 dark:text-white">Maintenance Required</h3>
                                         </div>
                                         <span class="px-2.5 py-0.5 rounded-full text-xs font-bold bg-amber-100 text-amber-800 dark:bg-amber-900/40 dark:text-amber-400 ring-1 ring-amber-500/20">
-                                            ${(stats.maintenance || []).length} PENDING
+                                            ${(stats.maintenance_list || []).length} PENDING
                                         </span>
                                     </div>
                                     <div class="flex-1 overflow-y-auto max-h-80 divide-y divide-gray-100 dark:divide-slate-700/50 custom-scrollbar">
-                                        ${renderMaintenanceList(stats.maintenance || [])}
+                                        ${renderMaintenanceList(stats.maintenance_list || [])}
                                     </div>
                                 </div>
 
@@ -189,9 +190,10 @@ function renderMaintenanceList(list) {
                     <span class="font-mono text-xs font-bold text-gray-500 dark:text-slate-400 bg-gray-100 dark:bg-slate-800 px-1.5 py-0.5 rounded">
                         ${item.code}
                     </span>
-                    <span class="text-xs font-semibold text-gray-900 dark:text-white group-hover:text-green-600 transition-colors">
+                    <span class="text-xs font-semibold text-gray-900 dark:text-white group-hover:text-green-600 transition-colors" title="${(item.reasons || []).map(r => r.reason).join(', ')}">
                         ${item.issue}
                     </span>
+                    <span class="text-[10px] font-bold text-amber-600 dark:text-amber-400">${item.score || 0}</span>
                 </div>
                 <span class="text-[10px] font-medium text-gray-400">
                     ${item.updated_at ? item.updated_at.split(' ')[0] : 'Just now'}
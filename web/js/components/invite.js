@@ -0,0 +1,88 @@
+// Accept Invitation (public, opened from the emailed link)
+async function renderAcceptInvite(params) {
+    let invitation;
+    try {
+        showLoading(true);
+        const response = await API.auth.invitation(params.token);
+        invitation = response.data;
+    } catch (error) {
+        showToast(error.message || 'Invalid or expired invitation', 'error');
+        Router.navigate('/login');
+        return;
+    } finally {
+        showLoading(false);
+    }
+
+    const html = `
+    <div class="min-h-screen flex items-center justify-center bg-gradient-to-br from-green-50 to-green-100 px-4">
+      <div class="max-w-md w-full">
+        <div class="card p-8">
+          <h2 class="text-2xl font-bold text-gray-900 mb-2">Join Tree-ID</h2>
+          <p class="text-sm text-gray-600 mb-6">
+            Invitation for <span class="font-medium" id="invite-email"></span> as
+            <span class="font-medium uppercase" id="invite-role"></span>.
+          </p>
+
+          <form id="invite-form" class="space-y-4">
+            <div>
+              <label for="invite-username" class="block text-sm font-medium text-gray-700 mb-2">Username</label>
+              <input type="text" id="invite-username" class="input" required maxlength="50" />
+            </div>
+
+            <div>
+              <label for="invite-fullname" class="block text-sm font-medium text-gray-700 mb-2">Full Name</label>
+              <input type="text" id="invite-fullname" class="input" maxlength="100" />
+            </div>
+
+            <div>
+              <label for="invite-password" class="block text-sm font-medium text-gray-700 mb-2">Password</label>
+              <input type="password" id="invite-password" class="input" required minlength="6" />
+              <p class="text-xs text-gray-500 mt-1">At least 6 characters</p>
+            </div>
+
+            <div>
+              <label for="invite-confirm" class="block text-sm font-medium text-gray-700 mb-2">Confirm Password</label>
+              <input type="password" id="invite-confirm" class="input" required />
+            </div>
+
+            <button type="submit" class="btn btn-primary w-full">Create Account</button>
+          </form>
+        </div>
+      </div>
+    </div>
+  `;
+
+    render(html);
+
+    // Set as text: the invitation was typed in by an admin
+    document.getElementById('invite-email').textContent = invitation.email;
+    document.getElementById('invite-role').textContent = invitation.role;
+    document.getElementById('invite-fullname').value = invitation.full_name || '';
+
+    document.getElementById('invite-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const password = document.getElementById('invite-password').value;
+        const confirm = document.getElementById('invite-confirm').value;
+
+        if (password !== confirm) {
+            showToast('Passwords do not match', 'error');
+            return;
+        }
+
+        try {
+            showLoading(true);
+            const response = await API.auth.acceptInvitation({
+                token: params.token,
+                username: document.getElementById('invite-username').value.trim(),
+                full_name: document.getElementById('invite-fullname').value.trim(),
+                password
+            });
+            showToast(response.message, 'success');
+            Router.navigate('/login');
+        } catch (error) {
+            showToast(error.message || 'Could not accept invitation', 'error');
+        } finally {
+            showLoading(false);
+        }
+    });
+}
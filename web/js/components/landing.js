@@ -111,8 +111,10 @@ function startQRScanner() {
 function onScanSuccess(decodedText) {
     console.log(`QR Code detected: ${decodedText}`);
     stopQRScanner();
-    showToast(`QR Code terdeteksi: ${decodedText}`, 'success');
-    Router.navigate(`/trees/${decodedText}`);
+    // Printed tags encode the public URL (.../#/trees/C001); older tags hold the bare code
+    const code = decodedText.includes('/trees/') ? decodedText.split('/trees/').pop() : decodedText;
+    showToast(`QR Code terdeteksi: ${code}`, 'success');
+    Router.navigate(`/trees/${code}`);
 }
 
 function handleFileScan(input) {
@@ -47,11 +47,24 @@ function renderLogin() {
               />
             </div>
 
+            <div class="text-right">
+              <a href="#/forgot-password" class="text-sm text-green-600 hover:text-green-700">
+                Forgot password?
+              </a>
+            </div>
+
             <button type="submit" class="btn btn-primary w-full">
               Login
             </button>
           </form>
 
+          <!-- Shown when single sign-on is configured -->
+          <div id="oidc-login" class="hidden mt-4">
+            <button type="button" id="oidc-login-btn" class="btn btn-secondary w-full">
+              Login with company account
+            </button>
+          </div>
+
           <div class="mt-6 text-center">
             <p class="text-gray-600">
               Don't have an account?
@@ -78,6 +91,15 @@ function renderLogin() {
 
     // Attach form handler
     document.getElementById('login-form').addEventListener('submit', handleLoginSubmit);
+    document.getElementById('oidc-login-btn').addEventListener('click', () => Auth.loginWithOIDC());
+
+    API.auth.oidcConfig()
+        .then((response) => {
+            if (response.data.enabled) {
+                document.getElementById('oidc-login')?.classList.remove('hidden');
+            }
+        })
+        .catch(() => {});
 }
 
 async function handleLoginSubmit(e) {
@@ -3,7 +3,7 @@ const Navigation = {
   // Render persistent navigation bar with active state
   renderNavbar(currentRoute = '', breadcrumbs = []) {
     const user = Storage.getUser();
-    const isAdmin = Storage.isAdmin();
+    const canManageUsers = Storage.canManageUsers();
     const isAuthenticated = Auth.isAuthenticated();
 
     // 🌟 Public Landing Nav
@@ -45,7 +45,7 @@ const Navigation = {
               <div class="hidden md:flex items-center space-x-6">
                 ${this.renderNavLink('dashboard', 'Dashboard', currentRoute)}
                 ${this.renderNavLink('trees', 'Trees', currentRoute)}
-                ${isAdmin ? this.renderNavLink('users', 'Users', currentRoute) : ''}
+                ${canManageUsers ? this.renderNavLink('users', 'Users', currentRoute) : ''}
               </div>
             </div>
 
@@ -60,9 +60,9 @@ const Navigation = {
               <div class="hidden sm:block h-6 w-px bg-gray-200 dark:bg-slate-700"></div>
 
               <!-- User Info (hidden on mobile) -->
-              <span class="hidden sm:block text-sm text-gray-600 dark:text-slate-400">
+              <a href="#/account/password" class="hidden sm:block text-sm text-gray-600 dark:text-slate-400" title="Change password">
                 Welcome, <strong class="text-gray-900 dark:text-white">${user?.username || 'User'}</strong>
-              </span>
+              </a>
               
               <!-- Logout (hidden on mobile) -->
               <button onclick="Auth.logout()" class="hidden sm:block px-4 py-2 text-sm font-medium text-gray-600 hover:text-red-600 hover:bg-red-50 dark:text-slate-300 dark:hover:text-white dark:hover:bg-white/5 rounded-lg transition-all duration-200">
@@ -85,7 +85,7 @@ const Navigation = {
             <div class="flex flex-col space-y-4">
               ${this.renderMobileNavLink('dashboard', 'Dashboard', currentRoute)}
               ${this.renderMobileNavLink('trees', 'Trees', currentRoute)}
-              ${isAdmin ? this.renderMobileNavLink('users', 'Users', currentRoute) : ''}
+              ${canManageUsers ? this.renderMobileNavLink('users', 'Users', currentRoute) : ''}
               
               <div class="border-t border-gray-200 dark:border-slate-700 pt-4 mt-4">
                 <div class="text-sm text-gray-600 dark:text-slate-400 mb-4">
@@ -183,6 +183,14 @@ const Navigation = {
       'users': [
         { label: 'Dashboard', url: '#/dashboard' },
         { label: 'Users', url: null }
+      ],
+      'account/password': [
+        { label: 'Dashboard', url: '#/dashboard' },
+        { label: 'Change Password', url: null }
+      ],
+      'account/2fa': [
+        { label: 'Dashboard', url: '#/dashboard' },
+        { label: 'Two-Factor Authentication', url: null }
       ]
     };
 
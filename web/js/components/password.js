@@ -0,0 +1,166 @@
+// Password Components - Forgot / Reset / Change
+
+// Forgot Password (public)
+function renderForgotPassword() {
+    const html = `
+    <div class="min-h-screen flex items-center justify-center bg-gradient-to-br from-green-50 to-green-100 px-4">
+      <div class="max-w-md w-full">
+        <div class="card p-8">
+          <h2 class="text-2xl font-bold text-gray-900 mb-2">Forgot Password</h2>
+          <p class="text-sm text-gray-600 mb-6">Enter your account email and we'll send you a reset link.</p>
+
+          <form id="forgot-form" class="space-y-4">
+            <div>
+              <label for="forgot-email" class="block text-sm font-medium text-gray-700 mb-2">Email</label>
+              <input type="email" id="forgot-email" class="input" placeholder="you@example.com" required />
+            </div>
+
+            <button type="submit" class="btn btn-primary w-full">Send Reset Link</button>
+          </form>
+
+          <div class="mt-6 text-center">
+            <a href="#/login" class="text-green-600 hover:text-green-700 font-medium">Back to login</a>
+          </div>
+        </div>
+      </div>
+    </div>
+  `;
+
+    render(html);
+
+    document.getElementById('forgot-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const email = document.getElementById('forgot-email').value.trim();
+
+        try {
+            showLoading(true);
+            const response = await API.auth.forgotPassword(email);
+            showToast(response.message, 'success');
+            Router.navigate('/login');
+        } catch (error) {
+            showToast(error.message || 'Request failed', 'error');
+        } finally {
+            showLoading(false);
+        }
+    });
+}
+
+// Reset Password (public, opened from the emailed link)
+function renderResetPassword(params) {
+    const html = `
+    <div class="min-h-screen flex items-center justify-center bg-gradient-to-br from-green-50 to-green-100 px-4">
+      <div class="max-w-md w-full">
+        <div class="card p-8">
+          <h2 class="text-2xl font-bold text-gray-900 mb-6">Choose a New Password</h2>
+
+          <form id="reset-form" class="space-y-4">
+            <div>
+              <label for="reset-password" class="block text-sm font-medium text-gray-700 mb-2">New Password</label>
+              <input type="password" id="reset-password" class="input" required minlength="6" />
+              <p class="text-xs text-gray-500 mt-1">At least 6 characters</p>
+            </div>
+
+            <div>
+              <label for="reset-confirm" class="block text-sm font-medium text-gray-700 mb-2">Confirm Password</label>
+              <input type="password" id="reset-confirm" class="input" required />
+            </div>
+
+            <button type="submit" class="btn btn-primary w-full">Reset Password</button>
+          </form>
+        </div>
+      </div>
+    </div>
+  `;
+
+    render(html);
+
+    document.getElementById('reset-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const password = document.getElementById('reset-password').value;
+        const confirm = document.getElementById('reset-confirm').value;
+
+        if (password !== confirm) {
+            showToast('Passwords do not match', 'error');
+            return;
+        }
+
+        try {
+            showLoading(true);
+            const response = await API.auth.resetPassword(params.token, password);
+            showToast(response.message, 'success');
+            Router.navigate('/login');
+        } catch (error) {
+            showToast(error.message || 'Reset failed', 'error');
+        } finally {
+            showLoading(false);
+        }
+    });
+}
+
+// Change Password (signed in)
+function renderChangePassword() {
+    const breadcrumbs = Navigation.getBreadcrumbs('account/password');
+    const html = `
+    <div class="min-h-screen bg-gray-50 dark:bg-slate-900 transition-colors duration-300">
+      ${Navigation.renderNavbar('account', breadcrumbs)}
+
+      <div class="pt-24 pb-12 px-4 sm:px-6 lg:px-8 max-w-md mx-auto">
+        <div class="bg-white dark:bg-slate-800/50 rounded-3xl shadow-sm border border-gray-200 dark:border-slate-700 p-8">
+          <h2 class="text-2xl font-bold text-gray-900 dark:text-white mb-2">Change Password</h2>
+          <p class="text-sm text-gray-600 dark:text-slate-400 mb-6">You will be signed out on all other devices.</p>
+
+          <form id="change-password-form" class="space-y-4">
+            <div>
+              <label for="current-password" class="block text-sm font-medium text-gray-700 dark:text-slate-300 mb-2">Current Password</label>
+              <input type="password" id="current-password" class="input" required />
+            </div>
+
+            <div>
+              <label for="new-password" class="block text-sm font-medium text-gray-700 dark:text-slate-300 mb-2">New Password</label>
+              <input type="password" id="new-password" class="input" required minlength="6" />
+            </div>
+
+            <div>
+              <label for="confirm-password" class="block text-sm font-medium text-gray-700 dark:text-slate-300 mb-2">Confirm New Password</label>
+              <input type="password" id="confirm-password" class="input" required />
+            </div>
+
+            <button type="submit" class="btn btn-primary w-full">Change Password</button>
+          </form>
+
+          <div class="mt-6 text-center">
+            <a href="#/account/2fa" class="text-green-600 hover:text-green-700 font-medium">Two-factor authentication</a>
+          </div>
+        </div>
+      </div>
+    </div>
+  `;
+
+    render(html);
+
+    document.getElementById('change-password-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const currentPassword = document.getElementById('current-password').value;
+        const newPassword = document.getElementById('new-password').value;
+        const confirm = document.getElementById('confirm-password').value;
+
+        if (newPassword !== confirm) {
+            showToast('Passwords do not match', 'error');
+            return;
+        }
+
+        try {
+            showLoading(true);
+            const response = await API.auth.changePassword(currentPassword, newPassword);
+            // Old sessions are revoked server-side; continue with the new one
+            Storage.setToken(response.data.token);
+            Storage.setRefreshToken(response.data.refresh_token);
+            showToast('Password changed', 'success');
+            Router.navigate('/dashboard');
+        } catch (error) {
+            showToast(error.message || 'Password change failed', 'error');
+        } finally {
+            showLoading(false);
+        }
+    });
+}
@@ -175,7 +175,8 @@ function renderTreeList() {
                     <span class="text-3xl">⚠️</span>
                 </div>
                 <h3 class="text-lg font-bold text-gray-900 dark:text-white mb-2">Confirm Deletion</h3>
-                <p class="text-sm text-gray-500 dark:text-slate-400 mb-6">Are you sure you want to delete tree <strong id="delete-tree-code" class="text-gray-900 dark:text-white"></strong>? This action cannot be undone.</p>
+                <p class="text-sm text-gray-500 dark:text-slate-400 mb-6">Are you sure you want to delete tree <strong id="delete-tree-code" class="text-gray-900 dark:text-white"></strong>? It will be moved to trash and can be restored until purged.</p>
+                <textarea id="delete-reason" rows="2" placeholder="Reason (required)" class="w-full mb-6 px-3 py-2 text-sm rounded-lg border border-gray-300 dark:border-slate-600 bg-white dark:bg-slate-900 text-gray-900 dark:text-white"></textarea>
                 <div class="flex justify-center gap-3">
                     <button onclick="executeDelete()" class="px-5 py-2.5 bg-red-600 hover:bg-red-700 text-white font-medium rounded-lg transition-colors shadow-lg shadow-red-500/20">Yes, Delete</button>
                     <button onclick="closeDeleteModal()" class="px-5 py-2.5 bg-gray-100 hover:bg-gray-200 dark:bg-slate-700 dark:hover:bg-slate-600 text-gray-700 dark:text-white font-medium rounded-lg transition-colors">Cancel</button>
@@ -497,6 +498,7 @@ async function openViewModal(code) {
 
 function confirmDeleteTree(code) {
   document.getElementById('delete-tree-code').textContent = code;
+  document.getElementById('delete-reason').value = '';
   document.getElementById('delete-modal').classList.remove('hidden');
 }
 
@@ -506,9 +508,14 @@ function closeDeleteModal() {
 
 async function executeDelete() {
   const code = document.getElementById('delete-tree-code').textContent;
+  const reason = document.getElementById('delete-reason').value.trim();
+  if (!reason) {
+    showToast('Please provide a reason for deletion', 'error');
+    return;
+  }
   try {
     showLoading(true);
-    const response = await API.trees.delete(code);
+    const response = await API.trees.delete(code, reason);
     if (response.success) {
       showToast('Deleted!', 'success');
       closeDeleteModal();
@@ -732,6 +739,7 @@ async function handleUpdateSubmit(e) {
 
 function confirmDeleteTree(code) {
   document.getElementById('delete-tree-code').textContent = code;
+  document.getElementById('delete-reason').value = '';
   document.getElementById('delete-modal').classList.remove('hidden');
 }
 
@@ -741,9 +749,14 @@ function closeDeleteModal() {
 
 async function executeDelete() {
   const code = document.getElementById('delete-tree-code').textContent;
+  const reason = document.getElementById('delete-reason').value.trim();
+  if (!reason) {
+    showToast('Please provide a reason for deletion', 'error');
+    return;
+  }
   try {
     showLoading(true);
-    const response = await API.trees.delete(code);
+    const response = await API.trees.delete(code, reason);
     if (response.success) {
       showToast('Deleted!', 'success');
       closeDeleteModal();
@@ -0,0 +1,299 @@
+// Two-Factor Components - Login step / Enrolment / Settings
+
+const TwoFactor = {
+    // Pre-auth token from the password step; kept in memory only
+    pendingToken: null,
+
+    // Shared markup for the secret + QR code returned by setup
+    renderSetup(setup) {
+        return `
+          <div class="text-center space-y-3">
+            <img src="${setup.qr_code}" alt="Authenticator QR code" class="mx-auto w-48 h-48" />
+            <p class="text-xs text-gray-500 dark:text-slate-400">Can't scan? Enter this key manually:</p>
+            <code class="block text-sm font-mono break-all text-gray-900 dark:text-white">${setup.secret}</code>
+          </div>
+        `;
+    },
+
+    // Shared markup for one-time recovery codes
+    renderRecoveryCodes(codes) {
+        return `
+          <div class="mt-6 p-4 rounded-xl bg-yellow-50 border border-yellow-200">
+            <p class="text-sm font-medium text-yellow-800 mb-2">Save these recovery codes. Each works once and they won't be shown again.</p>
+            <div class="grid grid-cols-2 gap-2 font-mono text-sm text-gray-900">
+              ${codes.map(code => `<span>${code}</span>`).join('')}
+            </div>
+          </div>
+        `;
+    }
+};
+
+// Second login step (public)
+function renderTwoFactorVerify() {
+    if (!TwoFactor.pendingToken) {
+        Router.navigate('/login');
+        return;
+    }
+
+    const html = `
+    <div class="min-h-screen flex items-center justify-center bg-gradient-to-br from-green-50 to-green-100 px-4">
+      <div class="max-w-md w-full">
+        <div class="card p-8">
+          <h2 class="text-2xl font-bold text-gray-900 mb-2">Two-Factor Authentication</h2>
+          <p class="text-sm text-gray-600 mb-6">Enter the 6-digit code from your authenticator app, or one of your recovery codes.</p>
+
+          <form id="verify-2fa-form" class="space-y-4">
+            <div>
+              <label for="verify-code" class="block text-sm font-medium text-gray-700 mb-2">Code</label>
+              <input type="text" id="verify-code" class="input" autocomplete="one-time-code" required />
+            </div>
+
+            <button type="submit" class="btn btn-primary w-full">Verify</button>
+          </form>
+
+          <div class="mt-6 text-center">
+            <a href="#/login" class="text-green-600 hover:text-green-700 font-medium">Back to login</a>
+          </div>
+        </div>
+      </div>
+    </div>
+  `;
+
+    render(html);
+
+    document.getElementById('verify-2fa-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const value = document.getElementById('verify-code').value.trim();
+        // Recovery codes look like "abcde-fghij"; authenticator codes are digits
+        const isRecovery = value.includes('-');
+
+        try {
+            showLoading(true);
+            const response = await API.auth.twoFactorVerify(
+                TwoFactor.pendingToken,
+                isRecovery ? '' : value,
+                isRecovery ? value : ''
+            );
+            TwoFactor.pendingToken = null;
+            await Auth.completeLogin(response.data);
+        } catch (error) {
+            showToast(error.message || 'Verification failed', 'error');
+        } finally {
+            showLoading(false);
+        }
+    });
+}
+
+// Forced enrolment during login for roles that require 2FA (public)
+async function renderTwoFactorEnroll() {
+    if (!TwoFactor.pendingToken) {
+        Router.navigate('/login');
+        return;
+    }
+
+    let setup;
+    try {
+        showLoading(true);
+        const response = await API.auth.twoFactorEnrollSetup(TwoFactor.pendingToken);
+        setup = response.data;
+    } catch (error) {
+        showToast(error.message || 'Could not start enrolment', 'error');
+        Router.navigate('/login');
+        return;
+    } finally {
+        showLoading(false);
+    }
+
+    const html = `
+    <div class="min-h-screen flex items-center justify-center bg-gradient-to-br from-green-50 to-green-100 px-4">
+      <div class="max-w-md w-full">
+        <div class="card p-8">
+          <h2 class="text-2xl font-bold text-gray-900 mb-2">Set Up Two-Factor Authentication</h2>
+          <p class="text-sm text-gray-600 mb-6">Your role requires two-factor authentication. Scan the code with an authenticator app, then enter the 6-digit code.</p>
+
+          ${TwoFactor.renderSetup(setup)}
+
+          <form id="enroll-2fa-form" class="space-y-4 mt-6">
+            <div>
+              <label for="enroll-code" class="block text-sm font-medium text-gray-700 mb-2">Code</label>
+              <input type="text" id="enroll-code" class="input" inputmode="numeric" autocomplete="one-time-code" required />
+            </div>
+
+            <button type="submit" class="btn btn-primary w-full">Enable and Sign In</button>
+          </form>
+
+          <div id="enroll-result"></div>
+        </div>
+      </div>
+    </div>
+  `;
+
+    render(html);
+
+    document.getElementById('enroll-2fa-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const code = document.getElementById('enroll-code').value.trim();
+
+        try {
+            showLoading(true);
+            const response = await API.auth.twoFactorEnrollComplete(TwoFactor.pendingToken, code);
+            TwoFactor.pendingToken = null;
+
+            // Show the recovery codes once before entering the app
+            document.getElementById('enroll-2fa-form').classList.add('hidden');
+            document.getElementById('enroll-result').innerHTML = `
+              ${TwoFactor.renderRecoveryCodes(response.data.recovery_codes || [])}
+              <button id="enroll-continue" class="btn btn-primary w-full mt-4">Continue</button>
+            `;
+            document.getElementById('enroll-continue').addEventListener('click', () => {
+                Auth.completeLogin(response.data);
+            });
+        } catch (error) {
+            showToast(error.message || 'Enrolment failed', 'error');
+        } finally {
+            showLoading(false);
+        }
+    });
+}
+
+// Two-factor settings (signed in)
+async function renderTwoFactorSettings() {
+    const breadcrumbs = Navigation.getBreadcrumbs('account/2fa');
+
+    let status;
+    try {
+        showLoading(true);
+        const response = await API.auth.twoFactorStatus();
+        status = response.data;
+    } catch (error) {
+        showToast(error.message || 'Failed to load two-factor status', 'error');
+        return;
+    } finally {
+        showLoading(false);
+    }
+
+    const enabledBody = `
+      <p class="text-sm text-green-700 mb-1 font-medium">Two-factor authentication is enabled.</p>
+      <p class="text-sm text-gray-600 dark:text-slate-400 mb-6">${status.recovery_codes_remaining} recovery codes remaining.</p>
+
+      <form id="recovery-codes-form" class="space-y-4">
+        <div>
+          <label for="recovery-code-input" class="block text-sm font-medium text-gray-700 dark:text-slate-300 mb-2">Authenticator Code</label>
+          <input type="text" id="recovery-code-input" class="input" inputmode="numeric" required />
+        </div>
+        <button type="submit" class="w-full px-4 py-3 text-sm font-medium text-gray-700 bg-gray-100 hover:bg-gray-200 dark:text-slate-200 dark:bg-slate-700 dark:hover:bg-slate-600 rounded-lg transition-all">Generate New Recovery Codes</button>
+      </form>
+
+      ${status.required ? `
+        <p class="text-sm text-gray-600 dark:text-slate-400 mt-6">Your role requires two-factor authentication, so it cannot be disabled.</p>
+      ` : `
+        <form id="disable-2fa-form" class="space-y-4 mt-8">
+          <div>
+            <label for="disable-password" class="block text-sm font-medium text-gray-700 dark:text-slate-300 mb-2">Password</label>
+            <input type="password" id="disable-password" class="input" required />
+          </div>
+          <div>
+            <label for="disable-code" class="block text-sm font-medium text-gray-700 dark:text-slate-300 mb-2">Authenticator Code</label>
+            <input type="text" id="disable-code" class="input" inputmode="numeric" required />
+          </div>
+          <button type="submit" class="w-full px-4 py-3 text-sm font-medium text-white bg-red-600 hover:bg-red-700 rounded-lg transition-all">Disable Two-Factor Authentication</button>
+        </form>
+      `}
+    `;
+
+    const disabledBody = `
+      <p class="text-sm text-gray-600 dark:text-slate-400 mb-6">Protect your account with a code from an authenticator app in addition to your password.</p>
+      <div id="setup-2fa-area">
+        <button id="setup-2fa-btn" class="btn btn-primary w-full">Set Up Two-Factor Authentication</button>
+      </div>
+    `;
+
+    const html = `
+    <div class="min-h-screen bg-gray-50 dark:bg-slate-900 transition-colors duration-300">
+      ${Navigation.renderNavbar('account', breadcrumbs)}
+
+      <div class="pt-24 pb-12 px-4 sm:px-6 lg:px-8 max-w-md mx-auto">
+        <div class="bg-white dark:bg-slate-800/50 rounded-3xl shadow-sm border border-gray-200 dark:border-slate-700 p-8">
+          <h2 class="text-2xl font-bold text-gray-900 dark:text-white mb-2">Two-Factor Authentication</h2>
+          ${status.enabled ? enabledBody : disabledBody}
+          <div id="two-factor-result"></div>
+        </div>
+      </div>
+    </div>
+  `;
+
+    render(html);
+
+    if (!status.enabled) {
+        document.getElementById('setup-2fa-btn').addEventListener('click', async () => {
+            try {
+                showLoading(true);
+                const response = await API.auth.twoFactorSetup();
+                document.getElementById('setup-2fa-area').innerHTML = `
+                  ${TwoFactor.renderSetup(response.data)}
+                  <form id="enable-2fa-form" class="space-y-4 mt-6">
+                    <div>
+                      <label for="enable-code" class="block text-sm font-medium text-gray-700 dark:text-slate-300 mb-2">Code</label>
+                      <input type="text" id="enable-code" class="input" inputmode="numeric" autocomplete="one-time-code" required />
+                    </div>
+                    <button type="submit" class="btn btn-primary w-full">Enable</button>
+                  </form>
+                `;
+                document.getElementById('enable-2fa-form').addEventListener('submit', async (e) => {
+                    e.preventDefault();
+                    const code = document.getElementById('enable-code').value.trim();
+                    try {
+                        showLoading(true);
+                        const result = await API.auth.twoFactorEnable(code);
+                        document.getElementById('setup-2fa-area').innerHTML =
+                            TwoFactor.renderRecoveryCodes(result.data.recovery_codes);
+                        showToast('Two-factor authentication enabled', 'success');
+                    } catch (error) {
+                        showToast(error.message || 'Invalid code', 'error');
+                    } finally {
+                        showLoading(false);
+                    }
+                });
+            } catch (error) {
+                showToast(error.message || 'Setup failed', 'error');
+            } finally {
+                showLoading(false);
+            }
+        });
+        return;
+    }
+
+    document.getElementById('recovery-codes-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const code = document.getElementById('recovery-code-input').value.trim();
+        try {
+            showLoading(true);
+            const response = await API.auth.twoFactorRecoveryCodes(code);
+            document.getElementById('two-factor-result').innerHTML =
+                TwoFactor.renderRecoveryCodes(response.data.recovery_codes);
+        } catch (error) {
+            showToast(error.message || 'Invalid code', 'error');
+        } finally {
+            showLoading(false);
+        }
+    });
+
+    const disableForm = document.getElementById('disable-2fa-form');
+    if (disableForm) {
+        disableForm.addEventListener('submit', async (e) => {
+            e.preventDefault();
+            const password = document.getElementById('disable-password').value;
+            const code = document.getElementById('disable-code').value.trim();
+            try {
+                showLoading(true);
+                await API.auth.twoFactorDisable(password, code);
+                showToast('Two-factor authentication disabled', 'success');
+                renderTwoFactorSettings();
+            } catch (error) {
+                showToast(error.message || 'Failed to disable', 'error');
+            } finally {
+                showLoading(false);
+            }
+        });
+    }
+}
@@ -1,5 +1,6 @@
 const usersList = {
     users: [],
+    roles: [],
 
     async init() {
         console.log('Initializing Users List...');
@@ -22,9 +23,14 @@ const usersList = {
                   <h2 class="text-2xl font-bold text-gray-900 dark:text-white">User Management</h2>
                   <p class="text-sm text-gray-600 dark:text-slate-400 mt-1">Manage system access and roles</p>
                 </div>
-                <button onclick="usersList.openCreateModal()" class="flex items-center gap-2 px-6 py-3 bg-green-600 dark:bg-green-500 text-white rounded-xl hover:bg-green-700 dark:hover:bg-green-400 transition-all shadow-lg shadow-green-500/20 font-medium cursor-pointer">
-                  <span>+</span> New User
-                </button>
+                <div class="flex items-center gap-3">
+                  <button onclick="usersList.handleInvite()" class="flex items-center gap-2 px-6 py-3 bg-white dark:bg-slate-800 text-green-700 dark:text-green-400 border border-green-600 dark:border-green-500 rounded-xl hover:bg-green-50 dark:hover:bg-slate-700 transition-all font-medium cursor-pointer">
+                    <span>✉</span> Invite
+                  </button>
+                  <button onclick="usersList.openCreateModal()" class="flex items-center gap-2 px-6 py-3 bg-green-600 dark:bg-green-500 text-white rounded-xl hover:bg-green-700 dark:hover:bg-green-400 transition-all shadow-lg shadow-green-500/20 font-medium cursor-pointer">
+                    <span>+</span> New User
+                  </button>
+                </div>
               </div>
 
               <!-- Table -->
@@ -110,9 +116,10 @@ const usersList = {
 
     async loadUsers() {
         try {
-            const response = await API.users.list();
+            const [response, roles] = await Promise.all([API.users.list(), API.roles.list()]);
             if (response.success) {
                 this.users = response.data;
+                this.roles = roles.data || [];
                 this.renderTableBody();
             }
         } catch (error) {
@@ -142,7 +149,7 @@ const usersList = {
                 <td class="px-6 py-4 whitespace-nowrap">
                     <div class="flex items-center gap-4">
                         <div class="h-10 w-10 flex-shrink-0 rounded-full bg-gradient-to-br from-green-400 to-emerald-600 flex items-center justify-center text-white font-bold text-sm shadow-md ring-2 ring-white dark:ring-slate-700">
-                            ${user.full_name.charAt(0).toUpperCase()}
+                            ${(user.full_name || user.username).charAt(0).toUpperCase()}
                         </div>
                         <div>
                             <div class="text-sm font-semibold text-gray-900 dark:text-white capitalize">${user.full_name}</div>
@@ -155,8 +162,7 @@ const usersList = {
                         <select onchange="usersList.handleRoleChange('${user.id}', this.value)" 
                                 class="appearance-none text-xs font-bold px-4 py-1.5 rounded-full border-0 focus:ring-2 focus:ring-offset-2 focus:ring-green-500 cursor-pointer transition-all uppercase tracking-wider ${this.getRoleBadgeColor(user.role)} disabled:opacity-80 disabled:cursor-not-allowed"
                                 ${user.id === currentUser.id ? 'disabled title="Cannot change your own role"' : ''}>
-                            <option value="admin" ${user.role === 'admin' ? 'selected' : ''} class="bg-white dark:bg-slate-800 text-gray-900 dark:text-white">ADMIN</option>
-                            <option value="editor" ${user.role === 'editor' ? 'selected' : ''} class="bg-white dark:bg-slate-800 text-gray-900 dark:text-white">EDITOR</option>
+                            ${this.renderRoleOptions(user.role)}
                         </select>
                         <!-- Custom Arrow for non-disabled -->
                          ${user.id !== currentUser.id ? `
@@ -175,6 +181,12 @@ const usersList = {
                 </td>
                 <td class="px-6 py-4 whitespace-nowrap text-right text-sm font-medium">
                     ${user.id !== currentUser.id ? `
+                    <button onclick="usersList.handleLocations('${user.id}')" class="text-gray-400 hover:text-green-600 dark:text-slate-500 dark:hover:text-green-400 p-2 hover:bg-green-50 dark:hover:bg-green-900/20 rounded-lg transition-all transform hover:scale-110 active:scale-95" title="Assigned Locations">
+                        <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M17.657 16.657L13.414 20.9a2 2 0 01-2.827 0l-4.244-4.243a8 8 0 1111.314 0z"></path><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M15 11a3 3 0 11-6 0 3 3 0 016 0z"></path></svg>
+                    </button>
+                    <button onclick="usersList.handleToggleActive('${user.id}', ${user.is_active})" class="text-gray-400 hover:text-amber-600 dark:text-slate-500 dark:hover:text-amber-400 p-2 hover:bg-amber-50 dark:hover:bg-amber-900/20 rounded-lg transition-all transform hover:scale-110 active:scale-95" title="${user.is_active ? 'Deactivate User' : 'Reactivate User'}">
+                        <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M18.364 5.636a9 9 0 11-12.728 0M12 3v9"></path></svg>
+                    </button>
                     <button onclick="usersList.handleDelete('${user.id}')" class="text-gray-400 hover:text-red-600 dark:text-slate-500 dark:hover:text-rose-400 p-2 hover:bg-red-50 dark:hover:bg-rose-900/20 rounded-lg transition-all transform hover:scale-110 active:scale-95" title="Delete User">
                         <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M19 7l-.867 12.142A2 2 0 0116.138 21H7.862a2 2 0 01-1.995-1.858L5 7m5 4v6m4-6v6m1-10V4a1 1 0 00-1-1h-4a1 1 0 00-1 1v3M4 7h16"></path></svg>
                     </button>
@@ -184,6 +196,16 @@ const usersList = {
         `).join('');
     },
 
+    // Roles come from the server; keep the user's current role even if it was removed
+    renderRoleOptions(current) {
+        const names = this.roles.map(role => role.name);
+        if (!names.includes(current)) names.push(current);
+
+        return names.map(name => `
+            <option value="${name}" ${name === current ? 'selected' : ''} class="bg-white dark:bg-slate-800 text-gray-900 dark:text-white">${name.toUpperCase()}</option>
+        `).join('');
+    },
+
     getRoleBadgeColor(role) {
         // High contrast colors for both modes
         switch (role) {
@@ -232,8 +254,57 @@ const usersList = {
         }
     },
 
+    // Assigned locations only restrict roles without tree:all_locations
+    async handleLocations(userId) {
+        try {
+            const response = await API.users.locations(userId);
+            const current = (response.data.location_ids || []).join(', ');
+            const input = prompt('Assigned location IDs (comma separated, e.g. LOC001, LOC002).\nOnly applies to roles without "tree:all_locations".', current);
+            if (input === null) return;
+
+            const locationIds = input.split(',').map(id => id.trim()).filter(id => id);
+            await API.users.setLocations(userId, locationIds);
+            showToast('Locations updated successfully', 'success');
+        } catch (error) {
+            showToast('Failed to update locations: ' + error.message, 'error');
+        }
+    },
+
+    // The invitee chooses username and password through the emailed link
+    async handleInvite() {
+        const email = prompt('Email address to invite:');
+        if (!email) return;
+        const role = prompt('Role (' + this.roles.map(r => r.name).join(', ') + '):', 'viewer');
+        if (role === null) return;
+
+        try {
+            await API.users.invite({ email: email.trim(), role: role.trim() });
+            showToast('Invitation sent to ' + email.trim(), 'success');
+        } catch (error) {
+            showToast('Failed to invite user: ' + error.message, 'error');
+        }
+    },
+
+    async handleToggleActive(userId, isActive) {
+        if (isActive && !confirm('Deactivate this user? They will be signed out and cannot log in until reactivated.')) {
+            return;
+        }
+
+        try {
+            if (isActive) {
+                await API.users.deactivate(userId);
+            } else {
+                await API.users.reactivate(userId);
+            }
+            showToast(isActive ? 'User deactivated' : 'User reactivated', 'success');
+            this.loadUsers();
+        } catch (error) {
+            showToast('Failed to update user: ' + error.message, 'error');
+        }
+    },
+
     async handleDelete(userId) {
-        if (!confirm('Are you sure you want to delete this user? This action cannot be undone.')) {
+        if (!confirm('Are you sure you want to delete this user? Their trees and monitoring logs stay attributed to the deleted account; this action cannot be undone.')) {
             return;
         }
 
@@ -57,6 +57,18 @@ const Storage = {
     return this.remove('token');
   },
 
+  getRefreshToken() {
+    return this.get('refreshToken');
+  },
+
+  setRefreshToken(token) {
+    return this.set('refreshToken', token);
+  },
+
+  removeRefreshToken() {
+    return this.remove('refreshToken');
+  },
+
   getUser() {
     return this.get('user');
   },
@@ -85,6 +97,25 @@ const Storage = {
     return this.getUserRole() === role;
   },
 
+  // Permissions of the user's role (from /auth/me/permissions)
+  setPermissions(permissions) {
+    return this.set('permissions', permissions);
+  },
+
+  getPermissions() {
+    return this.get('permissions');
+  },
+
+  removePermissions() {
+    return this.remove('permissions');
+  },
+
+  // Check if user's role grants a permission, e.g. 'tree:create'
+  hasPermission(permission) {
+    const permissions = this.getPermissions() || [];
+    return permissions.includes(permission);
+  },
+
   // Check if user is admin
   isAdmin() {
     return this.hasRole('admin');
@@ -92,17 +123,21 @@ const Storage = {
 
   // Check if user can edit
   canEdit() {
-    const role = this.getUserRole();
-    return role === 'admin' || role === 'editor';
+    return this.hasPermission('tree:update');
   },
 
-  // Check if user can create (admin only)
+  // Check if user can create
   canCreate() {
-    return this.isAdmin();
+    return this.hasPermission('tree:create');
   },
 
   // Check if user can delete
   canDelete() {
-    return this.isAdmin();
+    return this.hasPermission('tree:delete');
+  },
+
+  // Check if user can manage users
+  canManageUsers() {
+    return this.hasPermission('user:manage');
   }
 };